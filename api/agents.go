@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	errAgentNotFound     = errors.New("agent not found")
+	errAgentExists       = errors.New("wallet is already an agent")
+	errInvalidFloatLimit = errors.New("invalid float limit")
+)
+
+type createAgentRequest struct {
+	WalletID   string `json:"wallet_id" binding:"required"`
+	FloatLimit string `json:"float_limit" binding:"required"`
+}
+
+type updateAgentRequest struct {
+	FloatLimit *string `json:"float_limit"`
+	IsActive   *bool   `json:"is_active"`
+}
+
+func (server *Server) createAgent(c *gin.Context) {
+	var req createAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	walletID, err := uuid.Parse(req.WalletID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	floatLimit, ok := parseFloatLimit(c, req.FloatLimit)
+	if !ok {
+		return
+	}
+
+	agent, err := server.store.CreateAgentTx(c.Request.Context(), database.CreateAgentParams{
+		WalletID:   walletID,
+		FloatLimit: floatLimit,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return
+		}
+		if database.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, errorResponse(errAgentExists))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusCreated, agent)
+}
+
+func (server *Server) listAgents(c *gin.Context) {
+	limit, offset, ok := parseLimitOffset(c)
+	if !ok {
+		return
+	}
+	agents, err := server.store.ListAgents(c.Request.Context(), database.ListAgentsParams{
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, agents)
+}
+
+func (server *Server) getAgent(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	agent, err := server.store.GetAgent(c.Request.Context(), walletID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errAgentNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, agent)
+}
+
+func (server *Server) updateAgent(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	var req updateAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := database.UpdateAgentParams{
+		WalletID: walletID,
+		IsActive: req.IsActive,
+	}
+	if req.FloatLimit != nil {
+		var ok bool
+		arg.FloatLimit, ok = parseFloatLimit(c, *req.FloatLimit)
+		if !ok {
+			return
+		}
+	}
+
+	agent, err := server.store.UpdateAgent(c.Request.Context(), arg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errAgentNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, agent)
+}
+
+func parseFloatLimit(c *gin.Context, value string) (pgtype.Numeric, bool) {
+	var floatLimit pgtype.Numeric
+	if err := floatLimit.Scan(value); err != nil || floatLimit.Int == nil || floatLimit.Int.Sign() < 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidFloatLimit))
+		return pgtype.Numeric{}, false
+	}
+	return floatLimit, true
+}
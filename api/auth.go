@@ -1,27 +1,51 @@
 package api
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"net/http"
+	"net/netip"
 	"time"
 
 	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/Sahas001/pay-on/internal/signature"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const deviceChallengeDuration = 5 * time.Minute
+
+var (
+	errInvalidDevicePublicKey = errors.New("invalid device public key")
+	errInvalidChallengeID     = errors.New("invalid challenge id")
+)
+
 type registerRequest struct {
-	PhoneNumber string  `json:"phone_number" binding:"required"`
-	Email       *string `json:"email"`
-	Password    string  `json:"password" binding:"required"`
+	PhoneNumber     string  `json:"phone_number" binding:"required"`
+	Email           *string `json:"email"`
+	Password        string  `json:"password" binding:"required"`
+	DeviceID        string  `json:"device_id" binding:"required,max=255"`
+	DevicePublicKey string  `json:"device_public_key" binding:"required"`
+	DeviceName      *string `json:"device_name" binding:"omitempty,max=100"`
 }
 
 type authResponse struct {
-	AccessToken string  `json:"access_token"`
-	UserID      string  `json:"user_id"`
-	PhoneNumber string  `json:"phone_number"`
-	Email       *string `json:"email"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	SessionID             string    `json:"session_id"`
+	UserID                string    `json:"user_id"`
+	PhoneNumber           string    `json:"phone_number"`
+	Email                 *string   `json:"email"`
+	Role                  string    `json:"role"`
+	DeviceID              string    `json:"device_id,omitempty"`
 }
 
 func (server *Server) register(c *gin.Context) {
@@ -37,34 +61,102 @@ func (server *Server) register(c *gin.Context) {
 		return
 	}
 
-	user, err := server.store.CreateUser(c.Request.Context(), database.CreateUserParams{
-		PhoneNumber:  req.PhoneNumber,
-		Email:        req.Email,
-		PasswordHash: string(passwordHash),
+	if _, err := signature.ParsePublicKey(req.DevicePublicKey); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidDevicePublicKey))
+		return
+	}
+
+	result, err := server.store.CreateUserTx(c.Request.Context(), database.CreateUserTxParams{
+		User: database.CreateUserParams{
+			PhoneNumber:  req.PhoneNumber,
+			Email:        req.Email,
+			PasswordHash: string(passwordHash),
+		},
+		DeviceID:        req.DeviceID,
+		DeviceName:      req.DeviceName,
+		DevicePublicKey: req.DevicePublicKey,
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	// The code is only a convenience; the user can request another one with
+	// /auth/phone/send-code if it does not arrive.
+	_, _ = server.sendOTP(c.Request.Context(), result.User, database.OtpPurposePhoneVerification, pgtype.UUID{})
+
+	rsp, err := server.startSession(c, result.User, result.Device)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusCreated, rsp)
+}
+
+type challengeRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	Email       string `json:"email"`
+	DeviceID    string `json:"device_id" binding:"required,max=255"`
+}
+
+type challengeResponse struct {
+	ChallengeID uuid.UUID `json:"challenge_id"`
+	Challenge   string    `json:"challenge"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// createLoginChallenge issues the nonce a device signs to log in. Unknown
+// users get a challenge that is never stored, so the response does not reveal
+// which accounts exist.
+func (server *Server) createLoginChallenge(c *gin.Context) {
+	var req challengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.PhoneNumber == "" && req.Email == "" {
+		c.JSON(http.StatusBadRequest, errorResponse(errMissingQuery))
+		return
+	}
 
-	token, err := server.newAccessToken(user.ID)
+	nonce, err := randomToken()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	rsp := challengeResponse{
+		ChallengeID: uuid.New(),
+		Challenge:   nonce,
+		ExpiresAt:   time.Now().UTC().Add(deviceChallengeDuration),
+	}
 
-	c.JSON(http.StatusCreated, authResponse{
-		AccessToken: token,
-		UserID:      user.ID.String(),
-		PhoneNumber: user.PhoneNumber,
-		Email:       user.Email,
+	user, err := server.lookupLoginUser(c, req.PhoneNumber, req.Email)
+	if err != nil {
+		c.JSON(http.StatusOK, rsp)
+		return
+	}
+	challenge, err := server.store.CreateDeviceChallenge(c.Request.Context(), database.CreateDeviceChallengeParams{
+		UserID:    user.ID,
+		DeviceID:  req.DeviceID,
+		Challenge: rsp.Challenge,
+		ExpiresAt: pgtype.Timestamptz{Time: rsp.ExpiresAt, Valid: true},
 	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	rsp.ChallengeID = challenge.ID
+	c.JSON(http.StatusOK, rsp)
 }
 
 type loginRequest struct {
-	PhoneNumber string `json:"phone_number"`
-	Email       string `json:"email"`
-	Password    string `json:"password" binding:"required"`
+	PhoneNumber     string  `json:"phone_number"`
+	Email           string  `json:"email"`
+	Password        string  `json:"password" binding:"required"`
+	DeviceID        string  `json:"device_id" binding:"required,max=255"`
+	ChallengeID     string  `json:"challenge_id" binding:"required"`
+	DeviceSignature string  `json:"device_signature" binding:"required"`
+	DevicePublicKey string  `json:"device_public_key"`
+	DeviceName      *string `json:"device_name" binding:"omitempty,max=100"`
 }
 
 func (server *Server) login(c *gin.Context) {
@@ -73,49 +165,235 @@ func (server *Server) login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
-
-	var user database.User
-	var err error
-
-	switch {
-	case req.PhoneNumber != "":
-		user, err = server.store.GetUserByPhone(c.Request.Context(), req.PhoneNumber)
-	case req.Email != "":
-		user, err = server.store.GetUserByEmail(c.Request.Context(), &req.Email)
-	default:
+	if req.PhoneNumber == "" && req.Email == "" {
 		c.JSON(http.StatusBadRequest, errorResponse(errMissingQuery))
 		return
 	}
+	challengeID, err := uuid.Parse(req.ChallengeID)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidChallengeID))
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+	user, err := server.lookupLoginUser(c, req.PhoneNumber, req.Email)
+	if err != nil {
 		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
 		return
 	}
 
-	token, err := server.newAccessToken(user.ID)
+	if !server.verifyCredential(c, database.CredentialKindUserPassword, user.ID, user.PasswordHash, req.Password) {
+		return
+	}
+
+	device, err := server.store.BindDeviceTx(c.Request.Context(), database.BindDeviceTxParams{
+		UserID:      user.ID,
+		DeviceID:    req.DeviceID,
+		ChallengeID: challengeID,
+		Signature:   req.DeviceSignature,
+		PublicKey:   req.DevicePublicKey,
+		Name:        req.DeviceName,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrDeviceNotVerified):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "device": device})
+		case errors.Is(err, database.ErrDeviceRevoked):
+			c.JSON(http.StatusForbidden, errorResponse(err))
+		case errors.Is(err, database.ErrDeviceChallenge), errors.Is(err, database.ErrDeviceSignature):
+			c.JSON(http.StatusUnauthorized, errorResponse(err))
+		case errors.Is(err, database.ErrDevicePublicKey):
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+		case errors.Is(err, signature.ErrInvalidPublicKey), errors.Is(err, signature.ErrUnsupportedKey):
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidDevicePublicKey))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	rsp, err := server.startSession(c, user, device)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	c.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) lookupLoginUser(c *gin.Context, phoneNumber, email string) (database.User, error) {
+	if phoneNumber != "" {
+		return server.store.GetUserByPhone(c.Request.Context(), phoneNumber)
+	}
+	return server.store.GetUserByEmail(c.Request.Context(), &email)
+}
 
-	c.JSON(http.StatusOK, authResponse{
-		AccessToken: token,
-		UserID:      user.ID.String(),
-		PhoneNumber: user.PhoneNumber,
-		Email:       user.Email,
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// refresh exchanges a refresh token for a new access and refresh token pair.
+// The role is re-read from the database, so role changes apply on refresh.
+func (server *Server) refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	refreshToken, refreshTokenHash, err := newRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	session, err := server.store.RotateRefreshTokenTx(c.Request.Context(), database.RotateRefreshTokenTxParams{
+		TokenHash:    hashRefreshToken(req.RefreshToken),
+		NewTokenHash: refreshTokenHash,
 	})
+	if err != nil {
+		if errors.Is(err, database.ErrRefreshTokenInvalid) || errors.Is(err, database.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserByID(c.Request.Context(), session.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	rsp, err := server.sessionResponse(user, session, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, rsp)
+}
+
+// logout revokes the session of the access token used for the request.
+func (server *Server) logout(c *gin.Context) {
+	sessionID, ok := authSessionID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	if _, err := server.store.RevokeSession(c.Request.Context(), sessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, okayResponse("logged out"))
+}
+
+// logoutAll revokes every session of the caller, on all devices.
+func (server *Server) logoutAll(c *gin.Context) {
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	revoked, err := server.store.RevokeUserSessions(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out of all sessions", "revoked_sessions": revoked})
+}
+
+// startSession opens a session for a user who just registered or logged in
+// on a verified device.
+func (server *Server) startSession(c *gin.Context, user database.User, device database.Device) (authResponse, error) {
+	refreshToken, refreshTokenHash, err := newRefreshToken()
+	if err != nil {
+		return authResponse{}, err
+	}
+
+	arg := database.CreateSessionTxParams{
+		UserID:           user.ID,
+		ExpiresAt:        pgtype.Timestamptz{Time: time.Now().UTC().Add(server.config.RefreshTokenDuration), Valid: true},
+		RefreshTokenHash: refreshTokenHash,
+		DeviceID:         pgtype.UUID{Bytes: device.ID, Valid: true},
+	}
+	if userAgent := c.Request.UserAgent(); userAgent != "" {
+		arg.UserAgent = &userAgent
+	}
+	if ip, err := netip.ParseAddr(c.ClientIP()); err == nil {
+		arg.ClientIp = &ip
+	}
+
+	session, err := server.store.CreateSessionTx(c.Request.Context(), arg)
+	if err != nil {
+		return authResponse{}, err
+	}
+	return server.sessionResponse(user, session, refreshToken)
+}
+
+func (server *Server) sessionResponse(user database.User, session database.Session, refreshToken string) (authResponse, error) {
+	accessToken, expiresAt, err := server.newAccessToken(user, session.ID)
+	if err != nil {
+		return authResponse{}, err
+	}
+	var deviceID string
+	if session.DeviceID.Valid {
+		deviceID = uuid.UUID(session.DeviceID.Bytes).String()
+	}
+	return authResponse{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  expiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt.Time,
+		SessionID:             session.ID.String(),
+		UserID:                user.ID.String(),
+		PhoneNumber:           user.PhoneNumber,
+		Email:                 user.Email,
+		Role:                  string(user.Role),
+		DeviceID:              deviceID,
+	}, nil
 }
 
-func (server *Server) newAccessToken(userID uuid.UUID) (string, error) {
-	claims := jwt.RegisteredClaims{
-		Subject:   userID.String(),
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(server.config.AccessTokenDuration)),
+// newAccessToken issues a short-lived access token bound to a session. The
+// session ID is carried in the jti claim.
+func (server *Server) newAccessToken(user database.User, sessionID uuid.UUID) (string, time.Time, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(server.config.AccessTokenDuration)
+	claims := tokenClaims{
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID.String(),
+			Subject:   user.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	signed, err := server.tokenKeys.Sign(claims)
+	return signed, expiresAt, err
+}
+
+// newRefreshToken returns a random refresh token and the hash stored for it.
+func newRefreshToken() (token string, hash string, err error) {
+	token, err = randomToken()
+	if err != nil {
+		return "", "", err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(server.config.JWTSecret))
+	return token, hashRefreshToken(token), nil
+}
+
+// randomToken returns 32 random bytes, base64url encoded.
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// getJWKS publishes the public keys that verify access tokens, so other
+// services can check pay-on tokens without sharing a secret.
+func (server *Server) getJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, server.tokenKeys.JWKS())
 }
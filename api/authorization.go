@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ownershipCheck reports whether the user may access the resource with the given id.
+type ownershipCheck func(ctx context.Context, id uuid.UUID, userID pgtype.UUID) (bool, error)
+
+// authorizeParam guards routes whose :id path parameter names a resource.
+// Resources the caller may not access respond exactly like missing ones, so
+// the API does not reveal which IDs exist. Admins, and support staff on reads,
+// skip the ownership check.
+func (server *Server) authorizeParam(invalid, notFound error, check ownershipCheck) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := authUserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+			return
+		}
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(invalid))
+			return
+		}
+		if bypassesOwnership(c) {
+			c.Next()
+			return
+		}
+
+		allowed, err := check(c.Request.Context(), id, toPgUUID(userID))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusNotFound, errorResponse(notFound))
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorizeWallet allows /wallets/:id routes only for the wallet's owner.
+func (server *Server) authorizeWallet() gin.HandlerFunc {
+	return server.authorizeParam(errInvalidWalletID, errWalletNotFound, func(ctx context.Context, id uuid.UUID, userID pgtype.UUID) (bool, error) {
+		return server.store.IsWalletOwner(ctx, database.IsWalletOwnerParams{ID: id, UserID: userID})
+	})
+}
+
+// authorizeTransaction allows /transactions/:id routes for the owners of
+// either the sending or the receiving wallet.
+func (server *Server) authorizeTransaction() gin.HandlerFunc {
+	return server.authorizeParam(errInvalidTransactionID, errTransactionNotFound, func(ctx context.Context, id uuid.UUID, userID pgtype.UUID) (bool, error) {
+		return server.store.IsTransactionParticipant(ctx, database.IsTransactionParticipantParams{ID: id, UserID: userID})
+	})
+}
+
+// authorizePeer allows /peers/:id routes for the owner of the wallet the peer belongs to.
+func (server *Server) authorizePeer() gin.HandlerFunc {
+	return server.authorizeParam(errInvalidPeerID, errPeerNotFound, func(ctx context.Context, id uuid.UUID, userID pgtype.UUID) (bool, error) {
+		return server.store.IsPeerOwner(ctx, database.IsPeerOwnerParams{ID: id, UserID: userID})
+	})
+}
+
+// authorizeSyncLog allows /sync-logs/:id routes for the owner of the syncing wallet.
+func (server *Server) authorizeSyncLog() gin.HandlerFunc {
+	return server.authorizeParam(errInvalidSyncLogID, errSyncLogNotFound, func(ctx context.Context, id uuid.UUID, userID pgtype.UUID) (bool, error) {
+		return server.store.IsSyncLogOwner(ctx, database.IsSyncLogOwnerParams{ID: id, UserID: userID})
+	})
+}
+
+// authorizeDevice allows /devices/:id routes only for the device's owner.
+func (server *Server) authorizeDevice() gin.HandlerFunc {
+	return server.authorizeParam(errInvalidDeviceID, errDeviceNotFound, func(ctx context.Context, id uuid.UUID, userID pgtype.UUID) (bool, error) {
+		return server.store.IsDeviceOwner(ctx, database.IsDeviceOwnerParams{ID: id, UserID: userID.Bytes})
+	})
+}
+
+// authorizeWalletID checks ownership of a wallet named in the request body.
+// It writes the error response and returns false when access is denied.
+func (server *Server) authorizeWalletID(c *gin.Context, walletID uuid.UUID) bool {
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return false
+	}
+	if bypassesOwnership(c) {
+		return true
+	}
+	owner, err := server.store.IsWalletOwner(c.Request.Context(), database.IsWalletOwnerParams{
+		ID:     walletID,
+		UserID: toPgUUID(userID),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if !owner {
+		c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+		return false
+	}
+	return true
+}
+
+// publicWalletResponse is what other users see when they look a wallet up,
+// e.g. to find the recipient of a payment.
+type publicWalletResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	PhoneNumber string    `json:"phone_number"`
+	PublicKey   string    `json:"public_key"`
+}
+
+// walletLookupResponse returns the full wallet to its owner and to staff, and
+// the public view to everyone else.
+func walletLookupResponse(c *gin.Context, wallet database.Wallet) any {
+	if bypassesOwnership(c) {
+		return wallet
+	}
+	userID, ok := authUserID(c)
+	if ok && wallet.UserID.Valid && wallet.UserID.Bytes == toPgUUID(userID).Bytes {
+		return wallet
+	}
+	return publicWalletResponse{
+		ID:          wallet.ID,
+		Name:        wallet.Name,
+		PhoneNumber: wallet.PhoneNumber,
+		PublicKey:   wallet.PublicKey,
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// cashRequest is the body of both deposits and withdrawals. The request is
+// always made by the agent; Pin and Signature belong to the paying wallet,
+// which is the agent for a deposit and the customer for a withdrawal.
+type cashRequest struct {
+	AgentWalletID    string          `json:"agent_wallet_id" binding:"required"`
+	CustomerWalletID string          `json:"customer_wallet_id" binding:"required"`
+	Amount           string          `json:"amount" binding:"required"`
+	Pin              string          `json:"pin" binding:"required"`
+	Currency         string          `json:"currency"`
+	Signature        string          `json:"signature" binding:"required"`
+	Nonce            int64           `json:"nonce"`
+	Description      *string         `json:"description"`
+	Metadata         json.RawMessage `json:"metadata"`
+	TransactionAt    *time.Time      `json:"transaction_at"`
+}
+
+func (server *Server) createDeposit(c *gin.Context) {
+	server.cashTx(c, database.TransactionTypeDeposit)
+}
+
+func (server *Server) createWithdrawal(c *gin.Context) {
+	server.cashTx(c, database.TransactionTypeWithdraw)
+}
+
+func (server *Server) cashTx(c *gin.Context, txType database.TransactionType) {
+	var req cashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	agentWalletID, err := uuid.Parse(req.AgentWalletID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	customerWalletID, err := uuid.Parse(req.CustomerWalletID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+
+	var amount pgtype.Numeric
+	if err := amount.Scan(req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidAmount))
+		return
+	}
+
+	agentWallet, err := server.store.GetWalletByID(c.Request.Context(), agentWalletID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !agentWallet.UserID.Valid || agentWallet.UserID.Bytes != toPgUUID(userID).Bytes {
+		c.JSON(http.StatusForbidden, errorResponse(database.ErrNotAgent))
+		return
+	}
+
+	payer := agentWallet
+	if txType == database.TransactionTypeWithdraw {
+		payer, err = server.store.GetWalletByID(c.Request.Context(), customerWalletID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+	if !server.verifyCredential(c, database.CredentialKindWalletPin, payer.ID, payer.PinHash, req.Pin) {
+		return
+	}
+
+	var txTime pgtype.Timestamptz
+	if req.TransactionAt != nil {
+		txTime = pgtype.Timestamptz{Time: req.TransactionAt.UTC(), Valid: true}
+	}
+
+	result, err := server.store.CashTx(c.Request.Context(), database.CashTxParams{
+		Type:             txType,
+		AgentWalletID:    agentWalletID,
+		CustomerWalletID: customerWalletID,
+		Amount:           amount,
+		Currency:         req.Currency,
+		Signature:        req.Signature,
+		Nonce:            req.Nonce,
+		Description:      req.Description,
+		Metadata:         req.Metadata,
+		TransactionAt:    txTime,
+	})
+	if err != nil {
+		if signatureErrorResponse(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, database.ErrNotAgent):
+			c.JSON(http.StatusForbidden, errorResponse(err))
+		case errors.Is(err, database.ErrSameWallet), errors.Is(err, database.ErrPaymentRequestMismatch):
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+		case errors.Is(err, database.ErrPaymentRequestNotFound):
+			c.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, database.ErrPaymentRequestNotPayable):
+			c.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+		case errors.Is(err, database.ErrInsufficientBalance), errors.Is(err, database.ErrAgentFloatLimit):
+			c.JSON(http.StatusConflict, errorResponse(err))
+		case database.IsLimitError(err):
+			c.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		case database.IsUniqueViolation(err):
+			c.JSON(http.StatusConflict, errorResponse(database.ErrDuplicateNonce))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
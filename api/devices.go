@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errInvalidDeviceID      = errors.New("invalid device id")
+	errDeviceNotFound       = errors.New("device not found")
+	errDeviceAlreadyRevoked = errors.New("device is already revoked")
+)
+
+func (server *Server) listDevices(c *gin.Context) {
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	devices, err := server.store.ListDevicesByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, devices)
+}
+
+// verifyDevice approves a device that was registered at login, from a
+// session on another verified device.
+func (server *Server) verifyDevice(c *gin.Context) {
+	deviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidDeviceID))
+		return
+	}
+	device, err := server.store.VerifyDevice(c.Request.Context(), deviceID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusConflict, errorResponse(errDeviceAlreadyRevoked))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, device)
+}
+
+// revokeDevice stops a device from logging in or attesting offline payments
+// and ends its sessions.
+func (server *Server) revokeDevice(c *gin.Context) {
+	deviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidDeviceID))
+		return
+	}
+	device, err := server.store.RevokeDeviceTx(c.Request.Context(), deviceID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusConflict, errorResponse(errDeviceAlreadyRevoked))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, device)
+}
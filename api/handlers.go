@@ -2,8 +2,14 @@ package api
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
 
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/Sahas001/pay-on/internal/signature"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -21,6 +27,87 @@ func (server *Server) notImplemented(c *gin.Context) {
 	c.JSON(http.StatusNotImplemented, gin.H{"error": "not implemented"})
 }
 
+// signatureErrorResponse writes the response for signature verification
+// failures and reports whether err was one of them.
+func signatureErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, signature.ErrInvalidSignature):
+		c.JSON(http.StatusUnauthorized, errorResponse(signature.ErrInvalidSignature))
+	case errors.Is(err, signature.ErrMissingSignedData), errors.Is(err, signature.ErrInvalidAmount):
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+	case errors.Is(err, signature.ErrInvalidPublicKey), errors.Is(err, signature.ErrUnsupportedKey),
+		errors.Is(err, database.ErrOfflineTooOld), errors.Is(err, database.ErrNoWalletKeyAt):
+		c.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	default:
+		return false
+	}
+	return true
+}
+
+// verifyCredential checks a wallet PIN or account password against its hash
+// with brute-force protection, writing the error response and reporting
+// false when the check does not pass. A credential in backoff answers 429
+// and a locked one 423, both with Retry-After.
+func (server *Server) verifyCredential(c *gin.Context, kind database.CredentialKind, subjectID uuid.UUID, hash, secret string) bool {
+	arg := database.VerifyCredentialTxParams{
+		Kind:      kind,
+		SubjectID: subjectID,
+		Hash:      hash,
+		Secret:    secret,
+		Audit:     credentialAudit(c),
+	}
+	if !arg.Audit.ChangedBy.Valid && kind == database.CredentialKindUserPassword {
+		// A login attempt is made on behalf of the account itself.
+		arg.Audit.ChangedBy = toPgUUID(subjectID)
+	}
+
+	err := server.store.VerifyCredentialTx(c.Request.Context(), arg)
+	if err == nil {
+		return true
+	}
+
+	switch {
+	case credentialLockResponse(c, err):
+	case errors.Is(err, database.ErrInvalidCredential):
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+	return false
+}
+
+// credentialLockResponse writes the response for a *CredentialLockError and
+// reports whether err was one.
+func credentialLockResponse(c *gin.Context, err error) bool {
+	var lockErr *database.CredentialLockError
+	if !errors.As(err, &lockErr) {
+		return false
+	}
+	retryAfter := math.Ceil(time.Until(lockErr.Until).Seconds())
+	c.Header("Retry-After", strconv.Itoa(max(int(retryAfter), 1)))
+	status := http.StatusTooManyRequests
+	if lockErr.Locked {
+		status = http.StatusLocked
+	}
+	c.JSON(status, gin.H{"error": lockErr.Error(), "locked_until": lockErr.Until})
+	return true
+}
+
+// credentialAudit describes the caller for credential entries in audit_logs.
+func credentialAudit(c *gin.Context) database.CredentialAudit {
+	var audit database.CredentialAudit
+	if userID, ok := authUserID(c); ok {
+		audit.ChangedBy = toPgUUID(userID)
+	}
+	if userAgent := c.Request.UserAgent(); userAgent != "" {
+		audit.UserAgent = &userAgent
+	}
+	if ip, err := netip.ParseAddr(c.ClientIP()); err == nil {
+		audit.IpAddress = &ip
+	}
+	return audit
+}
+
 func toPgUUID(id uuid.UUID) pgtype.UUID {
 	var pgID pgtype.UUID
 	copy(pgID.Bytes[:], id[:])
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotentResponseContent = "application/json; charset=utf-8"
+	// idempotencyLease is how long a key may stay in progress without being
+	// renewed before a retry treats the request holding it as abandoned and
+	// takes the key over.
+	idempotencyLease = 2 * time.Minute
+	// idempotencyRenewal is how often a running request renews its lease.
+	idempotencyRenewal = idempotencyLease / 4
+)
+
+var (
+	errInvalidIdempotencyKey    = errors.New("invalid idempotency key")
+	errIdempotencyKeyReused     = errors.New("idempotency key reused with a different request")
+	errIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// idempotencyWriter keeps a copy of the response body so it can be stored
+// against the idempotency key once the handler returns.
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyMiddleware replays the stored response when a mutating request
+// is retried with the same Idempotency-Key. Keys are scoped per user, and a
+// key reused with a different method, path or body is rejected with 409.
+// Requests without the header pass through untouched. The lease on a key is
+// renewed while its handler runs, so only a key whose request died without
+// storing a response is released, or taken over by a retry once
+// idempotencyLease has passed.
+func (server *Server) idempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(errInvalidIdempotencyKey))
+			return
+		}
+
+		userID, ok := authUserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		requestHash := hashIdempotentRequest(c.Request.Method, c.Request.URL.Path, body)
+
+		record, err := server.store.CreateIdempotencyKey(ctx, database.CreateIdempotencyKeyParams{
+			UserID:         userID,
+			IdempotencyKey: key,
+			Method:         c.Request.Method,
+			Path:           c.Request.URL.Path,
+			RequestHash:    requestHash,
+			CreatedAt:      pgtype.Timestamptz{Time: time.Now().Add(-idempotencyLease), Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			server.replayIdempotentResponse(c, userID, key, requestHash)
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		// The key is settled even if the client hangs up or the handler
+		// panics, so that a retry is not refused as in progress.
+		storeCtx := context.WithoutCancel(ctx)
+		defer func() {
+			if r := recover(); r != nil {
+				server.releaseIdempotencyKey(storeCtx, record.ID)
+				panic(r)
+			}
+		}()
+
+		renewCtx, stopRenewal := context.WithCancel(storeCtx)
+		defer stopRenewal()
+		go server.renewIdempotencyKey(renewCtx, record.ID)
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		stopRenewal()
+
+		// Server errors are not stored so that the client can retry with the same key.
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			server.releaseIdempotencyKey(storeCtx, record.ID)
+			return
+		}
+
+		responseStatus := int32(status)
+		if _, err := server.store.CompleteIdempotencyKey(storeCtx, database.CompleteIdempotencyKeyParams{
+			ID:             record.ID,
+			ResponseStatus: &responseStatus,
+			ResponseBody:   writer.body.Bytes(),
+		}); err != nil {
+			log.Printf("Cannot store the response for idempotency key %s: %v", record.ID, err)
+		}
+	}
+}
+
+// renewIdempotencyKey keeps the lease on a key in progress until ctx is
+// done, so a slow handler is not mistaken for an abandoned one.
+func (server *Server) renewIdempotencyKey(ctx context.Context, id uuid.UUID) {
+	ticker := time.NewTicker(idempotencyRenewal)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		renewed, err := server.store.RenewIdempotencyKey(ctx, id)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Cannot renew idempotency key %s: %v", id, err)
+			}
+			continue
+		}
+		if renewed == 0 {
+			// Completed or released already.
+			return
+		}
+	}
+}
+
+func (server *Server) releaseIdempotencyKey(ctx context.Context, id uuid.UUID) {
+	if err := server.store.DeleteIdempotencyKey(ctx, id); err != nil {
+		log.Printf("Cannot release idempotency key %s: %v", id, err)
+	}
+}
+
+func (server *Server) replayIdempotentResponse(c *gin.Context, userID uuid.UUID, key string, requestHash string) {
+	record, err := server.store.GetIdempotencyKey(c.Request.Context(), database.GetIdempotencyKeyParams{
+		UserID:         userID,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if record.RequestHash != requestHash {
+		c.AbortWithStatusJSON(http.StatusConflict, errorResponse(errIdempotencyKeyReused))
+		return
+	}
+	if record.ResponseStatus == nil {
+		c.AbortWithStatusJSON(http.StatusConflict, errorResponse(errIdempotencyKeyInProgress))
+		return
+	}
+
+	c.Header(idempotentReplayedHeader, "true")
+	c.Data(int(*record.ResponseStatus), idempotentResponseContent, record.ResponseBody)
+	c.Abort()
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func hashIdempotentRequest(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/Sahas001/pay-on/internal/jobs"
+	"github.com/gin-gonic/gin"
+)
+
+var errJobNotFound = errors.New("job not found")
+
+// listJobs returns the maintenance jobs with their schedules and last runs.
+func (server *Server) listJobs(c *gin.Context) {
+	infos, err := server.jobs.Jobs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, infos)
+}
+
+// runJob runs a job now and waits for it to finish. A job that fails still
+// answers 200 with the failed run.
+func (server *Server) runJob(c *gin.Context) {
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	run, err := server.jobs.Trigger(c.Request.Context(), c.Param("name"), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, jobs.ErrUnknownJob):
+			c.JSON(http.StatusNotFound, errorResponse(errJobNotFound))
+		case errors.Is(err, jobs.ErrJobRunning):
+			c.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// listJobRuns returns the run history of a job, newest first.
+func (server *Server) listJobRuns(c *gin.Context) {
+	name := c.Param("name")
+	if !server.jobs.Has(name) {
+		c.JSON(http.StatusNotFound, errorResponse(errJobNotFound))
+		return
+	}
+	limit, offset, ok := parseLimitOffset(c)
+	if !ok {
+		return
+	}
+
+	runs, err := server.store.ListJobRuns(c.Request.Context(), database.ListJobRunsParams{
+		JobName: name,
+		Limit:   int32(limit),
+		Offset:  int32(offset),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const defaultReconcileLimit = 100
+
+func (server *Server) listWalletLedgerEntries(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	limit, offset, ok := parseLimitOffset(c)
+	if !ok {
+		return
+	}
+	entries, err := server.store.ListLedgerEntriesByWallet(c.Request.Context(), database.ListLedgerEntriesByWalletParams{
+		WalletID: toPgUUID(walletID),
+		Limit:    int32(limit),
+		Offset:   int32(offset),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+func (server *Server) getTransactionLedgerEntries(c *gin.Context) {
+	txID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidTransactionID))
+		return
+	}
+	entries, err := server.store.ListLedgerEntriesByTransaction(c.Request.Context(), toPgUUID(txID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+func (server *Server) reconcileLedger(c *gin.Context) {
+	limit, ok := parseLimit(c, defaultReconcileLimit)
+	if !ok {
+		return
+	}
+	result, err := server.store.Reconcile(c.Request.Context(), int32(limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
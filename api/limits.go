@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	errInvalidLimitAmount  = errors.New("limits must be positive amounts")
+	errOfflineLimitTooHigh = errors.New("offline limit must not exceed the daily limit")
+)
+
+// walletLimitsRequest replaces all limits of a wallet. An omitted or null
+// field removes that limit.
+type walletLimitsRequest struct {
+	PerTransactionLimit *string `json:"per_transaction_limit"`
+	DailyLimit          *string `json:"daily_limit"`
+	MonthlyLimit        *string `json:"monthly_limit"`
+	OfflineLimit        *string `json:"offline_limit"`
+}
+
+func (server *Server) getWalletLimits(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	usage, err := server.store.GetWalletLimitUsage(c.Request.Context(), walletID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+func (server *Server) updateWalletLimits(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	var req walletLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := database.UpsertWalletLimitsParams{WalletID: walletID}
+	for _, field := range []struct {
+		value  *string
+		target *pgtype.Numeric
+	}{
+		{req.PerTransactionLimit, &arg.PerTransactionLimit},
+		{req.DailyLimit, &arg.DailyLimit},
+		{req.MonthlyLimit, &arg.MonthlyLimit},
+		{req.OfflineLimit, &arg.OfflineLimit},
+	} {
+		if field.value == nil {
+			continue
+		}
+		if err := field.target.Scan(*field.value); err != nil || field.target.Int == nil || field.target.Int.Sign() <= 0 {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidLimitAmount))
+			return
+		}
+	}
+	if arg.OfflineLimit.Valid && arg.DailyLimit.Valid && numericGreater(arg.OfflineLimit, arg.DailyLimit) {
+		c.JSON(http.StatusBadRequest, errorResponse(errOfflineLimitTooHigh))
+		return
+	}
+
+	if _, err := server.store.GetWalletByID(c.Request.Context(), walletID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	limits, err := server.store.UpsertWalletLimits(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, limits)
+}
+
+func (server *Server) deleteWalletLimits(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	if err := server.store.DeleteWalletLimits(c.Request.Context(), walletID); err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "wallet limits removed"})
+}
+
+func numericGreater(a, b pgtype.Numeric) bool {
+	af, err := a.Float64Value()
+	if err != nil {
+		return false
+	}
+	bf, err := b.Float64Value()
+	if err != nil {
+		return false
+	}
+	return af.Float64 > bf.Float64
+}
@@ -3,14 +3,35 @@ package api
 import (
 	"errors"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
-const authUserIDKey = "auth_user_id"
+const (
+	authUserIDKey    = "auth_user_id"
+	authUserRoleKey  = "auth_user_role"
+	authSessionIDKey = "auth_session_id"
+	authDeviceIDKey  = "auth_device_id"
+	authExpiresAtKey = "auth_expires_at"
+)
+
+var (
+	errForbidden      = errors.New("insufficient role for this operation")
+	errDeviceRequired = errors.New("this operation requires a session started on a verified device")
+)
+
+// tokenClaims are the claims carried by access tokens.
+type tokenClaims struct {
+	Role database.UserRole `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
 
 func (server *Server) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -29,19 +50,14 @@ func (server *Server) authMiddleware() gin.HandlerFunc {
 		}
 
 		tokenStr := parts[1]
-		token, err := jwt.ParseWithClaims(tokenStr, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return []byte(server.config.JWTSecret), nil
-		})
+		token, err := jwt.ParseWithClaims(tokenStr, &tokenClaims{}, server.tokenKeys.Keyfunc)
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(*jwt.RegisteredClaims)
+		claims, ok := token.Claims.(*tokenClaims)
 		if !ok || claims.Subject == "" {
 			c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
 			c.Abort()
@@ -55,7 +71,46 @@ func (server *Server) authMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Tokens issued before roles existed carry no role claim.
+		role := claims.Role
+		if role == "" {
+			role = database.UserRoleUser
+		}
+		if !role.Valid() {
+			c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+			c.Abort()
+			return
+		}
+
+		// The session ID lets a logout revoke tokens before they expire.
+		sessionID, err := uuid.Parse(claims.ID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+			c.Abort()
+			return
+		}
+		session, err := server.store.GetActiveSession(c.Request.Context(), sessionID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+				c.Abort()
+				return
+			}
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			c.Abort()
+			return
+		}
+		if session.DeviceID.Valid {
+			c.Set(authDeviceIDKey, uuid.UUID(session.DeviceID.Bytes))
+		}
+
+		if claims.ExpiresAt != nil {
+			c.Set(authExpiresAtKey, claims.ExpiresAt.Time)
+		}
+
 		c.Set(authUserIDKey, userID)
+		c.Set(authSessionIDKey, sessionID)
+		c.Set(authUserRoleKey, role)
 		c.Next()
 	}
 }
@@ -68,3 +123,82 @@ func authUserID(c *gin.Context) (uuid.UUID, bool) {
 	id, ok := value.(uuid.UUID)
 	return id, ok
 }
+
+func authSessionID(c *gin.Context) (uuid.UUID, bool) {
+	value, ok := c.Get(authSessionIDKey)
+	if !ok {
+		return uuid.UUID{}, false
+	}
+	id, ok := value.(uuid.UUID)
+	return id, ok
+}
+
+func authDeviceID(c *gin.Context) (uuid.UUID, bool) {
+	value, ok := c.Get(authDeviceIDKey)
+	if !ok {
+		return uuid.UUID{}, false
+	}
+	id, ok := value.(uuid.UUID)
+	return id, ok
+}
+
+// authExpiresAt returns when the caller's access token expires.
+func authExpiresAt(c *gin.Context) (time.Time, bool) {
+	value, ok := c.Get(authExpiresAtKey)
+	if !ok {
+		return time.Time{}, false
+	}
+	at, ok := value.(time.Time)
+	return at, ok
+}
+
+func authUserRole(c *gin.Context) database.UserRole {
+	value, ok := c.Get(authUserRoleKey)
+	if !ok {
+		return database.UserRoleUser
+	}
+	role, ok := value.(database.UserRole)
+	if !ok {
+		return database.UserRoleUser
+	}
+	return role
+}
+
+// requireRole allows the request only for callers holding one of the given
+// roles. Admins are allowed everywhere.
+func (server *Server) requireRole(roles ...database.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := authUserRole(c)
+		if role == database.UserRoleAdmin || slices.Contains(roles, role) {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errForbidden))
+	}
+}
+
+// bypassesOwnership reports whether the caller may act on resources owned by
+// other users: admins always, support staff for read-only requests.
+func bypassesOwnership(c *gin.Context) bool {
+	switch authUserRole(c) {
+	case database.UserRoleAdmin:
+		return true
+	case database.UserRoleSupport:
+		return c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+	default:
+		return false
+	}
+}
+
+// requireDevice allows the request only for sessions bound to a verified
+// device. The session check in authMiddleware already rejects sessions whose
+// device was revoked.
+func (server *Server) requireDevice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := authDeviceID(c); !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errDeviceRequired))
+			return
+		}
+		c.Next()
+	}
+}
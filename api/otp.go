@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	errPhoneAlreadyVerified = errors.New("phone number is already verified")
+	errPhoneNotVerified     = errors.New("verify your phone number before resetting a PIN by code")
+	errMissingPINAuth       = errors.New("password or otp_code is required")
+)
+
+var otpMessages = map[database.OtpPurpose]string{
+	database.OtpPurposePhoneVerification: "Your Pay-On verification code is %s. It expires in %d minutes.",
+	database.OtpPurposePasswordReset:     "Your Pay-On password reset code is %s. It expires in %d minutes. Do not share it.",
+	database.OtpPurposePinReset:          "Your Pay-On wallet PIN reset code is %s. It expires in %d minutes. Do not share it.",
+}
+
+// newOTPCode returns a random six digit code.
+func newOTPCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// sendOTP issues a code for the user's phone number and sends it by SMS.
+func (server *Server) sendOTP(ctx context.Context, user database.User, purpose database.OtpPurpose, subjectID pgtype.UUID) (database.OtpCode, error) {
+	code, err := newOTPCode()
+	if err != nil {
+		return database.OtpCode{}, err
+	}
+	otp, err := server.store.IssueOTPTx(ctx, database.IssueOTPTxParams{
+		UserID:      user.ID,
+		PhoneNumber: user.PhoneNumber,
+		Purpose:     purpose,
+		SubjectID:   subjectID,
+		Code:        code,
+	})
+	if err != nil {
+		return database.OtpCode{}, err
+	}
+
+	message := fmt.Sprintf(otpMessages[purpose], code, int(database.OTPDuration.Minutes()))
+	if err := server.smsSender.Send(ctx, user.PhoneNumber, message); err != nil {
+		return database.OtpCode{}, fmt.Errorf("send code: %w", err)
+	}
+	return otp, nil
+}
+
+// otpErrorResponse writes the response for an error from sendOTP.
+func otpErrorResponse(c *gin.Context, err error) {
+	var rateErr *database.OTPRateLimitError
+	if errors.As(err, &rateErr) {
+		retryAfter := math.Ceil(time.Until(rateErr.RetryAt).Seconds())
+		c.Header("Retry-After", strconv.Itoa(max(int(retryAfter), 1)))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": rateErr.Error(), "retry_at": rateErr.RetryAt})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, errorResponse(err))
+}
+
+type otpSentResponse struct {
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (server *Server) sendPhoneVerificationCode(c *gin.Context) {
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	user, err := server.store.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if user.PhoneVerifiedAt.Valid {
+		c.JSON(http.StatusConflict, errorResponse(errPhoneAlreadyVerified))
+		return
+	}
+
+	otp, err := server.sendOTP(c.Request.Context(), user, database.OtpPurposePhoneVerification, pgtype.UUID{})
+	if err != nil {
+		otpErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, otpSentResponse{Message: "verification code sent", ExpiresAt: otp.ExpiresAt.Time})
+}
+
+type verifyPhoneRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func (server *Server) verifyPhone(c *gin.Context) {
+	var req verifyPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	user, err := server.store.VerifyPhoneTx(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		if errors.Is(err, database.ErrOTPInvalid) {
+			c.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":           user.ID,
+		"phone_number":      user.PhoneNumber,
+		"phone_verified_at": user.PhoneVerifiedAt.Time,
+	})
+}
+
+type forgotPasswordRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+}
+
+// forgotPassword sends a password reset code to a verified phone number. The
+// response is the same whether or not a code was sent, so it does not reveal
+// which accounts exist.
+func (server *Server) forgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rsp := okayResponse("if the phone number is registered and verified, a reset code has been sent")
+	user, err := server.store.GetUserByPhone(c.Request.Context(), req.PhoneNumber)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusAccepted, rsp)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !user.PhoneVerifiedAt.Valid {
+		c.JSON(http.StatusAccepted, rsp)
+		return
+	}
+
+	_, err = server.sendOTP(c.Request.Context(), user, database.OtpPurposePasswordReset, pgtype.UUID{})
+	var rateErr *database.OTPRateLimitError
+	if err != nil && !errors.As(err, &rateErr) {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusAccepted, rsp)
+}
+
+type resetPasswordRequest struct {
+	PhoneNumber string `json:"phone_number" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// resetPassword sets a new password with a code from forgotPassword. Every
+// session of the account is revoked.
+func (server *Server) resetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	_, err = server.store.ResetPasswordTx(c.Request.Context(), database.ResetPasswordTxParams{
+		PhoneNumber:  req.PhoneNumber,
+		Code:         req.Code,
+		PasswordHash: string(passwordHash),
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrOTPInvalid) {
+			c.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, okayResponse("password reset; log in again"))
+}
+
+// sendWalletPINCode sends a code that authorizes resetting the wallet's PIN
+// in place of the account password.
+func (server *Server) sendWalletPINCode(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	user, err := server.store.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !user.PhoneVerifiedAt.Valid {
+		c.JSON(http.StatusForbidden, errorResponse(errPhoneNotVerified))
+		return
+	}
+
+	otp, err := server.sendOTP(c.Request.Context(), user, database.OtpPurposePinReset, toPgUUID(walletID))
+	if err != nil {
+		otpErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, otpSentResponse{Message: "PIN reset code sent", ExpiresAt: otp.ExpiresAt.Time})
+}
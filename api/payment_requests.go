@@ -0,0 +1,231 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/Sahas001/pay-on/internal/qrcode"
+	"github.com/Sahas001/pay-on/internal/signature"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	paymentRequestDuration = 24 * time.Hour
+	// paymentRequestAudience keeps payment request payloads from being
+	// mistaken for access tokens signed by the same keys.
+	paymentRequestAudience = "pay-on:payment-request"
+)
+
+var (
+	errInvalidPaymentRequestID  = errors.New("invalid payment request id")
+	errPaymentRequestExpiry     = errors.New("expires_at must be in the future")
+	errPaymentRequestNotPending = errors.New("only pending payment requests can be cancelled")
+)
+
+type createPaymentRequestRequest struct {
+	WalletID    string     `json:"wallet_id" binding:"required"`
+	Amount      string     `json:"amount" binding:"required"`
+	Currency    string     `json:"currency" binding:"omitempty,len=3"`
+	Description *string    `json:"description" binding:"omitempty,max=140"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// paymentRequestClaims is the signed payload encoded in the QR code. Payers
+// can check it offline against the keys published at
+// /.well-known/jwks.json.
+type paymentRequestClaims struct {
+	WalletID    uuid.UUID `json:"wallet_id"`
+	Amount      string    `json:"amount"`
+	Currency    string    `json:"currency"`
+	Description *string   `json:"description,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type paymentRequestResponse struct {
+	database.PaymentRequest
+	Payload string `json:"payload"`
+}
+
+func (server *Server) createPaymentRequest(c *gin.Context) {
+	var req createPaymentRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	walletID, err := uuid.Parse(req.WalletID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	var amount pgtype.Numeric
+	if err := amount.Scan(req.Amount); err != nil || amount.Int == nil || amount.Int.Sign() <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidAmount))
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = "NPR"
+	}
+	expiresAt := time.Now().UTC().Add(paymentRequestDuration)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, errorResponse(errPaymentRequestExpiry))
+			return
+		}
+		expiresAt = req.ExpiresAt.UTC()
+	}
+	if !server.authorizeWalletID(c, walletID) {
+		return
+	}
+
+	request, err := server.store.CreatePaymentRequest(c.Request.Context(), database.CreatePaymentRequestParams{
+		WalletID:    walletID,
+		Amount:      amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		ExpiresAt:   pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	server.paymentRequestResponse(c, http.StatusCreated, request)
+}
+
+// getPaymentRequest lets any signed-in user look up a request, since payers
+// find it by the ID in the scanned payload.
+func (server *Server) getPaymentRequest(c *gin.Context) {
+	request, ok := server.loadPaymentRequest(c)
+	if !ok {
+		return
+	}
+	server.paymentRequestResponse(c, http.StatusOK, request)
+}
+
+// getPaymentRequestQR renders the signed payload as a QR code. The format is
+// png (the default) or svg; scale sets the pixels per module.
+func (server *Server) getPaymentRequestQR(c *gin.Context) {
+	format := qrcode.Format(c.DefaultQuery("format", string(qrcode.FormatPNG)))
+	scale, err := strconv.Atoi(c.DefaultQuery("scale", strconv.Itoa(qrcode.DefaultScale)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(qrcode.ErrInvalidScale))
+		return
+	}
+	request, ok := server.loadPaymentRequest(c)
+	if !ok {
+		return
+	}
+
+	payload, err := server.signPaymentRequest(request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	image, err := qrcode.Encode(payload, format, scale)
+	if err != nil {
+		if errors.Is(err, qrcode.ErrUnknownFormat) || errors.Is(err, qrcode.ErrInvalidScale) {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.Data(http.StatusOK, format.ContentType(), image)
+}
+
+func (server *Server) cancelPaymentRequest(c *gin.Context) {
+	request, ok := server.loadPaymentRequest(c)
+	if !ok {
+		return
+	}
+	if !server.authorizeWalletID(c, request.WalletID) {
+		return
+	}
+
+	request, err := server.store.CancelPaymentRequest(c.Request.Context(), request.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusConflict, errorResponse(errPaymentRequestNotPending))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+func (server *Server) listPaymentRequestsByWallet(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	limit, offset, ok := parseLimitOffset(c)
+	if !ok {
+		return
+	}
+	requests, err := server.store.ListPaymentRequestsByWallet(c.Request.Context(), database.ListPaymentRequestsByWalletParams{
+		WalletID: walletID,
+		Limit:    int32(limit),
+		Offset:   int32(offset),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+func (server *Server) loadPaymentRequest(c *gin.Context) (database.PaymentRequest, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidPaymentRequestID))
+		return database.PaymentRequest{}, false
+	}
+	request, err := server.store.GetPaymentRequest(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(database.ErrPaymentRequestNotFound))
+			return database.PaymentRequest{}, false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return database.PaymentRequest{}, false
+	}
+	return request, true
+}
+
+func (server *Server) paymentRequestResponse(c *gin.Context, status int, request database.PaymentRequest) {
+	payload, err := server.signPaymentRequest(request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(status, paymentRequestResponse{PaymentRequest: request, Payload: payload})
+}
+
+// signPaymentRequest returns the request as a compact JWS signed with the
+// access token keys.
+func (server *Server) signPaymentRequest(request database.PaymentRequest) (string, error) {
+	amount, err := signature.FormatAmount(request.Amount)
+	if err != nil {
+		return "", err
+	}
+	return server.tokenKeys.Sign(paymentRequestClaims{
+		WalletID:    request.WalletID,
+		Amount:      amount,
+		Currency:    request.Currency,
+		Description: request.Description,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        request.ID.String(),
+			Audience:  jwt.ClaimStrings{paymentRequestAudience},
+			IssuedAt:  jwt.NewNumericDate(request.CreatedAt.Time),
+			ExpiresAt: jwt.NewNumericDate(request.ExpiresAt.Time),
+		},
+	})
+}
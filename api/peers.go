@@ -38,6 +38,9 @@ func (server *Server) createPeer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	if !server.authorizeWalletID(c, req.WalletID) {
+		return
+	}
 
 	conn := database.ConnectionType(req.ConnectionType)
 	if !conn.Valid() {
@@ -87,6 +90,9 @@ func (server *Server) upsertPeer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	if !server.authorizeWalletID(c, req.WalletID) {
+		return
+	}
 
 	conn := database.ConnectionType(req.ConnectionType)
 	if !conn.Valid() {
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	errNotTransactionReceiver = errors.New("only the receiver of a transaction can refund it")
+	errReverseInstead         = errors.New("transaction has moved money; reverse it instead of failing it")
+	errRefundAboveThreshold   = errors.New("refunds above the transfer confirmation threshold are made by an admin reversal")
+)
+
+// refundRequest is a refund signed by the receiving wallet, like a transfer
+// from it back to the sender. Custodial wallets may leave the signature out.
+type refundRequest struct {
+	Amount        string     `json:"amount" binding:"required"`
+	Pin           string     `json:"pin" binding:"required"`
+	Signature     string     `json:"signature"`
+	Nonce         int64      `json:"nonce"`
+	TransactionAt *time.Time `json:"transaction_at"`
+	Description   *string    `json:"description"`
+	Reason        *string    `json:"reason" binding:"omitempty,max=500"`
+}
+
+// refundTransaction lets the receiver of a p2p transaction send all or part
+// of it back.
+func (server *Server) refundTransaction(c *gin.Context) {
+	var req refundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	txID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidTransactionID))
+		return
+	}
+	var amount pgtype.Numeric
+	if err := amount.Scan(req.Amount); err != nil || amount.Int == nil || amount.Int.Sign() <= 0 {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidAmount))
+		return
+	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	original, err := server.store.GetTransactionByID(c.Request.Context(), txID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errTransactionNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	receiver, err := server.store.GetWalletByID(c.Request.Context(), original.ToWalletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !receiver.UserID.Valid || receiver.UserID.Bytes != toPgUUID(userID).Bytes {
+		c.JSON(http.StatusForbidden, errorResponse(errNotTransactionReceiver))
+		return
+	}
+	if !server.verifyCredential(c, database.CredentialKindWalletPin, receiver.ID, receiver.PinHash, req.Pin) {
+		return
+	}
+	// Refunds are not held for a second factor like transfers, so large
+	// ones go through an admin instead.
+	if server.needsTransferConfirmation(amount) {
+		c.JSON(http.StatusUnprocessableEntity, errorResponse(errRefundAboveThreshold))
+		return
+	}
+
+	arg := database.RefundTxParams{
+		TransactionID: original.ID,
+		Amount:        amount,
+		Signature:     req.Signature,
+		Nonce:         req.Nonce,
+		Description:   req.Description,
+		Reason:        req.Reason,
+		CreatedBy:     userID,
+	}
+	if req.TransactionAt != nil {
+		arg.TransactionAt = pgtype.Timestamptz{Time: req.TransactionAt.UTC(), Valid: true}
+	}
+	if arg.Signature == "" {
+		refund := database.RefundTransfer(original, arg)
+		if !server.signCustodialTransfer(c, &refund) {
+			return
+		}
+		arg.Signature, arg.Nonce, arg.TransactionAt = refund.Signature, refund.Nonce, refund.TransactionAt
+	}
+
+	result, err := server.store.RefundTx(c.Request.Context(), arg)
+	if err != nil {
+		reversalErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
+
+type reverseTransactionRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// reverseTransaction undoes a transaction on an admin's say-so, paying back
+// whatever its receiver still holds from it.
+func (server *Server) reverseTransaction(c *gin.Context) {
+	var req reverseTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	txID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidTransactionID))
+		return
+	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	result, err := server.store.ReverseTx(c.Request.Context(), database.ReverseTxParams{
+		TransactionID: txID,
+		ReversedBy:    userID,
+		Reason:        req.Reason,
+	})
+	if err != nil {
+		reversalErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+type transactionReversalsResponse struct {
+	Refundable pgtype.Numeric         `json:"refundable"`
+	Reversals  []database.Transaction `json:"reversals"`
+}
+
+// listTransactionReversals returns the refunds and reversals of a
+// transaction and how much of it can still be refunded.
+func (server *Server) listTransactionReversals(c *gin.Context) {
+	txID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidTransactionID))
+		return
+	}
+	original, err := server.store.GetTransactionByID(c.Request.Context(), txID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errTransactionNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	refundable, err := server.store.GetRefundableAmount(c.Request.Context(), database.GetRefundableAmountParams{
+		TransactionID: original.ID,
+		WalletID:      original.ToWalletID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	reversals, err := server.store.ListTransactionReversals(c.Request.Context(), original.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, transactionReversalsResponse{Refundable: refundable, Reversals: reversals})
+}
+
+// reversalErrorResponse writes the response for an error from RefundTx or
+// ReverseTx.
+func reversalErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		c.JSON(http.StatusNotFound, errorResponse(errTransactionNotFound))
+	case errors.Is(err, database.ErrTransactionNotRefundable):
+		c.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, database.ErrRefundExceedsAmount), errors.Is(err, database.ErrReversalReason):
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+	default:
+		transferErrorResponse(c, err)
+	}
+}
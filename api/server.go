@@ -1,20 +1,81 @@
 package api
 
 import (
+	"fmt"
+
 	"github.com/Sahas001/pay-on/config"
 	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/Sahas001/pay-on/internal/envelope"
+	"github.com/Sahas001/pay-on/internal/jobs"
+	"github.com/Sahas001/pay-on/internal/sms"
+	"github.com/Sahas001/pay-on/internal/token"
+	"github.com/Sahas001/pay-on/internal/walletevents"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Server struct {
-	store  *database.Store
-	router *gin.Engine
-	config config.Config
+	store     *database.Store
+	router    *gin.Engine
+	config    config.Config
+	tokenKeys *token.KeySet
+	// walletSealer encrypts custodial wallet keys and authenticator
+	// secrets. It is nil when no master key is configured, which disables
+	// custodial wallets and authenticator apps.
+	walletSealer *envelope.Sealer
+	// smsSender delivers one-time codes.
+	smsSender sms.Sender
+	// transferConfirmationThreshold is the amount above which transfers
+	// need a second factor. It is not Valid when confirmation is disabled.
+	transferConfirmationThreshold pgtype.Numeric
+	// walletEvents feeds the wallet event streams.
+	walletEvents *walletevents.Hub
+	// jobs runs maintenance jobs on request.
+	jobs *jobs.Runner
 }
 
-func NewServer(cfg config.Config, store *database.Store) *Server {
-	server := &Server{store: store, config: cfg}
+func NewServer(cfg config.Config, store *database.Store, walletEvents *walletevents.Hub, runner *jobs.Runner) (*Server, error) {
+	tokenKeys := token.NewHMACKeySet(cfg.JWTSecret)
+	if len(cfg.JWTSigningKeyFiles) > 0 {
+		var err error
+		tokenKeys, err = token.LoadKeySet(cfg.JWTSigningKeyFiles)
+		if err != nil {
+			return nil, fmt.Errorf("load JWT signing keys: %w", err)
+		}
+	}
+
+	var walletSealer *envelope.Sealer
+	if cfg.WalletMasterKey != "" {
+		var err error
+		walletSealer, err = envelope.NewSealer(cfg.WalletMasterKey)
+		if err != nil {
+			return nil, fmt.Errorf("load wallet master key: %w", err)
+		}
+	}
+
+	smsSender, err := sms.New(cfg.SMSSender, cfg.SMSFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("configure SMS sender: %w", err)
+	}
+
+	var threshold pgtype.Numeric
+	if cfg.TransferConfirmationThreshold != "" {
+		if err := threshold.Scan(cfg.TransferConfirmationThreshold); err != nil {
+			return nil, fmt.Errorf("parse transfer confirmation threshold: %w", err)
+		}
+	}
+
+	server := &Server{
+		store:                         store,
+		config:                        cfg,
+		tokenKeys:                     tokenKeys,
+		walletSealer:                  walletSealer,
+		smsSender:                     smsSender,
+		transferConfirmationThreshold: threshold,
+		walletEvents:                  walletEvents,
+		jobs:                          runner,
+	}
 	router := gin.Default()
 	router.Use(cors.New(cors.Config{
 		AllowOriginFunc: func(origin string) bool {
@@ -28,63 +89,101 @@ func NewServer(cfg config.Config, store *database.Store) *Server {
 				return false
 			}
 		},
-		AllowMethods: []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders: []string{"Content-Type", "Authorization"},
+		AllowMethods:  []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:  []string{"Content-Type", "Authorization", "Idempotency-Key"},
+		ExposeHeaders: []string{"Idempotent-Replayed"},
 	}))
 
+	router.GET("/.well-known/jwks.json", server.getJWKS)
 	router.POST("/auth/register", server.register)
+	router.POST("/auth/challenge", server.createLoginChallenge)
 	router.POST("/auth/login", server.login)
+	router.POST("/auth/refresh", server.refresh)
+	router.POST("/auth/password/forgot", server.forgotPassword)
+	router.POST("/auth/password/reset", server.resetPassword)
 
 	api := router.Group("/")
-	api.Use(server.authMiddleware())
+	api.Use(server.authMiddleware(), server.idempotencyMiddleware())
+
+	api.POST("/auth/logout", server.logout)
+	api.POST("/auth/logout-all", server.logoutAll)
+	api.POST("/auth/phone/send-code", server.sendPhoneVerificationCode)
+	api.POST("/auth/phone/verify", server.verifyPhone)
+	api.GET("/auth/2fa", server.getTwoFactorStatus)
+	api.POST("/auth/2fa/totp", server.enrollTOTP)
+	api.POST("/auth/2fa/totp/confirm", server.confirmTOTP)
+	api.DELETE("/auth/2fa/totp", server.disableTOTP)
+
+	ownWallet := server.authorizeWallet()
+	ownTransaction := server.authorizeTransaction()
+	ownPeer := server.authorizePeer()
+	ownSyncLog := server.authorizeSyncLog()
+	ownDevice := server.authorizeDevice()
+	onDevice := server.requireDevice()
+
+	// Operational endpoints that span all users are restricted by role.
+	adminOnly := server.requireRole(database.UserRoleAdmin)
+	staffOnly := server.requireRole(database.UserRoleSupport)
+	agentOnly := server.requireRole(database.UserRoleAgent)
 
 	wallets := api.Group("/wallets")
 	wallets.POST("", server.createWallet)
-	wallets.GET("", server.listWallets)
-	wallets.GET("/active", server.listActiveWallets)
-	wallets.GET("/count", server.countWallets)
-	wallets.GET("/needs-sync", server.getWalletsNeedingSync)
-	wallets.GET("/search/name", server.searchWalletsByName)
-	wallets.GET("/search/phone", server.searchWalletsByPhone)
+	wallets.GET("", staffOnly, server.listWallets)
+	wallets.GET("/active", staffOnly, server.listActiveWallets)
+	wallets.GET("/count", staffOnly, server.countWallets)
+	wallets.GET("/needs-sync", staffOnly, server.getWalletsNeedingSync)
+	wallets.GET("/search/name", staffOnly, server.searchWalletsByName)
+	wallets.GET("/search/phone", staffOnly, server.searchWalletsByPhone)
 	wallets.GET("/phone/:phone", server.getWalletByPhoneNumber)
 	wallets.GET("/public/:public_key", server.getWalletByPublicKey)
 	wallets.GET("/device/:device_id", server.getWalletByDeviceID)
-	wallets.GET("/:id", server.getWalletByID)
-	wallets.GET("/:id/summary", server.getWalletWithBalance)
-	wallets.GET("/:id/balance", server.getWalletBalance)
-	wallets.GET("/:id/balance-history", server.getWalletBalanceHistory)
-	wallets.GET("/:id/dashboard", server.getWalletDashboard)
-	wallets.PATCH("/:id", server.updateWallet)
-	wallets.PATCH("/:id/balance", server.updateWalletBalance)
-	wallets.POST("/:id/balance/increment", server.incrementWalletBalance)
-	wallets.POST("/:id/balance/decrement", server.decrementWalletBalance)
-	wallets.PATCH("/:id/pin", server.updateWalletPIN)
-	wallets.PATCH("/:id/sync", server.updateWalletLastSync)
-	wallets.POST("/:id/deactivate", server.deactivateWallet)
-	wallets.POST("/:id/activate", server.activateWallet)
-	wallets.DELETE("/:id", server.softDeleteWallet)
-	wallets.DELETE("/:id/hard", server.hardDeleteWallet)
+	wallets.GET("/:id", ownWallet, server.getWalletByID)
+	wallets.GET("/:id/summary", ownWallet, server.getWalletWithBalance)
+	wallets.GET("/:id/balance", ownWallet, server.getWalletBalance)
+	wallets.GET("/:id/balance-history", ownWallet, server.getWalletBalanceHistory)
+	wallets.GET("/:id/dashboard", ownWallet, server.getWalletDashboard)
+	wallets.GET("/:id/ledger", ownWallet, server.listWalletLedgerEntries)
+	wallets.GET("/:id/limits", ownWallet, server.getWalletLimits)
+	wallets.PUT("/:id/limits", adminOnly, server.updateWalletLimits)
+	wallets.DELETE("/:id/limits", adminOnly, server.deleteWalletLimits)
+	wallets.PATCH("/:id", ownWallet, server.updateWallet)
+	wallets.PATCH("/:id/balance", adminOnly, server.updateWalletBalance)
+	wallets.POST("/:id/balance/increment", adminOnly, server.incrementWalletBalance)
+	wallets.POST("/:id/balance/decrement", adminOnly, server.decrementWalletBalance)
+	wallets.PATCH("/:id/pin", ownWallet, server.updateWalletPIN)
+	wallets.POST("/:id/pin/send-code", ownWallet, server.sendWalletPINCode)
+	wallets.GET("/:id/keys", ownWallet, server.listWalletKeys)
+	wallets.POST("/:id/keys/rotate", ownWallet, onDevice, server.rotateWalletKey)
+	wallets.POST("/:id/keys/:key_id/revoke", adminOnly, server.revokeWalletKey)
+	wallets.GET("/:id/payment-requests", ownWallet, server.listPaymentRequestsByWallet)
+	wallets.GET("/:id/events", ownWallet, server.streamWalletEvents)
+	wallets.PATCH("/:id/sync", ownWallet, server.updateWalletLastSync)
+	wallets.POST("/:id/deactivate", ownWallet, server.deactivateWallet)
+	wallets.POST("/:id/activate", adminOnly, server.activateWallet)
+	wallets.DELETE("/:id", ownWallet, server.softDeleteWallet)
+	wallets.DELETE("/:id/hard", adminOnly, server.hardDeleteWallet)
 
 	transactions := api.Group("/transactions")
-	transactions.POST("", server.createTransaction)
-	transactions.GET("/search", server.searchTransactions)
-	transactions.GET("/recent", server.getRecentTransactions)
-	transactions.GET("/status/:status", server.listTransactionsByStatus)
-	transactions.GET("/unsynced", server.listUnsyncedTransactions)
-	transactions.GET("/pending/count", server.countPendingTransactions)
-	transactions.GET("/connection/:type", server.getTransactionsByConnectionType)
-	transactions.GET("/large", server.getLargeTransactions)
-	transactions.GET("/metadata", server.getTransactionsByMetadata)
-	transactions.GET("/:id", server.getTransactionByID)
-	transactions.GET("/:id/with-wallets", server.getTransactionWithWallets)
-	transactions.PATCH("/:id/status", server.updateTransactionStatus)
-	transactions.POST("/:id/confirm", server.confirmTransaction)
-	transactions.POST("/:id/settling", server.settingTransaction)
-	transactions.POST("/:id/settled", server.settledTransaction)
-	transactions.POST("/:id/mark-settled", server.markTransactionSettled)
-	transactions.POST("/:id/fail", server.failTransaction)
-
-	walletTransactions := api.Group("/wallets/:id/transactions")
+	transactions.POST("", onDevice, server.createTransaction)
+	transactions.GET("/search", staffOnly, server.searchTransactions)
+	transactions.GET("/recent", staffOnly, server.getRecentTransactions)
+	transactions.GET("/status/:status", staffOnly, server.listTransactionsByStatus)
+	transactions.GET("/unsynced", staffOnly, server.listUnsyncedTransactions)
+	transactions.GET("/pending/count", staffOnly, server.countPendingTransactions)
+	transactions.GET("/connection/:type", staffOnly, server.getTransactionsByConnectionType)
+	transactions.GET("/large", staffOnly, server.getLargeTransactions)
+	transactions.GET("/metadata", staffOnly, server.getTransactionsByMetadata)
+	transactions.GET("/:id", ownTransaction, server.getTransactionByID)
+	transactions.GET("/:id/with-wallets", ownTransaction, server.getTransactionWithWallets)
+	transactions.POST("/:id/confirm", adminOnly, server.confirmTransaction)
+	transactions.POST("/:id/fail", staffOnly, server.failTransaction)
+	transactions.POST("/:id/refund", ownTransaction, onDevice, server.refundTransaction)
+	transactions.POST("/:id/reverse", adminOnly, server.reverseTransaction)
+	transactions.GET("/:id/reversals", ownTransaction, server.listTransactionReversals)
+	transactions.POST("/:id/settle", ownTransaction, server.settleTransaction)
+	transactions.GET("/:id/ledger", ownTransaction, server.getTransactionLedgerEntries)
+
+	walletTransactions := api.Group("/wallets/:id/transactions", ownWallet)
 	walletTransactions.GET("", server.listTransactionsByWallet)
 	walletTransactions.GET("/sent", server.listSentTransactions)
 	walletTransactions.GET("/received", server.listReceivedTransactions)
@@ -94,17 +193,18 @@ func NewServer(cfg config.Config, store *database.Store) *Server {
 	walletTransactions.GET("/daily-summary", server.getDailyTransactionSummary)
 	walletTransactions.GET("/count", server.countTransactionsByWallet)
 	walletTransactions.GET("/nonce/:nonce", server.checkNonceExists)
+	walletTransactions.POST("/settle", server.settleWalletTransactions)
 
 	peers := api.Group("/peers")
 	peers.POST("", server.createPeer)
 	peers.POST("/upsert", server.upsertPeer)
-	peers.POST("/auto-trust", server.autoTrustFrequentPeers)
-	peers.GET("/:id", server.getPeerByID)
-	peers.PATCH("/:id/last-seen", server.updatePeerLastSeen)
-	peers.DELETE("/:id", server.deletePeer)
-	peers.DELETE("/:id/hard", server.hardDeletePeer)
+	peers.POST("/auto-trust", adminOnly, server.autoTrustFrequentPeers)
+	peers.GET("/:id", ownPeer, server.getPeerByID)
+	peers.PATCH("/:id/last-seen", ownPeer, server.updatePeerLastSeen)
+	peers.DELETE("/:id", ownPeer, server.deletePeer)
+	peers.DELETE("/:id/hard", ownPeer, server.hardDeletePeer)
 
-	walletPeers := api.Group("/wallets/:id/peers")
+	walletPeers := api.Group("/wallets/:id/peers", ownWallet)
 	walletPeers.GET("", server.listPeersByWallet)
 	walletPeers.GET("/trusted", server.listTrustedPeers)
 	walletPeers.GET("/recent", server.listRecentPeers)
@@ -120,30 +220,35 @@ func NewServer(cfg config.Config, store *database.Store) *Server {
 	walletPeers.POST("/:peer_id/transaction-count", server.incrementPeerTransactionCount)
 
 	syncLogs := api.Group("/sync-logs")
-	syncLogs.POST("", server.createSyncLog)
-	syncLogs.GET("/pending", server.listAllPendingSyncs)
-	syncLogs.GET("/retry", server.getSyncsNeedingRetry)
-	syncLogs.GET("/count/:status", server.countSyncLogsByStatus)
-	syncLogs.DELETE("/old", server.deleteOldSyncLogs)
-	syncLogs.GET("/:id", server.getSyncLogByID)
-	syncLogs.PATCH("/:id/status", server.updateSyncLogStatus)
-	syncLogs.POST("/:id/settle-success", server.markSettleSuccessful)
-	syncLogs.POST("/:id/settle-failed", server.markSettleFailed)
-	syncLogs.POST("/:id/settle-conflict", server.markSettleConflict)
-	syncLogs.POST("/:id/resolve", server.resolveSyncConflict)
-
-	walletSyncLogs := api.Group("/wallets/:id/sync-logs")
+	syncLogs.POST("", staffOnly, server.createSyncLog)
+	syncLogs.GET("/pending", staffOnly, server.listAllPendingSyncs)
+	syncLogs.GET("/retry", staffOnly, server.getSyncsNeedingRetry)
+	syncLogs.GET("/dead-letters", staffOnly, server.listDeadLetterSyncs)
+	syncLogs.GET("/count/:status", staffOnly, server.countSyncLogsByStatus)
+	syncLogs.DELETE("/old", adminOnly, server.deleteOldSyncLogs)
+	syncLogs.GET("/:id", ownSyncLog, server.getSyncLogByID)
+	syncLogs.PATCH("/:id/status", adminOnly, server.updateSyncLogStatus)
+	syncLogs.POST("/:id/settle-success", adminOnly, server.markSettleSuccessful)
+	syncLogs.POST("/:id/settle-failed", adminOnly, server.markSettleFailed)
+	syncLogs.POST("/:id/settle-conflict", adminOnly, server.markSettleConflict)
+	syncLogs.POST("/:id/resolve", adminOnly, server.resolveSyncConflict)
+	syncLogs.POST("/:id/requeue", staffOnly, server.requeueSyncLog)
+
+	walletSyncLogs := api.Group("/wallets/:id/sync-logs", ownWallet)
 	walletSyncLogs.GET("", server.getSyncLogsByWallet)
 	walletSyncLogs.GET("/pending", server.listPendingSyncs)
 	walletSyncLogs.GET("/failed", server.listFailedSyncs)
 	walletSyncLogs.GET("/conflicts", server.listConflictedSyncs)
 	walletSyncLogs.GET("/stats", server.getSyncStats)
 
-	transactionSyncLogs := api.Group("/transactions/:id/sync-logs")
+	transactionSyncLogs := api.Group("/transactions/:id/sync-logs", ownTransaction)
 	transactionSyncLogs.GET("", server.getSyncLogsByTransaction)
 
-	auditLogs := api.Group("/audit-logs")
-	auditLogs.POST("", server.createAuditLog)
+	ledger := api.Group("/ledger")
+	ledger.GET("/reconcile", staffOnly, server.reconcileLedger)
+
+	auditLogs := api.Group("/audit-logs", staffOnly)
+	auditLogs.POST("", adminOnly, server.createAuditLog)
 	auditLogs.GET("", server.listAuditLogs)
 	auditLogs.GET("/recent", server.getRecentAuditLogs)
 	auditLogs.GET("/count", server.countAuditLogs)
@@ -156,16 +261,54 @@ func NewServer(cfg config.Config, store *database.Store) *Server {
 	auditLogs.GET("/ip/:ip", server.listAuditLogsByIP)
 	auditLogs.GET("/history/:table/:record_id", server.getRecordHistory)
 	auditLogs.GET("/balance-history/:wallet_id", server.getBalanceHistory)
-	auditLogs.DELETE("/old", server.deleteOldAuditLogs)
+	auditLogs.DELETE("/old", adminOnly, server.deleteOldAuditLogs)
 	auditLogs.GET("/:id", server.getAuditLogByID)
 
 	stats := api.Group("/stats")
-	stats.GET("/system", server.getSystemStats)
+	stats.GET("/system", staffOnly, server.getSystemStats)
+
+	agents := api.Group("/agents")
+	agents.POST("", adminOnly, server.createAgent)
+	agents.GET("", staffOnly, server.listAgents)
+	agents.GET("/:id", staffOnly, server.getAgent)
+	agents.PATCH("/:id", adminOnly, server.updateAgent)
+
+	devices := api.Group("/devices")
+	devices.GET("", server.listDevices)
+	devices.POST("/:id/verify", ownDevice, onDevice, server.verifyDevice)
+	devices.DELETE("/:id", ownDevice, server.revokeDevice)
+
+	users := api.Group("/users")
+	users.PATCH("/:id/role", adminOnly, server.updateUserRole)
+
+	paymentRequests := api.Group("/payment-requests")
+	paymentRequests.POST("", server.createPaymentRequest)
+	paymentRequests.GET("/:id", server.getPaymentRequest)
+	paymentRequests.GET("/:id/qr", server.getPaymentRequestQR)
+	paymentRequests.POST("/:id/cancel", server.cancelPaymentRequest)
+
+	webhooks := api.Group("/webhooks")
+	webhooks.POST("", server.createWebhook)
+	webhooks.GET("", server.listWebhooks)
+	webhooks.GET("/:id", server.getWebhook)
+	webhooks.PATCH("/:id", server.updateWebhook)
+	webhooks.DELETE("/:id", server.deleteWebhook)
+	webhooks.GET("/:id/deliveries", server.listWebhookDeliveries)
+
+	jobs := api.Group("/jobs", adminOnly)
+	jobs.GET("", server.listJobs)
+	jobs.POST("/:name/run", server.runJob)
+	jobs.GET("/:name/runs", server.listJobRuns)
 
-	api.POST("/transfers", server.transferTx)
+	api.POST("/transfers", onDevice, server.transferTx)
+	api.POST("/transfers/challenges/:id/confirm", onDevice, server.confirmTransfer)
+	api.POST("/transfers/challenges/:id/send-code", server.sendTransferChallengeCode)
+	api.POST("/deposits", agentOnly, onDevice, server.createDeposit)
+	api.POST("/withdrawals", agentOnly, onDevice, server.createWithdrawal)
+	api.POST("/sync", onDevice, server.syncTransactions)
 
 	server.router = router
-	return server
+	return server, nil
 }
 
 func (server *Server) Start(address string) error {
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var errEmptySyncBatch = errors.New("sync batch must contain between 1 and 100 transactions")
+
+const maxSyncBatchSize = 100
+
+type syncTransactionRequest struct {
+	FromWalletID   uuid.UUID       `json:"from_wallet_id" binding:"required"`
+	ToWalletID     uuid.UUID       `json:"to_wallet_id" binding:"required"`
+	Amount         pgtype.Numeric  `json:"amount" binding:"required"`
+	Currency       string          `json:"currency"`
+	Type           string          `json:"type"`
+	Signature      string          `json:"signature" binding:"required"`
+	Nonce          int64           `json:"nonce" binding:"required"`
+	ConnectionType string          `json:"connection_type"`
+	Description    *string         `json:"description"`
+	Metadata       json.RawMessage `json:"metadata"`
+	TransactionAt  time.Time       `json:"transaction_at" binding:"required"`
+	// DeviceID and DeviceSignature attest the sender device the payment was
+	// signed on; the device key signs the same payload as the wallet key.
+	DeviceID        string `json:"device_id" binding:"required"`
+	DeviceSignature string `json:"device_signature" binding:"required"`
+}
+
+type syncRequest struct {
+	WalletID     uuid.UUID                `json:"wallet_id" binding:"required"`
+	Transactions []syncTransactionRequest `json:"transactions" binding:"required,dive"`
+}
+
+func (server *Server) syncTransactions(c *gin.Context) {
+	var req syncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if len(req.Transactions) == 0 || len(req.Transactions) > maxSyncBatchSize {
+		c.JSON(http.StatusBadRequest, errorResponse(errEmptySyncBatch))
+		return
+	}
+
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	wallet, err := server.store.GetWalletByID(c.Request.Context(), req.WalletID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !wallet.UserID.Valid || wallet.UserID.Bytes != toPgUUID(userID).Bytes {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	transactions := make([]database.TransferTxParams, 0, len(req.Transactions))
+	devices := make([]database.DeviceAttestation, 0, len(req.Transactions))
+	for _, item := range req.Transactions {
+		txType := database.TransactionType(item.Type)
+		if item.Type != "" && !txType.Valid() {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidType))
+			return
+		}
+
+		var connType database.NullConnectionType
+		if item.ConnectionType != "" {
+			typed := database.ConnectionType(item.ConnectionType)
+			if !typed.Valid() {
+				c.JSON(http.StatusBadRequest, errorResponse(errInvalidConnectionType))
+				return
+			}
+			connType = database.NullConnectionType{ConnectionType: typed, Valid: true}
+		}
+
+		transactions = append(transactions, database.TransferTxParams{
+			FromWalletID:   item.FromWalletID,
+			ToWalletID:     item.ToWalletID,
+			Amount:         item.Amount,
+			Currency:       item.Currency,
+			Type:           txType,
+			Signature:      item.Signature,
+			Nonce:          item.Nonce,
+			ConnectionType: connType,
+			Description:    item.Description,
+			Metadata:       item.Metadata,
+			TransactionAt:  pgtype.Timestamptz{Time: item.TransactionAt.UTC(), Valid: true},
+		})
+		devices = append(devices, database.DeviceAttestation{
+			DeviceID:  item.DeviceID,
+			Signature: item.DeviceSignature,
+		})
+	}
+
+	result, err := server.store.SyncTx(c.Request.Context(), database.SyncTxParams{
+		WalletID:     req.WalletID,
+		Transactions: transactions,
+		Devices:      devices,
+		MaxAmount:    server.transferConfirmationThreshold,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (server *Server) settleTransaction(c *gin.Context) {
+	txID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidTransactionID))
+		return
+	}
+
+	result, err := server.store.SettleTx(c.Request.Context(), txID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errTransactionNotFound))
+			return
+		}
+		if errors.Is(err, database.ErrTransactionNotSettleable) {
+			c.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if database.IsLimitError(err) {
+			c.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if result.Conflict != nil {
+		c.JSON(http.StatusConflict, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (server *Server) settleWalletTransactions(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	limit, ok := parseLimit(c, maxSyncBatchSize)
+	if !ok {
+		return
+	}
+
+	results, err := server.store.SettleWalletTx(c.Request.Context(), walletID, int32(limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 var (
@@ -18,6 +19,7 @@ var (
 	errInvalidRetryCount  = errors.New("invalid retry count")
 	errSyncLogNotFound    = errors.New("sync log not found")
 	errInvalidTransaction = errors.New("invalid transaction id")
+	errSyncLogNotDead     = errors.New("sync log is not a dead letter")
 )
 
 type createSyncLogRequest struct {
@@ -37,6 +39,9 @@ func (server *Server) createSyncLog(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(errInvalidSyncStatus))
 		return
 	}
+	if !server.authorizeWalletID(c, req.WalletID) {
+		return
+	}
 	log, err := server.store.CreateSyncLog(c.Request.Context(), database.CreateSyncLogParams{
 		TransactionID: req.TransactionID,
 		WalletID:      req.WalletID,
@@ -92,6 +97,49 @@ func (server *Server) getSyncsNeedingRetry(c *gin.Context) {
 	c.JSON(http.StatusOK, logs)
 }
 
+// listDeadLetterSyncs returns sync logs whose settlement ran out of retries,
+// most recently given up on first.
+func (server *Server) listDeadLetterSyncs(c *gin.Context) {
+	limit, offset, ok := parseLimitOffset(c)
+	if !ok {
+		return
+	}
+	logs, err := server.store.ListDeadLetterSyncs(c.Request.Context(), database.ListDeadLetterSyncsParams{
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, logs)
+}
+
+// requeueSyncLog hands a dead letter back to the retry worker with a fresh
+// set of attempts.
+func (server *Server) requeueSyncLog(c *gin.Context) {
+	logID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidSyncLogID))
+		return
+	}
+	log, err := server.store.RequeueSyncLog(c.Request.Context(), logID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Tell a missing log apart from one that is not a dead letter.
+		if _, err := server.store.GetSyncLogByID(c.Request.Context(), logID); err == nil {
+			c.JSON(http.StatusConflict, errorResponse(errSyncLogNotDead))
+			return
+		}
+		c.JSON(http.StatusNotFound, errorResponse(errSyncLogNotFound))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, log)
+}
+
 func (server *Server) countSyncLogsByStatus(c *gin.Context) {
 	status := database.SyncStatus(c.Param("status"))
 	if !status.Valid() {
@@ -255,22 +303,57 @@ func (server *Server) markSettleConflict(c *gin.Context) {
 	c.JSON(http.StatusOK, log)
 }
 
+type resolveSyncConflictRequest struct {
+	Resolution string         `json:"resolution" binding:"required"`
+	Reason     string         `json:"reason" binding:"required"`
+	Amount     pgtype.Numeric `json:"amount"`
+}
+
 func (server *Server) resolveSyncConflict(c *gin.Context) {
 	logID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, errorResponse(errInvalidSyncLogID))
 		return
 	}
-	log, err := server.store.ResolveSyncConflict(c.Request.Context(), logID)
+	var req resolveSyncConflictRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	resolution := database.SyncResolution(req.Resolution)
+	if !resolution.Valid() {
+		c.JSON(http.StatusBadRequest, errorResponse(database.ErrInvalidResolution))
+		return
+	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	result, err := server.store.ResolveConflictTx(c.Request.Context(), database.ResolveConflictTxParams{
+		SyncLogID:  logID,
+		Resolution: resolution,
+		ResolvedBy: userID,
+		Reason:     req.Reason,
+		Amount:     req.Amount,
+	})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
 			c.JSON(http.StatusNotFound, errorResponse(errSyncLogNotFound))
-			return
+		case errors.Is(err, database.ErrInvalidSplitAmount), errors.Is(err, database.ErrResolutionReason):
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+		case errors.Is(err, database.ErrSyncLogNotInConflict),
+			errors.Is(err, database.ErrTransactionNotResolvable),
+			errors.Is(err, database.ErrInsufficientBalance):
+			c.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
 		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	c.JSON(http.StatusOK, log)
+	c.JSON(http.StatusOK, result)
 }
 
 func (server *Server) getSyncLogsByWallet(c *gin.Context) {
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/Sahas001/pay-on/internal/signature"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -22,6 +23,7 @@ var (
 	errInvalidNonce           = errors.New("invalid nonce")
 	errInvalidAmount          = errors.New("invalid amount")
 	errTransactionNotFound    = errors.New("transaction not found")
+	errTransactionNotPending  = errors.New("only pending transactions can be confirmed")
 	errInvalidMetadataPayload = errors.New("invalid metadata payload")
 )
 
@@ -46,6 +48,9 @@ func (server *Server) createTransaction(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	if !server.authorizeWalletID(c, req.FromWalletID) {
+		return
+	}
 
 	txType := database.TransactionType(req.Type)
 	if req.Type == "" {
@@ -55,12 +60,14 @@ func (server *Server) createTransaction(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(errInvalidType))
 		return
 	}
-
-	txStatus := database.TransactionStatus(req.Status)
-	if req.Status == "" {
-		txStatus = database.TransactionStatusPending
+	if txType == database.TransactionTypeRefund || txType == database.TransactionTypeReversal {
+		c.JSON(http.StatusBadRequest, errorResponse(database.ErrReversalTransferType))
+		return
 	}
-	if !txStatus.Valid() {
+
+	// New transactions are always pending. Nothing is checked against the
+	// spending limits here; settlement does that before any money moves.
+	if req.Status != "" && req.Status != string(database.TransactionStatusPending) {
 		c.JSON(http.StatusBadRequest, errorResponse(errInvalidStatus))
 		return
 	}
@@ -80,26 +87,50 @@ func (server *Server) createTransaction(c *gin.Context) {
 		metadata = []byte(`{}`)
 	}
 
-	txTime := pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
-	if req.TransactionAt != nil {
-		txTime = pgtype.Timestamptz{Time: req.TransactionAt.UTC(), Valid: true}
+	if req.TransactionAt == nil {
+		c.JSON(http.StatusBadRequest, errorResponse(signature.ErrMissingSignedData))
+		return
 	}
+	txTime := pgtype.Timestamptz{Time: req.TransactionAt.UTC(), Valid: true}
 
-	transaction, err := server.store.CreateTransaction(c.Request.Context(), database.CreateTransactionParams{
+	currency := req.Currency
+	if currency == "" {
+		currency = "NPR"
+	}
+
+	arg := database.CreateTransactionParams{
 		FromWalletID:   req.FromWalletID,
 		ToWalletID:     req.ToWalletID,
 		Amount:         req.Amount,
-		Currency:       req.Currency,
+		Currency:       currency,
 		Type:           txType,
-		Status:         txStatus,
+		Status:         database.TransactionStatusPending,
 		Signature:      req.Signature,
 		Nonce:          req.Nonce,
 		ConnectionType: connType,
 		Description:    req.Description,
 		Metadata:       metadata,
 		TransactionAt:  txTime,
-	})
+	}
+
+	if err := server.store.VerifyTransactionSignature(c.Request.Context(), arg); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return
+		}
+		if signatureErrorResponse(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	transaction, err := server.store.CreateTransaction(c.Request.Context(), arg)
 	if err != nil {
+		if database.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, errorResponse(database.ErrDuplicateNonce))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -316,41 +347,9 @@ func (server *Server) getTransactionWithWallets(c *gin.Context) {
 	c.JSON(http.StatusOK, transaction)
 }
 
-type updateTransactionStatusRequest struct {
-	Status string `json:"status" binding:"required"`
-}
-
-func (server *Server) updateTransactionStatus(c *gin.Context) {
-	var req updateTransactionStatusRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
-		return
-	}
-	status := database.TransactionStatus(req.Status)
-	if !status.Valid() {
-		c.JSON(http.StatusBadRequest, errorResponse(errInvalidStatus))
-		return
-	}
-	txID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(errInvalidTransactionID))
-		return
-	}
-	transaction, err := server.store.UpdateTransactionStatus(c.Request.Context(), database.UpdateTransactionStatusParams{
-		ID:     txID,
-		Status: status,
-	})
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, errorResponse(errTransactionNotFound))
-			return
-		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
-	c.JSON(http.StatusOK, transaction)
-}
-
+// confirmTransaction confirms a pending transaction so that it can be
+// settled. Anything past pending has already been confirmed, settled or
+// given up on and returns 409.
 func (server *Server) confirmTransaction(c *gin.Context) {
 	txID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -358,24 +357,13 @@ func (server *Server) confirmTransaction(c *gin.Context) {
 		return
 	}
 	transaction, err := server.store.ConfirmTransaction(c.Request.Context(), txID)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, errorResponse(errTransactionNotFound))
+	if errors.Is(err, pgx.ErrNoRows) {
+		_, err = server.store.GetTransactionByID(c.Request.Context(), txID)
+		if err == nil {
+			c.JSON(http.StatusConflict, errorResponse(errTransactionNotPending))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
-	c.JSON(http.StatusOK, transaction)
-}
-
-func (server *Server) settingTransaction(c *gin.Context) {
-	txID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(errInvalidTransactionID))
-		return
 	}
-	transaction, err := server.store.SettingTransaction(c.Request.Context(), txID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			c.JSON(http.StatusNotFound, errorResponse(errTransactionNotFound))
@@ -387,13 +375,13 @@ func (server *Server) settingTransaction(c *gin.Context) {
 	c.JSON(http.StatusOK, transaction)
 }
 
-func (server *Server) settledTransaction(c *gin.Context) {
+func (server *Server) failTransaction(c *gin.Context) {
 	txID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, errorResponse(errInvalidTransactionID))
 		return
 	}
-	transaction, err := server.store.SettledTransaction(c.Request.Context(), txID)
+	transaction, err := server.store.GetTransactionByID(c.Request.Context(), txID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			c.JSON(http.StatusNotFound, errorResponse(errTransactionNotFound))
@@ -402,34 +390,21 @@ func (server *Server) settledTransaction(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	c.JSON(http.StatusOK, transaction)
-}
-
-func (server *Server) markTransactionSettled(c *gin.Context) {
-	txID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(errInvalidTransactionID))
-		return
-	}
-	transaction, err := server.store.MarkTransactionSettled(c.Request.Context(), txID)
+	// Failing only flips the status, so money that already moved has to go
+	// back through a reversal.
+	refundable, err := server.store.GetRefundableAmount(c.Request.Context(), database.GetRefundableAmountParams{
+		TransactionID: transaction.ID,
+		WalletID:      transaction.ToWalletID,
+	})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			c.JSON(http.StatusNotFound, errorResponse(errTransactionNotFound))
-			return
-		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	c.JSON(http.StatusOK, transaction)
-}
-
-func (server *Server) failTransaction(c *gin.Context) {
-	txID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(errInvalidTransactionID))
+	if refundable.Int != nil && refundable.Int.Sign() > 0 {
+		c.JSON(http.StatusConflict, errorResponse(errReverseInstead))
 		return
 	}
-	if err := server.store.FailTransaction(c.Request.Context(), txID); err != nil {
+	if _, err := server.store.FailTransactionTx(c.Request.Context(), txID); err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
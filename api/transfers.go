@@ -9,15 +9,17 @@ import (
 	database "github.com/Sahas001/pay-on/internal/database/sqlc"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	errInvalidTransferType   = errors.New("invalid transfer type")
-	errInvalidTransferStatus = errors.New("invalid transfer status")
+	errInvalidTransferType = errors.New("invalid transfer type")
+	errMissingSignature    = errors.New("signature is required for wallets that are not custodial")
 )
 
+// transferRequest is a signed online transfer. Transfers from custodial
+// wallets may leave the signature out for the server to add.
 type transferRequest struct {
 	FromWalletID   string          `json:"from_wallet_id" binding:"required"`
 	ToWalletID     string          `json:"to_wallet_id" binding:"required"`
@@ -25,8 +27,7 @@ type transferRequest struct {
 	Pin            string          `json:"pin" binding:"required"`
 	Currency       string          `json:"currency"`
 	Type           string          `json:"type"`
-	Status         string          `json:"status"`
-	Signature      string          `json:"signature" binding:"required"`
+	Signature      string          `json:"signature"`
 	Nonce          int64           `json:"nonce"`
 	ConnectionType string          `json:"connection_type"`
 	Description    *string         `json:"description"`
@@ -66,6 +67,10 @@ func (server *Server) transferTx(c *gin.Context) {
 
 	fromWallet, err := server.store.GetWalletByID(c.Request.Context(), fromWalletID)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -73,8 +78,7 @@ func (server *Server) transferTx(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
 		return
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(fromWallet.PinHash), []byte(req.Pin)); err != nil {
-		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+	if !server.verifyCredential(c, database.CredentialKindWalletPin, fromWallet.ID, fromWallet.PinHash, req.Pin) {
 		return
 	}
 
@@ -84,12 +88,6 @@ func (server *Server) transferTx(c *gin.Context) {
 		return
 	}
 
-	txStatus := database.TransactionStatus(req.Status)
-	if req.Status != "" && !txStatus.Valid() {
-		c.JSON(http.StatusBadRequest, errorResponse(errInvalidTransferStatus))
-		return
-	}
-
 	var connType database.NullConnectionType
 	if req.ConnectionType != "" {
 		typed := database.ConnectionType(req.ConnectionType)
@@ -110,24 +108,84 @@ func (server *Server) transferTx(c *gin.Context) {
 		txTime = pgtype.Timestamptz{Time: req.TransactionAt.UTC(), Valid: true}
 	}
 
-	result, err := server.store.TransferTx(c.Request.Context(), database.TransferTxParams{
+	arg := database.TransferTxParams{
 		FromWalletID:   fromWalletID,
 		ToWalletID:     toWalletID,
 		Amount:         amount,
 		Currency:       req.Currency,
 		Type:           txType,
-		Status:         txStatus,
 		Signature:      req.Signature,
 		Nonce:          req.Nonce,
 		ConnectionType: connType,
 		Description:    req.Description,
 		Metadata:       metadata,
 		TransactionAt:  txTime,
-	})
+	}
+	if arg.Signature == "" {
+		if !server.signCustodialTransfer(c, &arg) {
+			return
+		}
+	}
+
+	if server.needsTransferConfirmation(amount) {
+		server.createTransferChallenge(c, userID, arg)
+		return
+	}
+
+	result, err := server.store.TransferTx(c.Request.Context(), arg)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		transferErrorResponse(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, result)
 }
+
+// transferErrorResponse writes the response for an error from TransferTx.
+func transferErrorResponse(c *gin.Context, err error) {
+	if signatureErrorResponse(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, database.ErrCashTransferType), errors.Is(err, database.ErrReversalTransferType),
+		errors.Is(err, database.ErrSameWallet),
+		errors.Is(err, database.ErrPaymentRequestMismatch):
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+	case errors.Is(err, database.ErrPaymentRequestNotFound), errors.Is(err, database.ErrWalletNotFound):
+		c.JSON(http.StatusNotFound, errorResponse(err))
+	case errors.Is(err, database.ErrPaymentRequestNotPayable):
+		c.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, database.ErrInsufficientBalance):
+		c.JSON(http.StatusConflict, errorResponse(err))
+	case database.IsLimitError(err):
+		c.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	case database.IsUniqueViolation(err):
+		c.JSON(http.StatusConflict, errorResponse(database.ErrDuplicateNonce))
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+// signCustodialTransfer signs an unsigned transfer with the server-held key
+// of a custodial wallet, writing the error response and reporting false when
+// it cannot.
+func (server *Server) signCustodialTransfer(c *gin.Context, arg *database.TransferTxParams) bool {
+	if server.walletSealer == nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errMissingSignature))
+		return false
+	}
+	err := server.store.SignCustodialTransfer(c.Request.Context(), arg, server.walletSealer)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, database.ErrNotCustodial):
+		c.JSON(http.StatusBadRequest, errorResponse(errMissingSignature))
+	case errors.Is(err, database.ErrCustodialKeyUnsealed):
+		c.JSON(http.StatusServiceUnavailable, errorResponse(err))
+	case errors.Is(err, database.ErrCustodialKeyRejected):
+		c.JSON(http.StatusConflict, errorResponse(err))
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+	return false
+}
@@ -0,0 +1,348 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/Sahas001/pay-on/internal/totp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const totpIssuer = "Pay-On"
+
+var (
+	errTwoFactorUnavailable      = errors.New("transfers above the confirmation threshold need an authenticator app or a verified phone number")
+	errInvalidChallengeIDParam   = errors.New("invalid transfer challenge id")
+	errTransferChallengeNotFound = errors.New("transfer challenge not found")
+	errChallengeNotOTP           = errors.New("transfer challenge is confirmed with an authenticator app")
+)
+
+type twoFactorStatusResponse struct {
+	TOTPEnabled   bool `json:"totp_enabled"`
+	PhoneVerified bool `json:"phone_verified"`
+}
+
+func (server *Server) getTwoFactorStatus(c *gin.Context) {
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	user, err := server.store.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	totpEnabled, err := server.totpEnabled(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, twoFactorStatusResponse{
+		TOTPEnabled:   totpEnabled,
+		PhoneVerified: user.PhoneVerifiedAt.Valid,
+	})
+}
+
+func (server *Server) totpEnabled(c *gin.Context, userID uuid.UUID) (bool, error) {
+	secret, err := server.store.GetTOTPSecret(c.Request.Context(), userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return secret.ConfirmedAt.Valid, nil
+}
+
+type enrollTOTPResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// enrollTOTP starts enrolling an authenticator app. The secret is shown once
+// and takes effect when a code from the app is confirmed.
+func (server *Server) enrollTOTP(c *gin.Context) {
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	user, err := server.store.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if _, err := server.store.EnrollTOTP(c.Request.Context(), userID, secret, server.walletSealer); err != nil {
+		switch {
+		case errors.Is(err, database.ErrTOTPAlreadyEnabled):
+			c.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, database.ErrTOTPUnavailable):
+			c.JSON(http.StatusServiceUnavailable, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, enrollTOTPResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(totpIssuer, user.PhoneNumber, secret),
+	})
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func (server *Server) confirmTOTP(c *gin.Context) {
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	secret, err := server.store.ConfirmTOTPTx(c.Request.Context(), userID, req.Code, server.walletSealer)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrTOTPNotEnrolled):
+			c.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, database.ErrTOTPAlreadyEnabled):
+			c.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, database.ErrTOTPUnavailable):
+			c.JSON(http.StatusServiceUnavailable, errorResponse(err))
+		case errors.Is(err, database.ErrInvalidTwoFactorCode):
+			c.JSON(http.StatusUnauthorized, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"totp_enabled": true, "confirmed_at": secret.ConfirmedAt.Time})
+}
+
+type disableTOTPRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// disableTOTP removes the authenticator app. It needs both the account
+// password and a current code.
+func (server *Server) disableTOTP(c *gin.Context) {
+	var req disableTOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	user, err := server.store.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !server.verifyCredential(c, database.CredentialKindUserPassword, user.ID, user.PasswordHash, req.Password) {
+		return
+	}
+
+	err = server.store.DisableTOTPTx(c.Request.Context(), database.DisableTOTPTxParams{
+		UserID: userID,
+		Code:   req.Code,
+		Audit:  credentialAudit(c),
+	}, server.walletSealer)
+	if err != nil {
+		switch {
+		case credentialLockResponse(c, err):
+		case errors.Is(err, database.ErrTOTPNotEnrolled):
+			c.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, database.ErrTOTPUnavailable):
+			c.JSON(http.StatusServiceUnavailable, errorResponse(err))
+		case errors.Is(err, database.ErrInvalidTwoFactorCode):
+			c.JSON(http.StatusUnauthorized, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, okayResponse("authenticator app removed"))
+}
+
+// needsTransferConfirmation reports whether a transfer of amount must wait
+// for a second factor.
+func (server *Server) needsTransferConfirmation(amount pgtype.Numeric) bool {
+	return server.transferConfirmationThreshold.Valid &&
+		numericGreater(amount, server.transferConfirmationThreshold)
+}
+
+type transferChallengeResponse struct {
+	ChallengeID uuid.UUID                `json:"challenge_id"`
+	Method      database.TwoFactorMethod `json:"method"`
+	Amount      pgtype.Numeric           `json:"amount"`
+	ExpiresAt   time.Time                `json:"expires_at"`
+	// CodeRetryAt is set when the SMS code could not be sent yet because of
+	// the rate limit; request it again with the send-code endpoint.
+	CodeRetryAt *time.Time `json:"code_retry_at,omitempty"`
+}
+
+// createTransferChallenge holds a high-value transfer until it is confirmed
+// with the caller's authenticator app or, failing that, a code sent to their
+// verified phone number.
+func (server *Server) createTransferChallenge(c *gin.Context, userID uuid.UUID, arg database.TransferTxParams) {
+	user, err := server.store.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	totpEnabled, err := server.totpEnabled(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	method := database.TwoFactorMethodTotp
+	if !totpEnabled {
+		if !user.PhoneVerifiedAt.Valid {
+			c.JSON(http.StatusForbidden, errorResponse(errTwoFactorUnavailable))
+			return
+		}
+		method = database.TwoFactorMethodOtp
+	}
+
+	challenge, err := server.store.CreateTransferChallengeTx(c.Request.Context(), database.CreateTransferChallengeTxParams{
+		UserID:   userID,
+		Method:   method,
+		Transfer: arg,
+	})
+	if err != nil {
+		if !credentialLockResponse(c, err) {
+			transferErrorResponse(c, err)
+		}
+		return
+	}
+
+	rsp := transferChallengeResponse{
+		ChallengeID: challenge.ID,
+		Method:      challenge.Method,
+		Amount:      challenge.Amount,
+		ExpiresAt:   challenge.ExpiresAt.Time,
+	}
+	if method == database.TwoFactorMethodOtp {
+		_, err := server.sendOTP(c.Request.Context(), user, database.OtpPurposeTransferConfirmation, toPgUUID(challenge.ID))
+		var rateErr *database.OTPRateLimitError
+		switch {
+		case errors.As(err, &rateErr):
+			rsp.CodeRetryAt = &rateErr.RetryAt
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+	c.JSON(http.StatusAccepted, rsp)
+}
+
+// sendTransferChallengeCode sends a new SMS code for a transfer challenge.
+func (server *Server) sendTransferChallengeCode(c *gin.Context) {
+	challengeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidChallengeIDParam))
+		return
+	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	challenge, err := server.store.GetTransferChallenge(c.Request.Context(), challengeID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if err != nil || challenge.UserID != userID {
+		c.JSON(http.StatusNotFound, errorResponse(errTransferChallengeNotFound))
+		return
+	}
+	if challenge.Method != database.TwoFactorMethodOtp {
+		c.JSON(http.StatusConflict, errorResponse(errChallengeNotOTP))
+		return
+	}
+	if challenge.ConfirmedAt.Valid || !challenge.ExpiresAt.Time.After(time.Now()) ||
+		challenge.Attempts >= database.MaxTransferChallengeAttempts {
+		c.JSON(http.StatusGone, errorResponse(database.ErrTransferChallengeClosed))
+		return
+	}
+
+	user, err := server.store.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	otp, err := server.sendOTP(c.Request.Context(), user, database.OtpPurposeTransferConfirmation, toPgUUID(challenge.ID))
+	if err != nil {
+		otpErrorResponse(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, otpSentResponse{Message: "transfer confirmation code sent", ExpiresAt: otp.ExpiresAt.Time})
+}
+
+// confirmTransfer carries out a held transfer once its code checks out.
+func (server *Server) confirmTransfer(c *gin.Context) {
+	var req totpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	challengeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidChallengeIDParam))
+		return
+	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	result, err := server.store.ConfirmTransferTx(c.Request.Context(), database.ConfirmTransferTxParams{
+		ChallengeID: challengeID,
+		UserID:      userID,
+		Code:        req.Code,
+		Audit:       credentialAudit(c),
+	}, server.walletSealer)
+	if err != nil {
+		switch {
+		case credentialLockResponse(c, err):
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, errorResponse(errTransferChallengeNotFound))
+		case errors.Is(err, database.ErrTransferChallengeClosed):
+			c.JSON(http.StatusGone, errorResponse(err))
+		case errors.Is(err, database.ErrInvalidTwoFactorCode):
+			c.JSON(http.StatusUnauthorized, errorResponse(err))
+		case errors.Is(err, database.ErrTOTPNotEnrolled):
+			c.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, database.ErrTOTPUnavailable):
+			c.JSON(http.StatusServiceUnavailable, errorResponse(err))
+		default:
+			transferErrorResponse(c, err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errUserNotFound    = errors.New("user not found")
+	errInvalidRole     = errors.New("role must be one of user, agent, support or admin")
+	errLastAdminDemote = errors.New("cannot remove the role of the last admin")
+)
+
+type updateUserRoleRequest struct {
+	Role database.UserRole `json:"role" binding:"required"`
+}
+
+// updateUserRole changes a user's role. The new role is applied to access
+// tokens issued after the change.
+func (server *Server) updateUserRole(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidUserID))
+		return
+	}
+	var req updateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !req.Role.Valid() {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidRole))
+		return
+	}
+
+	user, err := server.store.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errUserNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if user.Role == database.UserRoleAdmin && req.Role != database.UserRoleAdmin {
+		admins, err := server.store.CountUsersByRole(c.Request.Context(), database.UserRoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if admins <= 1 {
+			c.JSON(http.StatusConflict, errorResponse(errLastAdminDemote))
+			return
+		}
+	}
+
+	user, err = server.store.UpdateUserRole(c.Request.Context(), database.UpdateUserRoleParams{
+		ID:   userID,
+		Role: req.Role,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":      user.ID,
+		"phone_number": user.PhoneNumber,
+		"role":         user.Role,
+	})
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Sahas001/pay-on/internal/walletevents"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// walletEventHeartbeat keeps idle streams from being closed by proxies.
+	walletEventHeartbeat = 25 * time.Second
+	// walletEventSessionCheck is how often a stream checks that its session
+	// has not been revoked.
+	walletEventSessionCheck = time.Minute
+)
+
+// streamWalletEvents streams a wallet's balance, transaction and sync log
+// changes as server-sent events. The stream opens with the current balance
+// and ends when the client falls behind, the server loses its database
+// listener, the access token expires or its session is revoked; clients
+// should reconnect and treat the opening balance as fresh state.
+func (server *Server) streamWalletEvents(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	sessionID, ok := authSessionID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	expiresAt, ok := authExpiresAt(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	// Subscribe before reading the balance so no change falls in between.
+	events, unsubscribe := server.walletEvents.Subscribe(walletID)
+	defer unsubscribe()
+
+	wallet, err := server.store.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent(walletevents.TypeBalance, gin.H{"balance": wallet.Balance, "updated_at": wallet.UpdatedAt})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(walletEventHeartbeat)
+	defer heartbeat.Stop()
+	sessionCheck := time.NewTicker(walletEventSessionCheck)
+	defer sessionCheck.Stop()
+	expired := time.NewTimer(time.Until(expiresAt))
+	defer expired.Stop()
+	c.Stream(func(io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-expired.C:
+			return false
+		case <-sessionCheck.C:
+			// A logout or revoked device ends the stream; so does a failed
+			// check, and the client reconnects through the auth middleware.
+			_, err := server.store.GetActiveSession(c.Request.Context(), sessionID)
+			return err == nil
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event.Data)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", gin.H{"time": time.Now().UTC()})
+			return true
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errInvalidWalletKeyID = errors.New("invalid wallet key id")
+	errWalletKeyNotFound  = errors.New("wallet key not found")
+)
+
+// rotateWalletKeyRequest hands a wallet over to a new key. Signature is made
+// by the current wallet key over the rotation payload.
+type rotateWalletKeyRequest struct {
+	NewPublicKey string `json:"new_public_key" binding:"required"`
+	Signature    string `json:"signature" binding:"required"`
+	Pin          string `json:"pin" binding:"required"`
+}
+
+func (server *Server) rotateWalletKey(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	var req rotateWalletKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	wallet, err := server.store.GetWalletByID(c.Request.Context(), walletID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !server.verifyCredential(c, database.CredentialKindWalletPin, wallet.ID, wallet.PinHash, req.Pin) {
+		return
+	}
+
+	result, err := server.store.RotateWalletKeyTx(c.Request.Context(), database.RotateWalletKeyTxParams{
+		WalletID:     walletID,
+		NewPublicKey: req.NewPublicKey,
+		Signature:    req.Signature,
+	})
+	if err != nil {
+		if signatureErrorResponse(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
+		case errors.Is(err, database.ErrWalletInactive), errors.Is(err, database.ErrCustodialKeyRotation):
+			c.JSON(http.StatusForbidden, errorResponse(err))
+		case errors.Is(err, database.ErrWalletKeyReused):
+			c.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (server *Server) listWalletKeys(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	keys, err := server.store.ListWalletKeys(c.Request.Context(), walletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+type revokeWalletKeyRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// revokeWalletKey marks a retired key of the wallet as compromised so that
+// offline transactions are no longer verified with it.
+func (server *Server) revokeWalletKey(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+		return
+	}
+	keyID, err := uuid.Parse(c.Param("key_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletKeyID))
+		return
+	}
+	var req revokeWalletKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	key, err := server.store.RevokeWalletKeyTx(c.Request.Context(), walletID, keyID, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, errorResponse(errWalletKeyNotFound))
+		case errors.Is(err, database.ErrRevokeCurrentKey):
+			c.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, key)
+}
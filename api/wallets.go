@@ -19,15 +19,20 @@ var (
 	errWalletNotFound     = errors.New("wallet not found")
 	errInsufficientFunds  = errors.New("insufficient wallet balance")
 	errInvalidWalletPhone = errors.New("invalid phone number")
+	errMissingPublicKey   = errors.New("public_key is required unless the wallet is custodial")
+	errCustodyDisabled    = errors.New("custodial wallets are not enabled on this server")
 )
 
+// createWalletRequest creates a wallet. The client keeps its private key and
+// sends only public_key, unless the wallet is custodial, in which case the
+// server generates the key pair and holds the private key encrypted.
 type createWalletRequest struct {
 	Name        string `json:"name" binding:"required"`
 	PhoneNumber string `json:"phone_number" binding:"required"`
 	Pin         string `json:"pin" binding:"required"`
 	DeviceID    string `json:"device_id"`
-	PublicKey   string `json:"public_key" binding:"required"`
-	PrivateKey  string `json:"private_key"`
+	PublicKey   string `json:"public_key"`
+	Custodial   bool   `json:"custodial"`
 }
 
 func (server *Server) createWallet(c *gin.Context) {
@@ -37,6 +42,15 @@ func (server *Server) createWallet(c *gin.Context) {
 		return
 	}
 
+	switch {
+	case req.Custodial && server.walletSealer == nil:
+		c.JSON(http.StatusBadRequest, errorResponse(errCustodyDisabled))
+		return
+	case !req.Custodial && req.PublicKey == "":
+		c.JSON(http.StatusBadRequest, errorResponse(errMissingPublicKey))
+		return
+	}
+
 	userID, ok := authUserID(c)
 	if !ok {
 		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
@@ -60,21 +74,21 @@ func (server *Server) createWallet(c *gin.Context) {
 		deviceID = &req.DeviceID
 	}
 
-	privateKey := req.PrivateKey
-	if privateKey == "" {
-		privateKey = "client-managed"
-	}
-
-	wallet, err := server.store.CreateWallet(c.Request.Context(), database.CreateWalletParams{
+	arg := database.CreateWalletParams{
 		UserID:      toPgUUID(userID),
 		PublicKey:   req.PublicKey,
-		PrivateKey:  privateKey,
 		Balance:     balance,
 		PhoneNumber: req.PhoneNumber,
 		Name:        req.Name,
 		PinHash:     string(pinHash),
 		DeviceID:    deviceID,
-	})
+	}
+	var wallet database.Wallet
+	if req.Custodial {
+		wallet, err = server.store.CreateCustodialWalletTx(c.Request.Context(), arg, server.walletSealer)
+	} else {
+		wallet, err = server.store.CreateWallet(c.Request.Context(), arg)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -93,6 +107,7 @@ func (server *Server) createWallet(c *gin.Context) {
 		"name":         wallet.Name,
 		"is_active":    wallet.IsActive != nil && *wallet.IsActive,
 		"device_id":    wallet.DeviceID,
+		"custodial":    req.Custodial,
 		"created_at":   createdAt,
 	})
 }
@@ -265,7 +280,7 @@ func (server *Server) getWalletByPhoneNumber(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	c.JSON(http.StatusOK, wallet)
+	c.JSON(http.StatusOK, walletLookupResponse(c, wallet))
 }
 
 func (server *Server) getWalletByPublicKey(c *gin.Context) {
@@ -283,7 +298,7 @@ func (server *Server) getWalletByPublicKey(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	c.JSON(http.StatusOK, wallet)
+	c.JSON(http.StatusOK, walletLookupResponse(c, wallet))
 }
 
 func (server *Server) getWalletByDeviceID(c *gin.Context) {
@@ -301,7 +316,7 @@ func (server *Server) getWalletByDeviceID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	c.JSON(http.StatusOK, wallet)
+	c.JSON(http.StatusOK, walletLookupResponse(c, wallet))
 }
 
 func (server *Server) getWalletByID(c *gin.Context) {
@@ -475,11 +490,12 @@ func (server *Server) updateWalletBalance(c *gin.Context) {
 		return
 	}
 
-	wallet, err := server.store.UpdateWalletBalance(c.Request.Context(), database.UpdateWalletBalanceParams{
-		ID:      walletID,
-		Balance: req.Balance,
-	})
+	wallet, err := server.store.SetBalanceTx(c.Request.Context(), walletID, req.Balance, nil)
 	if err != nil {
+		if errors.Is(err, database.ErrInvalidLedgerAmount) {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidAmount))
+			return
+		}
 		if errors.Is(err, pgx.ErrNoRows) {
 			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
 			return
@@ -506,12 +522,16 @@ func (server *Server) incrementWalletBalance(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
 		return
 	}
-	arg := database.IncrementWalletBalanceParams{
-		ID:      walletID,
-		Balance: req.Amount,
+	arg := database.AdjustBalanceTxParams{
+		WalletID: walletID,
+		Amount:   req.Amount,
 	}
-	_, err = server.store.IncrementWalletBalance(c.Request.Context(), arg)
+	_, err = server.store.AdjustBalanceTx(c.Request.Context(), arg)
 	if err != nil {
+		if errors.Is(err, database.ErrInvalidLedgerAmount) {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidAmount))
+			return
+		}
 		if errors.Is(err, pgx.ErrNoRows) {
 			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
 			return
@@ -538,12 +558,17 @@ func (server *Server) decrementWalletBalance(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
 		return
 	}
-	arg := database.DecrementWalletBalanceParams{
-		ID:      walletID,
-		Balance: req.Amount,
+	arg := database.AdjustBalanceTxParams{
+		WalletID: walletID,
+		Amount:   req.Amount,
+		Decrease: true,
 	}
-	_, err = server.store.DecrementWalletBalance(c.Request.Context(), arg)
+	_, err = server.store.AdjustBalanceTx(c.Request.Context(), arg)
 	if err != nil {
+		if errors.Is(err, database.ErrInvalidLedgerAmount) {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidAmount))
+			return
+		}
 		if errors.Is(err, pgx.ErrNoRows) {
 			c.JSON(http.StatusBadRequest, errorResponse(errInsufficientFunds))
 			return
@@ -554,8 +579,13 @@ func (server *Server) decrementWalletBalance(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "wallet balance decremented successfully"})
 }
 
+// updateWalletPINRequest sets a new wallet PIN. The reset is authorized by
+// the caller's account password or by a code from the pin send-code
+// endpoint, and also lifts a lockout.
 type updateWalletPINRequest struct {
-	PIN string `json:"pin" binding:"required"`
+	PIN      string `json:"pin" binding:"required"`
+	Password string `json:"password"`
+	OTPCode  string `json:"otp_code"`
 }
 
 func (server *Server) updateWalletPIN(c *gin.Context) {
@@ -564,27 +594,58 @@ func (server *Server) updateWalletPIN(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	if req.Password == "" && req.OTPCode == "" {
+		c.JSON(http.StatusBadRequest, errorResponse(errMissingPINAuth))
+		return
+	}
 	id := c.Param("id")
 	walletID, err := uuid.Parse(id)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
 		return
 	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	user, err := server.store.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if req.Password != "" && !server.verifyCredential(c, database.CredentialKindUserPassword, user.ID, user.PasswordHash, req.Password) {
+		return
+	}
+
 	pinHash, err := bcrypt.GenerateFromPassword([]byte(req.PIN), bcrypt.DefaultCost)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	arg := database.UpdateWalletPINParams{
-		ID:      walletID,
-		PinHash: string(pinHash),
+	arg := database.ResetWalletPINTxParams{
+		WalletID: walletID,
+		PinHash:  string(pinHash),
+		Audit:    credentialAudit(c),
 	}
-	err = server.store.UpdateWalletPIN(c.Request.Context(), arg)
+	if req.Password == "" {
+		arg.OTP = &database.OTPCheck{
+			PhoneNumber: user.PhoneNumber,
+			Purpose:     database.OtpPurposePinReset,
+			SubjectID:   toPgUUID(walletID),
+			Code:        req.OTPCode,
+		}
+	}
+	err = server.store.ResetWalletPINTx(c.Request.Context(), arg)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
 			return
 		}
+		if errors.Is(err, database.ErrOTPInvalid) {
+			c.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -616,13 +677,22 @@ func (server *Server) deactivateWallet(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
 		return
 	}
-	err = server.store.DeactivateWallet(c.Request.Context(), walletID)
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	err = server.store.DeactivateWalletTx(c.Request.Context(), database.DeactivateWalletTxParams{
+		WalletID:      walletID,
+		DeactivatedBy: userID,
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
 			return
 		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "wallet deactivated successfully"})
 }
@@ -652,7 +722,16 @@ func (server *Server) softDeleteWallet(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
 		return
 	}
-	err = server.store.SoftDeleteWallet(c.Request.Context(), walletID)
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	err = server.store.DeactivateWalletTx(c.Request.Context(), database.DeactivateWalletTxParams{
+		WalletID:      walletID,
+		DeactivatedBy: userID,
+		Delete:        true,
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
@@ -671,11 +750,15 @@ func (server *Server) hardDeleteWallet(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
 		return
 	}
-	err = server.store.HardDeleteWallet(c.Request.Context(), walletID)
+	err = server.store.HardDeleteWalletTx(c.Request.Context(), walletID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
 			c.JSON(http.StatusNotFound, errorResponse(errWalletNotFound))
 			return
+		case errors.Is(err, database.ErrWalletHasLedger):
+			c.JSON(http.StatusConflict, errorResponse(err))
+			return
 		}
 		c.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
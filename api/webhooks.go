@@ -0,0 +1,264 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/Sahas001/pay-on/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	errInvalidWebhookID    = errors.New("invalid webhook id")
+	errWebhookNotFound     = errors.New("webhook not found")
+	errInvalidWebhookEvent = errors.New("unknown webhook event type")
+)
+
+type createWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	WalletID   *string  `json:"wallet_id"`
+	EventTypes []string `json:"event_types"`
+}
+
+type updateWebhookRequest struct {
+	URL        *string   `json:"url" binding:"omitempty,url,max=2048"`
+	EventTypes *[]string `json:"event_types"`
+	IsActive   *bool     `json:"is_active"`
+}
+
+// webhookResponse is a subscription without its secret, which is only
+// returned when the subscription is created.
+type webhookResponse struct {
+	ID         uuid.UUID          `json:"id"`
+	UserID     uuid.UUID          `json:"user_id"`
+	WalletID   pgtype.UUID        `json:"wallet_id"`
+	URL        string             `json:"url"`
+	EventTypes []string           `json:"event_types"`
+	IsActive   bool               `json:"is_active"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+}
+
+type createWebhookResponse struct {
+	webhookResponse
+	Secret string `json:"secret"`
+}
+
+func newWebhookResponse(subscription database.WebhookSubscription) webhookResponse {
+	return webhookResponse{
+		ID:         subscription.ID,
+		UserID:     subscription.UserID,
+		WalletID:   subscription.WalletID,
+		URL:        subscription.Url,
+		EventTypes: subscription.EventTypes,
+		IsActive:   subscription.IsActive,
+		CreatedAt:  subscription.CreatedAt,
+		UpdatedAt:  subscription.UpdatedAt,
+	}
+}
+
+// createWebhook subscribes a URL to events for one of the caller's wallets,
+// or for all of them when wallet_id is left out.
+func (server *Server) createWebhook(c *gin.Context) {
+	var req createWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	eventTypes, err := webhookEventTypes(req.EventTypes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := webhook.ValidateURL(c.Request.Context(), req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+
+	var walletID pgtype.UUID
+	if req.WalletID != nil {
+		id, err := uuid.Parse(*req.WalletID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(errInvalidWalletID))
+			return
+		}
+		if !server.authorizeWalletID(c, id) {
+			return
+		}
+		walletID = toPgUUID(id)
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	subscription, err := server.store.CreateWebhookSubscription(c.Request.Context(), database.CreateWebhookSubscriptionParams{
+		UserID:     userID,
+		WalletID:   walletID,
+		Url:        req.URL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusCreated, createWebhookResponse{
+		webhookResponse: newWebhookResponse(subscription),
+		Secret:          subscription.Secret,
+	})
+}
+
+func (server *Server) listWebhooks(c *gin.Context) {
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return
+	}
+	subscriptions, err := server.store.ListWebhookSubscriptionsByUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	response := make([]webhookResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		response[i] = newWebhookResponse(subscription)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (server *Server) getWebhook(c *gin.Context) {
+	subscription, ok := server.loadWebhook(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, newWebhookResponse(subscription))
+}
+
+// updateWebhook changes a subscription's URL or events, or pauses it.
+// Events queued while it is paused are dropped when they come due.
+func (server *Server) updateWebhook(c *gin.Context) {
+	var req updateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	arg := database.UpdateWebhookSubscriptionParams{
+		Url:      req.URL,
+		IsActive: req.IsActive,
+	}
+	if req.EventTypes != nil {
+		eventTypes, err := webhookEventTypes(*req.EventTypes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		arg.EventTypes = eventTypes
+	}
+	if req.URL != nil {
+		if err := webhook.ValidateURL(c.Request.Context(), *req.URL); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+	subscription, ok := server.loadWebhook(c)
+	if !ok {
+		return
+	}
+	arg.ID = subscription.ID
+
+	subscription, err := server.store.UpdateWebhookSubscription(c.Request.Context(), arg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, newWebhookResponse(subscription))
+}
+
+func (server *Server) deleteWebhook(c *gin.Context) {
+	subscription, ok := server.loadWebhook(c)
+	if !ok {
+		return
+	}
+	if err := server.store.DeleteWebhookSubscription(c.Request.Context(), subscription.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, okayResponse("webhook deleted"))
+}
+
+// listWebhookDeliveries returns the delivery attempts of a subscription's
+// events, newest first.
+func (server *Server) listWebhookDeliveries(c *gin.Context) {
+	limit, offset, ok := parseLimitOffset(c)
+	if !ok {
+		return
+	}
+	subscription, ok := server.loadWebhook(c)
+	if !ok {
+		return
+	}
+	deliveries, err := server.store.ListWebhookDeliveriesBySubscription(c.Request.Context(), database.ListWebhookDeliveriesBySubscriptionParams{
+		SubscriptionID: subscription.ID,
+		Limit:          int32(limit),
+		Offset:         int32(offset),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// loadWebhook returns the subscription named in the path. Subscriptions of
+// other users are reported as not found.
+func (server *Server) loadWebhook(c *gin.Context) (database.WebhookSubscription, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(errInvalidWebhookID))
+		return database.WebhookSubscription{}, false
+	}
+	userID, ok := authUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse(errInvalidCredentials))
+		return database.WebhookSubscription{}, false
+	}
+	subscription, err := server.store.GetWebhookSubscription(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.JSON(http.StatusNotFound, errorResponse(errWebhookNotFound))
+			return database.WebhookSubscription{}, false
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		return database.WebhookSubscription{}, false
+	}
+	if subscription.UserID != userID {
+		c.JSON(http.StatusNotFound, errorResponse(errWebhookNotFound))
+		return database.WebhookSubscription{}, false
+	}
+	return subscription, true
+}
+
+// webhookEventTypes checks requested event types. An empty list subscribes
+// to every event.
+func webhookEventTypes(eventTypes []string) ([]string, error) {
+	checked := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !database.WebhookEventType(eventType).Valid() {
+			return nil, fmt.Errorf("%w: %q", errInvalidWebhookEvent, eventType)
+		}
+		checked = append(checked, eventType)
+	}
+	return checked, nil
+}
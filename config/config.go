@@ -8,11 +8,48 @@ import (
 )
 
 type Config struct {
-	DBDriver            string        `mapstructure:"DB_DRIVER"`
-	DBSource            string        `mapstructure:"DB_SOURCE"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	JWTSecret           string        `mapstructure:"JWT_SECRET"`
+	DBDriver      string `mapstructure:"DB_DRIVER"`
+	DBSource      string `mapstructure:"DB_SOURCE"`
+	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	JWTSecret     string `mapstructure:"JWT_SECRET"`
+	// JWTSigningKeyFiles lists PEM key files for signing access tokens,
+	// comma separated. The first key signs; the rest only verify. When empty,
+	// tokens are signed with HS256 and JWTSecret.
+	JWTSigningKeyFiles  []string      `mapstructure:"JWT_SIGNING_KEY_FILES"`
 	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	// RefreshTokenDuration is the lifetime of a login session. Refreshing
+	// rotates the refresh token but does not extend the session.
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	// BootstrapAdminPhone names the registered user promoted to admin at
+	// startup while no admin exists yet. Leave empty to disable.
+	BootstrapAdminPhone string `mapstructure:"BOOTSTRAP_ADMIN_PHONE"`
+	// WalletMasterKey is a base64 encoded 256-bit key that encrypts the keys
+	// of custodial wallets. Custodial wallets are disabled while it is empty.
+	WalletMasterKey string `mapstructure:"WALLET_MASTER_KEY"`
+	// SMSSender selects how one-time codes are delivered: "log" (the
+	// default) writes them to the server log and "file" appends them to
+	// SMSFilePath.
+	SMSSender   string `mapstructure:"SMS_SENDER"`
+	SMSFilePath string `mapstructure:"SMS_FILE_PATH"`
+	// TransferConfirmationThreshold is the amount above which a transfer
+	// waits for a TOTP or SMS code. Leave empty to disable.
+	TransferConfirmationThreshold string `mapstructure:"TRANSFER_CONFIRMATION_THRESHOLD"`
+	// WebhookDispatchInterval is how often queued webhook events are
+	// delivered. Set to 0 to run no dispatcher in this process.
+	WebhookDispatchInterval time.Duration `mapstructure:"WEBHOOK_DISPATCH_INTERVAL"`
+	// EventRelayInterval is how often domain events in the outbox are
+	// handed to subscribers. Set to 0 to run no relay in this process;
+	// the webhook dispatcher then has to be disabled too.
+	EventRelayInterval time.Duration `mapstructure:"EVENT_RELAY_INTERVAL"`
+	// SyncRetryInterval is how often failed settlements that are due are
+	// retried. Set to 0 to run no retry worker in this process.
+	SyncRetryInterval time.Duration `mapstructure:"SYNC_RETRY_INTERVAL"`
+	// JobsEnabled runs maintenance jobs on their schedules in this process.
+	// Admins can still run them by hand when it is off.
+	JobsEnabled bool `mapstructure:"JOBS_ENABLED"`
+	// JobSchedules overrides job schedules as semicolon separated
+	// name=schedule pairs; "off" leaves a job to run by hand only.
+	JobSchedules string `mapstructure:"JOB_SCHEDULES"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -0,0 +1,12 @@
+-- migrations/000012_add_sync_log_resolution.down.sql
+
+ALTER TABLE sync_logs DROP CONSTRAINT IF EXISTS chk_resolved_amount_positive;
+ALTER TABLE sync_logs DROP CONSTRAINT IF EXISTS fk_sync_resolved_by;
+
+ALTER TABLE sync_logs
+    DROP COLUMN IF EXISTS resolved_amount,
+    DROP COLUMN IF EXISTS resolution_note,
+    DROP COLUMN IF EXISTS resolved_by,
+    DROP COLUMN IF EXISTS resolution;
+
+DROP TYPE IF EXISTS sync_resolution;
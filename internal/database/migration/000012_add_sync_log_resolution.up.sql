@@ -0,0 +1,19 @@
+-- migrations/000012_add_sync_log_resolution.up.sql
+
+CREATE TYPE sync_resolution AS ENUM ('force_accept', 'reject', 'split');
+
+ALTER TABLE sync_logs
+    ADD COLUMN IF NOT EXISTS resolution sync_resolution,
+    ADD COLUMN IF NOT EXISTS resolved_by UUID,
+    ADD COLUMN IF NOT EXISTS resolution_note TEXT,
+    ADD COLUMN IF NOT EXISTS resolved_amount DECIMAL(15, 2);
+
+ALTER TABLE sync_logs
+    ADD CONSTRAINT fk_sync_resolved_by
+    FOREIGN KEY (resolved_by) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE sync_logs
+    ADD CONSTRAINT chk_resolved_amount_positive CHECK (resolved_amount IS NULL OR resolved_amount > 0);
+
+COMMENT ON COLUMN sync_logs.resolution IS 'How an operator cleared a settlement conflict';
+COMMENT ON COLUMN sync_logs.resolved_amount IS 'Amount actually moved when a conflict was resolved';
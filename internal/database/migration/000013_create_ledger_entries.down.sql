@@ -0,0 +1,7 @@
+-- migrations/000013_create_ledger_entries.down.sql
+
+DROP TRIGGER IF EXISTS trigger_ledger_immutable ON ledger_entries;
+DROP FUNCTION IF EXISTS prevent_ledger_mutation();
+DROP TABLE IF EXISTS ledger_entries;
+DROP TYPE IF EXISTS ledger_entry_type;
+DROP TYPE IF EXISTS ledger_direction;
@@ -0,0 +1,58 @@
+-- migrations/000013_create_ledger_entries.up.sql
+
+CREATE TYPE ledger_direction AS ENUM ('debit', 'credit');
+CREATE TYPE ledger_entry_type AS ENUM ('opening', 'transfer', 'deposit', 'withdrawal', 'adjustment', 'reversal');
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    posting_id UUID NOT NULL,
+    transaction_id UUID,
+    wallet_id UUID,
+    account VARCHAR(50) NOT NULL,
+    direction ledger_direction NOT NULL,
+    entry_type ledger_entry_type NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_ledger_wallet FOREIGN KEY (wallet_id)
+        REFERENCES wallets(id) ON DELETE CASCADE,
+    CONSTRAINT fk_ledger_transaction FOREIGN KEY (transaction_id)
+        REFERENCES transactions(id) ON DELETE CASCADE,
+    CONSTRAINT chk_ledger_amount_positive CHECK (amount > 0),
+    CONSTRAINT chk_ledger_wallet_account CHECK ((account = 'wallet') = (wallet_id IS NOT NULL))
+);
+
+CREATE INDEX idx_ledger_wallet ON ledger_entries(wallet_id, created_at DESC) WHERE wallet_id IS NOT NULL;
+CREATE INDEX idx_ledger_posting ON ledger_entries(posting_id);
+CREATE INDEX idx_ledger_transaction ON ledger_entries(transaction_id) WHERE transaction_id IS NOT NULL;
+
+-- Entries are append-only; rows may only disappear through a cascading delete.
+CREATE OR REPLACE FUNCTION prevent_ledger_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'ledger entries are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_ledger_immutable
+BEFORE UPDATE OR DELETE ON ledger_entries
+FOR EACH ROW EXECUTE FUNCTION prevent_ledger_mutation();
+
+-- Open the ledger with the balances wallets already hold.
+WITH opening AS (
+    SELECT id, balance, gen_random_uuid() AS posting_id
+    FROM wallets
+    WHERE balance > 0
+)
+INSERT INTO ledger_entries (posting_id, wallet_id, account, direction, entry_type, amount, description)
+SELECT posting_id, id, 'wallet', 'credit', 'opening', balance, 'opening balance' FROM opening
+UNION ALL
+SELECT posting_id, NULL, 'system:opening', 'debit', 'opening', balance, 'opening balance' FROM opening;
+
+COMMENT ON TABLE ledger_entries IS 'Immutable double-entry ledger; wallets.balance is a projection of it';
+COMMENT ON COLUMN ledger_entries.posting_id IS 'Groups the debit and credit of one money movement';
+COMMENT ON COLUMN ledger_entries.account IS 'wallet, or a system:* account on the other side of deposits and adjustments';
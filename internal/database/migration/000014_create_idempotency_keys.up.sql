@@ -0,0 +1,26 @@
+-- migrations/000014_create_idempotency_keys.up.sql
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+
+    -- Filled in once the original request has finished
+    response_status INTEGER,
+    response_body BYTEA,
+    completed_at TIMESTAMP WITH TIME ZONE,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_idempotency_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT uq_idempotency_user_key UNIQUE (user_id, idempotency_key)
+);
+
+CREATE INDEX idx_idempotency_created_at ON idempotency_keys(created_at);
+
+COMMENT ON TABLE idempotency_keys IS 'Stored responses for requests retried with the same Idempotency-Key';
+COMMENT ON COLUMN idempotency_keys.request_hash IS 'SHA-256 of method, path and body of the original request';
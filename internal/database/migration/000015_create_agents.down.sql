@@ -0,0 +1,4 @@
+-- migrations/000015_create_agents.down.sql
+
+DROP TRIGGER IF EXISTS update_agents_updated_at ON agents;
+DROP TABLE IF EXISTS agents;
@@ -0,0 +1,24 @@
+-- migrations/000015_create_agents.up.sql
+
+CREATE TABLE IF NOT EXISTS agents (
+    wallet_id UUID PRIMARY KEY,
+    float_limit DECIMAL(15, 2) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+
+    -- Timestamps
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_agent_wallet FOREIGN KEY (wallet_id)
+        REFERENCES wallets(id) ON DELETE CASCADE,
+    CONSTRAINT chk_agent_float_limit CHECK (float_limit >= 0)
+);
+
+CREATE INDEX idx_agents_is_active ON agents(is_active) WHERE is_active = TRUE;
+
+CREATE TRIGGER update_agents_updated_at
+BEFORE UPDATE ON agents
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE agents IS 'Wallets allowed to exchange cash for e-money (deposits and withdrawals)';
+COMMENT ON COLUMN agents.float_limit IS 'Maximum e-money balance the agent wallet may hold';
@@ -0,0 +1,5 @@
+-- migrations/000016_create_wallet_limits.down.sql
+
+DROP INDEX IF EXISTS idx_transactions_from_wallet_at;
+DROP TRIGGER IF EXISTS update_wallet_limits_updated_at ON wallet_limits;
+DROP TABLE IF EXISTS wallet_limits;
@@ -0,0 +1,34 @@
+-- migrations/000016_create_wallet_limits.up.sql
+
+CREATE TABLE IF NOT EXISTS wallet_limits (
+    wallet_id UUID PRIMARY KEY,
+
+    -- NULL means no limit beyond chk_valid_amount on transactions
+    per_transaction_limit DECIMAL(15, 2),
+    daily_limit DECIMAL(15, 2),
+    monthly_limit DECIMAL(15, 2),
+    offline_limit DECIMAL(15, 2),
+
+    -- Timestamps
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_wallet_limits_wallet FOREIGN KEY (wallet_id)
+        REFERENCES wallets(id) ON DELETE CASCADE,
+    CONSTRAINT chk_positive_limits CHECK (
+        (per_transaction_limit IS NULL OR per_transaction_limit > 0) AND
+        (daily_limit IS NULL OR daily_limit > 0) AND
+        (monthly_limit IS NULL OR monthly_limit > 0) AND
+        (offline_limit IS NULL OR offline_limit > 0)
+    )
+);
+
+CREATE TRIGGER update_wallet_limits_updated_at
+BEFORE UPDATE ON wallet_limits
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Outflow sums filter on sender and transaction time
+CREATE INDEX IF NOT EXISTS idx_transactions_from_wallet_at ON transactions(from_wallet_id, transaction_at);
+
+COMMENT ON TABLE wallet_limits IS 'Configurable spending limits per wallet';
+COMMENT ON COLUMN wallet_limits.offline_limit IS 'Cap on offline spend not yet synced since the wallet last synced';
@@ -0,0 +1,5 @@
+-- migrations/000017_add_user_roles.down.sql
+
+DROP INDEX IF EXISTS idx_users_role;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+DROP TYPE IF EXISTS user_role;
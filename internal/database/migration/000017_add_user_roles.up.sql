@@ -0,0 +1,18 @@
+-- migrations/000017_add_user_roles.up.sql
+
+CREATE TYPE user_role AS ENUM ('user', 'agent', 'support', 'admin');
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role user_role NOT NULL DEFAULT 'user';
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role) WHERE role <> 'user';
+
+-- Owners of existing agent wallets get the agent role
+UPDATE users SET role = 'agent'
+WHERE id IN (
+    SELECT w.user_id FROM agents a
+    JOIN wallets w ON w.id = a.wallet_id
+    WHERE w.user_id IS NOT NULL
+);
+
+COMMENT ON COLUMN users.role IS 'Access role; copied into the JWT role claim at login';
@@ -0,0 +1,4 @@
+-- migrations/000018_create_sessions.down.sql
+
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,39 @@
+-- migrations/000018_create_sessions.up.sql
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    user_agent TEXT,
+    client_ip INET,
+
+    -- Lifetime of the session and every refresh token issued for it
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+
+    -- Timestamps
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_refreshed_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT fk_session_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id) WHERE revoked_at IS NULL;
+CREATE INDEX idx_sessions_expires_at ON sessions(expires_at);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_refresh_token_session FOREIGN KEY (session_id)
+        REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens(session_id);
+
+COMMENT ON TABLE sessions IS 'Login sessions; access tokens carry the session ID and stop working once it is revoked';
+COMMENT ON TABLE refresh_tokens IS 'Single-use refresh tokens; presenting a used one revokes its session';
+COMMENT ON COLUMN refresh_tokens.token_hash IS 'SHA-256 of the refresh token; the token itself is never stored';
@@ -0,0 +1,6 @@
+-- migrations/000019_create_devices.down.sql
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS device_id;
+
+DROP TABLE IF EXISTS device_challenges;
+DROP TABLE IF EXISTS devices;
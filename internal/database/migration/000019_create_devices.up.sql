@@ -0,0 +1,53 @@
+-- migrations/000019_create_devices.up.sql
+
+CREATE TABLE IF NOT EXISTS devices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    device_id VARCHAR(255) NOT NULL,
+    name VARCHAR(100),
+    public_key TEXT NOT NULL,
+
+    -- A device may sign in and move money only once verified
+    verified_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+
+    first_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    -- Timestamps
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_device_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT uq_device_user_device UNIQUE (user_id, device_id)
+);
+
+CREATE TRIGGER update_devices_updated_at
+BEFORE UPDATE ON devices
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS device_challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    device_id VARCHAR(255) NOT NULL,
+    challenge VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_device_challenge_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_device_challenges_expires_at ON device_challenges(expires_at);
+
+ALTER TABLE sessions
+    ADD COLUMN device_id UUID REFERENCES devices(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_sessions_device_id ON sessions(device_id);
+
+COMMENT ON TABLE devices IS 'Devices registered by a user; logins and offline payments are signed with the device key';
+COMMENT ON COLUMN devices.device_id IS 'Identifier reported by the client, as in wallets.device_id';
+COMMENT ON TABLE device_challenges IS 'Single-use nonces a device signs to prove it holds its key at login';
+COMMENT ON COLUMN sessions.device_id IS 'Device the session was started on; revoking the device ends the session';
@@ -0,0 +1,4 @@
+-- migrations/000020_create_credential_attempts.down.sql
+
+DROP TABLE IF EXISTS credential_attempts;
+DROP TYPE IF EXISTS credential_kind;
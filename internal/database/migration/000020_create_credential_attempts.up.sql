@@ -0,0 +1,31 @@
+-- migrations/000020_create_credential_attempts.up.sql
+
+CREATE TYPE credential_kind AS ENUM ('wallet_pin', 'user_password');
+
+CREATE TABLE IF NOT EXISTS credential_attempts (
+    kind credential_kind NOT NULL,
+    subject_id UUID NOT NULL,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP WITH TIME ZONE,
+
+    -- No attempt is checked before this time (backoff or lockout)
+    locked_until TIMESTAMP WITH TIME ZONE,
+
+    -- Timestamps
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    PRIMARY KEY (kind, subject_id),
+    CONSTRAINT chk_credential_failed_attempts CHECK (failed_attempts >= 0)
+);
+
+CREATE INDEX idx_credential_attempts_locked_until ON credential_attempts(locked_until)
+    WHERE locked_until IS NOT NULL;
+
+CREATE TRIGGER update_credential_attempts_updated_at
+BEFORE UPDATE ON credential_attempts
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE credential_attempts IS 'Failed PIN and password attempts per wallet or user, for backoff and lockout';
+COMMENT ON COLUMN credential_attempts.subject_id IS 'Wallet ID for wallet_pin, user ID for user_password';
+COMMENT ON COLUMN credential_attempts.locked_until IS 'No attempt is checked before this time (backoff or lockout)';
@@ -0,0 +1,13 @@
+-- migrations/000021_move_wallet_private_keys.down.sql
+
+ALTER TABLE wallets ADD COLUMN private_key TEXT NOT NULL DEFAULT 'client-managed';
+ALTER TABLE wallets ALTER COLUMN private_key DROP DEFAULT;
+
+-- Sealed keys cannot be restored without the master key
+UPDATE wallets w
+SET private_key = k.legacy_private_key
+FROM wallet_custodial_keys k
+WHERE k.wallet_id = w.id
+  AND k.legacy_private_key IS NOT NULL;
+
+DROP TABLE IF EXISTS wallet_custodial_keys;
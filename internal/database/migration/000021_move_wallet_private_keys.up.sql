@@ -0,0 +1,56 @@
+-- migrations/000021_move_wallet_private_keys.up.sql
+
+-- Wallet keys are generated and kept on the client. Only custodial wallets
+-- have a server-side key, encrypted under a per-key data key that is itself
+-- encrypted with the configured master key.
+CREATE TABLE IF NOT EXISTS wallet_custodial_keys (
+    wallet_id UUID PRIMARY KEY REFERENCES wallets(id) ON DELETE CASCADE,
+
+    -- Envelope encryption
+    master_key_id VARCHAR(64),
+    encrypted_data_key BYTEA,
+    encrypted_private_key BYTEA,
+
+    -- Plaintext key copied from wallets.private_key, until the server seals it at startup
+    legacy_private_key TEXT,
+
+    -- Timestamps
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT chk_wallet_custodial_key_sealed CHECK (
+        legacy_private_key IS NOT NULL OR (
+            master_key_id IS NOT NULL
+            AND encrypted_data_key IS NOT NULL
+            AND encrypted_private_key IS NOT NULL
+        )
+    )
+);
+
+CREATE INDEX idx_wallet_custodial_keys_legacy ON wallet_custodial_keys(wallet_id)
+    WHERE legacy_private_key IS NOT NULL;
+
+CREATE TRIGGER update_wallet_custodial_keys_updated_at
+BEFORE UPDATE ON wallet_custodial_keys
+FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Keep the keys of existing wallets that were not client-managed; blank
+-- keys and the client-managed placeholder were never keys
+INSERT INTO wallet_custodial_keys (wallet_id, legacy_private_key)
+SELECT id, private_key FROM wallets
+WHERE btrim(private_key) <> ''
+  AND lower(btrim(private_key)) <> 'client-managed';
+
+ALTER TABLE wallets DROP COLUMN private_key;
+
+-- The wallet audit trigger copied whole rows, keys included
+UPDATE audit_logs
+SET
+    old_data = old_data - 'private_key',
+    new_data = new_data - 'private_key'
+WHERE table_name = 'wallets'
+  AND (old_data ? 'private_key' OR new_data ? 'private_key');
+
+COMMENT ON TABLE wallet_custodial_keys IS 'Server-held private keys of custodial wallets, envelope encrypted';
+COMMENT ON COLUMN wallet_custodial_keys.master_key_id IS 'Identifies the master key that encrypted the data key';
+COMMENT ON COLUMN wallet_custodial_keys.legacy_private_key IS 'Plaintext key copied from wallets.private_key, until the server seals it at startup';
@@ -0,0 +1,6 @@
+-- migrations/000022_create_wallet_keys.down.sql
+
+DROP TRIGGER IF EXISTS trigger_record_initial_wallet_key ON wallets;
+DROP FUNCTION IF EXISTS record_initial_wallet_key();
+
+DROP TABLE IF EXISTS wallet_keys;
@@ -0,0 +1,45 @@
+-- migrations/000022_create_wallet_keys.up.sql
+
+CREATE TABLE IF NOT EXISTS wallet_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL,
+    public_key TEXT NOT NULL UNIQUE,
+
+    -- The key signs transactions made from valid_from until valid_until
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    valid_until TIMESTAMP WITH TIME ZONE,
+
+    -- Signature of the handover by the previous key; NULL for the first key
+    rotation_signature TEXT,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_wallet_key_wallet FOREIGN KEY (wallet_id)
+        REFERENCES wallets(id) ON DELETE CASCADE,
+    CONSTRAINT chk_wallet_key_validity CHECK (valid_until IS NULL OR valid_until >= valid_from)
+);
+
+CREATE INDEX idx_wallet_keys_wallet_valid_from ON wallet_keys(wallet_id, valid_from);
+CREATE UNIQUE INDEX uq_wallet_keys_current ON wallet_keys(wallet_id)
+    WHERE valid_until IS NULL;
+
+-- Every wallet starts with the key it was created with
+INSERT INTO wallet_keys (wallet_id, public_key, valid_from)
+SELECT id, public_key, COALESCE(created_at, NOW()) FROM wallets;
+
+CREATE OR REPLACE FUNCTION record_initial_wallet_key()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO wallet_keys (wallet_id, public_key, valid_from)
+    VALUES (NEW.id, NEW.public_key, COALESCE(NEW.created_at, NOW()));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_record_initial_wallet_key
+AFTER INSERT ON wallets
+FOR EACH ROW EXECUTE FUNCTION record_initial_wallet_key();
+
+COMMENT ON TABLE wallet_keys IS 'Public key history of each wallet; offline transactions are verified with the key active at transaction_at';
+COMMENT ON COLUMN wallet_keys.valid_until IS 'When the key was rotated out; NULL for the current key';
+COMMENT ON COLUMN wallet_keys.rotation_signature IS 'Signature of the handover by the previous key; NULL for the first key';
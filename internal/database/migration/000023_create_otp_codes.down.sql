@@ -0,0 +1,7 @@
+-- migrations/000023_create_otp_codes.down.sql
+
+DROP TABLE IF EXISTS otp_codes;
+
+ALTER TABLE users DROP COLUMN IF EXISTS phone_verified_at;
+
+DROP TYPE IF EXISTS otp_purpose;
@@ -0,0 +1,34 @@
+-- migrations/000023_create_otp_codes.up.sql
+
+CREATE TYPE otp_purpose AS ENUM ('phone_verification', 'password_reset', 'pin_reset');
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS otp_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    phone_number VARCHAR(20) NOT NULL,
+    purpose otp_purpose NOT NULL,
+
+    -- Wallet ID for pin_reset codes
+    subject_id UUID,
+
+    code_hash TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    consumed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_otp_code_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_otp_attempts CHECK (attempts >= 0)
+);
+
+CREATE INDEX idx_otp_codes_phone_purpose ON otp_codes(phone_number, purpose, created_at DESC);
+CREATE INDEX idx_otp_codes_expires_at ON otp_codes(expires_at);
+
+COMMENT ON TABLE otp_codes IS 'One-time codes sent by SMS for phone verification, password reset and PIN reset';
+COMMENT ON COLUMN otp_codes.subject_id IS 'Wallet ID for pin_reset codes';
+COMMENT ON COLUMN otp_codes.code_hash IS 'Bcrypt hash of the code';
+COMMENT ON COLUMN users.phone_verified_at IS 'When the user proved ownership of phone_number with an OTP';
@@ -0,0 +1,14 @@
+-- migrations/000024_create_transfer_challenges.down.sql
+
+DROP TABLE IF EXISTS transfer_challenges;
+DROP TABLE IF EXISTS totp_secrets;
+DROP TYPE IF EXISTS two_factor_method;
+
+-- Enum values cannot be dropped, so otp_purpose is recreated without
+-- transfer_confirmation.
+DELETE FROM otp_codes WHERE purpose = 'transfer_confirmation';
+ALTER TYPE otp_purpose RENAME TO otp_purpose_old;
+CREATE TYPE otp_purpose AS ENUM ('phone_verification', 'password_reset', 'pin_reset');
+ALTER TABLE otp_codes
+    ALTER COLUMN purpose TYPE otp_purpose USING purpose::text::otp_purpose;
+DROP TYPE otp_purpose_old;
@@ -0,0 +1,60 @@
+-- migrations/000024_create_transfer_challenges.up.sql
+
+CREATE TYPE two_factor_method AS ENUM ('totp', 'otp');
+
+ALTER TYPE otp_purpose ADD VALUE IF NOT EXISTS 'transfer_confirmation';
+
+CREATE TABLE IF NOT EXISTS totp_secrets (
+    user_id UUID PRIMARY KEY,
+    secret TEXT NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+
+    -- Time step of the last accepted code, so a code cannot be replayed
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_totp_secret_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_totp_secrets_updated_at
+    BEFORE UPDATE ON totp_secrets
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS transfer_challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    from_wallet_id UUID NOT NULL,
+    to_wallet_id UUID NOT NULL,
+    amount DECIMAL(15, 2) NOT NULL,
+    method two_factor_method NOT NULL,
+
+    -- The signed transfer, carried out once the challenge is confirmed
+    transfer JSONB NOT NULL,
+
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    transaction_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_transfer_challenge_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_transfer_challenge_from_wallet FOREIGN KEY (from_wallet_id)
+        REFERENCES wallets(id) ON DELETE CASCADE,
+    CONSTRAINT fk_transfer_challenge_transaction FOREIGN KEY (transaction_id)
+        REFERENCES transactions(id) ON DELETE SET NULL,
+    CONSTRAINT chk_transfer_challenge_amount CHECK (amount > 0),
+    CONSTRAINT chk_transfer_challenge_attempts CHECK (attempts >= 0)
+);
+
+CREATE INDEX idx_transfer_challenges_user_id ON transfer_challenges(user_id, created_at DESC);
+CREATE INDEX idx_transfer_challenges_expires_at ON transfer_challenges(expires_at) WHERE confirmed_at IS NULL;
+
+COMMENT ON TABLE totp_secrets IS 'Authenticator app secrets; a secret is enrolled once confirmed_at is set';
+COMMENT ON COLUMN totp_secrets.last_used_step IS 'Time step of the last accepted code, so a code cannot be replayed';
+COMMENT ON TABLE transfer_challenges IS 'High-value transfers waiting for a TOTP or SMS code';
+COMMENT ON COLUMN transfer_challenges.transfer IS 'The signed transfer, carried out once the challenge is confirmed';
@@ -0,0 +1,5 @@
+-- migrations/000025_create_payment_requests.down.sql
+
+DROP TABLE IF EXISTS payment_requests;
+
+DROP TYPE IF EXISTS payment_request_status;
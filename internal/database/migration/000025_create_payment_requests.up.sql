@@ -0,0 +1,44 @@
+-- migrations/000025_create_payment_requests.up.sql
+
+CREATE TYPE payment_request_status AS ENUM ('pending', 'fulfilled', 'cancelled');
+
+CREATE TABLE IF NOT EXISTS payment_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+    -- Wallet that receives the payment
+    wallet_id UUID NOT NULL,
+
+    amount DECIMAL(15, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'NPR',
+    description VARCHAR(140),
+    status payment_request_status NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    -- Transaction that paid the request
+    transaction_id UUID,
+    fulfilled_at TIMESTAMP WITH TIME ZONE,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_payment_request_wallet FOREIGN KEY (wallet_id)
+        REFERENCES wallets(id) ON DELETE CASCADE,
+    CONSTRAINT fk_payment_request_transaction FOREIGN KEY (transaction_id)
+        REFERENCES transactions(id) ON DELETE SET NULL,
+    CONSTRAINT chk_payment_request_amount CHECK (amount > 0 AND amount <= 1000000),
+    CONSTRAINT chk_payment_request_fulfilled CHECK (
+        (status = 'fulfilled') = (fulfilled_at IS NOT NULL)
+    )
+);
+
+CREATE INDEX idx_payment_requests_wallet_id ON payment_requests(wallet_id, created_at DESC);
+CREATE UNIQUE INDEX idx_payment_requests_transaction_id ON payment_requests(transaction_id);
+
+CREATE TRIGGER update_payment_requests_updated_at
+    BEFORE UPDATE ON payment_requests
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE payment_requests IS 'Invoices a merchant wallet shows as a QR code for a payer to settle';
+COMMENT ON COLUMN payment_requests.wallet_id IS 'Wallet that receives the payment';
+COMMENT ON COLUMN payment_requests.transaction_id IS 'Transaction that paid the request, linked through its metadata.payment_request_id';
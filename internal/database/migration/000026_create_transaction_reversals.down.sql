@@ -0,0 +1,18 @@
+-- migrations/000026_create_transaction_reversals.down.sql
+
+DROP TABLE IF EXISTS transaction_reversals;
+
+-- Enum values cannot be dropped, so transaction_type is recreated without
+-- refund and reversal. Their transactions (and ledger entries) go with them.
+DELETE FROM transactions WHERE type IN ('refund', 'reversal');
+
+DROP INDEX IF EXISTS uq_wallet_nonce;
+ALTER TABLE transactions ADD CONSTRAINT uq_wallet_nonce UNIQUE (from_wallet_id, nonce);
+
+ALTER TYPE transaction_type RENAME TO transaction_type_old;
+CREATE TYPE transaction_type AS ENUM ('p2p', 'deposit', 'withdraw');
+ALTER TABLE transactions ALTER COLUMN type DROP DEFAULT;
+ALTER TABLE transactions
+    ALTER COLUMN type TYPE transaction_type USING type::text::transaction_type;
+ALTER TABLE transactions ALTER COLUMN type SET DEFAULT 'p2p';
+DROP TYPE transaction_type_old;
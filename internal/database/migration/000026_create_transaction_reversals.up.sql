@@ -0,0 +1,32 @@
+-- migrations/000026_create_transaction_reversals.up.sql
+
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'refund';
+ALTER TYPE transaction_type ADD VALUE IF NOT EXISTS 'reversal';
+
+-- Reversals are made by the server rather than signed by a wallet, so they
+-- carry nonce 0, which no signed transaction can use.
+ALTER TABLE transactions DROP CONSTRAINT uq_wallet_nonce;
+CREATE UNIQUE INDEX uq_wallet_nonce ON transactions(from_wallet_id, nonce) WHERE nonce <> 0;
+
+CREATE TABLE IF NOT EXISTS transaction_reversals (
+    transaction_id UUID PRIMARY KEY,
+    original_transaction_id UUID NOT NULL,
+    reason TEXT,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_reversal_transaction FOREIGN KEY (transaction_id)
+        REFERENCES transactions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_reversal_original FOREIGN KEY (original_transaction_id)
+        REFERENCES transactions(id) ON DELETE RESTRICT,
+    CONSTRAINT fk_reversal_created_by FOREIGN KEY (created_by)
+        REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT chk_reversal_not_self CHECK (transaction_id <> original_transaction_id)
+);
+
+CREATE INDEX idx_transaction_reversals_original ON transaction_reversals(original_transaction_id);
+
+COMMENT ON TABLE transaction_reversals IS 'Links refund and reversal transactions to the transaction they pay back';
+COMMENT ON COLUMN transaction_reversals.transaction_id IS 'The refund or reversal transaction';
+COMMENT ON COLUMN transaction_reversals.original_transaction_id IS 'The transaction being paid back';
+COMMENT ON COLUMN transaction_reversals.created_by IS 'User who made the refund or reversal';
@@ -0,0 +1,77 @@
+-- migrations/000027_create_webhooks.up.sql
+
+CREATE TYPE webhook_outbox_status AS ENUM ('pending', 'delivered', 'failed');
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    wallet_id UUID,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_webhook_subscription_user FOREIGN KEY (user_id)
+        REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_webhook_subscription_wallet FOREIGN KEY (wallet_id)
+        REFERENCES wallets(id) ON DELETE CASCADE,
+    CONSTRAINT chk_webhook_url CHECK (url ~ '^https?://')
+);
+
+CREATE INDEX idx_webhook_subscriptions_user ON webhook_subscriptions(user_id);
+CREATE INDEX idx_webhook_subscriptions_wallet ON webhook_subscriptions(wallet_id) WHERE wallet_id IS NOT NULL;
+
+CREATE TRIGGER update_webhook_subscriptions_updated_at
+    BEFORE UPDATE ON webhook_subscriptions
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status webhook_outbox_status NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_webhook_outbox_subscription FOREIGN KEY (subscription_id)
+        REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_outbox_due ON webhook_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_outbox_subscription ON webhook_outbox(subscription_id, created_at DESC);
+
+CREATE TRIGGER update_webhook_outbox_updated_at
+    BEFORE UPDATE ON webhook_outbox
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    outbox_id UUID NOT NULL,
+    attempt INT NOT NULL,
+    status_code INT,
+    error TEXT,
+    duration_ms INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_webhook_delivery_outbox FOREIGN KEY (outbox_id)
+        REFERENCES webhook_outbox(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_outbox ON webhook_deliveries(outbox_id, created_at DESC);
+
+COMMENT ON TABLE webhook_subscriptions IS 'Endpoints that receive signed transaction events for a user or one wallet';
+COMMENT ON COLUMN webhook_subscriptions.wallet_id IS 'Only events for this wallet; NULL for every wallet of the user';
+COMMENT ON COLUMN webhook_subscriptions.secret IS 'HMAC-SHA256 key for the X-PayOn-Signature header';
+COMMENT ON COLUMN webhook_subscriptions.event_types IS 'Event types to send; empty for all';
+COMMENT ON TABLE webhook_outbox IS 'Events waiting to be delivered, written in the same transaction as the change they report';
+COMMENT ON COLUMN webhook_outbox.next_attempt_at IS 'When the event is due; pushed forward while a dispatcher holds it';
+COMMENT ON TABLE webhook_deliveries IS 'One row per attempt to deliver an outbox event';
@@ -0,0 +1,12 @@
+-- migrations/000028_create_wallet_event_triggers.down.sql
+
+DROP TRIGGER IF EXISTS trigger_notify_wallet_balance ON wallets;
+DROP TRIGGER IF EXISTS trigger_notify_transaction_insert ON transactions;
+DROP TRIGGER IF EXISTS trigger_notify_transaction_status ON transactions;
+DROP TRIGGER IF EXISTS trigger_notify_sync_log_insert ON sync_logs;
+DROP TRIGGER IF EXISTS trigger_notify_sync_log_status ON sync_logs;
+
+DROP FUNCTION IF EXISTS notify_wallet_balance_change() CASCADE;
+DROP FUNCTION IF EXISTS notify_transaction_change() CASCADE;
+DROP FUNCTION IF EXISTS notify_sync_log_change() CASCADE;
+DROP FUNCTION IF EXISTS notify_wallet_event(UUID, TEXT, JSONB) CASCADE;
@@ -0,0 +1,92 @@
+-- migrations/000028_create_wallet_event_triggers.up.sql
+
+-- Wallet changes are published on the wallet_events channel so every API
+-- instance can push them to connected clients. Notifications are sent when
+-- the changing transaction commits.
+CREATE OR REPLACE FUNCTION notify_wallet_event(p_wallet_id UUID, p_type TEXT, p_data JSONB)
+RETURNS VOID AS $$
+BEGIN
+    PERFORM pg_notify(
+        'wallet_events',
+        jsonb_build_object('wallet_id', p_wallet_id, 'type', p_type, 'data', p_data)::text
+    );
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION notify_wallet_balance_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM notify_wallet_event(NEW.id, 'balance', jsonb_build_object(
+        'balance', NEW.balance,
+        'updated_at', NEW.updated_at
+    ));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_notify_wallet_balance
+AFTER UPDATE OF balance ON wallets
+FOR EACH ROW
+WHEN (OLD.balance IS DISTINCT FROM NEW.balance)
+EXECUTE FUNCTION notify_wallet_balance_change();
+
+-- Both wallets of a transaction hear about it; direction tells them apart.
+CREATE OR REPLACE FUNCTION notify_transaction_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_data JSONB := jsonb_build_object(
+        'id', NEW.id,
+        'from_wallet_id', NEW.from_wallet_id,
+        'to_wallet_id', NEW.to_wallet_id,
+        'amount', NEW.amount,
+        'currency', NEW.currency,
+        'type', NEW.type,
+        'status', NEW.status,
+        'transaction_at', NEW.transaction_at
+    );
+BEGIN
+    PERFORM notify_wallet_event(NEW.to_wallet_id, 'transaction', v_data || '{"direction": "incoming"}');
+    IF NEW.from_wallet_id <> NEW.to_wallet_id THEN
+        PERFORM notify_wallet_event(NEW.from_wallet_id, 'transaction', v_data || '{"direction": "outgoing"}');
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_notify_transaction_insert
+AFTER INSERT ON transactions
+FOR EACH ROW
+EXECUTE FUNCTION notify_transaction_change();
+
+CREATE TRIGGER trigger_notify_transaction_status
+AFTER UPDATE OF status ON transactions
+FOR EACH ROW
+WHEN (OLD.status IS DISTINCT FROM NEW.status)
+EXECUTE FUNCTION notify_transaction_change();
+
+CREATE OR REPLACE FUNCTION notify_sync_log_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM notify_wallet_event(NEW.wallet_id, 'sync_log', jsonb_build_object(
+        'id', NEW.id,
+        'transaction_id', NEW.transaction_id,
+        'status', NEW.status,
+        'attempt_count', NEW.attempt_count,
+        'error_message', NEW.error_message
+    ));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_notify_sync_log_insert
+AFTER INSERT ON sync_logs
+FOR EACH ROW
+EXECUTE FUNCTION notify_sync_log_change();
+
+CREATE TRIGGER trigger_notify_sync_log_status
+AFTER UPDATE OF status ON sync_logs
+FOR EACH ROW
+WHEN (OLD.status IS DISTINCT FROM NEW.status)
+EXECUTE FUNCTION notify_sync_log_change();
+
+COMMENT ON FUNCTION notify_wallet_event(UUID, TEXT, JSONB) IS 'Publishes a wallet event on the wallet_events channel';
@@ -0,0 +1,4 @@
+-- migrations/000029_create_outbox.down.sql
+
+DROP TABLE IF EXISTS outbox;
+DROP TYPE IF EXISTS outbox_status;
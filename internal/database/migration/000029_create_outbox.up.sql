@@ -0,0 +1,29 @@
+-- migrations/000029_create_outbox.up.sql
+
+CREATE TYPE outbox_status AS ENUM ('pending', 'published', 'failed');
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    status outbox_status NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_outbox_due ON outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_outbox_aggregate ON outbox(aggregate_id, created_at);
+
+CREATE TRIGGER update_outbox_updated_at
+    BEFORE UPDATE ON outbox
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+COMMENT ON TABLE outbox IS 'Domain events written in the same transaction as the change they describe, relayed to in-process subscribers';
+COMMENT ON COLUMN outbox.aggregate_id IS 'The wallet, transaction or sync log the event is about';
+COMMENT ON COLUMN outbox.next_attempt_at IS 'When the event is due; pushed forward while a relay holds it';
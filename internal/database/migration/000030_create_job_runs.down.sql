@@ -0,0 +1,5 @@
+-- migrations/000030_create_job_runs.down.sql
+
+DROP TABLE IF EXISTS job_runs;
+DROP TYPE IF EXISTS job_trigger;
+DROP TYPE IF EXISTS job_run_status;
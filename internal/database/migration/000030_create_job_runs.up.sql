@@ -0,0 +1,25 @@
+-- migrations/000030_create_job_runs.up.sql
+
+CREATE TYPE job_run_status AS ENUM ('running', 'succeeded', 'failed');
+CREATE TYPE job_trigger AS ENUM ('schedule', 'manual');
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_name VARCHAR(100) NOT NULL,
+    trigger job_trigger NOT NULL,
+    triggered_by UUID,
+    status job_run_status NOT NULL DEFAULT 'running',
+    result TEXT,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT fk_job_run_triggered_by FOREIGN KEY (triggered_by)
+        REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_job_runs_job ON job_runs(job_name, started_at DESC);
+
+COMMENT ON TABLE job_runs IS 'One row per run of a background maintenance job';
+COMMENT ON COLUMN job_runs.triggered_by IS 'Admin who ran the job by hand; NULL for scheduled runs';
+COMMENT ON COLUMN job_runs.result IS 'Short summary of what the job did';
@@ -0,0 +1,41 @@
+-- migrations/000031_add_sync_log_dead_letters.down.sql
+
+DROP INDEX IF EXISTS idx_sync_logs_retry;
+
+ALTER TABLE sync_logs DROP COLUMN IF EXISTS next_attempt_at;
+
+-- Postgres cannot drop an enum value, so the type is rebuilt without it and
+-- dead letters go back to failed.
+DROP TRIGGER IF EXISTS trigger_notify_sync_log_status ON sync_logs;
+
+ALTER TABLE sync_logs ALTER COLUMN status DROP DEFAULT;
+
+DROP INDEX IF EXISTS idx_sync_logs_status;
+DROP INDEX IF EXISTS idx_sync_logs_wallet;
+
+CREATE TYPE sync_status_old AS ENUM ('pending', 'confirmed', 'settling', 'settled', 'failed', 'conflict');
+
+ALTER TABLE sync_logs
+    ALTER COLUMN status TYPE text
+    USING status::text;
+
+ALTER TABLE sync_logs
+    ALTER COLUMN status TYPE sync_status_old
+    USING (CASE
+        WHEN status = 'dead_letter' THEN 'failed'
+        ELSE status
+    END)::sync_status_old;
+
+DROP TYPE sync_status;
+ALTER TYPE sync_status_old RENAME TO sync_status;
+
+ALTER TABLE sync_logs ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE INDEX idx_sync_logs_wallet ON sync_logs(wallet_id, status);
+CREATE INDEX idx_sync_logs_status ON sync_logs(status, created_at) WHERE status = 'pending';
+
+CREATE TRIGGER trigger_notify_sync_log_status
+AFTER UPDATE OF status ON sync_logs
+FOR EACH ROW
+WHEN (OLD.status IS DISTINCT FROM NEW.status)
+EXECUTE FUNCTION notify_sync_log_change();
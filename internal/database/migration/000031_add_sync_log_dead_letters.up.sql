@@ -0,0 +1,10 @@
+-- migrations/000031_add_sync_log_dead_letters.up.sql
+
+ALTER TYPE sync_status ADD VALUE IF NOT EXISTS 'dead_letter';
+
+ALTER TABLE sync_logs
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_sync_logs_retry ON sync_logs(attempt_count, created_at) WHERE status = 'failed';
+
+COMMENT ON COLUMN sync_logs.next_attempt_at IS 'When a failed settlement is retried; NULL retries it on the next pass';
@@ -0,0 +1,4 @@
+-- migrations/000032_add_legacy_key_errors.down.sql
+
+ALTER TABLE wallet_custodial_keys
+    DROP COLUMN IF EXISTS legacy_key_error;
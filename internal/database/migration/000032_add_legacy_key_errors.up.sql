@@ -0,0 +1,6 @@
+-- migrations/000032_add_legacy_key_errors.up.sql
+
+ALTER TABLE wallet_custodial_keys
+    ADD COLUMN IF NOT EXISTS legacy_key_error TEXT;
+
+COMMENT ON COLUMN wallet_custodial_keys.legacy_key_error IS 'Why the legacy key could not be sealed; the row is skipped until an operator fixes or removes the key';
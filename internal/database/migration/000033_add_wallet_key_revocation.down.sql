@@ -0,0 +1,5 @@
+-- migrations/000033_add_wallet_key_revocation.down.sql
+
+ALTER TABLE wallet_keys
+    DROP COLUMN IF EXISTS revoked_reason,
+    DROP COLUMN IF EXISTS revoked_at;
@@ -0,0 +1,8 @@
+-- migrations/000033_add_wallet_key_revocation.up.sql
+
+ALTER TABLE wallet_keys
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS revoked_reason TEXT;
+
+COMMENT ON COLUMN wallet_keys.revoked_at IS 'When the key was reported compromised; offline transactions are no longer verified with it';
+COMMENT ON COLUMN wallet_keys.revoked_reason IS 'Why the key was revoked';
@@ -0,0 +1,15 @@
+-- migrations/000034_redact_sync_log_events.down.sql
+
+CREATE OR REPLACE FUNCTION notify_sync_log_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM notify_wallet_event(NEW.wallet_id, 'sync_log', jsonb_build_object(
+        'id', NEW.id,
+        'transaction_id', NEW.transaction_id,
+        'status', NEW.status,
+        'attempt_count', NEW.attempt_count,
+        'error_message', NEW.error_message
+    ));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
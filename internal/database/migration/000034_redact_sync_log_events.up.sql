@@ -0,0 +1,16 @@
+-- migrations/000034_redact_sync_log_events.up.sql
+
+-- Sync log events no longer carry error_message: it can hold internal
+-- details, and every API instance listening on the channel receives it.
+CREATE OR REPLACE FUNCTION notify_sync_log_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM notify_wallet_event(NEW.wallet_id, 'sync_log', jsonb_build_object(
+        'id', NEW.id,
+        'transaction_id', NEW.transaction_id,
+        'status', NEW.status,
+        'attempt_count', NEW.attempt_count
+    ));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,8 @@
+-- migrations/000035_add_webhook_outbox_source_event.down.sql
+
+DROP INDEX IF EXISTS idx_webhook_outbox_source_event;
+
+ALTER TABLE webhook_outbox
+    DROP COLUMN IF EXISTS source_event_id;
+
+COMMENT ON TABLE webhook_outbox IS 'Events waiting to be delivered, written in the same transaction as the change they report';
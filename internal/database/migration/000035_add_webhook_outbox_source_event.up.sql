@@ -0,0 +1,10 @@
+-- migrations/000035_add_webhook_outbox_source_event.up.sql
+
+ALTER TABLE webhook_outbox
+    ADD COLUMN IF NOT EXISTS source_event_id UUID;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_outbox_source_event
+    ON webhook_outbox(subscription_id, source_event_id);
+
+COMMENT ON TABLE webhook_outbox IS 'Events waiting to be delivered, queued from the domain events relayed off the outbox';
+COMMENT ON COLUMN webhook_outbox.source_event_id IS 'The outbox domain event this webhook event was queued for, so a relayed event is queued once per subscription';
@@ -0,0 +1,6 @@
+-- migrations/000036_add_job_run_slots.down.sql
+
+DROP INDEX IF EXISTS idx_job_runs_slot;
+
+ALTER TABLE job_runs
+    DROP COLUMN IF EXISTS scheduled_at;
@@ -0,0 +1,8 @@
+-- migrations/000036_add_job_run_slots.up.sql
+
+ALTER TABLE job_runs
+    ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_job_runs_slot ON job_runs(job_name, scheduled_at);
+
+COMMENT ON COLUMN job_runs.scheduled_at IS 'The schedule slot a scheduled run claimed, so each slot runs once across servers; NULL for manual runs';
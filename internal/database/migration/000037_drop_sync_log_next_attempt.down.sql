@@ -0,0 +1,6 @@
+-- migrations/000037_drop_sync_log_next_attempt.down.sql
+
+ALTER TABLE sync_logs
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN sync_logs.next_attempt_at IS 'When a failed settlement is retried; NULL retries it on the next pass';
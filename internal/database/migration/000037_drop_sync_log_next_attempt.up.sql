@@ -0,0 +1,5 @@
+-- migrations/000037_drop_sync_log_next_attempt.up.sql
+
+-- A failed log is due once the backoff after last_attempt_at has passed,
+-- which attempt_count decides, so the retry time is no longer stored.
+ALTER TABLE sync_logs DROP COLUMN IF EXISTS next_attempt_at;
@@ -0,0 +1,21 @@
+-- migrations/000038_restrict_ledger_deletes.down.sql
+
+CREATE OR REPLACE FUNCTION prevent_ledger_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'ledger entries are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE ledger_entries
+    DROP CONSTRAINT fk_ledger_wallet,
+    DROP CONSTRAINT fk_ledger_transaction;
+
+ALTER TABLE ledger_entries
+    ADD CONSTRAINT fk_ledger_wallet FOREIGN KEY (wallet_id)
+        REFERENCES wallets(id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_ledger_transaction FOREIGN KEY (transaction_id)
+        REFERENCES transactions(id) ON DELETE CASCADE;
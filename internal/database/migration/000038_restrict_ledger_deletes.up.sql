@@ -0,0 +1,22 @@
+-- migrations/000038_restrict_ledger_deletes.up.sql
+
+-- Ledger entries outlive the wallets and transactions they record: deleting
+-- either is refused while entries point at it, instead of taking the entries
+-- with it and leaving the other side of their postings unbalanced.
+ALTER TABLE ledger_entries
+    DROP CONSTRAINT fk_ledger_wallet,
+    DROP CONSTRAINT fk_ledger_transaction;
+
+ALTER TABLE ledger_entries
+    ADD CONSTRAINT fk_ledger_wallet FOREIGN KEY (wallet_id)
+        REFERENCES wallets(id) ON DELETE RESTRICT,
+    ADD CONSTRAINT fk_ledger_transaction FOREIGN KEY (transaction_id)
+        REFERENCES transactions(id) ON DELETE RESTRICT;
+
+-- Entries are append-only; no delete gets through, cascading or not.
+CREATE OR REPLACE FUNCTION prevent_ledger_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger entries are immutable';
+END;
+$$ LANGUAGE plpgsql;
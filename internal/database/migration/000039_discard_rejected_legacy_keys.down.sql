@@ -0,0 +1,18 @@
+-- migrations/000039_discard_rejected_legacy_keys.down.sql
+
+ALTER TABLE wallet_custodial_keys DROP CONSTRAINT chk_wallet_custodial_key_sealed;
+
+-- Discarded keys cannot come back
+DELETE FROM wallet_custodial_keys
+WHERE legacy_private_key IS NULL
+  AND master_key_id IS NULL;
+
+ALTER TABLE wallet_custodial_keys ADD CONSTRAINT chk_wallet_custodial_key_sealed CHECK (
+    legacy_private_key IS NOT NULL OR (
+        master_key_id IS NOT NULL
+        AND encrypted_data_key IS NOT NULL
+        AND encrypted_private_key IS NOT NULL
+    )
+);
+
+COMMENT ON COLUMN wallet_custodial_keys.legacy_key_error IS 'Why the legacy key could not be sealed; the row is skipped until an operator fixes or removes the key';
@@ -0,0 +1,26 @@
+-- migrations/000039_discard_rejected_legacy_keys.up.sql
+
+-- A legacy key that cannot be sealed is not kept in plaintext either:
+-- legacy_key_error records why it was discarded.
+ALTER TABLE wallet_custodial_keys DROP CONSTRAINT chk_wallet_custodial_key_sealed;
+
+UPDATE wallet_custodial_keys
+SET legacy_private_key = NULL
+WHERE legacy_key_error IS NOT NULL;
+
+-- Placeholders copied before 000021 trimmed its filter were never keys
+DELETE FROM wallet_custodial_keys
+WHERE legacy_private_key IS NOT NULL
+  AND (btrim(legacy_private_key) = '' OR lower(btrim(legacy_private_key)) = 'client-managed');
+
+ALTER TABLE wallet_custodial_keys ADD CONSTRAINT chk_wallet_custodial_key_sealed CHECK (
+    legacy_private_key IS NOT NULL
+    OR legacy_key_error IS NOT NULL
+    OR (
+        master_key_id IS NOT NULL
+        AND encrypted_data_key IS NOT NULL
+        AND encrypted_private_key IS NOT NULL
+    )
+);
+
+COMMENT ON COLUMN wallet_custodial_keys.legacy_key_error IS 'Why the legacy key could not be sealed; its plaintext has been discarded';
@@ -0,0 +1,13 @@
+-- migrations/000040_lock_two_factor_attempts.down.sql
+
+-- Enum values cannot be dropped, so credential_kind is recreated without
+-- two_factor.
+DELETE FROM credential_attempts WHERE kind = 'two_factor';
+ALTER TYPE credential_kind RENAME TO credential_kind_old;
+CREATE TYPE credential_kind AS ENUM ('wallet_pin', 'user_password');
+ALTER TABLE credential_attempts
+    ALTER COLUMN kind TYPE credential_kind USING kind::text::credential_kind;
+DROP TYPE credential_kind_old;
+
+COMMENT ON TABLE credential_attempts IS 'Failed PIN and password attempts per wallet or user, for backoff and lockout';
+COMMENT ON COLUMN credential_attempts.subject_id IS 'Wallet ID for wallet_pin, user ID for user_password';
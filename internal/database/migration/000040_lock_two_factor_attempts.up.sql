@@ -0,0 +1,7 @@
+-- migrations/000040_lock_two_factor_attempts.up.sql
+
+-- Wrong TOTP and SMS codes count against the user like wrong passwords
+ALTER TYPE credential_kind ADD VALUE IF NOT EXISTS 'two_factor';
+
+COMMENT ON TABLE credential_attempts IS 'Failed PIN, password and second-factor attempts per wallet or user, for backoff and lockout';
+COMMENT ON COLUMN credential_attempts.subject_id IS 'Wallet ID for wallet_pin, user ID for user_password and two_factor';
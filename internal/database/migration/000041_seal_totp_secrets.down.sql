@@ -0,0 +1,15 @@
+-- migrations/000041_seal_totp_secrets.down.sql
+
+-- Sealed secrets cannot be restored without the master key; those users
+-- enroll their authenticator app again
+DELETE FROM totp_secrets WHERE secret IS NULL;
+
+DROP INDEX IF EXISTS idx_totp_secrets_plaintext;
+ALTER TABLE totp_secrets DROP CONSTRAINT chk_totp_secret_sealed;
+ALTER TABLE totp_secrets
+    DROP COLUMN master_key_id,
+    DROP COLUMN encrypted_data_key,
+    DROP COLUMN encrypted_secret,
+    ALTER COLUMN secret SET NOT NULL;
+
+COMMENT ON COLUMN totp_secrets.secret IS NULL;
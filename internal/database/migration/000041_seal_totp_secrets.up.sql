@@ -0,0 +1,23 @@
+-- migrations/000041_seal_totp_secrets.up.sql
+
+-- Authenticator secrets are envelope encrypted like custodial wallet keys.
+-- Existing secrets stay in plaintext until the server seals them at startup.
+ALTER TABLE totp_secrets
+    ADD COLUMN master_key_id VARCHAR(64),
+    ADD COLUMN encrypted_data_key BYTEA,
+    ADD COLUMN encrypted_secret BYTEA,
+    ALTER COLUMN secret DROP NOT NULL;
+
+ALTER TABLE totp_secrets ADD CONSTRAINT chk_totp_secret_sealed CHECK (
+    secret IS NOT NULL OR (
+        master_key_id IS NOT NULL
+        AND encrypted_data_key IS NOT NULL
+        AND encrypted_secret IS NOT NULL
+    )
+);
+
+CREATE INDEX idx_totp_secrets_plaintext ON totp_secrets(user_id)
+    WHERE secret IS NOT NULL;
+
+COMMENT ON COLUMN totp_secrets.secret IS 'Plaintext secret stored by older versions, until the server seals it at startup';
+COMMENT ON COLUMN totp_secrets.master_key_id IS 'Identifies the master key that encrypted the data key';
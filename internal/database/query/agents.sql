@@ -0,0 +1,32 @@
+-- internal/database/query/agents.sql
+
+-- name: CreateAgent :one
+INSERT INTO agents (
+    wallet_id,
+    float_limit
+) VALUES (
+    $1, $2
+)
+RETURNING *;
+
+-- name: GetAgent :one
+SELECT * FROM agents
+WHERE wallet_id = $1;
+
+-- name: GetAgentForUpdate :one
+SELECT * FROM agents
+WHERE wallet_id = $1
+FOR UPDATE;
+
+-- name: ListAgents :many
+SELECT * FROM agents
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: UpdateAgent :one
+UPDATE agents
+SET
+    float_limit = COALESCE(sqlc.narg('float_limit'), float_limit),
+    is_active = COALESCE(sqlc.narg('is_active'), is_active)
+WHERE wallet_id = sqlc.arg('wallet_id')
+RETURNING *;
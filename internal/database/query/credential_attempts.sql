@@ -0,0 +1,32 @@
+-- internal/database/query/credential_attempts.sql
+
+-- name: LockCredentialAttempts :one
+INSERT INTO credential_attempts (
+    kind,
+    subject_id
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (kind, subject_id) DO UPDATE
+SET kind = EXCLUDED.kind
+RETURNING *;
+
+-- name: GetCredentialAttempts :one
+SELECT * FROM credential_attempts
+WHERE kind = $1 AND subject_id = $2;
+
+-- name: RecordCredentialFailure :one
+UPDATE credential_attempts
+SET
+    failed_attempts = $3,
+    last_failed_at = NOW(),
+    locked_until = $4
+WHERE kind = $1 AND subject_id = $2
+RETURNING *;
+
+-- name: ResetCredentialAttempts :exec
+UPDATE credential_attempts
+SET
+    failed_attempts = 0,
+    locked_until = NULL
+WHERE kind = $1 AND subject_id = $2;
@@ -0,0 +1,85 @@
+-- internal/database/query/devices.sql
+
+-- name: CreateDevice :one
+INSERT INTO devices (
+    user_id,
+    device_id,
+    name,
+    public_key,
+    verified_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetDevice :one
+SELECT * FROM devices
+WHERE id = $1;
+
+-- name: GetUserDevice :one
+SELECT * FROM devices
+WHERE user_id = $1 AND device_id = $2;
+
+-- name: GetWalletOwnerDevice :one
+SELECT d.* FROM devices d
+JOIN wallets w ON w.user_id = d.user_id
+WHERE w.id = $1 AND d.device_id = $2;
+
+-- name: ListDevicesByUser :many
+SELECT * FROM devices
+WHERE user_id = $1
+ORDER BY last_seen_at DESC;
+
+-- name: CountVerifiedDevices :one
+SELECT COUNT(*) FROM devices
+WHERE user_id = $1
+  AND verified_at IS NOT NULL
+  AND revoked_at IS NULL;
+
+-- name: IsDeviceOwner :one
+SELECT EXISTS(
+    SELECT 1 FROM devices
+    WHERE id = $1 AND user_id = $2
+);
+
+-- name: VerifyDevice :one
+UPDATE devices
+SET verified_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeDevice :one
+UPDATE devices
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: TouchDevice :exec
+UPDATE devices
+SET last_seen_at = NOW()
+WHERE id = $1;
+
+-- name: CreateDeviceChallenge :one
+INSERT INTO device_challenges (
+    user_id,
+    device_id,
+    challenge,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetDeviceChallengeForUpdate :one
+SELECT * FROM device_challenges
+WHERE id = $1
+FOR UPDATE;
+
+-- name: MarkDeviceChallengeUsed :exec
+UPDATE device_challenges
+SET used_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteExpiredDeviceChallenges :execrows
+DELETE FROM device_challenges
+WHERE expires_at < $1;
@@ -0,0 +1,47 @@
+-- internal/database/query/idempotency_keys.sql
+
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+    user_id,
+    idempotency_key,
+    method,
+    path,
+    request_hash
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id, idempotency_key) DO UPDATE
+SET
+    method = EXCLUDED.method,
+    path = EXCLUDED.path,
+    request_hash = EXCLUDED.request_hash,
+    created_at = NOW()
+WHERE idempotency_keys.response_status IS NULL
+    AND idempotency_keys.created_at < $6
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = $1 AND idempotency_key = $2;
+
+-- name: RenewIdempotencyKey :execrows
+UPDATE idempotency_keys
+SET created_at = NOW()
+WHERE id = $1 AND response_status IS NULL;
+
+-- name: CompleteIdempotencyKey :one
+UPDATE idempotency_keys
+SET
+    response_status = $2,
+    response_body = $3,
+    completed_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE id = $1;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE created_at < $1;
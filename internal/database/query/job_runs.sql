@@ -0,0 +1,42 @@
+-- name: CreateJobRun :one
+INSERT INTO job_runs (
+    job_name,
+    trigger,
+    triggered_by,
+    scheduled_at
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (job_name, scheduled_at) DO NOTHING
+RETURNING *;
+
+-- name: FinishJobRun :one
+UPDATE job_runs
+SET
+    status = $2,
+    result = $3,
+    error = $4,
+    finished_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListJobRuns :many
+SELECT * FROM job_runs
+WHERE job_name = $1
+ORDER BY started_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: ListLatestJobRuns :many
+SELECT DISTINCT ON (job_name) * FROM job_runs
+ORDER BY job_name, started_at DESC;
+
+-- name: DeleteOldJobRuns :execrows
+DELETE FROM job_runs
+WHERE status <> 'running'
+  AND started_at < $1;
+
+-- name: TryAdvisoryLock :one
+SELECT pg_try_advisory_lock(hashtext(sqlc.arg('key')::text)) AS locked;
+
+-- name: AdvisoryUnlock :one
+SELECT pg_advisory_unlock(hashtext(sqlc.arg('key')::text)) AS unlocked;
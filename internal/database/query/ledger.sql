@@ -0,0 +1,72 @@
+-- internal/database/query/ledger.sql
+
+-- name: CreateLedgerEntry :one
+INSERT INTO ledger_entries (
+    posting_id,
+    transaction_id,
+    wallet_id,
+    account,
+    direction,
+    entry_type,
+    amount,
+    description
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: ListLedgerEntriesByWallet :many
+SELECT * FROM ledger_entries
+WHERE wallet_id = $1
+ORDER BY created_at DESC, id
+LIMIT $2 OFFSET $3;
+
+-- name: ListLedgerEntriesByTransaction :many
+SELECT * FROM ledger_entries
+WHERE transaction_id = $1
+ORDER BY created_at ASC, direction ASC;
+
+-- name: GetWalletLedgerBalance :one
+SELECT COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)::DECIMAL(15, 2) AS ledger_balance
+FROM ledger_entries
+WHERE wallet_id = $1;
+
+-- name: WalletHasLedgerEntries :one
+SELECT EXISTS(
+    SELECT 1 FROM ledger_entries
+    WHERE wallet_id = $1
+);
+
+-- name: TransactionHasLedgerEntries :one
+SELECT EXISTS(
+    SELECT 1 FROM ledger_entries
+    WHERE transaction_id = $1
+);
+
+-- name: ListBalanceMismatches :many
+SELECT
+    w.id AS wallet_id,
+    w.balance,
+    COALESCE(l.ledger_balance, 0)::DECIMAL(15, 2) AS ledger_balance
+FROM wallets w
+LEFT JOIN (
+    SELECT
+        wallet_id,
+        SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END) AS ledger_balance
+    FROM ledger_entries
+    WHERE wallet_id IS NOT NULL
+    GROUP BY wallet_id
+) l ON l.wallet_id = w.id
+WHERE w.balance <> COALESCE(l.ledger_balance, 0)
+ORDER BY w.id
+LIMIT $1;
+
+-- name: ListUnbalancedPostings :many
+SELECT
+    posting_id,
+    COALESCE(SUM(amount) FILTER (WHERE direction = 'debit'), 0)::DECIMAL(15, 2) AS debit_total,
+    COALESCE(SUM(amount) FILTER (WHERE direction = 'credit'), 0)::DECIMAL(15, 2) AS credit_total
+FROM ledger_entries
+GROUP BY posting_id
+HAVING COALESCE(SUM(amount) FILTER (WHERE direction = 'debit'), 0)
+    <> COALESCE(SUM(amount) FILTER (WHERE direction = 'credit'), 0)
+LIMIT $1;
@@ -0,0 +1,55 @@
+-- internal/database/query/otp_codes.sql
+
+-- name: CreateOTPCode :one
+INSERT INTO otp_codes (
+    user_id,
+    phone_number,
+    purpose,
+    subject_id,
+    code_hash,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetOTPSendStats :one
+SELECT
+    COUNT(*) AS sent,
+    MIN(created_at)::timestamptz AS first_sent_at,
+    MAX(created_at)::timestamptz AS last_sent_at
+FROM otp_codes
+WHERE phone_number = $1
+  AND purpose = $2
+  AND created_at >= $3;
+
+-- name: GetActiveOTPCodeForUpdate :one
+SELECT * FROM otp_codes
+WHERE phone_number = $1
+  AND purpose = $2
+  AND consumed_at IS NULL
+ORDER BY created_at DESC
+LIMIT 1
+FOR UPDATE;
+
+-- name: IncrementOTPAttempts :one
+UPDATE otp_codes
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: ConsumeOTPCode :exec
+UPDATE otp_codes
+SET consumed_at = NOW()
+WHERE id = $1;
+
+-- name: ConsumeUserOTPCodes :exec
+UPDATE otp_codes
+SET consumed_at = NOW()
+WHERE user_id = $1
+  AND purpose = $2
+  AND consumed_at IS NULL;
+
+-- name: DeleteExpiredOTPCodes :execrows
+DELETE FROM otp_codes
+WHERE expires_at < $1;
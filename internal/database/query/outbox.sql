@@ -0,0 +1,58 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox (
+    event_type,
+    aggregate_id,
+    payload
+) VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: ClaimOutboxEvents :many
+WITH due AS (
+    SELECT id FROM outbox
+    WHERE status = 'pending'
+      AND next_attempt_at <= NOW()
+    ORDER BY created_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+)
+UPDATE outbox o
+SET
+    attempts = o.attempts + 1,
+    next_attempt_at = sqlc.arg('lease_until')::timestamptz
+FROM due
+WHERE o.id = due.id
+RETURNING o.*;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox
+SET
+    status = 'published',
+    published_at = NOW(),
+    last_error = NULL
+WHERE id = $1;
+
+-- name: ScheduleOutboxEventRetry :exec
+UPDATE outbox
+SET
+    next_attempt_at = $2,
+    last_error = $3
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox
+SET
+    status = 'failed',
+    last_error = $2
+WHERE id = $1;
+
+-- name: ListOutboxEventsByAggregate :many
+SELECT * FROM outbox
+WHERE aggregate_id = $1
+ORDER BY created_at;
+
+-- name: DeleteOldOutboxEvents :execrows
+DELETE FROM outbox
+WHERE status = 'published'
+  AND published_at < $1;
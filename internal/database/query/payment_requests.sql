@@ -0,0 +1,43 @@
+-- internal/database/query/payment_requests.sql
+
+-- name: CreatePaymentRequest :one
+INSERT INTO payment_requests (
+    wallet_id,
+    amount,
+    currency,
+    description,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetPaymentRequest :one
+SELECT * FROM payment_requests
+WHERE id = $1;
+
+-- name: GetPaymentRequestForUpdate :one
+SELECT * FROM payment_requests
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListPaymentRequestsByWallet :many
+SELECT * FROM payment_requests
+WHERE wallet_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: FulfillPaymentRequest :one
+UPDATE payment_requests
+SET status = 'fulfilled',
+    transaction_id = $2,
+    fulfilled_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CancelPaymentRequest :one
+UPDATE payment_requests
+SET status = 'cancelled'
+WHERE id = $1
+  AND status = 'pending'
+RETURNING *;
@@ -154,3 +154,26 @@ WHERE transaction_count >= $1
   AND is_trusted = FALSE
   AND last_seen_at > NOW() - INTERVAL '30 days'
   AND deleted_at IS NULL;
+
+-- name: IsPeerOwner :one
+SELECT EXISTS(
+    SELECT 1 FROM peers p
+    JOIN wallets w ON w.id = p.wallet_id
+    WHERE p.id = $1 AND w.user_id = $2
+);
+
+-- name: UpdatePeerPublicKeys :execrows
+UPDATE peers
+SET public_key = $2
+WHERE peer_wallet_id = $1;
+
+-- name: PruneStalePeers :execrows
+UPDATE peers
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM peers
+    WHERE last_seen_at < NOW() - INTERVAL '90 days'
+      AND deleted_at IS NULL
+    ORDER BY last_seen_at ASC
+    LIMIT $1
+);
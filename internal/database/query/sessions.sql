@@ -0,0 +1,90 @@
+-- internal/database/query/sessions.sql
+
+-- name: CreateSession :one
+INSERT INTO sessions (
+    user_id,
+    user_agent,
+    client_ip,
+    expires_at,
+    device_id
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions
+WHERE id = $1;
+
+-- name: GetSessionForUpdate :one
+SELECT * FROM sessions
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetActiveSession :one
+SELECT s.* FROM sessions s
+LEFT JOIN devices d ON d.id = s.device_id
+WHERE s.id = $1
+  AND s.revoked_at IS NULL
+  AND s.expires_at > NOW()
+  AND (s.device_id IS NULL OR (d.verified_at IS NOT NULL AND d.revoked_at IS NULL));
+
+-- name: IsSessionActive :one
+SELECT EXISTS (
+    SELECT 1 FROM sessions s
+    LEFT JOIN devices d ON d.id = s.device_id
+    WHERE s.id = $1
+      AND s.revoked_at IS NULL
+      AND s.expires_at > NOW()
+      AND (s.device_id IS NULL OR (d.verified_at IS NOT NULL AND d.revoked_at IS NULL))
+);
+
+-- name: ListActiveSessionsByUser :many
+SELECT * FROM sessions
+WHERE user_id = $1
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+ORDER BY created_at DESC;
+
+-- name: MarkSessionRefreshed :exec
+UPDATE sessions
+SET last_refreshed_at = NOW()
+WHERE id = $1;
+
+-- name: RevokeSession :execrows
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :execrows
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeDeviceSessions :execrows
+UPDATE sessions
+SET revoked_at = NOW()
+WHERE device_id = $1 AND revoked_at IS NULL;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE expires_at < $1;
+
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (
+    session_id,
+    token_hash
+) VALUES (
+    $1, $2
+)
+RETURNING *;
+
+-- name: GetRefreshTokenForUpdate :one
+SELECT * FROM refresh_tokens
+WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: MarkRefreshTokenUsed :exec
+UPDATE refresh_tokens
+SET used_at = NOW()
+WHERE id = $1;
@@ -87,6 +87,24 @@ SET
 WHERE id = $1
 RETURNING *;
 
+-- name: FailOpenSyncLog :one
+UPDATE sync_logs
+SET
+    status = 'failed',
+    last_attempt_at = NOW(),
+    attempt_count = attempt_count + 1,
+    error_message = $2,
+    updated_at = NOW()
+WHERE id = (
+    SELECT id FROM sync_logs
+    WHERE transaction_id = $1
+      AND status IN ('pending', 'confirmed', 'settling', 'failed')
+    ORDER BY created_at DESC
+    LIMIT 1
+    FOR UPDATE
+)
+RETURNING *;
+
 -- name: MarkSettleConflict :one
 UPDATE sync_logs
 SET 
@@ -113,6 +131,7 @@ SELECT
     COUNT(*) FILTER (WHERE status = 'settled') as synced_count,
     COUNT(*) FILTER (WHERE status = 'failed') as failed_count,
     COUNT(*) FILTER (WHERE status = 'conflict') as conflict_count,
+    COUNT(*) FILTER (WHERE status = 'dead_letter') as dead_letter_count,
     AVG(attempt_count) as avg_attempts
 FROM sync_logs
 WHERE wallet_id = $1;
@@ -120,11 +139,63 @@ WHERE wallet_id = $1;
 -- name: GetSyncsNeedingRetry :many
 SELECT * FROM sync_logs
 WHERE status = 'failed'
+  AND resolution IS NULL
   AND attempt_count < $1
-  AND (last_attempt_at IS NULL OR last_attempt_at < NOW() - INTERVAL '5 minutes')
+  AND (
+    attempt_count = 0
+    OR last_attempt_at IS NULL
+    OR last_attempt_at
+        + LEAST(INTERVAL '1 minute' * POWER(2, attempt_count - 1), INTERVAL '6 hours')
+        * (1 - get_byte(uuid_send(id), 15) / 510.0) <= NOW()
+  )
 ORDER BY attempt_count ASC, created_at ASC
 LIMIT $2;
 
+-- name: ScheduleSyncRetry :one
+UPDATE sync_logs
+SET
+    last_attempt_at = NOW(),
+    attempt_count = attempt_count + 1,
+    error_message = $2,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'failed'
+RETURNING *;
+
+-- name: DeadLetterSyncLog :one
+UPDATE sync_logs
+SET
+    status = 'dead_letter',
+    last_attempt_at = NOW(),
+    attempt_count = attempt_count + 1,
+    error_message = $2,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'failed'
+RETURNING *;
+
+-- name: DeadLetterExhaustedSyncs :execrows
+UPDATE sync_logs
+SET
+    status = 'dead_letter',
+    updated_at = NOW()
+WHERE status = 'failed'
+  AND resolution IS NULL
+  AND attempt_count >= $1;
+
+-- name: ListDeadLetterSyncs :many
+SELECT * FROM sync_logs
+WHERE status = 'dead_letter'
+ORDER BY updated_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: RequeueSyncLog :one
+UPDATE sync_logs
+SET
+    status = 'failed',
+    attempt_count = 0,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'dead_letter'
+RETURNING *;
+
 -- name: DeleteOldSyncLogs :exec
 DELETE FROM sync_logs
 WHERE status = 'settled'
@@ -133,3 +204,28 @@ WHERE status = 'settled'
 -- name: CountSyncLogsByStatus :one
 SELECT COUNT(*) FROM sync_logs
 WHERE status = $1;
+
+-- name: GetSyncLogForUpdate :one
+SELECT * FROM sync_logs
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ResolveSyncLog :one
+UPDATE sync_logs
+SET
+    status = $2,
+    resolution = $3,
+    resolved_by = $4,
+    resolution_note = $5,
+    resolved_amount = $6,
+    resolved_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND status = 'conflict'
+RETURNING *;
+
+-- name: IsSyncLogOwner :one
+SELECT EXISTS(
+    SELECT 1 FROM sync_logs s
+    JOIN wallets w ON w.id = s.wallet_id
+    WHERE s.id = $1 AND w.user_id = $2
+);
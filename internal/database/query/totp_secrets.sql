@@ -0,0 +1,64 @@
+-- internal/database/query/totp_secrets.sql
+
+-- name: UpsertTOTPSecret :one
+INSERT INTO totp_secrets (
+    user_id,
+    master_key_id,
+    encrypted_data_key,
+    encrypted_secret
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (user_id) DO UPDATE
+SET master_key_id = EXCLUDED.master_key_id,
+    encrypted_data_key = EXCLUDED.encrypted_data_key,
+    encrypted_secret = EXCLUDED.encrypted_secret,
+    secret = NULL,
+    last_used_step = 0
+WHERE totp_secrets.confirmed_at IS NULL
+RETURNING *;
+
+-- name: GetTOTPSecret :one
+SELECT * FROM totp_secrets
+WHERE user_id = $1;
+
+-- name: GetTOTPSecretForUpdate :one
+SELECT * FROM totp_secrets
+WHERE user_id = $1
+FOR UPDATE;
+
+-- name: ConfirmTOTPSecret :one
+UPDATE totp_secrets
+SET confirmed_at = NOW(),
+    last_used_step = $2
+WHERE user_id = $1
+RETURNING *;
+
+-- name: UpdateTOTPLastUsedStep :exec
+UPDATE totp_secrets
+SET last_used_step = $2
+WHERE user_id = $1;
+
+-- name: DeleteTOTPSecret :exec
+DELETE FROM totp_secrets
+WHERE user_id = $1;
+
+-- name: ListPlaintextTOTPSecrets :many
+SELECT * FROM totp_secrets
+WHERE secret IS NOT NULL
+ORDER BY user_id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: CountPlaintextTOTPSecrets :one
+SELECT COUNT(*) FROM totp_secrets
+WHERE secret IS NOT NULL;
+
+-- name: SealTOTPSecret :exec
+UPDATE totp_secrets
+SET
+    master_key_id = $2,
+    encrypted_data_key = $3,
+    encrypted_secret = $4,
+    secret = NULL
+WHERE user_id = $1;
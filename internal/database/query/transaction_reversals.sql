@@ -0,0 +1,33 @@
+-- name: CreateTransactionReversal :one
+INSERT INTO transaction_reversals (
+    transaction_id,
+    original_transaction_id,
+    reason,
+    created_by
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetRefundableAmount :one
+SELECT (
+    COALESCE((
+        SELECT SUM(CASE WHEN l.direction = 'credit' THEN l.amount ELSE -l.amount END)
+        FROM ledger_entries l
+        WHERE l.transaction_id = sqlc.arg('transaction_id')::uuid
+          AND l.wallet_id = sqlc.arg('wallet_id')::uuid
+    ), 0)
+    - COALESCE((
+        SELECT SUM(t.amount)
+        FROM transaction_reversals r
+        JOIN transactions t ON t.id = r.transaction_id
+        WHERE r.original_transaction_id = sqlc.arg('transaction_id')::uuid
+          AND t.status NOT IN ('failed', 'rolled_back')
+    ), 0)
+)::numeric AS refundable;
+
+-- name: ListTransactionReversals :many
+SELECT t.* FROM transactions t
+JOIN transaction_reversals r ON r.transaction_id = t.id
+WHERE r.original_transaction_id = $1
+ORDER BY t.created_at ASC;
@@ -99,7 +99,7 @@ SET
     status = 'confirmed',
     confirmed_at = NOW(),
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND status = 'pending'
 RETURNING *;
 
 -- name: SettingTransaction :one
@@ -208,3 +208,61 @@ WHERE amount >= $1
   AND status IN ('confirmed', 'settled')
 ORDER BY amount DESC, transaction_at DESC
 LIMIT $2;
+
+-- name: GetTransactionForUpdate :one
+SELECT * FROM transactions
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetTransactionByWalletNonce :one
+SELECT * FROM transactions
+WHERE from_wallet_id = $1 AND nonce = $2;
+
+-- name: ListSettleableTransactions :many
+SELECT * FROM transactions
+WHERE from_wallet_id = $1
+  AND status = 'confirmed'
+  AND NOT EXISTS (
+    SELECT 1 FROM sync_logs
+    WHERE sync_logs.transaction_id = transactions.id
+      AND sync_logs.status = 'conflict'
+  )
+ORDER BY transaction_at ASC, nonce ASC
+LIMIT $2;
+
+-- name: ListCompetingTransactions :many
+SELECT * FROM transactions
+WHERE from_wallet_id = $1
+  AND id <> $2
+  AND status IN ('confirmed', 'settling', 'settled')
+  AND transaction_at >= $3
+ORDER BY transaction_at ASC, nonce ASC
+LIMIT $4;
+
+-- name: ListOutOfOrderTransactions :many
+SELECT * FROM transactions
+WHERE from_wallet_id = $1
+  AND id <> $2
+  AND status = 'settled'
+  AND nonce <> 0
+  AND (
+    (nonce < $3 AND transaction_at > $4)
+    OR (nonce > $3 AND transaction_at < $4)
+  )
+ORDER BY transaction_at ASC
+LIMIT $5;
+
+-- name: RollbackTransaction :one
+UPDATE transactions
+SET
+    status = 'rolled_back',
+    updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'confirmed', 'settled')
+RETURNING *;
+
+-- name: IsTransactionParticipant :one
+SELECT EXISTS(
+    SELECT 1 FROM transactions t
+    JOIN wallets w ON w.id IN (t.from_wallet_id, t.to_wallet_id)
+    WHERE t.id = $1 AND w.user_id = $2
+);
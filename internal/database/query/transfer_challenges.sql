@@ -0,0 +1,50 @@
+-- internal/database/query/transfer_challenges.sql
+
+-- name: CreateTransferChallenge :one
+INSERT INTO transfer_challenges (
+    user_id,
+    from_wallet_id,
+    to_wallet_id,
+    amount,
+    method,
+    transfer,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetTransferChallengeStats :one
+SELECT
+    COUNT(*) AS created,
+    MIN(created_at)::timestamptz AS first_created_at
+FROM transfer_challenges
+WHERE user_id = $1
+  AND created_at >= $2;
+
+-- name: GetTransferChallenge :one
+SELECT * FROM transfer_challenges
+WHERE id = $1;
+
+-- name: GetTransferChallengeForUpdate :one
+SELECT * FROM transfer_challenges
+WHERE id = $1
+FOR UPDATE;
+
+-- name: IncrementTransferChallengeAttempts :one
+UPDATE transfer_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: ConfirmTransferChallenge :one
+UPDATE transfer_challenges
+SET confirmed_at = NOW(),
+    transaction_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteExpiredTransferChallenges :execrows
+DELETE FROM transfer_challenges
+WHERE confirmed_at IS NULL
+  AND expires_at < $1;
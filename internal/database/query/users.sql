@@ -17,3 +17,24 @@ SELECT * FROM users WHERE phone_number = $1;
 
 -- name: GetUserByEmail :one
 SELECT * FROM users WHERE email = $1;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CountUsersByRole :one
+SELECT COUNT(*) FROM users
+WHERE role = $1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkUserPhoneVerified :one
+UPDATE users
+SET phone_verified_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,43 @@
+-- internal/database/query/wallet_custodial_keys.sql
+
+-- name: CreateWalletCustodialKey :exec
+INSERT INTO wallet_custodial_keys (
+    wallet_id,
+    master_key_id,
+    encrypted_data_key,
+    encrypted_private_key
+) VALUES (
+    $1, $2, $3, $4
+);
+
+-- name: GetWalletCustodialKey :one
+SELECT * FROM wallet_custodial_keys
+WHERE wallet_id = $1;
+
+-- name: ListLegacyWalletCustodialKeys :many
+SELECT k.*, w.public_key FROM wallet_custodial_keys k
+JOIN wallets w ON w.id = k.wallet_id
+WHERE k.legacy_private_key IS NOT NULL
+  AND k.legacy_key_error IS NULL
+ORDER BY k.wallet_id
+LIMIT $1
+FOR UPDATE OF k SKIP LOCKED;
+
+-- name: CountLegacyWalletCustodialKeys :one
+SELECT COUNT(*) FROM wallet_custodial_keys
+WHERE legacy_private_key IS NOT NULL;
+
+-- name: SealWalletCustodialKey :exec
+UPDATE wallet_custodial_keys
+SET
+    master_key_id = $2,
+    encrypted_data_key = $3,
+    encrypted_private_key = $4,
+    legacy_private_key = NULL
+WHERE wallet_id = $1;
+
+-- name: RejectLegacyWalletCustodialKey :exec
+UPDATE wallet_custodial_keys
+SET legacy_key_error = $2,
+    legacy_private_key = NULL
+WHERE wallet_id = $1;
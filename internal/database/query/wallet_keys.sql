@@ -0,0 +1,50 @@
+-- internal/database/query/wallet_keys.sql
+
+-- name: CreateWalletKey :one
+INSERT INTO wallet_keys (
+    wallet_id,
+    public_key,
+    valid_from,
+    rotation_signature
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: RetireWalletKey :one
+UPDATE wallet_keys
+SET valid_until = $2
+WHERE wallet_id = $1 AND valid_until IS NULL
+RETURNING *;
+
+-- name: GetWalletKeyAt :one
+SELECT * FROM wallet_keys
+WHERE wallet_id = sqlc.arg('wallet_id')
+  AND valid_from <= sqlc.arg('at')
+  AND (valid_until IS NULL OR valid_until > sqlc.arg('at'))
+  AND revoked_at IS NULL
+ORDER BY valid_from DESC
+LIMIT 1;
+
+-- name: GetWalletKeyForUpdate :one
+SELECT * FROM wallet_keys
+WHERE id = $1 AND wallet_id = $2
+FOR UPDATE;
+
+-- name: RevokeWalletKey :one
+UPDATE wallet_keys
+SET
+    revoked_at = NOW(),
+    revoked_reason = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListWalletKeys :many
+SELECT * FROM wallet_keys
+WHERE wallet_id = $1
+ORDER BY valid_from DESC;
+
+-- name: IsWalletKeyUsed :one
+SELECT EXISTS (
+    SELECT 1 FROM wallet_keys WHERE public_key = $1
+);
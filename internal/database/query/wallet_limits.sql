@@ -0,0 +1,46 @@
+-- internal/database/query/wallet_limits.sql
+
+-- name: GetWalletLimits :one
+SELECT * FROM wallet_limits
+WHERE wallet_id = $1;
+
+-- name: UpsertWalletLimits :one
+INSERT INTO wallet_limits (
+    wallet_id,
+    per_transaction_limit,
+    daily_limit,
+    monthly_limit,
+    offline_limit
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (wallet_id) DO UPDATE SET
+    per_transaction_limit = EXCLUDED.per_transaction_limit,
+    daily_limit = EXCLUDED.daily_limit,
+    monthly_limit = EXCLUDED.monthly_limit,
+    offline_limit = EXCLUDED.offline_limit
+RETURNING *;
+
+-- name: DeleteWalletLimits :exec
+DELETE FROM wallet_limits
+WHERE wallet_id = $1;
+
+-- name: GetWalletOutflow :one
+SELECT
+    COALESCE(SUM(amount) FILTER (
+        WHERE created_at >= date_trunc('day', NOW(), 'UTC')
+    ), 0)::numeric AS daily_total,
+    COALESCE(SUM(amount), 0)::numeric AS monthly_total
+FROM transactions
+WHERE from_wallet_id = $1
+  AND status NOT IN ('failed', 'rolled_back')
+  AND type <> 'reversal'
+  AND created_at >= date_trunc('month', NOW(), 'UTC');
+
+-- name: GetOfflineExposure :one
+SELECT COALESCE(SUM(amount), 0)::numeric AS total
+FROM transactions
+WHERE from_wallet_id = sqlc.arg('from_wallet_id')
+  AND status NOT IN ('failed', 'rolled_back')
+  AND connection_type IS DISTINCT FROM 'online'
+  AND transaction_at > COALESCE(sqlc.narg('since')::timestamptz, '-infinity'::timestamptz);
@@ -4,14 +4,13 @@
 INSERT INTO wallets (
 user_id,
 public_key,
-private_key,
 balance,
 phone_number,
 name,
 pin_hash,
 device_id
 ) VALUES (
-	$1, $2, $3, $4, $5, $6, $7, $8
+	$1, $2, $3, $4, $5, $6, $7
 	) RETURNING *;
 
 
@@ -107,7 +106,7 @@ is_active = FALSE,
 updated_at = NOW()
 WHERE id = $1 AND deleted_at IS NULL;
 
--- name: HardDeleteWallet :exec
+-- name: HardDeleteWallet :execrows
 DELETE FROM wallets
 WHERE id = $1;
 
@@ -147,3 +146,22 @@ WHERE (last_synced_at IS NULL OR last_synced_at < NOW() - INTERVAL '1 day')
 	AND deleted_at IS NULL
 ORDER BY last_synced_at ASC NULLS FIRST
 LIMIT $1;
+
+-- name: GetWalletForUpdate :one
+SELECT * FROM wallets
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: IsWalletOwner :one
+SELECT EXISTS(
+    SELECT 1 FROM wallets
+    WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+);
+
+-- name: UpdateWalletPublicKey :one
+UPDATE wallets
+SET
+public_key = $2,
+updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
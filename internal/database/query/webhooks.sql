@@ -0,0 +1,117 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (
+    user_id,
+    wallet_id,
+    url,
+    secret,
+    event_types
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: ListWebhookSubscriptionsByUser :many
+SELECT * FROM webhook_subscriptions
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET
+    url = COALESCE(sqlc.narg('url'), url),
+    event_types = COALESCE(sqlc.narg('event_types')::text[], event_types),
+    is_active = COALESCE(sqlc.narg('is_active'), is_active)
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: DeleteWebhookSubscription :exec
+DELETE FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: EnqueueWebhookEvent :execrows
+INSERT INTO webhook_outbox (subscription_id, event_type, payload, source_event_id)
+SELECT s.id, sqlc.arg('event_type')::text, sqlc.arg('payload')::jsonb, sqlc.arg('source_event_id')::uuid
+FROM webhook_subscriptions s
+WHERE s.is_active
+  AND (cardinality(s.event_types) = 0 OR sqlc.arg('event_type')::text = ANY(s.event_types))
+  AND (
+    s.wallet_id = sqlc.arg('wallet_id')::uuid
+    OR (
+      s.wallet_id IS NULL
+      AND s.user_id = (SELECT w.user_id FROM wallets w WHERE w.id = sqlc.arg('wallet_id')::uuid)
+    )
+  )
+ON CONFLICT (subscription_id, source_event_id) DO NOTHING;
+
+-- name: ClaimWebhookOutbox :many
+WITH due AS (
+    SELECT id FROM webhook_outbox
+    WHERE status = 'pending'
+      AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg('limit')
+    FOR UPDATE SKIP LOCKED
+), claimed AS (
+    UPDATE webhook_outbox o
+    SET
+        attempts = o.attempts + 1,
+        next_attempt_at = sqlc.arg('lease_until')::timestamptz
+    FROM due
+    WHERE o.id = due.id
+    RETURNING o.*
+)
+SELECT claimed.*, s.url, s.secret, s.is_active AS subscription_active
+FROM claimed
+JOIN webhook_subscriptions s ON s.id = claimed.subscription_id
+ORDER BY claimed.created_at;
+
+-- name: MarkWebhookDelivered :exec
+UPDATE webhook_outbox
+SET
+    status = 'delivered',
+    delivered_at = NOW(),
+    last_error = NULL
+WHERE id = $1;
+
+-- name: ScheduleWebhookRetry :exec
+UPDATE webhook_outbox
+SET
+    next_attempt_at = $2,
+    last_error = $3
+WHERE id = $1;
+
+-- name: MarkWebhookFailed :exec
+UPDATE webhook_outbox
+SET
+    status = 'failed',
+    last_error = $2
+WHERE id = $1;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+    outbox_id,
+    attempt,
+    status_code,
+    error,
+    duration_ms
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ListWebhookDeliveriesBySubscription :many
+SELECT d.*, o.event_type, o.status AS outbox_status
+FROM webhook_deliveries d
+JOIN webhook_outbox o ON o.id = d.outbox_id
+WHERE o.subscription_id = $1
+ORDER BY d.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: DeleteOldWebhookEvents :execrows
+DELETE FROM webhook_outbox
+WHERE status <> 'pending'
+  AND updated_at < $1;
@@ -0,0 +1,30 @@
+package database
+
+import "context"
+
+// WithAdvisoryLock runs fn while holding the Postgres session advisory lock
+// named by key, so only one server runs it at a time. The lock is held on a
+// connection of its own for the whole call. When another session holds the
+// lock, fn is not run and false is returned.
+func (store *Store) WithAdvisoryLock(ctx context.Context, key string, fn func(context.Context) error) (bool, error) {
+	conn, err := store.pool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+
+	q := New(conn)
+	locked, err := q.TryAdvisoryLock(ctx, key)
+	if err != nil || !locked {
+		return false, err
+	}
+	defer func() {
+		// Unlock even when ctx is done. If that fails, close the connection
+		// rather than return it to the pool still holding the lock.
+		if _, err := q.AdvisoryUnlock(context.WithoutCancel(ctx), key); err != nil {
+			_ = conn.Conn().Close(context.WithoutCancel(ctx))
+		}
+	}()
+
+	return true, fn(ctx)
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestWithAdvisoryLock(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+	key := "test:" + uuid.NewString()
+
+	ran := false
+	locked, err := store.WithAdvisoryLock(ctx, key, func(ctx context.Context) error {
+		ran = true
+		nested, err := store.WithAdvisoryLock(ctx, key, func(context.Context) error {
+			t.Fatal("expected the held lock to skip the nested call")
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("nested lock: %v", err)
+		}
+		if nested {
+			t.Fatal("expected the nested lock to be refused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if !locked || !ran {
+		t.Fatalf("expected the lock taken and fn run, got locked=%v ran=%v", locked, ran)
+	}
+
+	// Released once fn returns.
+	locked, err = store.WithAdvisoryLock(ctx, key, func(context.Context) error { return nil })
+	if err != nil || !locked {
+		t.Fatalf("expected the lock free again, got locked=%v err=%v", locked, err)
+	}
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrCashTransferType = errors.New("deposits and withdrawals must be made through an agent")
+	ErrInvalidCashType  = errors.New("cash transaction type must be deposit or withdraw")
+	ErrNotAgent         = errors.New("wallet is not an active agent")
+	ErrAgentFloatLimit  = errors.New("agent float limit exceeded")
+)
+
+// CreateAgentTx registers a wallet as an agent and gives its owner the agent
+// role, so they may call the deposit and withdrawal endpoints. Owners that
+// already hold a staff role keep it.
+func (store *Store) CreateAgentTx(ctx context.Context, arg CreateAgentParams) (Agent, error) {
+	var agent Agent
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		wallet, err := q.GetWalletByID(ctx, arg.WalletID)
+		if err != nil {
+			return err
+		}
+		agent, err = q.CreateAgent(ctx, arg)
+		if err != nil {
+			return err
+		}
+		if !wallet.UserID.Valid {
+			return nil
+		}
+
+		owner, err := q.GetUserByID(ctx, uuid.UUID(wallet.UserID.Bytes))
+		if err != nil {
+			return err
+		}
+		if owner.Role != UserRoleUser {
+			return nil
+		}
+		_, err = q.UpdateUserRole(ctx, UpdateUserRoleParams{ID: owner.ID, Role: UserRoleAgent})
+		return err
+	})
+
+	return agent, err
+}
+
+// CashTxParams describes a deposit or withdrawal made at an agent.
+//
+// A deposit moves e-money from the agent to the customer in exchange for
+// cash and is signed by the agent wallet. A withdrawal moves e-money from
+// the customer to the agent and is signed by the customer wallet.
+type CashTxParams struct {
+	Type             TransactionType
+	AgentWalletID    uuid.UUID
+	CustomerWalletID uuid.UUID
+	Amount           pgtype.Numeric
+	Currency         string
+	Signature        string
+	Nonce            int64
+	Description      *string
+	Metadata         []byte
+	TransactionAt    pgtype.Timestamptz
+}
+
+// CashTxResult is the result of a deposit or withdrawal.
+type CashTxResult struct {
+	Transaction    Transaction `json:"transaction"`
+	Agent          Agent       `json:"agent"`
+	AgentWallet    Wallet      `json:"agent_wallet"`
+	CustomerWallet Wallet      `json:"customer_wallet"`
+}
+
+// CashTx records a deposit or withdrawal as a settled transaction between the
+// customer and an active agent.
+//
+// The agent row is locked for the whole transaction so float checks for one
+// agent are serialised. A deposit fails with ErrInsufficientBalance when the
+// agent has no float left; a withdrawal fails with ErrAgentFloatLimit when it
+// would push the agent balance above its float limit.
+func (store *Store) CashTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	var result CashTxResult
+
+	transferArg := TransferTxParams{
+		Amount:         arg.Amount,
+		Currency:       arg.Currency,
+		Type:           arg.Type,
+		Status:         TransactionStatusSettled,
+		Signature:      arg.Signature,
+		Nonce:          arg.Nonce,
+		ConnectionType: NullConnectionType{ConnectionType: ConnectionTypeOnline, Valid: true},
+		Description:    arg.Description,
+		Metadata:       arg.Metadata,
+		TransactionAt:  arg.TransactionAt,
+	}
+	switch arg.Type {
+	case TransactionTypeDeposit:
+		transferArg.FromWalletID, transferArg.ToWalletID = arg.AgentWalletID, arg.CustomerWalletID
+	case TransactionTypeWithdraw:
+		transferArg.FromWalletID, transferArg.ToWalletID = arg.CustomerWalletID, arg.AgentWalletID
+	default:
+		return result, ErrInvalidCashType
+	}
+	if arg.AgentWalletID == arg.CustomerWalletID {
+		return result, ErrSameWallet
+	}
+	if transferArg.Currency == "" {
+		transferArg.Currency = "NPR"
+	}
+	if len(transferArg.Metadata) == 0 {
+		transferArg.Metadata = []byte(`{}`)
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		agent, err := q.GetAgentForUpdate(ctx, arg.AgentWalletID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotAgent
+		}
+		if err != nil {
+			return err
+		}
+		if !agent.IsActive {
+			return ErrNotAgent
+		}
+		result.Agent = agent
+
+		var transferred TransferTxResult
+		if err := transfer(ctx, q, transferArg, &transferred); err != nil {
+			return err
+		}
+		result.Transaction = transferred.Transaction
+
+		if arg.Type == TransactionTypeDeposit {
+			result.AgentWallet, result.CustomerWallet = transferred.FromWallet, transferred.ToWallet
+			return nil
+		}
+
+		result.CustomerWallet, result.AgentWallet = transferred.FromWallet, transferred.ToWallet
+		if numericCmp(result.AgentWallet.Balance, agent.FloatLimit) > 0 {
+			return ErrAgentFloatLimit
+		}
+		return nil
+	})
+
+	return result, err
+}
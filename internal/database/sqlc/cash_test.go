@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCashTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	agentWallet, agentKey := createTestWalletWithKey(t, ctx, store.Queries)
+	customerWallet, customerKey := createTestWalletWithKey(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, agentWallet.ID, customerWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM transactions WHERE from_wallet_id = $1 OR from_wallet_id = $2",
+			agentWallet.ID,
+			customerWallet.ID,
+		)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			agentWallet.ID,
+			customerWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1 OR id = $2", agentWallet.ID, customerWallet.ID)
+	}()
+
+	cashArg := func(txType TransactionType, amount string, key ed25519.PrivateKey, fromID, toID uuid.UUID) CashTxParams {
+		t.Helper()
+		transfer := TransferTxParams{
+			FromWalletID: fromID,
+			ToWalletID:   toID,
+			Amount:       numericFromString(t, amount),
+		}
+		signTestTransfer(t, key, &transfer)
+		return CashTxParams{
+			Type:             txType,
+			AgentWalletID:    agentWallet.ID,
+			CustomerWalletID: customerWallet.ID,
+			Amount:           transfer.Amount,
+			Signature:        transfer.Signature,
+			Nonce:            transfer.Nonce,
+			TransactionAt:    transfer.TransactionAt,
+		}
+	}
+	deposit := func(amount string) CashTxParams {
+		return cashArg(TransactionTypeDeposit, amount, agentKey, agentWallet.ID, customerWallet.ID)
+	}
+	withdrawal := func(amount string) CashTxParams {
+		return cashArg(TransactionTypeWithdraw, amount, customerKey, customerWallet.ID, agentWallet.ID)
+	}
+
+	if _, err := store.CashTx(ctx, deposit("10.00")); !errors.Is(err, ErrNotAgent) {
+		t.Fatalf("expected not agent error, got %v", err)
+	}
+
+	if _, err := store.CreateAgent(ctx, CreateAgentParams{
+		WalletID:   agentWallet.ID,
+		FloatLimit: numericFromString(t, "150.00"),
+	}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+
+	deposited, err := store.CashTx(ctx, deposit("40.00"))
+	if err != nil {
+		t.Fatalf("deposit: %v", err)
+	}
+	if deposited.Transaction.Type != TransactionTypeDeposit || deposited.Transaction.Status != TransactionStatusSettled {
+		t.Fatalf("expected settled deposit, got %s / %s", deposited.Transaction.Type, deposited.Transaction.Status)
+	}
+	assertFloatApprox(t, numericToFloat64(t, deposited.AgentWallet.Balance), 60.00)
+	assertFloatApprox(t, numericToFloat64(t, deposited.CustomerWallet.Balance), 140.00)
+
+	if _, err := store.CashTx(ctx, deposit("70.00")); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected insufficient balance, got %v", err)
+	}
+
+	if _, err := store.CashTx(ctx, withdrawal("100.00")); !errors.Is(err, ErrAgentFloatLimit) {
+		t.Fatalf("expected float limit error, got %v", err)
+	}
+
+	withdrawn, err := store.CashTx(ctx, withdrawal("90.00"))
+	if err != nil {
+		t.Fatalf("withdrawal: %v", err)
+	}
+	assertFloatApprox(t, numericToFloat64(t, withdrawn.AgentWallet.Balance), 150.00)
+	assertFloatApprox(t, numericToFloat64(t, withdrawn.CustomerWallet.Balance), 50.00)
+
+	entries, err := store.ListLedgerEntriesByTransaction(ctx, pgUUIDFromUUID(withdrawn.Transaction.ID))
+	if err != nil {
+		t.Fatalf("list ledger entries: %v", err)
+	}
+	if len(entries) != 2 || entries[0].EntryType != LedgerEntryTypeWithdrawal {
+		t.Fatalf("expected withdrawal posting, got %d entries", len(entries))
+	}
+}
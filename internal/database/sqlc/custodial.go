@@ -0,0 +1,206 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Sahas001/pay-on/internal/envelope"
+	"github.com/Sahas001/pay-on/internal/signature"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const legacyKeyBatchSize = 100
+
+var (
+	ErrNotCustodial         = errors.New("wallet is not custodial; sign the transfer on the client")
+	ErrCustodialKeyUnsealed = errors.New("custodial wallet key has not been sealed yet")
+	ErrCustodialKeyRejected = errors.New("custodial wallet key could not be recovered; sign the transfer on the client")
+	ErrLegacyKeyMalformed   = errors.New("legacy private key is not a hex or base64 Ed25519 seed or key")
+	ErrLegacyKeyMismatch    = errors.New("legacy private key does not match the wallet public key")
+)
+
+// CreateCustodialWalletTx creates a wallet whose key pair is generated and
+// held by the server. arg.PublicKey is ignored. The private key is envelope
+// encrypted and bound to the wallet ID, so it cannot be moved to another
+// wallet's row.
+func (store *Store) CreateCustodialWalletTx(ctx context.Context, arg CreateWalletParams, sealer *envelope.Sealer) (Wallet, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Wallet{}, err
+	}
+	arg.PublicKey = base64.StdEncoding.EncodeToString(publicKey)
+
+	var wallet Wallet
+	err = store.execTx(ctx, func(q *Queries) error {
+		var err error
+		wallet, err = q.CreateWallet(ctx, arg)
+		if err != nil {
+			return err
+		}
+		sealed, err := sealer.Seal(privateKey, wallet.ID[:])
+		if err != nil {
+			return err
+		}
+		return q.CreateWalletCustodialKey(ctx, CreateWalletCustodialKeyParams{
+			WalletID:            wallet.ID,
+			MasterKeyID:         &sealed.KeyID,
+			EncryptedDataKey:    sealed.DataKey,
+			EncryptedPrivateKey: sealed.Ciphertext,
+		})
+	})
+
+	return wallet, err
+}
+
+// SignCustodialTransfer signs a transfer from a custodial wallet with the
+// key held by the server, filling in the currency, nonce and transaction
+// time when they are missing.
+func (store *Store) SignCustodialTransfer(ctx context.Context, arg *TransferTxParams, sealer *envelope.Sealer) error {
+	key, err := store.GetWalletCustodialKey(ctx, arg.FromWalletID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotCustodial
+		}
+		return err
+	}
+	if key.LegacyKeyError != nil {
+		return ErrCustodialKeyRejected
+	}
+	if key.LegacyPrivateKey != nil || key.MasterKeyID == nil {
+		return ErrCustodialKeyUnsealed
+	}
+
+	privateKey, err := sealer.Open(envelope.Sealed{
+		KeyID:      *key.MasterKeyID,
+		DataKey:    key.EncryptedDataKey,
+		Ciphertext: key.EncryptedPrivateKey,
+	}, key.WalletID[:])
+	if err != nil {
+		return err
+	}
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return envelope.ErrMalformed
+	}
+
+	if arg.Currency == "" {
+		arg.Currency = "NPR"
+	}
+	if arg.Nonce == 0 {
+		arg.Nonce = time.Now().UnixNano()
+	}
+	if !arg.TransactionAt.Valid {
+		arg.TransactionAt = pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
+	}
+	payload, err := transactionPayload(CreateTransactionParams(*arg))
+	if err != nil {
+		return err
+	}
+	arg.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, payload))
+	return nil
+}
+
+// SealLegacyWalletKeys encrypts the plaintext keys that were moved out of
+// wallets.private_key and clears the plaintext. Each key is decoded first so
+// that what gets sealed is the raw Ed25519 key SignCustodialTransfer expects.
+// A key that does not decode or does not match the wallet's public key is
+// discarded: its plaintext is cleared and legacy_key_error records why.
+//
+// It returns how many keys it sealed and rejected, and is safe to run on
+// every start.
+func (store *Store) SealLegacyWalletKeys(ctx context.Context, sealer *envelope.Sealer) (sealed, rejected int, err error) {
+	for {
+		batch := 0
+		err := store.execTx(ctx, func(q *Queries) error {
+			keys, err := q.ListLegacyWalletCustodialKeys(ctx, legacyKeyBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				privateKey, err := parseLegacyPrivateKey(*key.LegacyPrivateKey, key.PublicKey)
+				if err != nil {
+					message := err.Error()
+					if err := q.RejectLegacyWalletCustodialKey(ctx, RejectLegacyWalletCustodialKeyParams{
+						WalletID:       key.WalletID,
+						LegacyKeyError: &message,
+					}); err != nil {
+						return err
+					}
+					rejected++
+					continue
+				}
+
+				sealedKey, err := sealer.Seal(privateKey, key.WalletID[:])
+				if err != nil {
+					return err
+				}
+				if err := q.SealWalletCustodialKey(ctx, SealWalletCustodialKeyParams{
+					WalletID:            key.WalletID,
+					MasterKeyID:         &sealedKey.KeyID,
+					EncryptedDataKey:    sealedKey.DataKey,
+					EncryptedPrivateKey: sealedKey.Ciphertext,
+				}); err != nil {
+					return err
+				}
+				sealed++
+			}
+			batch = len(keys)
+			return nil
+		})
+		if err != nil || batch < legacyKeyBatchSize {
+			return sealed, rejected, err
+		}
+	}
+}
+
+// parseLegacyPrivateKey decodes a key stored by older versions: a 32-byte
+// seed or a full 64-byte Ed25519 key, in hex or base64. The key must belong
+// to the wallet's publicKey.
+func parseLegacyPrivateKey(text, publicKey string) (ed25519.PrivateKey, error) {
+	raw, err := decodeLegacyKey(strings.TrimSpace(text))
+	if err != nil {
+		return nil, err
+	}
+
+	// A full key is the seed followed by its public key; both halves must agree.
+	privateKey := ed25519.NewKeyFromSeed(raw[:ed25519.SeedSize])
+	if len(raw) == ed25519.PrivateKeySize && !bytes.Equal(privateKey, raw) {
+		return nil, ErrLegacyKeyMalformed
+	}
+
+	parsed, err := signature.ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, ErrLegacyKeyMismatch
+	}
+	walletKey, ok := parsed.(ed25519.PublicKey)
+	if !ok || !walletKey.Equal(privateKey.Public()) {
+		return nil, ErrLegacyKeyMismatch
+	}
+	return privateKey, nil
+}
+
+// decodeLegacyKey returns the first decoding of text that has the length of
+// an Ed25519 seed or key.
+func decodeLegacyKey(text string) ([]byte, error) {
+	decoders := []func(string) ([]byte, error){
+		hex.DecodeString,
+		base64.StdEncoding.DecodeString,
+		base64.RawStdEncoding.DecodeString,
+		base64.URLEncoding.DecodeString,
+		base64.RawURLEncoding.DecodeString,
+	}
+	for _, decode := range decoders {
+		raw, err := decode(text)
+		if err == nil && (len(raw) == ed25519.SeedSize || len(raw) == ed25519.PrivateKeySize) {
+			return raw, nil
+		}
+	}
+	return nil, ErrLegacyKeyMalformed
+}
@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/Sahas001/pay-on/internal/envelope"
+	"github.com/Sahas001/pay-on/internal/signature"
+	"github.com/google/uuid"
+)
+
+func newTestSealer(t *testing.T) *envelope.Sealer {
+	t.Helper()
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("generate master key: %v", err)
+	}
+	sealer, err := envelope.NewSealer(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatalf("new sealer: %v", err)
+	}
+	return sealer
+}
+
+func TestCustodialWalletSignsTransfers(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+	sealer := newTestSealer(t)
+
+	wallet, err := store.CreateCustodialWalletTx(ctx, CreateWalletParams{
+		Balance:     numericFromString(t, "0"),
+		PhoneNumber: nextPhoneNumber(),
+		Name:        "Custodial",
+		PinHash:     "pin-hash",
+	}, sealer)
+	if err != nil {
+		t.Fatalf("create custodial wallet: %v", err)
+	}
+	other := createTestWallet(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, wallet.ID, other.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id IN ($1, $2)", wallet.ID, other.ID)
+	}()
+
+	key, err := store.GetWalletCustodialKey(ctx, wallet.ID)
+	if err != nil {
+		t.Fatalf("get custodial key: %v", err)
+	}
+	if key.LegacyPrivateKey != nil || key.MasterKeyID == nil || *key.MasterKeyID != sealer.KeyID() {
+		t.Fatalf("expected a sealed key under %s, got %+v", sealer.KeyID(), key)
+	}
+
+	arg := TransferTxParams{
+		FromWalletID: wallet.ID,
+		ToWalletID:   other.ID,
+		Amount:       numericFromString(t, "10.00"),
+	}
+	if err := store.SignCustodialTransfer(ctx, &arg, sealer); err != nil {
+		t.Fatalf("sign transfer: %v", err)
+	}
+	payload, err := transactionPayload(CreateTransactionParams(arg))
+	if err != nil {
+		t.Fatalf("build signing payload: %v", err)
+	}
+	if err := signature.Verify(wallet.PublicKey, payload, arg.Signature); err != nil {
+		t.Fatalf("expected a valid wallet signature, got %v", err)
+	}
+
+	// Another master key cannot open the wallet key.
+	if err := store.SignCustodialTransfer(ctx, &arg, newTestSealer(t)); !errors.Is(err, envelope.ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+
+	arg.FromWalletID = other.ID
+	if err := store.SignCustodialTransfer(ctx, &arg, sealer); !errors.Is(err, ErrNotCustodial) {
+		t.Fatalf("expected ErrNotCustodial, got %v", err)
+	}
+}
+
+func TestSealLegacyWalletKeys(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+	sealer := newTestSealer(t)
+
+	wallet, privateKey := createTestWalletWithKey(t, ctx, store.Queries)
+	fullKey, fullKeyPrivate := createTestWalletWithKey(t, ctx, store.Queries)
+	garbled := createTestWallet(t, ctx, store.Queries)
+	defer func() {
+		deleteTestLedger(ctx, wallet.ID, fullKey.ID, garbled.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id IN ($1, $2, $3)", wallet.ID, fullKey.ID, garbled.ID)
+	}()
+
+	// Older versions stored the key as text: a hex seed, a base64 full key,
+	// or anything a client sent.
+	for walletID, legacy := range map[uuid.UUID]string{
+		wallet.ID:  hex.EncodeToString(privateKey.Seed()),
+		fullKey.ID: base64.StdEncoding.EncodeToString(fullKeyPrivate),
+		garbled.ID: "legacy-" + uuid.NewString(),
+	} {
+		if _, err := testPool.Exec(ctx, "INSERT INTO wallet_custodial_keys (wallet_id, legacy_private_key) VALUES ($1, $2)", walletID, legacy); err != nil {
+			t.Fatalf("insert legacy key: %v", err)
+		}
+	}
+
+	sealed, rejected, err := store.SealLegacyWalletKeys(ctx, sealer)
+	if err != nil {
+		t.Fatalf("seal legacy keys: %v", err)
+	}
+	if sealed < 2 || rejected < 1 {
+		t.Fatalf("expected at least 2 sealed and 1 rejected key, got %d and %d", sealed, rejected)
+	}
+
+	for _, sealedWallet := range []Wallet{wallet, fullKey} {
+		key, err := store.GetWalletCustodialKey(ctx, sealedWallet.ID)
+		if err != nil {
+			t.Fatalf("get custodial key: %v", err)
+		}
+		if key.LegacyPrivateKey != nil {
+			t.Fatalf("expected the plaintext key to be cleared")
+		}
+
+		arg := TransferTxParams{
+			FromWalletID: sealedWallet.ID,
+			ToWalletID:   garbled.ID,
+			Amount:       numericFromString(t, "10.00"),
+		}
+		if err := store.SignCustodialTransfer(ctx, &arg, sealer); err != nil {
+			t.Fatalf("sign with sealed legacy key: %v", err)
+		}
+		payload, err := transactionPayload(CreateTransactionParams(arg))
+		if err != nil {
+			t.Fatalf("build signing payload: %v", err)
+		}
+		if err := signature.Verify(sealedWallet.PublicKey, payload, arg.Signature); err != nil {
+			t.Fatalf("expected a valid wallet signature, got %v", err)
+		}
+	}
+
+	key, err := store.GetWalletCustodialKey(ctx, garbled.ID)
+	if err != nil {
+		t.Fatalf("get custodial key: %v", err)
+	}
+	if key.LegacyPrivateKey != nil || key.LegacyKeyError == nil || *key.LegacyKeyError != ErrLegacyKeyMalformed.Error() {
+		t.Fatalf("expected the garbled key discarded and flagged, got %+v", key)
+	}
+
+	arg := TransferTxParams{
+		FromWalletID: garbled.ID,
+		ToWalletID:   wallet.ID,
+		Amount:       numericFromString(t, "10.00"),
+	}
+	if err := store.SignCustodialTransfer(ctx, &arg, sealer); !errors.Is(err, ErrCustodialKeyRejected) {
+		t.Fatalf("expected ErrCustodialKeyRejected, got %v", err)
+	}
+}
+
+func TestParseLegacyPrivateKey(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	walletKey := base64.StdEncoding.EncodeToString(publicKey)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	for _, legacy := range []string{
+		hex.EncodeToString(privateKey.Seed()),
+		hex.EncodeToString(privateKey),
+		base64.StdEncoding.EncodeToString(privateKey.Seed()),
+		base64.RawURLEncoding.EncodeToString(privateKey) + "\n",
+	} {
+		parsed, err := parseLegacyPrivateKey(legacy, walletKey)
+		if err != nil {
+			t.Fatalf("parse %q: %v", legacy, err)
+		}
+		if !parsed.Equal(privateKey) {
+			t.Fatalf("parse %q: got a different key", legacy)
+		}
+	}
+
+	// A full key whose public half was tampered with.
+	tampered := append(privateKey.Seed(), otherKey.Public().(ed25519.PublicKey)...)
+	for legacy, want := range map[string]error{
+		"client-managed":                    ErrLegacyKeyMalformed,
+		hex.EncodeToString(tampered):        ErrLegacyKeyMalformed,
+		hex.EncodeToString(otherKey.Seed()): ErrLegacyKeyMismatch,
+		hex.EncodeToString(privateKey[:16]): ErrLegacyKeyMalformed,
+	} {
+		if _, err := parseLegacyPrivateKey(legacy, walletKey); !errors.Is(err, want) {
+			t.Fatalf("parse %q: expected %v, got %v", legacy, want, err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sahas001/pay-on/internal/signature"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrDeviceChallenge    = errors.New("device challenge is invalid or expired")
+	ErrDeviceSignature    = errors.New("invalid device signature")
+	ErrDeviceRevoked      = errors.New("device has been revoked")
+	ErrDeviceNotVerified  = errors.New("device must be verified from one of your other devices")
+	ErrDevicePublicKey    = errors.New("device public key is required to register a new device")
+	ErrUnregisteredDevice = errors.New("payment was not signed on a registered device")
+)
+
+const deviceChallengeVersion = "pay-on:device-challenge:v1"
+
+// DeviceChallengePayload returns the bytes a device signs to answer a login
+// challenge: the version tag, the challenge ID and the challenge, separated
+// by newlines.
+func DeviceChallengePayload(challengeID uuid.UUID, challenge string) []byte {
+	return fmt.Appendf(nil, "%s\n%s\n%s", deviceChallengeVersion, challengeID, challenge)
+}
+
+// CreateUserTxParams contains a new user and the device they register from.
+type CreateUserTxParams struct {
+	User            CreateUserParams
+	DeviceID        string
+	DeviceName      *string
+	DevicePublicKey string
+}
+
+// CreateUserTxResult is the result of the user registration transaction.
+type CreateUserTxResult struct {
+	User   User
+	Device Device
+}
+
+// CreateUserTx creates a user together with their first device. The first
+// device is trusted on registration; every later one needs verification.
+func (store *Store) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.User, err = q.CreateUser(ctx, arg.User)
+		if err != nil {
+			return err
+		}
+		result.Device, err = q.CreateDevice(ctx, CreateDeviceParams{
+			UserID:     result.User.ID,
+			DeviceID:   arg.DeviceID,
+			Name:       arg.DeviceName,
+			PublicKey:  arg.DevicePublicKey,
+			VerifiedAt: pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// BindDeviceTxParams contains a device's answer to a login challenge.
+// PublicKey and Name are only used when the device is not registered yet.
+type BindDeviceTxParams struct {
+	UserID      uuid.UUID
+	DeviceID    string
+	ChallengeID uuid.UUID
+	Signature   string
+	PublicKey   string
+	Name        *string
+}
+
+// BindDeviceTx consumes a login challenge and returns the verified device
+// that answered it.
+//
+// An unknown device is registered after proving it holds the private key of
+// PublicKey. It is verified straight away only if the user has no other
+// verified device; otherwise it is stored unverified and ErrDeviceNotVerified
+// is returned together with the new device.
+func (store *Store) BindDeviceTx(ctx context.Context, arg BindDeviceTxParams) (Device, error) {
+	var device Device
+	pending := false
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		challenge, err := q.GetDeviceChallengeForUpdate(ctx, arg.ChallengeID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrDeviceChallenge
+			}
+			return err
+		}
+		if challenge.UserID != arg.UserID || challenge.DeviceID != arg.DeviceID ||
+			challenge.UsedAt.Valid || !challenge.ExpiresAt.Time.After(time.Now()) {
+			return ErrDeviceChallenge
+		}
+		if err := q.MarkDeviceChallengeUsed(ctx, challenge.ID); err != nil {
+			return err
+		}
+		payload := DeviceChallengePayload(challenge.ID, challenge.Challenge)
+
+		device, err = q.GetUserDevice(ctx, GetUserDeviceParams{UserID: arg.UserID, DeviceID: arg.DeviceID})
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			if arg.PublicKey == "" {
+				return ErrDevicePublicKey
+			}
+			if err := verifyDeviceSignature(arg.PublicKey, payload, arg.Signature); err != nil {
+				return err
+			}
+			verified, err := q.CountVerifiedDevices(ctx, arg.UserID)
+			if err != nil {
+				return err
+			}
+			var verifiedAt pgtype.Timestamptz
+			if verified == 0 {
+				verifiedAt = pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
+			}
+			device, err = q.CreateDevice(ctx, CreateDeviceParams{
+				UserID:     arg.UserID,
+				DeviceID:   arg.DeviceID,
+				Name:       arg.Name,
+				PublicKey:  arg.PublicKey,
+				VerifiedAt: verifiedAt,
+			})
+			if err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			if device.RevokedAt.Valid {
+				return ErrDeviceRevoked
+			}
+			if err := verifyDeviceSignature(device.PublicKey, payload, arg.Signature); err != nil {
+				return err
+			}
+			if err := q.TouchDevice(ctx, device.ID); err != nil {
+				return err
+			}
+		}
+
+		// Keep the registration so the device can be approved, but do not
+		// let it sign in yet.
+		pending = !device.VerifiedAt.Valid
+		return nil
+	})
+	if err != nil {
+		return Device{}, err
+	}
+	if pending {
+		return device, ErrDeviceNotVerified
+	}
+
+	return device, nil
+}
+
+// RevokeDeviceTx revokes a device and ends every session started on it.
+func (store *Store) RevokeDeviceTx(ctx context.Context, id uuid.UUID) (Device, error) {
+	var device Device
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		device, err = q.RevokeDevice(ctx, id)
+		if err != nil {
+			return err
+		}
+		_, err = q.RevokeDeviceSessions(ctx, pgtype.UUID{Bytes: device.ID, Valid: true})
+		return err
+	})
+
+	return device, err
+}
+
+// DeviceAttestation names the sender device an offline payment was signed on,
+// with that device's signature over the transaction payload.
+type DeviceAttestation struct {
+	DeviceID  string
+	Signature string
+}
+
+// verifyDeviceAttestation checks that an offline payment was also signed by a
+// verified, unrevoked device of the sending wallet's owner.
+func verifyDeviceAttestation(ctx context.Context, q *Queries, sender Wallet, arg CreateTransactionParams, attestation DeviceAttestation) error {
+	if attestation.DeviceID == "" {
+		return ErrUnregisteredDevice
+	}
+	device, err := q.GetWalletOwnerDevice(ctx, GetWalletOwnerDeviceParams{
+		ID:       sender.ID,
+		DeviceID: attestation.DeviceID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUnregisteredDevice
+		}
+		return err
+	}
+	if !device.VerifiedAt.Valid || device.RevokedAt.Valid {
+		return ErrUnregisteredDevice
+	}
+
+	payload, err := transactionPayload(arg)
+	if err != nil {
+		return err
+	}
+	return verifyDeviceSignature(device.PublicKey, payload, attestation.Signature)
+}
+
+func verifyDeviceSignature(publicKey string, payload []byte, sig string) error {
+	if err := signature.Verify(publicKey, payload, sig); err != nil {
+		if errors.Is(err, signature.ErrInvalidSignature) {
+			return ErrDeviceSignature
+		}
+		return err
+	}
+	return nil
+}
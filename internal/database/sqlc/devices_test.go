@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestBindDeviceTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	user, err := store.CreateUser(ctx, CreateUserParams{
+		PhoneNumber:  nextPhoneNumber(),
+		PasswordHash: "password-hash",
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	defer func() {
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", user.ID)
+	}()
+
+	answer := func(deviceID string, key ed25519.PrivateKey) BindDeviceTxParams {
+		t.Helper()
+		challenge, err := store.CreateDeviceChallenge(ctx, CreateDeviceChallengeParams{
+			UserID:    user.ID,
+			DeviceID:  deviceID,
+			Challenge: uuid.NewString(),
+			ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("create device challenge: %v", err)
+		}
+		payload := DeviceChallengePayload(challenge.ID, challenge.Challenge)
+		return BindDeviceTxParams{
+			UserID:      user.ID,
+			DeviceID:    deviceID,
+			ChallengeID: challenge.ID,
+			Signature:   base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+			PublicKey:   base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		}
+	}
+	newKey := func() ed25519.PrivateKey {
+		t.Helper()
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("generate device key: %v", err)
+		}
+		return key
+	}
+
+	// The first device is trusted on first use.
+	phoneKey := newKey()
+	phone, err := store.BindDeviceTx(ctx, answer("phone", phoneKey))
+	if err != nil {
+		t.Fatalf("bind first device: %v", err)
+	}
+	if !phone.VerifiedAt.Valid {
+		t.Fatal("expected first device to be verified")
+	}
+
+	// A challenge can be answered only once.
+	arg := answer("phone", phoneKey)
+	if _, err := store.BindDeviceTx(ctx, arg); err != nil {
+		t.Fatalf("bind known device: %v", err)
+	}
+	if _, err := store.BindDeviceTx(ctx, arg); !errors.Is(err, ErrDeviceChallenge) {
+		t.Fatalf("expected used challenge to be rejected, got %v", err)
+	}
+
+	// A signature from the wrong key does not bind.
+	arg = answer("phone", newKey())
+	if _, err := store.BindDeviceTx(ctx, arg); !errors.Is(err, ErrDeviceSignature) {
+		t.Fatalf("expected invalid device signature, got %v", err)
+	}
+
+	// Further devices are registered but wait for verification.
+	tablet, err := store.BindDeviceTx(ctx, answer("tablet", newKey()))
+	if !errors.Is(err, ErrDeviceNotVerified) {
+		t.Fatalf("expected new device to need verification, got %v", err)
+	}
+	if tablet.ID == uuid.Nil || tablet.VerifiedAt.Valid {
+		t.Fatalf("expected an unverified tablet registration, got %+v", tablet)
+	}
+
+	if _, err := store.RevokeDeviceTx(ctx, phone.ID); err != nil {
+		t.Fatalf("revoke device: %v", err)
+	}
+	if _, err := store.BindDeviceTx(ctx, answer("phone", phoneKey)); !errors.Is(err, ErrDeviceRevoked) {
+		t.Fatalf("expected revoked device to be rejected, got %v", err)
+	}
+}
+
+func TestSyncTxRejectsUnregisteredDevice(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet, fromKey := createTestWalletWithKey(t, ctx, store.Queries)
+	toWallet := createTestWallet(t, ctx, store.Queries)
+	device, _ := createTestDevice(t, ctx, store.Queries, fromWallet)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1 OR id = $2", fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", device.UserID)
+	}()
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate device key: %v", err)
+	}
+	items := make([]TransferTxParams, 2)
+	for i := range items {
+		items[i] = TransferTxParams{
+			FromWalletID: fromWallet.ID,
+			ToWalletID:   toWallet.ID,
+			Amount:       numericFromString(t, "10.00"),
+		}
+		signTestTransfer(t, fromKey, &items[i])
+	}
+
+	result, err := store.SyncTx(ctx, SyncTxParams{
+		WalletID:     toWallet.ID,
+		Transactions: items,
+		Devices: []DeviceAttestation{
+			{DeviceID: "unknown-device", Signature: attestTestTransfer(t, device, otherKey, items[0]).Signature},
+			attestTestTransfer(t, device, otherKey, items[1]),
+		},
+	})
+	if err != nil {
+		t.Fatalf("sync tx: %v", err)
+	}
+	if item := result.Results[0]; item.Status != SyncItemFailed || item.Error != ErrUnregisteredDevice.Error() {
+		t.Fatalf("expected unregistered device failure, got %s: %s", item.Status, item.Error)
+	}
+	if item := result.Results[1]; item.Status != SyncItemFailed || item.Error != ErrDeviceSignature.Error() {
+		t.Fatalf("expected device signature failure, got %s: %s", item.Status, item.Error)
+	}
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Domain event names, stored in outbox.event_type.
+const (
+	EventTransferCompleted    = "transfer.completed"
+	EventTransactionConfirmed = "transaction.confirmed"
+	EventTransactionFailed    = "transaction.failed"
+	EventWalletDeactivated    = "wallet.deactivated"
+	EventSyncConflictRaised   = "sync.conflict_raised"
+)
+
+// DomainEvent is a change other parts of the system may react to. Store
+// methods write events to the outbox in the transaction that makes the
+// change; a relay hands them to subscribers once it has committed.
+type DomainEvent interface {
+	// EventName is the name subscribers register for.
+	EventName() string
+	// AggregateID is the wallet, transaction or sync log the event is about.
+	AggregateID() uuid.UUID
+}
+
+// TransferCompleted is raised when money moves between two wallets: online
+// transfers, refunds, settled offline transactions and reversals. Amount is
+// what moved, which is less than the transaction amount after a split
+// resolution.
+type TransferCompleted struct {
+	Transaction Transaction    `json:"transaction"`
+	Amount      pgtype.Numeric `json:"amount"`
+}
+
+func (TransferCompleted) EventName() string { return EventTransferCompleted }
+
+func (e TransferCompleted) AggregateID() uuid.UUID { return e.Transaction.ID }
+
+// TransactionConfirmed is raised when sync accepts an offline payment,
+// which then waits for settlement.
+type TransactionConfirmed struct {
+	Transaction Transaction `json:"transaction"`
+}
+
+func (TransactionConfirmed) EventName() string { return EventTransactionConfirmed }
+
+func (e TransactionConfirmed) AggregateID() uuid.UUID { return e.Transaction.ID }
+
+// TransactionFailed is raised when a transaction is failed or rolled back.
+type TransactionFailed struct {
+	Transaction Transaction `json:"transaction"`
+}
+
+func (TransactionFailed) EventName() string { return EventTransactionFailed }
+
+func (e TransactionFailed) AggregateID() uuid.UUID { return e.Transaction.ID }
+
+// WalletDeactivated is raised when an active wallet is deactivated or a
+// wallet is soft deleted.
+type WalletDeactivated struct {
+	WalletID      uuid.UUID   `json:"wallet_id"`
+	UserID        pgtype.UUID `json:"user_id"`
+	DeactivatedBy pgtype.UUID `json:"deactivated_by"`
+	Deleted       bool        `json:"deleted"`
+}
+
+func (WalletDeactivated) EventName() string { return EventWalletDeactivated }
+
+func (e WalletDeactivated) AggregateID() uuid.UUID { return e.WalletID }
+
+// SyncConflictRaised is raised when settlement parks a synced transaction
+// for an operator.
+type SyncConflictRaised struct {
+	SyncLogID   uuid.UUID    `json:"sync_log_id"`
+	Transaction Transaction  `json:"transaction"`
+	Conflict    ConflictData `json:"conflict"`
+}
+
+func (SyncConflictRaised) EventName() string { return EventSyncConflictRaised }
+
+func (e SyncConflictRaised) AggregateID() uuid.UUID { return e.SyncLogID }
+
+// publishEvent writes event to the outbox. It must run in the transaction
+// that makes the change, so the event exists exactly when the change does.
+func publishEvent(ctx context.Context, q *Queries, event DomainEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		EventType:   event.EventName(),
+		AggregateID: event.AggregateID(),
+		Payload:     payload,
+	})
+	return err
+}
+
+// DeactivateWalletTxParams names the wallet to deactivate and who asked.
+// Delete also soft deletes it.
+type DeactivateWalletTxParams struct {
+	WalletID      uuid.UUID
+	DeactivatedBy uuid.UUID
+	Delete        bool
+}
+
+// DeactivateWalletTx deactivates or soft deletes a wallet and raises
+// WalletDeactivated. A missing or already deleted wallet returns
+// pgx.ErrNoRows; deactivating an inactive wallet changes nothing.
+func (store *Store) DeactivateWalletTx(ctx context.Context, arg DeactivateWalletTxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		wallet, err := q.GetWalletForUpdate(ctx, arg.WalletID)
+		if err != nil {
+			return err
+		}
+		if !arg.Delete && wallet.IsActive != nil && !*wallet.IsActive {
+			return nil
+		}
+
+		if arg.Delete {
+			err = q.SoftDeleteWallet(ctx, wallet.ID)
+		} else {
+			err = q.DeactivateWallet(ctx, wallet.ID)
+		}
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, q, WalletDeactivated{
+			WalletID:      wallet.ID,
+			UserID:        wallet.UserID,
+			DeactivatedBy: pgtype.UUID{Bytes: arg.DeactivatedBy, Valid: arg.DeactivatedBy != uuid.Nil},
+			Deleted:       arg.Delete,
+		})
+	})
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestStoreWritesDomainEvents(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet, fromKey := createTestWalletWithKey(t, ctx, store.Queries)
+	toWallet, _ := createTestWalletWithKey(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM outbox WHERE aggregate_id IN ($1, $2) OR aggregate_id IN (SELECT id FROM transactions WHERE from_wallet_id = $1)",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id IN ($1, $2)", fromWallet.ID, toWallet.ID)
+	}()
+
+	arg := TransferTxParams{
+		FromWalletID: fromWallet.ID,
+		ToWalletID:   toWallet.ID,
+		Amount:       numericFromString(t, "12.50"),
+	}
+	signTestTransfer(t, fromKey, &arg)
+	result, err := store.TransferTx(ctx, arg)
+	if err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+
+	events, err := store.ListOutboxEventsByAggregate(ctx, result.Transaction.ID)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].EventType != EventTransferCompleted || events[0].Status != OutboxStatusPending {
+		t.Fatalf("expected one pending %s event, got %+v", EventTransferCompleted, events)
+	}
+	var completed TransferCompleted
+	if err := json.Unmarshal(events[0].Payload, &completed); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if completed.Transaction.ID != result.Transaction.ID {
+		t.Fatalf("expected transaction %s, got %s", result.Transaction.ID, completed.Transaction.ID)
+	}
+	assertFloatApprox(t, numericToFloat64(t, completed.Amount), 12.5)
+
+	if err := store.DeactivateWalletTx(ctx, DeactivateWalletTxParams{WalletID: toWallet.ID}); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	// Deactivating an inactive wallet raises nothing new.
+	if err := store.DeactivateWalletTx(ctx, DeactivateWalletTxParams{WalletID: toWallet.ID}); err != nil {
+		t.Fatalf("deactivate again: %v", err)
+	}
+	if err := store.DeactivateWalletTx(ctx, DeactivateWalletTxParams{WalletID: toWallet.ID, Delete: true}); err != nil {
+		t.Fatalf("soft delete: %v", err)
+	}
+
+	events, err = store.ListOutboxEventsByAggregate(ctx, toWallet.ID)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 %s events, got %d", EventWalletDeactivated, len(events))
+	}
+	var deleted WalletDeactivated
+	if err := json.Unmarshal(events[1].Payload, &deleted); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if deleted.WalletID != toWallet.ID || !deleted.Deleted {
+		t.Fatalf("expected the soft delete event last, got %+v", deleted)
+	}
+}
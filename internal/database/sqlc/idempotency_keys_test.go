@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestIdempotencyKeyQueries(t *testing.T) {
+	withTx(t, func(ctx context.Context, q *Queries) {
+		user, err := q.CreateUser(ctx, CreateUserParams{
+			PhoneNumber:  nextPhoneNumber(),
+			PasswordHash: "hash",
+		})
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+
+		arg := CreateIdempotencyKeyParams{
+			UserID:         user.ID,
+			IdempotencyKey: "transfer-1",
+			Method:         "POST",
+			Path:           "/transfers",
+			RequestHash:    "abc123",
+			CreatedAt:      pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true},
+		}
+		record, err := q.CreateIdempotencyKey(ctx, arg)
+		if err != nil {
+			t.Fatalf("create idempotency key: %v", err)
+		}
+		if record.ResponseStatus != nil {
+			t.Fatalf("expected new key to have no stored response")
+		}
+
+		if _, err := q.CreateIdempotencyKey(ctx, arg); !errors.Is(err, pgx.ErrNoRows) {
+			t.Fatalf("expected existing key to be skipped, got %v", err)
+		}
+
+		// Past its lease, an unfinished key goes to the next request.
+		stale := arg
+		stale.RequestHash = "def456"
+		stale.CreatedAt = pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true}
+		takenOver, err := q.CreateIdempotencyKey(ctx, stale)
+		if err != nil {
+			t.Fatalf("take over abandoned key: %v", err)
+		}
+		if takenOver.ID != record.ID || takenOver.RequestHash != "def456" {
+			t.Fatalf("expected the abandoned key taken over, got %+v", takenOver)
+		}
+
+		if renewed, err := q.RenewIdempotencyKey(ctx, record.ID); err != nil || renewed != 1 {
+			t.Fatalf("expected the key in progress renewed, got %d (%v)", renewed, err)
+		}
+
+		status := int32(200)
+		if _, err := q.CompleteIdempotencyKey(ctx, CompleteIdempotencyKeyParams{
+			ID:             record.ID,
+			ResponseStatus: &status,
+			ResponseBody:   []byte(`{"ok":true}`),
+		}); err != nil {
+			t.Fatalf("complete idempotency key: %v", err)
+		}
+
+		fetched, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+			UserID:         user.ID,
+			IdempotencyKey: "transfer-1",
+		})
+		if err != nil {
+			t.Fatalf("get idempotency key: %v", err)
+		}
+		if fetched.ResponseStatus == nil || *fetched.ResponseStatus != 200 || string(fetched.ResponseBody) != `{"ok":true}` {
+			t.Fatalf("expected stored response to be returned")
+		}
+		if _, err := q.CreateIdempotencyKey(ctx, stale); !errors.Is(err, pgx.ErrNoRows) {
+			t.Fatalf("expected a completed key to be kept, got %v", err)
+		}
+		if renewed, err := q.RenewIdempotencyKey(ctx, record.ID); err != nil || renewed != 0 {
+			t.Fatalf("expected a completed key left alone, got %d (%v)", renewed, err)
+		}
+
+		if err := q.DeleteIdempotencyKey(ctx, record.ID); err != nil {
+			t.Fatalf("delete idempotency key: %v", err)
+		}
+	})
+}
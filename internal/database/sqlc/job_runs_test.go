@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCreateJobRunClaimsSlot(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+	name := "test-" + uuid.NewString()
+	defer func() {
+		_, _ = testPool.Exec(ctx, "DELETE FROM job_runs WHERE job_name = $1", name)
+	}()
+
+	slot := pgtype.Timestamptz{Time: time.Now().Truncate(time.Minute), Valid: true}
+	arg := CreateJobRunParams{JobName: name, Trigger: JobTriggerSchedule, ScheduledAt: slot}
+	if _, err := store.CreateJobRun(ctx, arg); err != nil {
+		t.Fatalf("create run: %v", err)
+	}
+	if _, err := store.CreateJobRun(ctx, arg); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected the taken slot refused, got %v", err)
+	}
+
+	// Manual runs have no slot and never collide.
+	manual := CreateJobRunParams{JobName: name, Trigger: JobTriggerManual}
+	for range 2 {
+		if _, err := store.CreateJobRun(ctx, manual); err != nil {
+			t.Fatalf("create manual run: %v", err)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// System accounts sit on the other side of movements that do not have a
+// counterparty wallet.
+const (
+	LedgerAccountWallet     = "wallet"
+	LedgerAccountOpening    = "system:opening"
+	LedgerAccountAdjustment = "system:adjustment"
+	LedgerAccountCash       = "system:cash"
+)
+
+var (
+	ErrInvalidLedgerAmount = errors.New("ledger amount must be positive")
+	ErrWalletHasLedger     = errors.New("wallet has ledger entries; soft delete it instead")
+)
+
+// LedgerAccount is one side of a posting: a wallet or a system account.
+type LedgerAccount struct {
+	WalletID uuid.UUID
+	System   string
+}
+
+// WalletAccount returns the ledger account of a wallet.
+func WalletAccount(walletID uuid.UUID) LedgerAccount {
+	return LedgerAccount{WalletID: walletID}
+}
+
+// SystemAccount returns a system ledger account such as LedgerAccountCash.
+func SystemAccount(name string) LedgerAccount {
+	return LedgerAccount{System: name}
+}
+
+func (a LedgerAccount) isWallet() bool {
+	return a.System == ""
+}
+
+// LedgerPosting moves Amount from the Debit account to the Credit account.
+type LedgerPosting struct {
+	TransactionID pgtype.UUID
+	EntryType     LedgerEntryType
+	Debit         LedgerAccount
+	Credit        LedgerAccount
+	Amount        pgtype.Numeric
+	Description   *string
+}
+
+// LedgerPostingResult holds the written entry pair and the updated wallet projections.
+type LedgerPostingResult struct {
+	PostingID    uuid.UUID
+	DebitEntry   LedgerEntry
+	CreditEntry  LedgerEntry
+	DebitWallet  Wallet
+	CreditWallet Wallet
+}
+
+// postLedger writes a balanced debit and credit pair and updates the cached
+// wallets.balance of every wallet involved.
+//
+// The debit side is applied first, so an overdraft surfaces as pgx.ErrNoRows
+// from DecrementWalletBalance before anything is credited. Callers that move
+// money between two wallets are expected to lock both rows beforehand.
+func postLedger(ctx context.Context, q *Queries, arg LedgerPosting) (LedgerPostingResult, error) {
+	result := LedgerPostingResult{PostingID: uuid.New()}
+
+	if !arg.Amount.Valid || numericRat(arg.Amount).Sign() <= 0 {
+		return result, ErrInvalidLedgerAmount
+	}
+
+	var err error
+	if arg.Debit.isWallet() {
+		result.DebitWallet, err = q.DecrementWalletBalance(ctx, DecrementWalletBalanceParams{
+			ID:      arg.Debit.WalletID,
+			Balance: arg.Amount,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	if arg.Credit.isWallet() {
+		result.CreditWallet, err = q.IncrementWalletBalance(ctx, IncrementWalletBalanceParams{
+			ID:      arg.Credit.WalletID,
+			Balance: arg.Amount,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	result.DebitEntry, err = q.CreateLedgerEntry(ctx, ledgerEntryParams(result.PostingID, arg, arg.Debit, LedgerDirectionDebit))
+	if err != nil {
+		return result, err
+	}
+	result.CreditEntry, err = q.CreateLedgerEntry(ctx, ledgerEntryParams(result.PostingID, arg, arg.Credit, LedgerDirectionCredit))
+	return result, err
+}
+
+// ledgerEntryType maps a transaction type to the entry type it is posted as.
+func ledgerEntryType(txType TransactionType) LedgerEntryType {
+	switch txType {
+	case TransactionTypeDeposit:
+		return LedgerEntryTypeDeposit
+	case TransactionTypeWithdraw:
+		return LedgerEntryTypeWithdrawal
+	case TransactionTypeRefund, TransactionTypeReversal:
+		return LedgerEntryTypeReversal
+	default:
+		return LedgerEntryTypeTransfer
+	}
+}
+
+func ledgerEntryParams(postingID uuid.UUID, arg LedgerPosting, account LedgerAccount, direction LedgerDirection) CreateLedgerEntryParams {
+	params := CreateLedgerEntryParams{
+		PostingID:     postingID,
+		TransactionID: arg.TransactionID,
+		Account:       account.System,
+		Direction:     direction,
+		EntryType:     arg.EntryType,
+		Amount:        arg.Amount,
+		Description:   arg.Description,
+	}
+	if account.isWallet() {
+		params.WalletID = pgtype.UUID{Bytes: account.WalletID, Valid: true}
+		params.Account = LedgerAccountWallet
+	}
+	return params
+}
+
+// AdjustBalanceTxParams contains the input of a manual balance adjustment.
+type AdjustBalanceTxParams struct {
+	WalletID uuid.UUID
+	// Amount is added to the balance; Decrease takes it away instead.
+	Amount      pgtype.Numeric
+	Decrease    bool
+	Description *string
+}
+
+// AdjustBalanceTx posts a manual adjustment against the system adjustment account.
+func (store *Store) AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (Wallet, error) {
+	var wallet Wallet
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		wallet, err = adjustBalance(ctx, q, arg)
+		return err
+	})
+
+	return wallet, err
+}
+
+// SetBalanceTx moves a wallet to the given balance by posting the difference as an adjustment.
+func (store *Store) SetBalanceTx(ctx context.Context, walletID uuid.UUID, balance pgtype.Numeric, description *string) (Wallet, error) {
+	var wallet Wallet
+
+	if !balance.Valid || numericRat(balance).Sign() < 0 {
+		return wallet, ErrInvalidLedgerAmount
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		current, err := q.GetWalletForUpdate(ctx, walletID)
+		if err != nil {
+			return err
+		}
+
+		diff := new(big.Rat).Sub(numericRat(balance), numericRat(current.Balance))
+		if diff.Sign() == 0 {
+			wallet = current
+			return nil
+		}
+
+		wallet, err = adjustBalance(ctx, q, AdjustBalanceTxParams{
+			WalletID:    walletID,
+			Amount:      numericFromRat(new(big.Rat).Abs(diff)),
+			Decrease:    diff.Sign() < 0,
+			Description: description,
+		})
+		return err
+	})
+
+	return wallet, err
+}
+
+func adjustBalance(ctx context.Context, q *Queries, arg AdjustBalanceTxParams) (Wallet, error) {
+	posting := LedgerPosting{
+		EntryType:   LedgerEntryTypeAdjustment,
+		Debit:       SystemAccount(LedgerAccountAdjustment),
+		Credit:      WalletAccount(arg.WalletID),
+		Amount:      arg.Amount,
+		Description: arg.Description,
+	}
+	if arg.Decrease {
+		posting.Debit, posting.Credit = posting.Credit, posting.Debit
+	}
+
+	result, err := postLedger(ctx, q, posting)
+	if err != nil {
+		return Wallet{}, err
+	}
+	if arg.Decrease {
+		return result.DebitWallet, nil
+	}
+	return result.CreditWallet, nil
+}
+
+// HardDeleteWalletTx removes a wallet that has never been posted to. Ledger
+// entries are permanent, so a wallet with any can only be soft deleted.
+func (store *Store) HardDeleteWalletTx(ctx context.Context, walletID uuid.UUID) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		posted, err := q.WalletHasLedgerEntries(ctx, pgtype.UUID{Bytes: walletID, Valid: true})
+		if err != nil {
+			return err
+		}
+		if posted {
+			return ErrWalletHasLedger
+		}
+		deleted, err := q.HardDeleteWallet(ctx, walletID)
+		if err == nil && deleted == 0 {
+			return pgx.ErrNoRows
+		}
+		return err
+	})
+}
+
+// BalanceMismatch is a wallet whose cached balance differs from its ledger.
+type BalanceMismatch struct {
+	WalletID      uuid.UUID      `json:"wallet_id"`
+	Balance       pgtype.Numeric `json:"balance"`
+	LedgerBalance pgtype.Numeric `json:"ledger_balance"`
+	Difference    pgtype.Numeric `json:"difference"`
+}
+
+// ReconcileResult reports every way the ledger and the wallet projection disagree.
+type ReconcileResult struct {
+	Balanced           bool                        `json:"balanced"`
+	Mismatches         []BalanceMismatch           `json:"mismatches"`
+	UnbalancedPostings []ListUnbalancedPostingsRow `json:"unbalanced_postings"`
+}
+
+// Reconcile compares wallets.balance with the sum of each wallet's ledger
+// entries and checks that every posting is balanced.
+func (store *Store) Reconcile(ctx context.Context, limit int32) (ReconcileResult, error) {
+	result := ReconcileResult{Mismatches: []BalanceMismatch{}}
+
+	rows, err := store.ListBalanceMismatches(ctx, limit)
+	if err != nil {
+		return result, err
+	}
+	for _, row := range rows {
+		diff := new(big.Rat).Sub(numericRat(row.Balance), numericRat(row.LedgerBalance))
+		result.Mismatches = append(result.Mismatches, BalanceMismatch{
+			WalletID:      row.WalletID,
+			Balance:       row.Balance,
+			LedgerBalance: row.LedgerBalance,
+			Difference:    numericFromRat(diff),
+		})
+	}
+
+	result.UnbalancedPostings, err = store.ListUnbalancedPostings(ctx, limit)
+	if err != nil {
+		return result, err
+	}
+
+	result.Balanced = len(result.Mismatches) == 0 && len(result.UnbalancedPostings) == 0
+	return result, nil
+}
+
+func numericCmp(a, b pgtype.Numeric) int {
+	return numericRat(a).Cmp(numericRat(b))
+}
+
+func numericRat(n pgtype.Numeric) *big.Rat {
+	if n.Int == nil {
+		return new(big.Rat)
+	}
+	exp := n.Exp
+	if exp < 0 {
+		exp = -exp
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+	if n.Exp < 0 {
+		return new(big.Rat).SetFrac(n.Int, scale)
+	}
+	return new(big.Rat).SetInt(new(big.Int).Mul(n.Int, scale))
+}
+
+// numericFromRat rounds r half to even to the two decimal places used for
+// money columns.
+func numericFromRat(r *big.Rat) pgtype.Numeric {
+	cents := new(big.Rat).Mul(r, big.NewRat(100, 1))
+	value, rem := new(big.Int).QuoRem(cents.Num(), cents.Denom(), new(big.Int))
+
+	// Quo truncates towards zero; step away from zero past the halfway
+	// point, or at it when that makes the last digit even.
+	half := new(big.Int).Lsh(rem.Abs(rem), 1).Cmp(cents.Denom())
+	if half > 0 || (half == 0 && value.Bit(0) == 1) {
+		if cents.Sign() < 0 {
+			value.Sub(value, big.NewInt(1))
+		} else {
+			value.Add(value, big.NewInt(1))
+		}
+	}
+	return pgtype.Numeric{Int: value, Exp: -2, Valid: true}
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestLedgerPostings(t *testing.T) {
+	withTx(t, func(ctx context.Context, q *Queries) {
+		fromWallet := createTestWallet(t, ctx, q)
+		toWallet := createTestWallet(t, ctx, q)
+		transaction := createTestTransaction(t, ctx, q, fromWallet.ID, toWallet.ID, "30.00", TransactionStatusPending)
+
+		posted, err := postLedger(ctx, q, LedgerPosting{
+			TransactionID: pgtype.UUID{Bytes: transaction.ID, Valid: true},
+			EntryType:     LedgerEntryTypeTransfer,
+			Debit:         WalletAccount(fromWallet.ID),
+			Credit:        WalletAccount(toWallet.ID),
+			Amount:        transaction.Amount,
+		})
+		if err != nil {
+			t.Fatalf("post transfer: %v", err)
+		}
+		assertFloatApprox(t, numericToFloat64(t, posted.DebitWallet.Balance), 70.00)
+		assertFloatApprox(t, numericToFloat64(t, posted.CreditWallet.Balance), 130.00)
+
+		entries, err := q.ListLedgerEntriesByTransaction(ctx, pgtype.UUID{Bytes: transaction.ID, Valid: true})
+		if err != nil {
+			t.Fatalf("list ledger entries: %v", err)
+		}
+		if len(entries) != 2 || entries[0].PostingID != entries[1].PostingID {
+			t.Fatalf("expected one debit/credit pair, got %d entries", len(entries))
+		}
+
+		if _, err := adjustBalance(ctx, q, AdjustBalanceTxParams{
+			WalletID: fromWallet.ID,
+			Amount:   numericFromString(t, "20.00"),
+			Decrease: true,
+		}); err != nil {
+			t.Fatalf("adjust balance: %v", err)
+		}
+
+		for _, walletID := range []pgtype.UUID{pgUUIDFromUUID(fromWallet.ID), pgUUIDFromUUID(toWallet.ID)} {
+			wallet, err := q.GetWalletByID(ctx, walletID.Bytes)
+			if err != nil {
+				t.Fatalf("get wallet: %v", err)
+			}
+			ledgerBalance, err := q.GetWalletLedgerBalance(ctx, walletID)
+			if err != nil {
+				t.Fatalf("get ledger balance: %v", err)
+			}
+			assertFloatApprox(t, numericToFloat64(t, ledgerBalance), numericToFloat64(t, wallet.Balance))
+		}
+
+		if _, err := q.db.Exec(ctx, "UPDATE ledger_entries SET amount = 1 WHERE id = $1", entries[0].ID); err == nil {
+			t.Fatalf("expected ledger entries to be immutable")
+		}
+	})
+}
+
+func TestHardDeleteWalletTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	posted := createTestWallet(t, ctx, testQueries)
+	defer func() {
+		deleteTestLedger(ctx, posted.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1", posted.ID)
+	}()
+
+	if err := store.HardDeleteWalletTx(ctx, posted.ID); !errors.Is(err, ErrWalletHasLedger) {
+		t.Fatalf("expected ErrWalletHasLedger, got %v", err)
+	}
+	// The database refuses it too, rather than cascading into the ledger.
+	if _, err := testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1", posted.ID); err == nil {
+		t.Fatal("expected a wallet with ledger entries to survive a direct delete")
+	}
+
+	deviceID := "device-" + uuid.NewString()
+	unposted, err := testQueries.CreateWallet(ctx, CreateWalletParams{
+		PublicKey:   uuid.NewString(),
+		Balance:     numericFromString(t, "0"),
+		PhoneNumber: nextPhoneNumber(),
+		Name:        "Unposted",
+		PinHash:     "pin-hash",
+		DeviceID:    &deviceID,
+	})
+	if err != nil {
+		t.Fatalf("create wallet: %v", err)
+	}
+	if err := store.HardDeleteWalletTx(ctx, unposted.ID); err != nil {
+		t.Fatalf("hard delete: %v", err)
+	}
+	if err := store.HardDeleteWalletTx(ctx, unposted.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("expected pgx.ErrNoRows for a deleted wallet, got %v", err)
+	}
+}
+
+func TestNumericFromRat(t *testing.T) {
+	for _, tc := range []struct {
+		in   *big.Rat
+		want int64
+	}{
+		{big.NewRat(1, 3), 33},
+		{big.NewRat(2, 3), 67},
+		{big.NewRat(1005, 100000), 1}, // 0.01005
+		{big.NewRat(25, 1000), 2},     // 0.025: halfway, down to even
+		{big.NewRat(15, 1000), 2},     // 0.015: halfway, up to even
+		{big.NewRat(-15, 1000), -2},   // -0.015
+		{big.NewRat(-2, 3), -67},
+		{big.NewRat(12345, 100), 12345}, // exact
+	} {
+		got := numericFromRat(tc.in)
+		if got.Exp != -2 || got.Int.Int64() != tc.want {
+			t.Fatalf("%s: expected %d cents, got %s e%d", tc.in.FloatString(6), tc.want, got.Int, got.Exp)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrPerTransactionLimit = errors.New("amount exceeds the per-transaction limit")
+	ErrDailyLimit          = errors.New("daily spending limit exceeded")
+	ErrMonthlyLimit        = errors.New("monthly spending limit exceeded")
+	ErrOfflineLimit        = errors.New("offline spending cap exceeded")
+)
+
+// WalletLimitUsage reports the limits of a wallet together with what has
+// already been spent against them.
+type WalletLimitUsage struct {
+	Limits          WalletLimit    `json:"limits"`
+	DailyOutflow    pgtype.Numeric `json:"daily_outflow"`
+	MonthlyOutflow  pgtype.Numeric `json:"monthly_outflow"`
+	OfflineExposure pgtype.Numeric `json:"offline_exposure"`
+}
+
+// GetWalletLimitUsage returns the wallet's limits and its current outflow.
+// A wallet without configured limits gets an empty WalletLimit.
+func (store *Store) GetWalletLimitUsage(ctx context.Context, walletID uuid.UUID) (WalletLimitUsage, error) {
+	var usage WalletLimitUsage
+
+	wallet, err := store.GetWalletByID(ctx, walletID)
+	if err != nil {
+		return usage, err
+	}
+
+	usage.Limits, err = store.GetWalletLimits(ctx, walletID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		usage.Limits = WalletLimit{WalletID: walletID}
+	} else if err != nil {
+		return usage, err
+	}
+
+	outflow, err := store.GetWalletOutflow(ctx, walletID)
+	if err != nil {
+		return usage, err
+	}
+	usage.DailyOutflow, usage.MonthlyOutflow = outflow.DailyTotal, outflow.MonthlyTotal
+
+	usage.OfflineExposure, err = store.GetOfflineExposure(ctx, GetOfflineExposureParams{
+		FromWalletID: walletID,
+		Since:        wallet.LastSyncedAt,
+	})
+	return usage, err
+}
+
+// checkWalletLimits rejects a payment that would take the sender over one of
+// its limits. The sender row must already be locked so that concurrent
+// payments see each other's outflow.
+//
+// Daily and monthly windows are the server's current UTC day and month, and
+// payments count towards them by when they were recorded, so a backdated
+// transaction_at cannot move spend out of the window.
+// The offline cap only applies to offline payments and counts offline spend
+// since the sender last synced.
+func checkWalletLimits(ctx context.Context, q *Queries, sender Wallet, arg TransferTxParams, offline bool) error {
+	amount := numericRat(arg.Amount)
+	return checkLimits(ctx, q, sender, amount, amount, offline)
+}
+
+// checkSettlementLimits holds a recorded transaction to its sender's limits
+// before it is settled. It already counts towards the daily and monthly
+// outflow, so only the per-transaction limit looks at its amount. The
+// offline cap is for payments not yet synced and does not apply.
+func checkSettlementLimits(ctx context.Context, q *Queries, sender Wallet, transaction Transaction) error {
+	return checkLimits(ctx, q, sender, numericRat(transaction.Amount), new(big.Rat), false)
+}
+
+// checkLimits checks amount against the per-transaction limit, and the
+// sender's outflow plus added against the daily and monthly limits.
+func checkLimits(ctx context.Context, q *Queries, sender Wallet, amount, added *big.Rat, offline bool) error {
+	limits, err := q.GetWalletLimits(ctx, sender.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if limits.PerTransactionLimit.Valid && amount.Cmp(numericRat(limits.PerTransactionLimit)) > 0 {
+		return ErrPerTransactionLimit
+	}
+
+	if limits.DailyLimit.Valid || limits.MonthlyLimit.Valid {
+		outflow, err := q.GetWalletOutflow(ctx, sender.ID)
+		if err != nil {
+			return err
+		}
+		if exceedsLimit(outflow.DailyTotal, added, limits.DailyLimit) {
+			return ErrDailyLimit
+		}
+		if exceedsLimit(outflow.MonthlyTotal, added, limits.MonthlyLimit) {
+			return ErrMonthlyLimit
+		}
+	}
+
+	if offline && limits.OfflineLimit.Valid {
+		exposure, err := q.GetOfflineExposure(ctx, GetOfflineExposureParams{
+			FromWalletID: sender.ID,
+			Since:        sender.LastSyncedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if exceedsLimit(exposure, amount, limits.OfflineLimit) {
+			return ErrOfflineLimit
+		}
+	}
+
+	return nil
+}
+
+func exceedsLimit(spent pgtype.Numeric, amount *big.Rat, limit pgtype.Numeric) bool {
+	if !limit.Valid {
+		return false
+	}
+	total := new(big.Rat).Add(numericRat(spent), amount)
+	return total.Cmp(numericRat(limit)) > 0
+}
+
+// isOfflineConnection matches GetOfflineExposure: anything not marked online
+// counts as offline spend.
+func isOfflineConnection(connection NullConnectionType) bool {
+	return !connection.Valid || connection.ConnectionType != ConnectionTypeOnline
+}
+
+// IsLimitError reports whether err is one of the spending limit errors.
+func IsLimitError(err error) bool {
+	return errors.Is(err, ErrPerTransactionLimit) ||
+		errors.Is(err, ErrDailyLimit) ||
+		errors.Is(err, ErrMonthlyLimit) ||
+		errors.Is(err, ErrOfflineLimit)
+}
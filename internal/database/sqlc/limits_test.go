@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWalletLimits(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet, fromKey := createTestWalletWithKey(t, ctx, store.Queries)
+	toWallet := createTestWallet(t, ctx, store.Queries)
+	device, deviceKey := createTestDevice(t, ctx, store.Queries, fromWallet)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1 OR id = $2", fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", device.UserID)
+	}()
+
+	if _, err := store.UpsertWalletLimits(ctx, UpsertWalletLimitsParams{
+		WalletID:            fromWallet.ID,
+		PerTransactionLimit: numericFromString(t, "50.00"),
+		DailyLimit:          numericFromString(t, "80.00"),
+		OfflineLimit:        numericFromString(t, "30.00"),
+	}); err != nil {
+		t.Fatalf("upsert wallet limits: %v", err)
+	}
+
+	payment := func(amount string) TransferTxParams {
+		t.Helper()
+		arg := TransferTxParams{
+			FromWalletID: fromWallet.ID,
+			ToWalletID:   toWallet.ID,
+			Amount:       numericFromString(t, amount),
+		}
+		signTestTransfer(t, fromKey, &arg)
+		return arg
+	}
+
+	if _, err := store.TransferTx(ctx, payment("60.00")); !errors.Is(err, ErrPerTransactionLimit) {
+		t.Fatalf("expected per-transaction limit, got %v", err)
+	}
+	if _, err := store.TransferTx(ctx, payment("40.00")); err != nil {
+		t.Fatalf("transfer within limits: %v", err)
+	}
+	if _, err := store.TransferTx(ctx, payment("45.00")); !errors.Is(err, ErrDailyLimit) {
+		t.Fatalf("expected daily limit, got %v", err)
+	}
+
+	// Online transfers do not count towards the offline cap.
+	offline := []TransferTxParams{payment("35.00"), payment("20.00")}
+	synced, err := store.SyncTx(ctx, SyncTxParams{
+		WalletID:     fromWallet.ID,
+		Transactions: offline,
+		Devices: []DeviceAttestation{
+			attestTestTransfer(t, device, deviceKey, offline[0]),
+			attestTestTransfer(t, device, deviceKey, offline[1]),
+		},
+	})
+	if err != nil {
+		t.Fatalf("sync tx: %v", err)
+	}
+	if synced.Results[0].Status != SyncItemFailed || synced.Results[0].Error != ErrOfflineLimit.Error() {
+		t.Fatalf("expected offline cap failure, got %s: %s", synced.Results[0].Status, synced.Results[0].Error)
+	}
+	if synced.Results[1].Status != SyncItemSettled {
+		t.Fatalf("expected second offline payment to settle, got %s: %s", synced.Results[1].Status, synced.Results[1].Error)
+	}
+
+	usage, err := store.GetWalletLimitUsage(ctx, fromWallet.ID)
+	if err != nil {
+		t.Fatalf("get wallet limit usage: %v", err)
+	}
+	assertFloatApprox(t, numericToFloat64(t, usage.DailyOutflow), 60.00)
+	// The sync moved last_synced_at past the offline payment.
+	assertFloatApprox(t, numericToFloat64(t, usage.OfflineExposure), 0)
+
+	// Offline payments above the confirmation threshold are refused.
+	large := payment("15.00")
+	synced, err = store.SyncTx(ctx, SyncTxParams{
+		WalletID:     fromWallet.ID,
+		Transactions: []TransferTxParams{large},
+		Devices:      []DeviceAttestation{attestTestTransfer(t, device, deviceKey, large)},
+		MaxAmount:    numericFromString(t, "10.00"),
+	})
+	if err != nil {
+		t.Fatalf("sync tx: %v", err)
+	}
+	if synced.Results[0].Status != SyncItemFailed || synced.Results[0].Error != ErrOfflineAboveLimit.Error() {
+		t.Fatalf("expected threshold failure, got %s: %s", synced.Results[0].Status, synced.Results[0].Error)
+	}
+}
+
+func TestSettleTxChecksLimits(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet := createTestWallet(t, ctx, store.Queries)
+	toWallet := createTestWallet(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM sync_logs WHERE wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1 OR id = $2", fromWallet.ID, toWallet.ID)
+	}()
+
+	if _, err := store.UpsertWalletLimits(ctx, UpsertWalletLimitsParams{
+		WalletID:            fromWallet.ID,
+		PerTransactionLimit: numericFromString(t, "50.00"),
+		DailyLimit:          numericFromString(t, "100.00"),
+	}); err != nil {
+		t.Fatalf("upsert wallet limits: %v", err)
+	}
+
+	// Recorded without a limit check, as POST /transactions does.
+	over := createTestTransaction(t, ctx, store.Queries, fromWallet.ID, toWallet.ID, "60.00", TransactionStatusConfirmed)
+	if _, err := store.SettleTx(ctx, over.ID); !errors.Is(err, ErrPerTransactionLimit) {
+		t.Fatalf("expected per-transaction limit, got %v", err)
+	}
+
+	// The settled payment counts once: 60 + 30 is within the daily limit.
+	within := createTestTransaction(t, ctx, store.Queries, fromWallet.ID, toWallet.ID, "30.00", TransactionStatusConfirmed)
+	if _, err := store.SettleTx(ctx, within.ID); err != nil {
+		t.Fatalf("settle within limits: %v", err)
+	}
+
+	balance, err := store.GetWalletBalance(ctx, fromWallet.ID)
+	if err != nil {
+		t.Fatalf("get wallet balance: %v", err)
+	}
+	assertFloatApprox(t, numericToFloat64(t, balance), 70.00)
+}
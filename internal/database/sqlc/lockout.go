@@ -0,0 +1,261 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MaxCredentialAttempts is the number of consecutive failures after
+	// which a PIN, password or second factor is locked.
+	MaxCredentialAttempts = 5
+
+	credentialBackoff    = time.Second
+	credentialLockout    = 15 * time.Minute
+	maxCredentialLockout = 24 * time.Hour
+)
+
+var ErrInvalidCredential = errors.New("invalid credentials")
+
+// CredentialLockError is returned while a PIN, password or second factor may
+// not be tried.
+// Locked is false during the short backoff that follows each failure and
+// true once MaxCredentialAttempts is reached.
+type CredentialLockError struct {
+	Until  time.Time
+	Locked bool
+}
+
+func (e *CredentialLockError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("locked after too many failed attempts until %s", e.Until.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("too many attempts, retry after %s", e.Until.Format(time.RFC3339))
+}
+
+// credentialDelay returns how long the next attempt is refused after the
+// given number of consecutive failures: an exponential backoff from one
+// second, then a lockout from fifteen minutes that doubles with every
+// further failure, capped at a day.
+func credentialDelay(failures int32) (time.Duration, bool) {
+	if failures < MaxCredentialAttempts {
+		return credentialBackoff << (failures - 1), false
+	}
+	delay := credentialLockout
+	for i := int32(MaxCredentialAttempts); i < failures && delay < maxCredentialLockout; i++ {
+		delay *= 2
+	}
+	return min(delay, maxCredentialLockout), true
+}
+
+// CredentialAudit identifies who made a credential attempt, for audit_logs.
+type CredentialAudit struct {
+	ChangedBy pgtype.UUID
+	IpAddress *netip.Addr
+	UserAgent *string
+}
+
+// VerifyCredentialTxParams contains the input parameters of a PIN or
+// password check.
+type VerifyCredentialTxParams struct {
+	Kind      CredentialKind
+	SubjectID uuid.UUID
+	Hash      string
+	Secret    string
+	Audit     CredentialAudit
+}
+
+// VerifyCredentialTx compares a PIN or password with its bcrypt hash while
+// holding the subject's attempt counter, so concurrent guesses are counted
+// one after another.
+//
+// A failure is committed and ErrInvalidCredential returned, or a
+// *CredentialLockError once the failure locks the credential. Attempts made
+// before the backoff or lockout has passed are refused without being
+// checked. A success resets the counter.
+func (store *Store) VerifyCredentialTx(ctx context.Context, arg VerifyCredentialTxParams) error {
+	var failed error
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		attempts, err := lockCredential(ctx, q, arg.Kind, arg.SubjectID)
+		if err != nil {
+			return err
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(arg.Hash), []byte(arg.Secret)); err == nil {
+			return resetCredential(ctx, q, attempts)
+		}
+
+		// Commit the failure rather than rolling it back with an error.
+		lockErr, err := countCredentialFailure(ctx, q, attempts, arg.Audit)
+		if err != nil {
+			return err
+		}
+		failed = ErrInvalidCredential
+		if lockErr != nil {
+			failed = lockErr
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return failed
+}
+
+// lockCredential takes the subject's attempt counter for the rest of the
+// transaction. While the backoff or lockout lasts it returns a
+// *CredentialLockError, and the attempt must not be checked.
+func lockCredential(ctx context.Context, q *Queries, kind CredentialKind, subjectID uuid.UUID) (CredentialAttempt, error) {
+	attempts, err := q.LockCredentialAttempts(ctx, LockCredentialAttemptsParams{
+		Kind:      kind,
+		SubjectID: subjectID,
+	})
+	if err != nil {
+		return attempts, err
+	}
+	if attempts.LockedUntil.Valid && attempts.LockedUntil.Time.After(time.Now()) {
+		return attempts, &CredentialLockError{
+			Until:  attempts.LockedUntil.Time,
+			Locked: attempts.FailedAttempts >= MaxCredentialAttempts,
+		}
+	}
+	return attempts, nil
+}
+
+// resetCredential clears the counter taken by lockCredential after a
+// successful attempt.
+func resetCredential(ctx context.Context, q *Queries, attempts CredentialAttempt) error {
+	if attempts.FailedAttempts == 0 {
+		return nil
+	}
+	return q.ResetCredentialAttempts(ctx, ResetCredentialAttemptsParams{
+		Kind:      attempts.Kind,
+		SubjectID: attempts.SubjectID,
+	})
+}
+
+// countCredentialFailure counts a failed attempt on the counter taken by
+// lockCredential and returns a *CredentialLockError once the failure locks
+// the credential. The caller must commit, not roll back, for the failure to
+// count.
+func countCredentialFailure(ctx context.Context, q *Queries, attempts CredentialAttempt, audit CredentialAudit) (*CredentialLockError, error) {
+	failures := attempts.FailedAttempts + 1
+	delay, locked := credentialDelay(failures)
+	attempts, err := q.RecordCredentialFailure(ctx, RecordCredentialFailureParams{
+		Kind:           attempts.Kind,
+		SubjectID:      attempts.SubjectID,
+		FailedAttempts: failures,
+		LockedUntil:    pgtype.Timestamptz{Time: time.Now().UTC().Add(delay), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, nil
+	}
+	lockErr := &CredentialLockError{Until: attempts.LockedUntil.Time, Locked: true}
+	return lockErr, auditCredential(ctx, q, attempts.Kind, attempts.SubjectID, "LOCKOUT", attempts, audit)
+}
+
+// ResetWalletPINTxParams contains the input parameters of a PIN reset. OTP
+// is set when the reset is authorized with a pin_reset code rather than the
+// account password.
+type ResetWalletPINTxParams struct {
+	WalletID uuid.UUID
+	PinHash  string
+	OTP      *OTPCheck
+	Audit    CredentialAudit
+}
+
+// ResetWalletPINTx sets a new PIN and clears any backoff or lockout on it.
+// Lifting a lockout is recorded in audit_logs.
+func (store *Store) ResetWalletPINTx(ctx context.Context, arg ResetWalletPINTxParams) error {
+	invalid := false
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if _, err := q.GetWalletForUpdate(ctx, arg.WalletID); err != nil {
+			return err
+		}
+		if arg.OTP != nil {
+			_, ok, err := checkOTP(ctx, q, *arg.OTP)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				invalid = true
+				return nil
+			}
+		}
+		if err := q.UpdateWalletPIN(ctx, UpdateWalletPINParams{
+			ID:      arg.WalletID,
+			PinHash: arg.PinHash,
+		}); err != nil {
+			return err
+		}
+
+		attempts, err := q.LockCredentialAttempts(ctx, LockCredentialAttemptsParams{
+			Kind:      CredentialKindWalletPin,
+			SubjectID: arg.WalletID,
+		})
+		if err != nil {
+			return err
+		}
+		if attempts.FailedAttempts == 0 {
+			return nil
+		}
+		if err := q.ResetCredentialAttempts(ctx, ResetCredentialAttemptsParams{
+			Kind:      CredentialKindWalletPin,
+			SubjectID: arg.WalletID,
+		}); err != nil {
+			return err
+		}
+		if attempts.FailedAttempts < MaxCredentialAttempts {
+			return nil
+		}
+		return auditCredential(ctx, q, CredentialKindWalletPin, arg.WalletID, "UNLOCK", attempts, arg.Audit)
+	})
+	if err != nil {
+		return err
+	}
+	if invalid {
+		return ErrOTPInvalid
+	}
+
+	return nil
+}
+
+func auditCredential(ctx context.Context, q *Queries, kind CredentialKind, subjectID uuid.UUID, action string, attempts CredentialAttempt, audit CredentialAudit) error {
+	data, err := json.Marshal(map[string]any{
+		"kind":            kind,
+		"failed_attempts": attempts.FailedAttempts,
+		"locked_until":    attempts.LockedUntil.Time,
+	})
+	if err != nil {
+		return err
+	}
+
+	tableName := "users"
+	if kind == CredentialKindWalletPin {
+		tableName = "wallets"
+	}
+	_, err = q.CreateAuditLog(ctx, CreateAuditLogParams{
+		TableName: tableName,
+		RecordID:  subjectID,
+		Action:    action,
+		NewData:   data,
+		ChangedBy: audit.ChangedBy,
+		IpAddress: audit.IpAddress,
+		UserAgent: audit.UserAgent,
+	})
+	return err
+}
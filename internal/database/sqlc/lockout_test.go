@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCredentialDelay(t *testing.T) {
+	cases := []struct {
+		failures int32
+		delay    time.Duration
+		locked   bool
+	}{
+		{1, time.Second, false},
+		{4, 8 * time.Second, false},
+		{5, 15 * time.Minute, true},
+		{6, 30 * time.Minute, true},
+		{12, 24 * time.Hour, true},
+		{100, 24 * time.Hour, true},
+	}
+	for _, tc := range cases {
+		delay, locked := credentialDelay(tc.failures)
+		if delay != tc.delay || locked != tc.locked {
+			t.Fatalf("failures %d: expected %s locked=%v, got %s locked=%v", tc.failures, tc.delay, tc.locked, delay, locked)
+		}
+	}
+}
+
+func TestVerifyCredentialTxLocksWallet(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	wallet := createTestWallet(t, ctx, store.Queries)
+	pinHash, err := bcrypt.GenerateFromPassword([]byte("1234"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash pin: %v", err)
+	}
+
+	defer func() {
+		deleteTestLedger(ctx, wallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM audit_logs WHERE record_id = $1", wallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM credential_attempts WHERE subject_id = $1", wallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1", wallet.ID)
+	}()
+
+	arg := VerifyCredentialTxParams{
+		Kind:      CredentialKindWalletPin,
+		SubjectID: wallet.ID,
+		Hash:      string(pinHash),
+		Secret:    "0000",
+	}
+	// skipBackoff lets the next attempt through as if the delay had passed.
+	skipBackoff := func() {
+		t.Helper()
+		if _, err := testPool.Exec(ctx, "UPDATE credential_attempts SET locked_until = NULL WHERE subject_id = $1", wallet.ID); err != nil {
+			t.Fatalf("clear backoff: %v", err)
+		}
+	}
+
+	if err := store.VerifyCredentialTx(ctx, arg); !errors.Is(err, ErrInvalidCredential) {
+		t.Fatalf("expected ErrInvalidCredential, got %v", err)
+	}
+	var lockErr *CredentialLockError
+	if err := store.VerifyCredentialTx(ctx, arg); !errors.As(err, &lockErr) || lockErr.Locked {
+		t.Fatalf("expected a backoff error, got %v", err)
+	}
+
+	for i := 2; i < MaxCredentialAttempts; i++ {
+		skipBackoff()
+		if err := store.VerifyCredentialTx(ctx, arg); !errors.Is(err, ErrInvalidCredential) {
+			t.Fatalf("attempt %d: expected ErrInvalidCredential, got %v", i, err)
+		}
+	}
+	skipBackoff()
+	if err := store.VerifyCredentialTx(ctx, arg); !errors.As(err, &lockErr) || !lockErr.Locked {
+		t.Fatalf("expected the wallet to be locked, got %v", err)
+	}
+
+	// The right PIN is refused while locked.
+	arg.Secret = "1234"
+	if err := store.VerifyCredentialTx(ctx, arg); !errors.As(err, &lockErr) || !lockErr.Locked {
+		t.Fatalf("expected the lockout to hold, got %v", err)
+	}
+
+	var lockouts int
+	if err := testPool.QueryRow(ctx, "SELECT COUNT(*) FROM audit_logs WHERE record_id = $1 AND action = 'LOCKOUT'", wallet.ID).Scan(&lockouts); err != nil {
+		t.Fatalf("count lockouts: %v", err)
+	}
+	if lockouts != 1 {
+		t.Fatalf("expected 1 lockout audit log, got %d", lockouts)
+	}
+
+	newHash, err := bcrypt.GenerateFromPassword([]byte("5678"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash pin: %v", err)
+	}
+	if err := store.ResetWalletPINTx(ctx, ResetWalletPINTxParams{
+		WalletID: wallet.ID,
+		PinHash:  string(newHash),
+	}); err != nil {
+		t.Fatalf("reset pin: %v", err)
+	}
+
+	arg.Hash = string(newHash)
+	arg.Secret = "5678"
+	if err := store.VerifyCredentialTx(ctx, arg); err != nil {
+		t.Fatalf("expected the new PIN to be accepted, got %v", err)
+	}
+	attempts, err := store.GetCredentialAttempts(ctx, GetCredentialAttemptsParams{
+		Kind:      CredentialKindWalletPin,
+		SubjectID: wallet.ID,
+	})
+	if err != nil {
+		t.Fatalf("get attempts: %v", err)
+	}
+	if attempts.FailedAttempts != 0 || attempts.LockedUntil.Valid {
+		t.Fatalf("expected the counter to be reset, got %d until %v", attempts.FailedAttempts, attempts.LockedUntil)
+	}
+}
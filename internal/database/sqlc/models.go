@@ -75,15 +75,571 @@ func AllConnectionTypeValues() []ConnectionType {
 	}
 }
 
+type CredentialKind string
+
+const (
+	CredentialKindWalletPin    CredentialKind = "wallet_pin"
+	CredentialKindUserPassword CredentialKind = "user_password"
+	CredentialKindTwoFactor    CredentialKind = "two_factor"
+)
+
+func (e *CredentialKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = CredentialKind(s)
+	case string:
+		*e = CredentialKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for CredentialKind: %T", src)
+	}
+	return nil
+}
+
+type NullCredentialKind struct {
+	CredentialKind CredentialKind `json:"credential_kind"`
+	Valid          bool           `json:"valid"` // Valid is true if CredentialKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCredentialKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.CredentialKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.CredentialKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCredentialKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.CredentialKind), nil
+}
+
+func (e CredentialKind) Valid() bool {
+	switch e {
+	case CredentialKindWalletPin,
+		CredentialKindUserPassword,
+		CredentialKindTwoFactor:
+		return true
+	}
+	return false
+}
+
+func AllCredentialKindValues() []CredentialKind {
+	return []CredentialKind{
+		CredentialKindWalletPin,
+		CredentialKindUserPassword,
+		CredentialKindTwoFactor,
+	}
+}
+
+type JobRunStatus string
+
+const (
+	JobRunStatusRunning   JobRunStatus = "running"
+	JobRunStatusSucceeded JobRunStatus = "succeeded"
+	JobRunStatusFailed    JobRunStatus = "failed"
+)
+
+func (e *JobRunStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = JobRunStatus(s)
+	case string:
+		*e = JobRunStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for JobRunStatus: %T", src)
+	}
+	return nil
+}
+
+type NullJobRunStatus struct {
+	JobRunStatus JobRunStatus `json:"job_run_status"`
+	Valid        bool         `json:"valid"` // Valid is true if JobRunStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullJobRunStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.JobRunStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.JobRunStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullJobRunStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.JobRunStatus), nil
+}
+
+func (e JobRunStatus) Valid() bool {
+	switch e {
+	case JobRunStatusRunning,
+		JobRunStatusSucceeded,
+		JobRunStatusFailed:
+		return true
+	}
+	return false
+}
+
+func AllJobRunStatusValues() []JobRunStatus {
+	return []JobRunStatus{
+		JobRunStatusRunning,
+		JobRunStatusSucceeded,
+		JobRunStatusFailed,
+	}
+}
+
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "schedule"
+	JobTriggerManual   JobTrigger = "manual"
+)
+
+func (e *JobTrigger) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = JobTrigger(s)
+	case string:
+		*e = JobTrigger(s)
+	default:
+		return fmt.Errorf("unsupported scan type for JobTrigger: %T", src)
+	}
+	return nil
+}
+
+type NullJobTrigger struct {
+	JobTrigger JobTrigger `json:"job_trigger"`
+	Valid      bool       `json:"valid"` // Valid is true if JobTrigger is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullJobTrigger) Scan(value interface{}) error {
+	if value == nil {
+		ns.JobTrigger, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.JobTrigger.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullJobTrigger) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.JobTrigger), nil
+}
+
+func (e JobTrigger) Valid() bool {
+	switch e {
+	case JobTriggerSchedule,
+		JobTriggerManual:
+		return true
+	}
+	return false
+}
+
+func AllJobTriggerValues() []JobTrigger {
+	return []JobTrigger{
+		JobTriggerSchedule,
+		JobTriggerManual,
+	}
+}
+
+type LedgerDirection string
+
+const (
+	LedgerDirectionDebit  LedgerDirection = "debit"
+	LedgerDirectionCredit LedgerDirection = "credit"
+)
+
+func (e *LedgerDirection) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LedgerDirection(s)
+	case string:
+		*e = LedgerDirection(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LedgerDirection: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerDirection struct {
+	LedgerDirection LedgerDirection `json:"ledger_direction"`
+	Valid           bool            `json:"valid"` // Valid is true if LedgerDirection is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerDirection) Scan(value interface{}) error {
+	if value == nil {
+		ns.LedgerDirection, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LedgerDirection.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerDirection) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LedgerDirection), nil
+}
+
+func (e LedgerDirection) Valid() bool {
+	switch e {
+	case LedgerDirectionDebit,
+		LedgerDirectionCredit:
+		return true
+	}
+	return false
+}
+
+func AllLedgerDirectionValues() []LedgerDirection {
+	return []LedgerDirection{
+		LedgerDirectionDebit,
+		LedgerDirectionCredit,
+	}
+}
+
+type LedgerEntryType string
+
+const (
+	LedgerEntryTypeOpening    LedgerEntryType = "opening"
+	LedgerEntryTypeTransfer   LedgerEntryType = "transfer"
+	LedgerEntryTypeDeposit    LedgerEntryType = "deposit"
+	LedgerEntryTypeWithdrawal LedgerEntryType = "withdrawal"
+	LedgerEntryTypeAdjustment LedgerEntryType = "adjustment"
+	LedgerEntryTypeReversal   LedgerEntryType = "reversal"
+)
+
+func (e *LedgerEntryType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LedgerEntryType(s)
+	case string:
+		*e = LedgerEntryType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LedgerEntryType: %T", src)
+	}
+	return nil
+}
+
+type NullLedgerEntryType struct {
+	LedgerEntryType LedgerEntryType `json:"ledger_entry_type"`
+	Valid           bool            `json:"valid"` // Valid is true if LedgerEntryType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLedgerEntryType) Scan(value interface{}) error {
+	if value == nil {
+		ns.LedgerEntryType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LedgerEntryType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLedgerEntryType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LedgerEntryType), nil
+}
+
+func (e LedgerEntryType) Valid() bool {
+	switch e {
+	case LedgerEntryTypeOpening,
+		LedgerEntryTypeTransfer,
+		LedgerEntryTypeDeposit,
+		LedgerEntryTypeWithdrawal,
+		LedgerEntryTypeAdjustment,
+		LedgerEntryTypeReversal:
+		return true
+	}
+	return false
+}
+
+func AllLedgerEntryTypeValues() []LedgerEntryType {
+	return []LedgerEntryType{
+		LedgerEntryTypeOpening,
+		LedgerEntryTypeTransfer,
+		LedgerEntryTypeDeposit,
+		LedgerEntryTypeWithdrawal,
+		LedgerEntryTypeAdjustment,
+		LedgerEntryTypeReversal,
+	}
+}
+
+type OtpPurpose string
+
+const (
+	OtpPurposePhoneVerification    OtpPurpose = "phone_verification"
+	OtpPurposePasswordReset        OtpPurpose = "password_reset"
+	OtpPurposePinReset             OtpPurpose = "pin_reset"
+	OtpPurposeTransferConfirmation OtpPurpose = "transfer_confirmation"
+)
+
+func (e *OtpPurpose) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = OtpPurpose(s)
+	case string:
+		*e = OtpPurpose(s)
+	default:
+		return fmt.Errorf("unsupported scan type for OtpPurpose: %T", src)
+	}
+	return nil
+}
+
+type NullOtpPurpose struct {
+	OtpPurpose OtpPurpose `json:"otp_purpose"`
+	Valid      bool       `json:"valid"` // Valid is true if OtpPurpose is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullOtpPurpose) Scan(value interface{}) error {
+	if value == nil {
+		ns.OtpPurpose, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.OtpPurpose.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullOtpPurpose) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.OtpPurpose), nil
+}
+
+func (e OtpPurpose) Valid() bool {
+	switch e {
+	case OtpPurposePhoneVerification,
+		OtpPurposePasswordReset,
+		OtpPurposePinReset,
+		OtpPurposeTransferConfirmation:
+		return true
+	}
+	return false
+}
+
+func AllOtpPurposeValues() []OtpPurpose {
+	return []OtpPurpose{
+		OtpPurposePhoneVerification,
+		OtpPurposePasswordReset,
+		OtpPurposePinReset,
+		OtpPurposeTransferConfirmation,
+	}
+}
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending   OutboxStatus = "pending"
+	OutboxStatusPublished OutboxStatus = "published"
+	OutboxStatusFailed    OutboxStatus = "failed"
+)
+
+func (e *OutboxStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = OutboxStatus(s)
+	case string:
+		*e = OutboxStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for OutboxStatus: %T", src)
+	}
+	return nil
+}
+
+type NullOutboxStatus struct {
+	OutboxStatus OutboxStatus `json:"outbox_status"`
+	Valid        bool         `json:"valid"` // Valid is true if OutboxStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullOutboxStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.OutboxStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.OutboxStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullOutboxStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.OutboxStatus), nil
+}
+
+func (e OutboxStatus) Valid() bool {
+	switch e {
+	case OutboxStatusPending,
+		OutboxStatusPublished,
+		OutboxStatusFailed:
+		return true
+	}
+	return false
+}
+
+func AllOutboxStatusValues() []OutboxStatus {
+	return []OutboxStatus{
+		OutboxStatusPending,
+		OutboxStatusPublished,
+		OutboxStatusFailed,
+	}
+}
+
+type PaymentRequestStatus string
+
+const (
+	PaymentRequestStatusPending   PaymentRequestStatus = "pending"
+	PaymentRequestStatusFulfilled PaymentRequestStatus = "fulfilled"
+	PaymentRequestStatusCancelled PaymentRequestStatus = "cancelled"
+)
+
+func (e *PaymentRequestStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PaymentRequestStatus(s)
+	case string:
+		*e = PaymentRequestStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PaymentRequestStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPaymentRequestStatus struct {
+	PaymentRequestStatus PaymentRequestStatus `json:"payment_request_status"`
+	Valid                bool                 `json:"valid"` // Valid is true if PaymentRequestStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPaymentRequestStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PaymentRequestStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PaymentRequestStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPaymentRequestStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PaymentRequestStatus), nil
+}
+
+func (e PaymentRequestStatus) Valid() bool {
+	switch e {
+	case PaymentRequestStatusPending,
+		PaymentRequestStatusFulfilled,
+		PaymentRequestStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func AllPaymentRequestStatusValues() []PaymentRequestStatus {
+	return []PaymentRequestStatus{
+		PaymentRequestStatusPending,
+		PaymentRequestStatusFulfilled,
+		PaymentRequestStatusCancelled,
+	}
+}
+
+type SyncResolution string
+
+const (
+	SyncResolutionForceAccept SyncResolution = "force_accept"
+	SyncResolutionReject      SyncResolution = "reject"
+	SyncResolutionSplit       SyncResolution = "split"
+)
+
+func (e *SyncResolution) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SyncResolution(s)
+	case string:
+		*e = SyncResolution(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SyncResolution: %T", src)
+	}
+	return nil
+}
+
+type NullSyncResolution struct {
+	SyncResolution SyncResolution `json:"sync_resolution"`
+	Valid          bool           `json:"valid"` // Valid is true if SyncResolution is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSyncResolution) Scan(value interface{}) error {
+	if value == nil {
+		ns.SyncResolution, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SyncResolution.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSyncResolution) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SyncResolution), nil
+}
+
+func (e SyncResolution) Valid() bool {
+	switch e {
+	case SyncResolutionForceAccept,
+		SyncResolutionReject,
+		SyncResolutionSplit:
+		return true
+	}
+	return false
+}
+
+func AllSyncResolutionValues() []SyncResolution {
+	return []SyncResolution{
+		SyncResolutionForceAccept,
+		SyncResolutionReject,
+		SyncResolutionSplit,
+	}
+}
+
 type SyncStatus string
 
 const (
-	SyncStatusPending   SyncStatus = "pending"
-	SyncStatusConfirmed SyncStatus = "confirmed"
-	SyncStatusSettling  SyncStatus = "settling"
-	SyncStatusSettled   SyncStatus = "settled"
-	SyncStatusFailed    SyncStatus = "failed"
-	SyncStatusConflict  SyncStatus = "conflict"
+	SyncStatusPending    SyncStatus = "pending"
+	SyncStatusConfirmed  SyncStatus = "confirmed"
+	SyncStatusSettling   SyncStatus = "settling"
+	SyncStatusSettled    SyncStatus = "settled"
+	SyncStatusFailed     SyncStatus = "failed"
+	SyncStatusConflict   SyncStatus = "conflict"
+	SyncStatusDeadLetter SyncStatus = "dead_letter"
 )
 
 func (e *SyncStatus) Scan(src interface{}) error {
@@ -128,7 +684,8 @@ func (e SyncStatus) Valid() bool {
 		SyncStatusSettling,
 		SyncStatusSettled,
 		SyncStatusFailed,
-		SyncStatusConflict:
+		SyncStatusConflict,
+		SyncStatusDeadLetter:
 		return true
 	}
 	return false
@@ -142,6 +699,7 @@ func AllSyncStatusValues() []SyncStatus {
 		SyncStatusSettled,
 		SyncStatusFailed,
 		SyncStatusConflict,
+		SyncStatusDeadLetter,
 	}
 }
 
@@ -221,6 +779,8 @@ const (
 	TransactionTypeP2p      TransactionType = "p2p"
 	TransactionTypeDeposit  TransactionType = "deposit"
 	TransactionTypeWithdraw TransactionType = "withdraw"
+	TransactionTypeRefund   TransactionType = "refund"
+	TransactionTypeReversal TransactionType = "reversal"
 )
 
 func (e *TransactionType) Scan(src interface{}) error {
@@ -262,7 +822,9 @@ func (e TransactionType) Valid() bool {
 	switch e {
 	case TransactionTypeP2p,
 		TransactionTypeDeposit,
-		TransactionTypeWithdraw:
+		TransactionTypeWithdraw,
+		TransactionTypeRefund,
+		TransactionTypeReversal:
 		return true
 	}
 	return false
@@ -273,7 +835,202 @@ func AllTransactionTypeValues() []TransactionType {
 		TransactionTypeP2p,
 		TransactionTypeDeposit,
 		TransactionTypeWithdraw,
+		TransactionTypeRefund,
+		TransactionTypeReversal,
+	}
+}
+
+type TwoFactorMethod string
+
+const (
+	TwoFactorMethodTotp TwoFactorMethod = "totp"
+	TwoFactorMethodOtp  TwoFactorMethod = "otp"
+)
+
+func (e *TwoFactorMethod) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TwoFactorMethod(s)
+	case string:
+		*e = TwoFactorMethod(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TwoFactorMethod: %T", src)
+	}
+	return nil
+}
+
+type NullTwoFactorMethod struct {
+	TwoFactorMethod TwoFactorMethod `json:"two_factor_method"`
+	Valid           bool            `json:"valid"` // Valid is true if TwoFactorMethod is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTwoFactorMethod) Scan(value interface{}) error {
+	if value == nil {
+		ns.TwoFactorMethod, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TwoFactorMethod.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTwoFactorMethod) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TwoFactorMethod), nil
+}
+
+func (e TwoFactorMethod) Valid() bool {
+	switch e {
+	case TwoFactorMethodTotp,
+		TwoFactorMethodOtp:
+		return true
+	}
+	return false
+}
+
+func AllTwoFactorMethodValues() []TwoFactorMethod {
+	return []TwoFactorMethod{
+		TwoFactorMethodTotp,
+		TwoFactorMethodOtp,
+	}
+}
+
+type UserRole string
+
+const (
+	UserRoleUser    UserRole = "user"
+	UserRoleAgent   UserRole = "agent"
+	UserRoleSupport UserRole = "support"
+	UserRoleAdmin   UserRole = "admin"
+)
+
+func (e *UserRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = UserRole(s)
+	case string:
+		*e = UserRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for UserRole: %T", src)
+	}
+	return nil
+}
+
+type NullUserRole struct {
+	UserRole UserRole `json:"user_role"`
+	Valid    bool     `json:"valid"` // Valid is true if UserRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullUserRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.UserRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.UserRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullUserRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.UserRole), nil
+}
+
+func (e UserRole) Valid() bool {
+	switch e {
+	case UserRoleUser,
+		UserRoleAgent,
+		UserRoleSupport,
+		UserRoleAdmin:
+		return true
+	}
+	return false
+}
+
+func AllUserRoleValues() []UserRole {
+	return []UserRole{
+		UserRoleUser,
+		UserRoleAgent,
+		UserRoleSupport,
+		UserRoleAdmin,
+	}
+}
+
+type WebhookOutboxStatus string
+
+const (
+	WebhookOutboxStatusPending   WebhookOutboxStatus = "pending"
+	WebhookOutboxStatusDelivered WebhookOutboxStatus = "delivered"
+	WebhookOutboxStatusFailed    WebhookOutboxStatus = "failed"
+)
+
+func (e *WebhookOutboxStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookOutboxStatus(s)
+	case string:
+		*e = WebhookOutboxStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookOutboxStatus: %T", src)
 	}
+	return nil
+}
+
+type NullWebhookOutboxStatus struct {
+	WebhookOutboxStatus WebhookOutboxStatus `json:"webhook_outbox_status"`
+	Valid               bool                `json:"valid"` // Valid is true if WebhookOutboxStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWebhookOutboxStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WebhookOutboxStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WebhookOutboxStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWebhookOutboxStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WebhookOutboxStatus), nil
+}
+
+func (e WebhookOutboxStatus) Valid() bool {
+	switch e {
+	case WebhookOutboxStatusPending,
+		WebhookOutboxStatusDelivered,
+		WebhookOutboxStatusFailed:
+		return true
+	}
+	return false
+}
+
+func AllWebhookOutboxStatusValues() []WebhookOutboxStatus {
+	return []WebhookOutboxStatus{
+		WebhookOutboxStatusPending,
+		WebhookOutboxStatusDelivered,
+		WebhookOutboxStatusFailed,
+	}
+}
+
+// Wallets allowed to exchange cash for e-money (deposits and withdrawals)
+type Agent struct {
+	WalletID uuid.UUID `json:"wallet_id"`
+	// Maximum e-money balance the agent wallet may hold
+	FloatLimit pgtype.Numeric     `json:"float_limit"`
+	IsActive   bool               `json:"is_active"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
 }
 
 // Audit trail for all data modifications
@@ -290,6 +1047,144 @@ type AuditLog struct {
 	UserAgent *string            `json:"user_agent"`
 }
 
+// Failed PIN and password attempts per wallet or user, for backoff and lockout
+type CredentialAttempt struct {
+	Kind CredentialKind `json:"kind"`
+	// Wallet ID for wallet_pin, user ID for user_password and two_factor
+	SubjectID      uuid.UUID          `json:"subject_id"`
+	FailedAttempts int32              `json:"failed_attempts"`
+	LastFailedAt   pgtype.Timestamptz `json:"last_failed_at"`
+	// No attempt is checked before this time (backoff or lockout)
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+// Devices registered by a user; logins and offline payments are signed with the device key
+type Device struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// Identifier reported by the client, as in wallets.device_id
+	DeviceID    string             `json:"device_id"`
+	Name        *string            `json:"name"`
+	PublicKey   string             `json:"public_key"`
+	VerifiedAt  pgtype.Timestamptz `json:"verified_at"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
+	FirstSeenAt pgtype.Timestamptz `json:"first_seen_at"`
+	LastSeenAt  pgtype.Timestamptz `json:"last_seen_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+// Single-use nonces a device signs to prove it holds its key at login
+type DeviceChallenge struct {
+	ID        uuid.UUID          `json:"id"`
+	UserID    uuid.UUID          `json:"user_id"`
+	DeviceID  string             `json:"device_id"`
+	Challenge string             `json:"challenge"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// Stored responses for requests retried with the same Idempotency-Key
+type IdempotencyKey struct {
+	ID             uuid.UUID `json:"id"`
+	UserID         uuid.UUID `json:"user_id"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	// SHA-256 of method, path and body of the original request
+	RequestHash    string             `json:"request_hash"`
+	ResponseStatus *int32             `json:"response_status"`
+	ResponseBody   []byte             `json:"response_body"`
+	CompletedAt    pgtype.Timestamptz `json:"completed_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+// One row per run of a background maintenance job
+type JobRun struct {
+	ID      uuid.UUID  `json:"id"`
+	JobName string     `json:"job_name"`
+	Trigger JobTrigger `json:"trigger"`
+	// Admin who ran the job by hand; NULL for scheduled runs
+	TriggeredBy pgtype.UUID  `json:"triggered_by"`
+	Status      JobRunStatus `json:"status"`
+	// Short summary of what the job did
+	Result     *string            `json:"result"`
+	Error      *string            `json:"error"`
+	StartedAt  pgtype.Timestamptz `json:"started_at"`
+	FinishedAt pgtype.Timestamptz `json:"finished_at"`
+	// The schedule slot a scheduled run claimed, so each slot runs once across servers; NULL for manual runs
+	ScheduledAt pgtype.Timestamptz `json:"scheduled_at"`
+}
+
+// Immutable double-entry ledger; wallets.balance is a projection of it
+type LedgerEntry struct {
+	ID uuid.UUID `json:"id"`
+	// Groups the debit and credit of one money movement
+	PostingID     uuid.UUID   `json:"posting_id"`
+	TransactionID pgtype.UUID `json:"transaction_id"`
+	WalletID      pgtype.UUID `json:"wallet_id"`
+	// wallet, or a system:* account on the other side of deposits and adjustments
+	Account     string             `json:"account"`
+	Direction   LedgerDirection    `json:"direction"`
+	EntryType   LedgerEntryType    `json:"entry_type"`
+	Amount      pgtype.Numeric     `json:"amount"`
+	Description *string            `json:"description"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+// One-time codes sent by SMS for phone verification, password reset and PIN reset
+type OtpCode struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	PhoneNumber string     `json:"phone_number"`
+	Purpose     OtpPurpose `json:"purpose"`
+	// Wallet ID for pin_reset codes
+	SubjectID pgtype.UUID `json:"subject_id"`
+	// Bcrypt hash of the code
+	CodeHash   string             `json:"code_hash"`
+	Attempts   int32              `json:"attempts"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	ConsumedAt pgtype.Timestamptz `json:"consumed_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+// Invoices a merchant wallet shows as a QR code for a payer to settle
+// Domain events written in the same transaction as the change they describe, relayed to in-process subscribers
+type Outbox struct {
+	ID        uuid.UUID `json:"id"`
+	EventType string    `json:"event_type"`
+	// The wallet, transaction or sync log the event is about
+	AggregateID uuid.UUID    `json:"aggregate_id"`
+	Payload     []byte       `json:"payload"`
+	Status      OutboxStatus `json:"status"`
+	Attempts    int32        `json:"attempts"`
+	// When the event is due; pushed forward while a relay holds it
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	LastError     *string            `json:"last_error"`
+	PublishedAt   pgtype.Timestamptz `json:"published_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+type PaymentRequest struct {
+	ID uuid.UUID `json:"id"`
+	// Wallet that receives the payment
+	WalletID    uuid.UUID            `json:"wallet_id"`
+	Amount      pgtype.Numeric       `json:"amount"`
+	Currency    string               `json:"currency"`
+	Description *string              `json:"description"`
+	Status      PaymentRequestStatus `json:"status"`
+	ExpiresAt   pgtype.Timestamptz   `json:"expires_at"`
+	// Transaction that paid the request, linked through its metadata.payment_request_id
+	TransactionID pgtype.UUID        `json:"transaction_id"`
+	FulfilledAt   pgtype.Timestamptz `json:"fulfilled_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
 // Known peers for each wallet with connection history
 type Peer struct {
 	ID               uuid.UUID          `json:"id"`
@@ -309,6 +1204,30 @@ type Peer struct {
 	DeletedAt        pgtype.Timestamptz `json:"deleted_at"`
 }
 
+// Single-use refresh tokens; presenting a used one revokes its session
+type RefreshToken struct {
+	ID        uuid.UUID `json:"id"`
+	SessionID uuid.UUID `json:"session_id"`
+	// SHA-256 of the refresh token; the token itself is never stored
+	TokenHash string             `json:"token_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// Login sessions; access tokens carry the session ID and stop working once it is revoked
+type Session struct {
+	ID              uuid.UUID          `json:"id"`
+	UserID          uuid.UUID          `json:"user_id"`
+	UserAgent       *string            `json:"user_agent"`
+	ClientIp        *netip.Addr        `json:"client_ip"`
+	ExpiresAt       pgtype.Timestamptz `json:"expires_at"`
+	RevokedAt       pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	LastRefreshedAt pgtype.Timestamptz `json:"last_refreshed_at"`
+	// Device the session was started on; revoking the device ends the session
+	DeviceID pgtype.UUID `json:"device_id"`
+}
+
 // Synchronization logs for offline transactions
 type SyncLog struct {
 	ID            uuid.UUID          `json:"id"`
@@ -322,6 +1241,28 @@ type SyncLog struct {
 	ResolvedAt    pgtype.Timestamptz `json:"resolved_at"`
 	CreatedAt     pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	// How an operator cleared a settlement conflict
+	Resolution     NullSyncResolution `json:"resolution"`
+	ResolvedBy     pgtype.UUID        `json:"resolved_by"`
+	ResolutionNote *string            `json:"resolution_note"`
+	// Amount actually moved when a conflict was resolved
+	ResolvedAmount pgtype.Numeric `json:"resolved_amount"`
+}
+
+// Authenticator app secrets; a secret is enrolled once confirmed_at is set
+type TotpSecret struct {
+	UserID uuid.UUID `json:"user_id"`
+	// Plaintext secret stored by older versions, until the server seals it at startup
+	Secret      *string            `json:"secret"`
+	ConfirmedAt pgtype.Timestamptz `json:"confirmed_at"`
+	// Time step of the last accepted code, so a code cannot be replayed
+	LastUsedStep int64              `json:"last_used_step"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	// Identifies the master key that encrypted the data key
+	MasterKeyID      *string `json:"master_key_id"`
+	EncryptedDataKey []byte  `json:"encrypted_data_key"`
+	EncryptedSecret  []byte  `json:"encrypted_secret"`
 }
 
 // All payment transactions between wallets
@@ -347,6 +1288,35 @@ type Transaction struct {
 	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
 }
 
+// Links refund and reversal transactions to the transaction they pay back
+type TransactionReversal struct {
+	// The refund or reversal transaction
+	TransactionID uuid.UUID `json:"transaction_id"`
+	// The transaction being paid back
+	OriginalTransactionID uuid.UUID `json:"original_transaction_id"`
+	Reason                *string   `json:"reason"`
+	// User who made the refund or reversal
+	CreatedBy pgtype.UUID        `json:"created_by"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// High-value transfers waiting for a TOTP or SMS code
+type TransferChallenge struct {
+	ID           uuid.UUID       `json:"id"`
+	UserID       uuid.UUID       `json:"user_id"`
+	FromWalletID uuid.UUID       `json:"from_wallet_id"`
+	ToWalletID   uuid.UUID       `json:"to_wallet_id"`
+	Amount       pgtype.Numeric  `json:"amount"`
+	Method       TwoFactorMethod `json:"method"`
+	// The signed transfer, carried out once the challenge is confirmed
+	Transfer      []byte             `json:"transfer"`
+	Attempts      int32              `json:"attempts"`
+	ExpiresAt     pgtype.Timestamptz `json:"expires_at"`
+	ConfirmedAt   pgtype.Timestamptz `json:"confirmed_at"`
+	TransactionID pgtype.UUID        `json:"transaction_id"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
 type User struct {
 	ID           uuid.UUID          `json:"id"`
 	PhoneNumber  string             `json:"phone_number"`
@@ -354,13 +1324,16 @@ type User struct {
 	PasswordHash string             `json:"password_hash"`
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	// Access role; copied into the JWT role claim at login
+	Role UserRole `json:"role"`
+	// When the user proved ownership of phone_number with an OTP
+	PhoneVerifiedAt pgtype.Timestamptz `json:"phone_verified_at"`
 }
 
 // User wallet information with cryptographic keys and balance
 type Wallet struct {
-	ID         uuid.UUID `json:"id"`
-	PublicKey  string    `json:"public_key"`
-	PrivateKey string    `json:"private_key"`
+	ID        uuid.UUID `json:"id"`
+	PublicKey string    `json:"public_key"`
 	// Current balance in NPR (Nepali Rupees)
 	Balance     pgtype.Numeric `json:"balance"`
 	PhoneNumber string         `json:"phone_number"`
@@ -375,3 +1348,92 @@ type Wallet struct {
 	DeletedAt    pgtype.Timestamptz `json:"deleted_at"`
 	UserID       pgtype.UUID        `json:"user_id"`
 }
+
+// Server-held private keys of custodial wallets, envelope encrypted
+type WalletCustodialKey struct {
+	WalletID uuid.UUID `json:"wallet_id"`
+	// Identifies the master key that encrypted the data key
+	MasterKeyID         *string `json:"master_key_id"`
+	EncryptedDataKey    []byte  `json:"encrypted_data_key"`
+	EncryptedPrivateKey []byte  `json:"encrypted_private_key"`
+	// Plaintext key copied from wallets.private_key, until the server seals it at startup
+	LegacyPrivateKey *string            `json:"legacy_private_key"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	// Why the legacy key could not be sealed; its plaintext has been discarded
+	LegacyKeyError *string `json:"legacy_key_error"`
+}
+
+// Public key history of each wallet; offline transactions are verified with the key active at transaction_at
+type WalletKey struct {
+	ID        uuid.UUID          `json:"id"`
+	WalletID  uuid.UUID          `json:"wallet_id"`
+	PublicKey string             `json:"public_key"`
+	ValidFrom pgtype.Timestamptz `json:"valid_from"`
+	// When the key was rotated out; NULL for the current key
+	ValidUntil pgtype.Timestamptz `json:"valid_until"`
+	// Signature of the handover by the previous key; NULL for the first key
+	RotationSignature *string            `json:"rotation_signature"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	// When the key was reported compromised; offline transactions are no longer verified with it
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+	// Why the key was revoked
+	RevokedReason *string `json:"revoked_reason"`
+}
+
+// Configurable spending limits per wallet
+type WalletLimit struct {
+	WalletID            uuid.UUID      `json:"wallet_id"`
+	PerTransactionLimit pgtype.Numeric `json:"per_transaction_limit"`
+	DailyLimit          pgtype.Numeric `json:"daily_limit"`
+	MonthlyLimit        pgtype.Numeric `json:"monthly_limit"`
+	// Cap on offline spend not yet synced since the wallet last synced
+	OfflineLimit pgtype.Numeric     `json:"offline_limit"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+}
+
+// One row per attempt to deliver an outbox event
+type WebhookDelivery struct {
+	ID         uuid.UUID          `json:"id"`
+	OutboxID   uuid.UUID          `json:"outbox_id"`
+	Attempt    int32              `json:"attempt"`
+	StatusCode *int32             `json:"status_code"`
+	Error      *string            `json:"error"`
+	DurationMs int32              `json:"duration_ms"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+// Events waiting to be delivered, queued from the domain events relayed off the outbox
+type WebhookOutbox struct {
+	ID             uuid.UUID           `json:"id"`
+	SubscriptionID uuid.UUID           `json:"subscription_id"`
+	EventType      string              `json:"event_type"`
+	Payload        []byte              `json:"payload"`
+	Status         WebhookOutboxStatus `json:"status"`
+	Attempts       int32               `json:"attempts"`
+	// When the event is due; pushed forward while a dispatcher holds it
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	LastError     *string            `json:"last_error"`
+	DeliveredAt   pgtype.Timestamptz `json:"delivered_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	// The outbox domain event this webhook event was queued for, so a relayed event is queued once per subscription
+	SourceEventID pgtype.UUID `json:"source_event_id"`
+}
+
+// Endpoints that receive signed transaction events for a user or one wallet
+type WebhookSubscription struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	// Only events for this wallet; NULL for every wallet of the user
+	WalletID pgtype.UUID `json:"wallet_id"`
+	Url      string      `json:"url"`
+	// HMAC-SHA256 key for the X-PayOn-Signature header
+	Secret string `json:"secret"`
+	// Event types to send; empty for all
+	EventTypes []string           `json:"event_types"`
+	IsActive   bool               `json:"is_active"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// OTPDuration is how long a code stays valid.
+	OTPDuration = 5 * time.Minute
+	// MaxOTPAttempts is the number of wrong guesses after which a code is
+	// no longer accepted.
+	MaxOTPAttempts = 5
+
+	otpResendDelay  = time.Minute
+	otpWindow       = time.Hour
+	maxOTPsInWindow = 5
+)
+
+var ErrOTPInvalid = errors.New("code is invalid or expired")
+
+// OTPRateLimitError is returned when a code was sent to the phone number too
+// recently or too often.
+type OTPRateLimitError struct {
+	RetryAt time.Time
+}
+
+func (e *OTPRateLimitError) Error() string {
+	return fmt.Sprintf("too many codes requested, retry after %s", e.RetryAt.Format(time.RFC3339))
+}
+
+// IssueOTPTxParams contains the input parameters of a new one-time code.
+type IssueOTPTxParams struct {
+	UserID      uuid.UUID
+	PhoneNumber string
+	Purpose     OtpPurpose
+	SubjectID   pgtype.UUID
+	Code        string
+}
+
+// IssueOTPTx stores a new code for the phone number and purpose, replacing
+// the user's unused codes for that purpose. Codes are rate limited per phone
+// number: one a minute and five an hour.
+func (store *Store) IssueOTPTx(ctx context.Context, arg IssueOTPTxParams) (OtpCode, error) {
+	codeHash, err := bcrypt.GenerateFromPassword([]byte(arg.Code), bcrypt.DefaultCost)
+	if err != nil {
+		return OtpCode{}, err
+	}
+
+	var code OtpCode
+	err = store.execTx(ctx, func(q *Queries) error {
+		now := time.Now().UTC()
+		stats, err := q.GetOTPSendStats(ctx, GetOTPSendStatsParams{
+			PhoneNumber: arg.PhoneNumber,
+			Purpose:     arg.Purpose,
+			CreatedAt:   pgtype.Timestamptz{Time: now.Add(-otpWindow), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if stats.LastSentAt.Valid && now.Before(stats.LastSentAt.Time.Add(otpResendDelay)) {
+			return &OTPRateLimitError{RetryAt: stats.LastSentAt.Time.Add(otpResendDelay)}
+		}
+		if stats.Sent >= maxOTPsInWindow {
+			return &OTPRateLimitError{RetryAt: stats.FirstSentAt.Time.Add(otpWindow)}
+		}
+
+		if err := q.ConsumeUserOTPCodes(ctx, ConsumeUserOTPCodesParams{
+			UserID:  arg.UserID,
+			Purpose: arg.Purpose,
+		}); err != nil {
+			return err
+		}
+		code, err = q.CreateOTPCode(ctx, CreateOTPCodeParams{
+			UserID:      arg.UserID,
+			PhoneNumber: arg.PhoneNumber,
+			Purpose:     arg.Purpose,
+			SubjectID:   arg.SubjectID,
+			CodeHash:    string(codeHash),
+			ExpiresAt:   pgtype.Timestamptz{Time: now.Add(OTPDuration), Valid: true},
+		})
+		return err
+	})
+
+	return code, err
+}
+
+// OTPCheck is a code presented by the user. SubjectID must match the wallet
+// a pin_reset code was issued for.
+type OTPCheck struct {
+	PhoneNumber string
+	Purpose     OtpPurpose
+	SubjectID   pgtype.UUID
+	Code        string
+}
+
+// checkOTP consumes the newest unused code for the phone number and purpose
+// if the presented code matches it. A wrong code counts as an attempt and
+// ok is false; the caller commits the attempt and returns ErrOTPInvalid.
+func checkOTP(ctx context.Context, q *Queries, check OTPCheck) (code OtpCode, ok bool, err error) {
+	code, err = q.GetActiveOTPCodeForUpdate(ctx, GetActiveOTPCodeForUpdateParams{
+		PhoneNumber: check.PhoneNumber,
+		Purpose:     check.Purpose,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return code, false, nil
+		}
+		return code, false, err
+	}
+	if !code.ExpiresAt.Time.After(time.Now()) || code.Attempts >= MaxOTPAttempts {
+		return code, false, nil
+	}
+	if code.SubjectID != check.SubjectID {
+		return code, false, nil
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(code.CodeHash), []byte(check.Code)); err != nil {
+		code, err = q.IncrementOTPAttempts(ctx, code.ID)
+		return code, false, err
+	}
+	return code, true, q.ConsumeOTPCode(ctx, code.ID)
+}
+
+// VerifyPhoneTx marks the user's phone number as verified with a
+// phone_verification code.
+func (store *Store) VerifyPhoneTx(ctx context.Context, userID uuid.UUID, code string) (User, error) {
+	var user User
+	invalid := false
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.GetUserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		_, ok, err := checkOTP(ctx, q, OTPCheck{
+			PhoneNumber: user.PhoneNumber,
+			Purpose:     OtpPurposePhoneVerification,
+			Code:        code,
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			invalid = true
+			return nil
+		}
+		user, err = q.MarkUserPhoneVerified(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+	if invalid {
+		return User{}, ErrOTPInvalid
+	}
+
+	return user, nil
+}
+
+// ResetPasswordTxParams contains the input parameters of a password reset.
+type ResetPasswordTxParams struct {
+	PhoneNumber  string
+	Code         string
+	PasswordHash string
+}
+
+// ResetPasswordTx sets a new password with a password_reset code. It lifts a
+// password lockout and ends all of the user's sessions.
+func (store *Store) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error) {
+	var user User
+	invalid := false
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		code, ok, err := checkOTP(ctx, q, OTPCheck{
+			PhoneNumber: arg.PhoneNumber,
+			Purpose:     OtpPurposePasswordReset,
+			Code:        arg.Code,
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			invalid = true
+			return nil
+		}
+
+		if err := q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			ID:           code.UserID,
+			PasswordHash: arg.PasswordHash,
+		}); err != nil {
+			return err
+		}
+		if err := q.ResetCredentialAttempts(ctx, ResetCredentialAttemptsParams{
+			Kind:      CredentialKindUserPassword,
+			SubjectID: code.UserID,
+		}); err != nil {
+			return err
+		}
+		if _, err := q.RevokeUserSessions(ctx, code.UserID); err != nil {
+			return err
+		}
+		user, err = q.GetUserByID(ctx, code.UserID)
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+	if invalid {
+		return User{}, ErrOTPInvalid
+	}
+
+	return user, nil
+}
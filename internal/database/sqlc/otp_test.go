@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestVerifyPhoneTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	user, err := store.CreateUser(ctx, CreateUserParams{
+		PhoneNumber:  nextPhoneNumber(),
+		PasswordHash: "password-hash",
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	defer func() {
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", user.ID)
+	}()
+
+	issue := IssueOTPTxParams{
+		UserID:      user.ID,
+		PhoneNumber: user.PhoneNumber,
+		Purpose:     OtpPurposePhoneVerification,
+		Code:        "123456",
+	}
+	if _, err := store.IssueOTPTx(ctx, issue); err != nil {
+		t.Fatalf("issue code: %v", err)
+	}
+
+	// A second code within the resend delay is refused.
+	_, err = store.IssueOTPTx(ctx, issue)
+	var rateErr *OTPRateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("expected OTPRateLimitError, got %v", err)
+	}
+
+	if _, err := store.VerifyPhoneTx(ctx, user.ID, "000000"); !errors.Is(err, ErrOTPInvalid) {
+		t.Fatalf("expected ErrOTPInvalid, got %v", err)
+	}
+	verified, err := store.VerifyPhoneTx(ctx, user.ID, "123456")
+	if err != nil {
+		t.Fatalf("verify phone: %v", err)
+	}
+	if !verified.PhoneVerifiedAt.Valid {
+		t.Fatalf("expected the phone number to be verified")
+	}
+
+	// The code is consumed.
+	if _, err := store.VerifyPhoneTx(ctx, user.ID, "123456"); !errors.Is(err, ErrOTPInvalid) {
+		t.Fatalf("expected a used code to be refused, got %v", err)
+	}
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	user, err := store.CreateUser(ctx, CreateUserParams{
+		PhoneNumber:  nextPhoneNumber(),
+		PasswordHash: "password-hash",
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	defer func() {
+		_, _ = testPool.Exec(ctx, "DELETE FROM credential_attempts WHERE subject_id = $1", user.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", user.ID)
+	}()
+
+	if _, err := store.IssueOTPTx(ctx, IssueOTPTxParams{
+		UserID:      user.ID,
+		PhoneNumber: user.PhoneNumber,
+		Purpose:     OtpPurposePasswordReset,
+		Code:        "654321",
+	}); err != nil {
+		t.Fatalf("issue code: %v", err)
+	}
+
+	arg := ResetPasswordTxParams{
+		PhoneNumber:  user.PhoneNumber,
+		Code:         "000000",
+		PasswordHash: "new-password-hash",
+	}
+	// Wrong guesses use up the code.
+	for i := 0; i < MaxOTPAttempts; i++ {
+		if _, err := store.ResetPasswordTx(ctx, arg); !errors.Is(err, ErrOTPInvalid) {
+			t.Fatalf("attempt %d: expected ErrOTPInvalid, got %v", i+1, err)
+		}
+	}
+	arg.Code = "654321"
+	if _, err := store.ResetPasswordTx(ctx, arg); !errors.Is(err, ErrOTPInvalid) {
+		t.Fatalf("expected the code to be spent after %d attempts, got %v", MaxOTPAttempts, err)
+	}
+
+	// A fresh code resets the password, even past the resend delay.
+	if _, err := testPool.Exec(ctx, "UPDATE otp_codes SET created_at = created_at - interval '2 minutes' WHERE user_id = $1", user.ID); err != nil {
+		t.Fatalf("age codes: %v", err)
+	}
+	if _, err := store.IssueOTPTx(ctx, IssueOTPTxParams{
+		UserID:      user.ID,
+		PhoneNumber: user.PhoneNumber,
+		Purpose:     OtpPurposePasswordReset,
+		Code:        "654321",
+	}); err != nil {
+		t.Fatalf("issue code: %v", err)
+	}
+	updated, err := store.ResetPasswordTx(ctx, arg)
+	if err != nil {
+		t.Fatalf("reset password: %v", err)
+	}
+	if updated.PasswordHash != "new-password-hash" {
+		t.Fatalf("expected the new password hash, got %q", updated.PasswordHash)
+	}
+}
+
+func TestResetWalletPINTxWithOTP(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	user, err := store.CreateUser(ctx, CreateUserParams{
+		PhoneNumber:  nextPhoneNumber(),
+		PasswordHash: "password-hash",
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	wallet := createTestWallet(t, ctx, store.Queries)
+	other := createTestWallet(t, ctx, store.Queries)
+	defer func() {
+		deleteTestLedger(ctx, wallet.ID, other.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id IN ($1, $2)", wallet.ID, other.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", user.ID)
+	}()
+
+	if _, err := store.IssueOTPTx(ctx, IssueOTPTxParams{
+		UserID:      user.ID,
+		PhoneNumber: user.PhoneNumber,
+		Purpose:     OtpPurposePinReset,
+		SubjectID:   pgtype.UUID{Bytes: wallet.ID, Valid: true},
+		Code:        "111111",
+	}); err != nil {
+		t.Fatalf("issue code: %v", err)
+	}
+
+	pinHash, err := bcrypt.GenerateFromPassword([]byte("4321"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash pin: %v", err)
+	}
+	check := OTPCheck{
+		PhoneNumber: user.PhoneNumber,
+		Purpose:     OtpPurposePinReset,
+		SubjectID:   pgtype.UUID{Bytes: other.ID, Valid: true},
+		Code:        "111111",
+	}
+	// The code only resets the wallet it was issued for.
+	err = store.ResetWalletPINTx(ctx, ResetWalletPINTxParams{WalletID: other.ID, PinHash: string(pinHash), OTP: &check})
+	if !errors.Is(err, ErrOTPInvalid) {
+		t.Fatalf("expected ErrOTPInvalid for another wallet, got %v", err)
+	}
+
+	check.SubjectID = pgtype.UUID{Bytes: wallet.ID, Valid: true}
+	if err := store.ResetWalletPINTx(ctx, ResetWalletPINTxParams{WalletID: wallet.ID, PinHash: string(pinHash), OTP: &check}); err != nil {
+		t.Fatalf("reset PIN: %v", err)
+	}
+	updated, err := store.GetWalletByID(ctx, wallet.ID)
+	if err != nil {
+		t.Fatalf("get wallet: %v", err)
+	}
+	if updated.PinHash != string(pinHash) {
+		t.Fatalf("expected the new PIN hash")
+	}
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PaymentRequestMetadataKey is the transaction metadata field naming the
+// payment request a transfer pays.
+const PaymentRequestMetadataKey = "payment_request_id"
+
+var (
+	ErrPaymentRequestNotFound   = errors.New("payment request not found")
+	ErrPaymentRequestNotPayable = errors.New("payment request is fulfilled, cancelled or expired")
+	ErrPaymentRequestMismatch   = errors.New("transfer does not match the payment request's wallet, amount or currency")
+)
+
+// paymentRequestID returns the payment request named in transaction
+// metadata, if any.
+func paymentRequestID(metadata []byte) (uuid.UUID, bool, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(metadata, &fields); err != nil {
+		// Metadata that is not an object cannot name a request.
+		return uuid.Nil, false, nil
+	}
+	value, ok := fields[PaymentRequestMetadataKey]
+	if !ok {
+		return uuid.Nil, false, nil
+	}
+	text, _ := value.(string)
+	id, err := uuid.Parse(text)
+	if err != nil {
+		return uuid.Nil, false, ErrPaymentRequestNotFound
+	}
+	return id, true, nil
+}
+
+// linkPaymentRequest marks the payment request named in the
+// transaction's metadata as paid by it. The request must still have been
+// open at transaction_at, so an offline payment made before the expiry can
+// be synced later, and the transfer must match it exactly.
+func linkPaymentRequest(ctx context.Context, q *Queries, transaction Transaction) error {
+	id, ok, err := paymentRequestID(transaction.Metadata)
+	if err != nil || !ok {
+		return err
+	}
+
+	request, err := q.GetPaymentRequestForUpdate(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrPaymentRequestNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	paidAt := time.Now()
+	if transaction.TransactionAt.Valid {
+		paidAt = transaction.TransactionAt.Time
+	}
+	if request.Status != PaymentRequestStatusPending || !paidAt.Before(request.ExpiresAt.Time) {
+		return ErrPaymentRequestNotPayable
+	}
+	if request.WalletID != transaction.ToWalletID ||
+		request.Currency != transaction.Currency ||
+		numericCmp(request.Amount, transaction.Amount) != 0 {
+		return ErrPaymentRequestMismatch
+	}
+
+	_, err = q.FulfillPaymentRequest(ctx, FulfillPaymentRequestParams{
+		ID:            request.ID,
+		TransactionID: pgtype.UUID{Bytes: transaction.ID, Valid: true},
+	})
+	return err
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestTransferTxFulfillsPaymentRequest(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet, fromKey := createTestWalletWithKey(t, ctx, store.Queries)
+	toWallet := createTestWallet(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM payment_requests WHERE wallet_id = $1", toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id IN ($1, $2)", fromWallet.ID, toWallet.ID)
+	}()
+
+	request, err := store.CreatePaymentRequest(ctx, CreatePaymentRequestParams{
+		WalletID:  toWallet.ID,
+		Amount:    numericFromString(t, "25.00"),
+		Currency:  "NPR",
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().UTC().Add(time.Hour), Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("create payment request: %v", err)
+	}
+	metadata := []byte(fmt.Sprintf(`{%q: %q}`, PaymentRequestMetadataKey, request.ID))
+
+	mismatched := TransferTxParams{
+		FromWalletID: fromWallet.ID,
+		ToWalletID:   toWallet.ID,
+		Amount:       numericFromString(t, "20.00"),
+		Metadata:     metadata,
+	}
+	signTestTransfer(t, fromKey, &mismatched)
+	if _, err := store.TransferTx(ctx, mismatched); !errors.Is(err, ErrPaymentRequestMismatch) {
+		t.Fatalf("expected ErrPaymentRequestMismatch, got %v", err)
+	}
+
+	arg := TransferTxParams{
+		FromWalletID: fromWallet.ID,
+		ToWalletID:   toWallet.ID,
+		Amount:       numericFromString(t, "25.00"),
+		Metadata:     metadata,
+	}
+	signTestTransfer(t, fromKey, &arg)
+	result, err := store.TransferTx(ctx, arg)
+	if err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	assertFloatApprox(t, numericToFloat64(t, result.FromWallet.Balance), 75)
+
+	fulfilled, err := store.GetPaymentRequest(ctx, request.ID)
+	if err != nil {
+		t.Fatalf("get payment request: %v", err)
+	}
+	if fulfilled.Status != PaymentRequestStatusFulfilled || fulfilled.TransactionID.Bytes != result.Transaction.ID {
+		t.Fatalf("unexpected payment request %+v", fulfilled)
+	}
+
+	again := TransferTxParams{
+		FromWalletID: fromWallet.ID,
+		ToWalletID:   toWallet.ID,
+		Amount:       numericFromString(t, "25.00"),
+		Metadata:     metadata,
+	}
+	signTestTransfer(t, fromKey, &again)
+	if _, err := store.TransferTx(ctx, again); !errors.Is(err, ErrPaymentRequestNotPayable) {
+		t.Fatalf("expected ErrPaymentRequestNotPayable, got %v", err)
+	}
+}
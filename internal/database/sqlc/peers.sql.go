@@ -382,6 +382,26 @@ func (q *Queries) IncrementPeerTransactionCount(ctx context.Context, arg Increme
 	return err
 }
 
+const isPeerOwner = `-- name: IsPeerOwner :one
+SELECT EXISTS(
+    SELECT 1 FROM peers p
+    JOIN wallets w ON w.id = p.wallet_id
+    WHERE p.id = $1 AND w.user_id = $2
+)
+`
+
+type IsPeerOwnerParams struct {
+	ID     uuid.UUID   `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) IsPeerOwner(ctx context.Context, arg IsPeerOwnerParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isPeerOwner, arg.ID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const listPeersByConnectionType = `-- name: ListPeersByConnectionType :many
 SELECT id, wallet_id, peer_wallet_id, name, public_key, ip_address, bt_address, connection_type, is_trusted, transaction_count, last_seen_at, first_seen_at, created_at, updated_at, deleted_at FROM peers
 WHERE wallet_id = $1 AND connection_type = $2 AND deleted_at IS NULL
@@ -572,6 +592,26 @@ func (q *Queries) ListTrustedPeers(ctx context.Context, walletID uuid.UUID) ([]P
 	return items, nil
 }
 
+const pruneStalePeers = `-- name: PruneStalePeers :execrows
+UPDATE peers
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM peers
+    WHERE last_seen_at < NOW() - INTERVAL '90 days'
+      AND deleted_at IS NULL
+    ORDER BY last_seen_at ASC
+    LIMIT $1
+)
+`
+
+func (q *Queries) PruneStalePeers(ctx context.Context, limit int32) (int64, error) {
+	result, err := q.db.Exec(ctx, pruneStalePeers, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const setPeerTrusted = `-- name: SetPeerTrusted :exec
 UPDATE peers
 SET is_trusted = $2, updated_at = NOW()
@@ -651,6 +691,25 @@ func (q *Queries) UpdatePeerLastSeen(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const updatePeerPublicKeys = `-- name: UpdatePeerPublicKeys :execrows
+UPDATE peers
+SET public_key = $2
+WHERE peer_wallet_id = $1
+`
+
+type UpdatePeerPublicKeysParams struct {
+	PeerWalletID uuid.UUID `json:"peer_wallet_id"`
+	PublicKey    string    `json:"public_key"`
+}
+
+func (q *Queries) UpdatePeerPublicKeys(ctx context.Context, arg UpdatePeerPublicKeysParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updatePeerPublicKeys, arg.PeerWalletID, arg.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const upsertPeer = `-- name: UpsertPeer :one
 INSERT INTO peers (
     wallet_id,
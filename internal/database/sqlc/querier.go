@@ -13,77 +13,185 @@ import (
 
 type Querier interface {
 	ActivateWallet(ctx context.Context, id uuid.UUID) error
+	AdvisoryUnlock(ctx context.Context, key string) (bool, error)
 	AutoTrustFrequentPeers(ctx context.Context, transactionCount *int32) error
+	CancelPaymentRequest(ctx context.Context, id uuid.UUID) (PaymentRequest, error)
 	CheckNonceExists(ctx context.Context, arg CheckNonceExistsParams) (bool, error)
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]Outbox, error)
+	ClaimWebhookOutbox(ctx context.Context, arg ClaimWebhookOutboxParams) ([]ClaimWebhookOutboxRow, error)
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) (IdempotencyKey, error)
+	ConfirmTOTPSecret(ctx context.Context, arg ConfirmTOTPSecretParams) (TotpSecret, error)
 	ConfirmTransaction(ctx context.Context, id uuid.UUID) (Transaction, error)
+	ConfirmTransferChallenge(ctx context.Context, arg ConfirmTransferChallengeParams) (TransferChallenge, error)
+	ConsumeOTPCode(ctx context.Context, id uuid.UUID) error
+	ConsumeUserOTPCodes(ctx context.Context, arg ConsumeUserOTPCodesParams) error
 	CountAuditLogs(ctx context.Context) (int64, error)
 	CountAuditLogsByTable(ctx context.Context, tableName string) (int64, error)
+	CountLegacyWalletCustodialKeys(ctx context.Context) (int64, error)
 	CountPeersByWallet(ctx context.Context, walletID uuid.UUID) (int64, error)
 	CountPendingTransactions(ctx context.Context) (int64, error)
+	CountPlaintextTOTPSecrets(ctx context.Context) (int64, error)
 	CountSyncLogsByStatus(ctx context.Context, status SyncStatus) (int64, error)
 	CountTransactionsByWallet(ctx context.Context, fromWalletID uuid.UUID) (int64, error)
 	CountTrustedPeers(ctx context.Context, walletID uuid.UUID) (int64, error)
+	CountUsersByRole(ctx context.Context, role UserRole) (int64, error)
+	CountVerifiedDevices(ctx context.Context, userID uuid.UUID) (int64, error)
 	CountWallets(ctx context.Context) (int64, error)
+	// internal/database/query/agents.sql
+	CreateAgent(ctx context.Context, arg CreateAgentParams) (Agent, error)
 	// internal/database/query/audit_logs.sql
 	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
+	// internal/database/query/devices.sql
+	CreateDevice(ctx context.Context, arg CreateDeviceParams) (Device, error)
+	CreateDeviceChallenge(ctx context.Context, arg CreateDeviceChallengeParams) (DeviceChallenge, error)
+	// internal/database/query/idempotency_keys.sql
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	// internal/database/query/job_runs.sql
+	CreateJobRun(ctx context.Context, arg CreateJobRunParams) (JobRun, error)
+	// internal/database/query/ledger.sql
+	CreateLedgerEntry(ctx context.Context, arg CreateLedgerEntryParams) (LedgerEntry, error)
+	// internal/database/query/otp_codes.sql
+	CreateOTPCode(ctx context.Context, arg CreateOTPCodeParams) (OtpCode, error)
+	// internal/database/query/outbox.sql
+	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (Outbox, error)
+	// internal/database/query/payment_requests.sql
+	CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error)
 	// internal/database/query/peers.sql
 	CreatePeer(ctx context.Context, arg CreatePeerParams) (Peer, error)
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
+	// internal/database/query/sessions.sql
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	// internal/database/query/sync_logs.sql
 	CreateSyncLog(ctx context.Context, arg CreateSyncLogParams) (SyncLog, error)
 	// internal/database/query/transactions.sql
 	CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error)
+	// internal/database/query/transaction_reversals.sql
+	CreateTransactionReversal(ctx context.Context, arg CreateTransactionReversalParams) (TransactionReversal, error)
+	// internal/database/query/transfer_challenges.sql
+	CreateTransferChallenge(ctx context.Context, arg CreateTransferChallengeParams) (TransferChallenge, error)
 	// internal/database/query/users.sql
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	// internal/database/query/wallets.sql
 	CreateWallet(ctx context.Context, arg CreateWalletParams) (Wallet, error)
+	// internal/database/query/wallet_custodial_keys.sql
+	CreateWalletCustodialKey(ctx context.Context, arg CreateWalletCustodialKeyParams) error
+	// internal/database/query/wallet_keys.sql
+	CreateWalletKey(ctx context.Context, arg CreateWalletKeyParams) (WalletKey, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	// internal/database/query/webhooks.sql
+	CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (WebhookSubscription, error)
 	DeactivateWallet(ctx context.Context, id uuid.UUID) error
+	DeadLetterExhaustedSyncs(ctx context.Context, attemptCount *int32) (int64, error)
+	DeadLetterSyncLog(ctx context.Context, arg DeadLetterSyncLogParams) (SyncLog, error)
 	DecrementWalletBalance(ctx context.Context, arg DecrementWalletBalanceParams) (Wallet, error)
+	DeleteExpiredDeviceChallenges(ctx context.Context, expiresAt pgtype.Timestamptz) (int64, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error)
+	DeleteExpiredOTPCodes(ctx context.Context, expiresAt pgtype.Timestamptz) (int64, error)
+	DeleteExpiredSessions(ctx context.Context, expiresAt pgtype.Timestamptz) (int64, error)
+	DeleteExpiredTransferChallenges(ctx context.Context, expiresAt pgtype.Timestamptz) (int64, error)
+	DeleteIdempotencyKey(ctx context.Context, id uuid.UUID) error
 	DeleteOldAuditLogs(ctx context.Context, dollar_1 *string) error
+	DeleteOldJobRuns(ctx context.Context, startedAt pgtype.Timestamptz) (int64, error)
+	DeleteOldOutboxEvents(ctx context.Context, publishedAt pgtype.Timestamptz) (int64, error)
 	DeleteOldSyncLogs(ctx context.Context, dollar_1 *string) error
+	DeleteOldWebhookEvents(ctx context.Context, updatedAt pgtype.Timestamptz) (int64, error)
 	DeletePeer(ctx context.Context, id uuid.UUID) error
+	DeleteTOTPSecret(ctx context.Context, userID uuid.UUID) error
+	DeleteWalletLimits(ctx context.Context, walletID uuid.UUID) error
+	DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error
+	EnqueueWebhookEvent(ctx context.Context, arg EnqueueWebhookEventParams) (int64, error)
+	FailOpenSyncLog(ctx context.Context, arg FailOpenSyncLogParams) (SyncLog, error)
 	FailTransaction(ctx context.Context, id uuid.UUID) error
+	FinishJobRun(ctx context.Context, arg FinishJobRunParams) (JobRun, error)
+	FulfillPaymentRequest(ctx context.Context, arg FulfillPaymentRequestParams) (PaymentRequest, error)
+	GetActiveOTPCodeForUpdate(ctx context.Context, arg GetActiveOTPCodeForUpdateParams) (OtpCode, error)
+	GetActiveSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetAgent(ctx context.Context, walletID uuid.UUID) (Agent, error)
+	GetAgentForUpdate(ctx context.Context, walletID uuid.UUID) (Agent, error)
 	GetAuditLogByID(ctx context.Context, id uuid.UUID) (AuditLog, error)
 	GetBalanceHistory(ctx context.Context, arg GetBalanceHistoryParams) ([]GetBalanceHistoryRow, error)
+	GetCredentialAttempts(ctx context.Context, arg GetCredentialAttemptsParams) (CredentialAttempt, error)
 	GetDailyTransactionSummary(ctx context.Context, fromWalletID uuid.UUID) ([]GetDailyTransactionSummaryRow, error)
+	GetDevice(ctx context.Context, id uuid.UUID) (Device, error)
+	GetDeviceChallengeForUpdate(ctx context.Context, id uuid.UUID) (DeviceChallenge, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
 	GetLargeTransactions(ctx context.Context, arg GetLargeTransactionsParams) ([]Transaction, error)
+	GetOTPSendStats(ctx context.Context, arg GetOTPSendStatsParams) (GetOTPSendStatsRow, error)
+	GetOfflineExposure(ctx context.Context, arg GetOfflineExposureParams) (pgtype.Numeric, error)
+	GetPaymentRequest(ctx context.Context, id uuid.UUID) (PaymentRequest, error)
+	GetPaymentRequestForUpdate(ctx context.Context, id uuid.UUID) (PaymentRequest, error)
 	GetPeerByID(ctx context.Context, id uuid.UUID) (Peer, error)
 	GetPeerByWalletAndPeerID(ctx context.Context, arg GetPeerByWalletAndPeerIDParams) (Peer, error)
 	GetRecentAuditLogs(ctx context.Context, limit int32) ([]AuditLog, error)
 	GetRecentTransactions(ctx context.Context, limit int32) ([]GetRecentTransactionsRow, error)
 	GetRecordHistory(ctx context.Context, arg GetRecordHistoryParams) ([]AuditLog, error)
+	GetRefreshTokenForUpdate(ctx context.Context, tokenHash string) (RefreshToken, error)
+	GetRefundableAmount(ctx context.Context, arg GetRefundableAmountParams) (pgtype.Numeric, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetSessionForUpdate(ctx context.Context, id uuid.UUID) (Session, error)
 	GetStalePeers(ctx context.Context, limit int32) ([]Peer, error)
 	GetSyncLogByID(ctx context.Context, id uuid.UUID) (SyncLog, error)
+	GetSyncLogForUpdate(ctx context.Context, id uuid.UUID) (SyncLog, error)
 	GetSyncLogsByTransaction(ctx context.Context, transactionID uuid.UUID) ([]SyncLog, error)
 	GetSyncLogsByWallet(ctx context.Context, arg GetSyncLogsByWalletParams) ([]SyncLog, error)
 	GetSyncStats(ctx context.Context, walletID uuid.UUID) (GetSyncStatsRow, error)
 	GetSyncsNeedingRetry(ctx context.Context, arg GetSyncsNeedingRetryParams) ([]SyncLog, error)
 	GetSystemStats(ctx context.Context) (GetSystemStatsRow, error)
+	GetTOTPSecret(ctx context.Context, userID uuid.UUID) (TotpSecret, error)
+	GetTOTPSecretForUpdate(ctx context.Context, userID uuid.UUID) (TotpSecret, error)
 	GetTopPeersByTransactionCount(ctx context.Context, arg GetTopPeersByTransactionCountParams) ([]Peer, error)
 	GetTopPeersByVolume(ctx context.Context, arg GetTopPeersByVolumeParams) ([]GetTopPeersByVolumeRow, error)
 	GetTransactionByID(ctx context.Context, id uuid.UUID) (Transaction, error)
+	GetTransactionByWalletNonce(ctx context.Context, arg GetTransactionByWalletNonceParams) (Transaction, error)
+	GetTransactionForUpdate(ctx context.Context, id uuid.UUID) (Transaction, error)
 	GetTransactionStats(ctx context.Context, fromWalletID uuid.UUID) (GetTransactionStatsRow, error)
 	GetTransactionWithWallets(ctx context.Context, id uuid.UUID) (GetTransactionWithWalletsRow, error)
 	GetTransactionsByConnectionType(ctx context.Context, arg GetTransactionsByConnectionTypeParams) ([]Transaction, error)
 	GetTransactionsByDateRange(ctx context.Context, arg GetTransactionsByDateRangeParams) ([]Transaction, error)
 	GetTransactionsByMetadata(ctx context.Context, arg GetTransactionsByMetadataParams) ([]Transaction, error)
+	GetTransferChallenge(ctx context.Context, id uuid.UUID) (TransferChallenge, error)
+	GetTransferChallengeForUpdate(ctx context.Context, id uuid.UUID) (TransferChallenge, error)
+	GetTransferChallengeStats(ctx context.Context, arg GetTransferChallengeStatsParams) (GetTransferChallengeStatsRow, error)
 	GetUserByEmail(ctx context.Context, email *string) (User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
 	GetUserByPhone(ctx context.Context, phoneNumber string) (User, error)
+	GetUserDevice(ctx context.Context, arg GetUserDeviceParams) (Device, error)
 	GetWalletBalance(ctx context.Context, id uuid.UUID) (pgtype.Numeric, error)
 	GetWalletBalanceHistory(ctx context.Context, arg GetWalletBalanceHistoryParams) ([]GetWalletBalanceHistoryRow, error)
 	GetWalletByDeviceID(ctx context.Context, deviceID *string) (Wallet, error)
 	GetWalletByID(ctx context.Context, id uuid.UUID) (Wallet, error)
 	GetWalletByPhoneNumber(ctx context.Context, phoneNumber string) (Wallet, error)
 	GetWalletByPublicKey(ctx context.Context, publicKey string) (Wallet, error)
+	GetWalletCustodialKey(ctx context.Context, walletID uuid.UUID) (WalletCustodialKey, error)
 	// internal/database/query/utils.sql
 	GetWalletDashboard(ctx context.Context, id uuid.UUID) (GetWalletDashboardRow, error)
+	GetWalletForUpdate(ctx context.Context, id uuid.UUID) (Wallet, error)
+	GetWalletKeyAt(ctx context.Context, arg GetWalletKeyAtParams) (WalletKey, error)
+	GetWalletKeyForUpdate(ctx context.Context, arg GetWalletKeyForUpdateParams) (WalletKey, error)
+	GetWalletLedgerBalance(ctx context.Context, walletID pgtype.UUID) (pgtype.Numeric, error)
+	// internal/database/query/wallet_limits.sql
+	GetWalletLimits(ctx context.Context, walletID uuid.UUID) (WalletLimit, error)
+	GetWalletOutflow(ctx context.Context, fromWalletID uuid.UUID) (GetWalletOutflowRow, error)
+	GetWalletOwnerDevice(ctx context.Context, arg GetWalletOwnerDeviceParams) (Device, error)
 	GetWalletWithBalance(ctx context.Context, id uuid.UUID) (GetWalletWithBalanceRow, error)
 	GetWalletsNeedingSync(ctx context.Context, limit int32) ([]Wallet, error)
+	GetWebhookSubscription(ctx context.Context, id uuid.UUID) (WebhookSubscription, error)
 	HardDeletePeer(ctx context.Context, id uuid.UUID) error
-	HardDeleteWallet(ctx context.Context, id uuid.UUID) error
+	HardDeleteWallet(ctx context.Context, id uuid.UUID) (int64, error)
+	IncrementOTPAttempts(ctx context.Context, id uuid.UUID) (OtpCode, error)
 	IncrementPeerTransactionCount(ctx context.Context, arg IncrementPeerTransactionCountParams) error
+	IncrementTransferChallengeAttempts(ctx context.Context, id uuid.UUID) (TransferChallenge, error)
 	IncrementWalletBalance(ctx context.Context, arg IncrementWalletBalanceParams) (Wallet, error)
+	IsDeviceOwner(ctx context.Context, arg IsDeviceOwnerParams) (bool, error)
+	IsPeerOwner(ctx context.Context, arg IsPeerOwnerParams) (bool, error)
+	IsSessionActive(ctx context.Context, id uuid.UUID) (bool, error)
+	IsSyncLogOwner(ctx context.Context, arg IsSyncLogOwnerParams) (bool, error)
+	IsTransactionParticipant(ctx context.Context, arg IsTransactionParticipantParams) (bool, error)
+	IsWalletKeyUsed(ctx context.Context, publicKey string) (bool, error)
+	IsWalletOwner(ctx context.Context, arg IsWalletOwnerParams) (bool, error)
+	ListActiveSessionsByUser(ctx context.Context, userID uuid.UUID) ([]Session, error)
 	ListActiveWallets(ctx context.Context, arg ListActiveWalletsParams) ([]Wallet, error)
+	ListAgents(ctx context.Context, arg ListAgentsParams) ([]Agent, error)
 	ListAllPendingSyncs(ctx context.Context, arg ListAllPendingSyncsParams) ([]SyncLog, error)
 	ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error)
 	ListAuditLogsByAction(ctx context.Context, arg ListAuditLogsByActionParams) ([]AuditLog, error)
@@ -92,25 +200,73 @@ type Querier interface {
 	ListAuditLogsByRecord(ctx context.Context, arg ListAuditLogsByRecordParams) ([]AuditLog, error)
 	ListAuditLogsByTable(ctx context.Context, arg ListAuditLogsByTableParams) ([]AuditLog, error)
 	ListAuditLogsByUser(ctx context.Context, arg ListAuditLogsByUserParams) ([]AuditLog, error)
+	ListBalanceMismatches(ctx context.Context, limit int32) ([]ListBalanceMismatchesRow, error)
+	ListCompetingTransactions(ctx context.Context, arg ListCompetingTransactionsParams) ([]Transaction, error)
 	ListConflictedSyncs(ctx context.Context, arg ListConflictedSyncsParams) ([]SyncLog, error)
+	ListDeadLetterSyncs(ctx context.Context, arg ListDeadLetterSyncsParams) ([]SyncLog, error)
+	ListDevicesByUser(ctx context.Context, userID uuid.UUID) ([]Device, error)
 	ListFailedSyncs(ctx context.Context, arg ListFailedSyncsParams) ([]SyncLog, error)
+	ListJobRuns(ctx context.Context, arg ListJobRunsParams) ([]JobRun, error)
+	ListLatestJobRuns(ctx context.Context) ([]JobRun, error)
+	ListLedgerEntriesByTransaction(ctx context.Context, transactionID pgtype.UUID) ([]LedgerEntry, error)
+	ListLedgerEntriesByWallet(ctx context.Context, arg ListLedgerEntriesByWalletParams) ([]LedgerEntry, error)
+	ListLegacyWalletCustodialKeys(ctx context.Context, limit int32) ([]ListLegacyWalletCustodialKeysRow, error)
+	ListOutOfOrderTransactions(ctx context.Context, arg ListOutOfOrderTransactionsParams) ([]Transaction, error)
+	ListOutboxEventsByAggregate(ctx context.Context, aggregateID uuid.UUID) ([]Outbox, error)
+	ListPaymentRequestsByWallet(ctx context.Context, arg ListPaymentRequestsByWalletParams) ([]PaymentRequest, error)
 	ListPeersByConnectionType(ctx context.Context, arg ListPeersByConnectionTypeParams) ([]Peer, error)
 	ListPeersByWallet(ctx context.Context, arg ListPeersByWalletParams) ([]Peer, error)
 	ListPendingSyncs(ctx context.Context, arg ListPendingSyncsParams) ([]ListPendingSyncsRow, error)
 	ListPendingTransactions(ctx context.Context, arg ListPendingTransactionsParams) ([]Transaction, error)
+	ListPlaintextTOTPSecrets(ctx context.Context, limit int32) ([]TotpSecret, error)
 	ListReceivedTransactions(ctx context.Context, arg ListReceivedTransactionsParams) ([]Transaction, error)
 	ListRecentPeers(ctx context.Context, arg ListRecentPeersParams) ([]Peer, error)
 	ListSentTransactions(ctx context.Context, arg ListSentTransactionsParams) ([]Transaction, error)
+	ListSettleableTransactions(ctx context.Context, arg ListSettleableTransactionsParams) ([]Transaction, error)
+	ListTransactionReversals(ctx context.Context, originalTransactionID uuid.UUID) ([]Transaction, error)
 	ListTransactionsByStatus(ctx context.Context, arg ListTransactionsByStatusParams) ([]Transaction, error)
 	ListTransactionsByWallet(ctx context.Context, arg ListTransactionsByWalletParams) ([]ListTransactionsByWalletRow, error)
 	ListTrustedPeers(ctx context.Context, walletID uuid.UUID) ([]Peer, error)
+	ListUnbalancedPostings(ctx context.Context, limit int32) ([]ListUnbalancedPostingsRow, error)
 	ListUnsyncedTransactions(ctx context.Context, arg ListUnsyncedTransactionsParams) ([]Transaction, error)
+	ListWalletKeys(ctx context.Context, walletID uuid.UUID) ([]WalletKey, error)
 	ListWallets(ctx context.Context, arg ListWalletsParams) ([]Wallet, error)
+	ListWebhookDeliveriesBySubscription(ctx context.Context, arg ListWebhookDeliveriesBySubscriptionParams) ([]ListWebhookDeliveriesBySubscriptionRow, error)
+	ListWebhookSubscriptionsByUser(ctx context.Context, userID uuid.UUID) ([]WebhookSubscription, error)
+	// internal/database/query/credential_attempts.sql
+	LockCredentialAttempts(ctx context.Context, arg LockCredentialAttemptsParams) (CredentialAttempt, error)
+	MarkDeviceChallengeUsed(ctx context.Context, id uuid.UUID) error
+	MarkOutboxEventFailed(ctx context.Context, arg MarkOutboxEventFailedParams) error
+	MarkOutboxEventPublished(ctx context.Context, id uuid.UUID) error
+	MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID) error
+	MarkSessionRefreshed(ctx context.Context, id uuid.UUID) error
 	MarkSettleConflict(ctx context.Context, arg MarkSettleConflictParams) (SyncLog, error)
 	MarkSettleFailed(ctx context.Context, arg MarkSettleFailedParams) (SyncLog, error)
 	MarkSettleSuccessful(ctx context.Context, id uuid.UUID) (SyncLog, error)
 	MarkTransactionSettled(ctx context.Context, id uuid.UUID) (Transaction, error)
+	MarkUserPhoneVerified(ctx context.Context, id uuid.UUID) (User, error)
+	MarkWebhookDelivered(ctx context.Context, id uuid.UUID) error
+	MarkWebhookFailed(ctx context.Context, arg MarkWebhookFailedParams) error
+	PruneStalePeers(ctx context.Context, limit int32) (int64, error)
+	RecordCredentialFailure(ctx context.Context, arg RecordCredentialFailureParams) (CredentialAttempt, error)
+	RejectLegacyWalletCustodialKey(ctx context.Context, arg RejectLegacyWalletCustodialKeyParams) error
+	RenewIdempotencyKey(ctx context.Context, id uuid.UUID) (int64, error)
+	RequeueSyncLog(ctx context.Context, id uuid.UUID) (SyncLog, error)
+	ResetCredentialAttempts(ctx context.Context, arg ResetCredentialAttemptsParams) error
 	ResolveSyncConflict(ctx context.Context, id uuid.UUID) (SyncLog, error)
+	ResolveSyncLog(ctx context.Context, arg ResolveSyncLogParams) (SyncLog, error)
+	RetireWalletKey(ctx context.Context, arg RetireWalletKeyParams) (WalletKey, error)
+	RevokeDevice(ctx context.Context, id uuid.UUID) (Device, error)
+	RevokeDeviceSessions(ctx context.Context, deviceID pgtype.UUID) (int64, error)
+	RevokeSession(ctx context.Context, id uuid.UUID) (int64, error)
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) (int64, error)
+	RevokeWalletKey(ctx context.Context, arg RevokeWalletKeyParams) (WalletKey, error)
+	RollbackTransaction(ctx context.Context, id uuid.UUID) (Transaction, error)
+	ScheduleOutboxEventRetry(ctx context.Context, arg ScheduleOutboxEventRetryParams) error
+	ScheduleSyncRetry(ctx context.Context, arg ScheduleSyncRetryParams) (SyncLog, error)
+	ScheduleWebhookRetry(ctx context.Context, arg ScheduleWebhookRetryParams) error
+	SealTOTPSecret(ctx context.Context, arg SealTOTPSecretParams) error
+	SealWalletCustodialKey(ctx context.Context, arg SealWalletCustodialKeyParams) error
 	SearchTransactions(ctx context.Context, arg SearchTransactionsParams) ([]SearchTransactionsRow, error)
 	SearchWalletsByName(ctx context.Context, arg SearchWalletsByNameParams) ([]SearchWalletsByNameRow, error)
 	SearchWalletsByPhoneNumber(ctx context.Context, arg SearchWalletsByPhoneNumberParams) ([]SearchWalletsByPhoneNumberRow, error)
@@ -118,15 +274,30 @@ type Querier interface {
 	SettingTransaction(ctx context.Context, id uuid.UUID) (Transaction, error)
 	SettledTransaction(ctx context.Context, id uuid.UUID) (Transaction, error)
 	SoftDeleteWallet(ctx context.Context, id uuid.UUID) error
+	TouchDevice(ctx context.Context, id uuid.UUID) error
+	TransactionHasLedgerEntries(ctx context.Context, transactionID pgtype.UUID) (bool, error)
+	TryAdvisoryLock(ctx context.Context, key string) (bool, error)
+	UpdateAgent(ctx context.Context, arg UpdateAgentParams) (Agent, error)
 	UpdatePeerInfo(ctx context.Context, arg UpdatePeerInfoParams) (Peer, error)
 	UpdatePeerLastSeen(ctx context.Context, id uuid.UUID) error
+	UpdatePeerPublicKeys(ctx context.Context, arg UpdatePeerPublicKeysParams) (int64, error)
 	UpdateSyncLogStatus(ctx context.Context, arg UpdateSyncLogStatusParams) (SyncLog, error)
+	UpdateTOTPLastUsedStep(ctx context.Context, arg UpdateTOTPLastUsedStepParams) error
 	UpdateTransactionStatus(ctx context.Context, arg UpdateTransactionStatusParams) (Transaction, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
 	UpdateWallet(ctx context.Context, arg UpdateWalletParams) (Wallet, error)
 	UpdateWalletBalance(ctx context.Context, arg UpdateWalletBalanceParams) (Wallet, error)
 	UpdateWalletLastSync(ctx context.Context, id uuid.UUID) error
 	UpdateWalletPIN(ctx context.Context, arg UpdateWalletPINParams) error
+	UpdateWalletPublicKey(ctx context.Context, arg UpdateWalletPublicKeyParams) (Wallet, error)
+	UpdateWebhookSubscription(ctx context.Context, arg UpdateWebhookSubscriptionParams) (WebhookSubscription, error)
 	UpsertPeer(ctx context.Context, arg UpsertPeerParams) (Peer, error)
+	UpsertTOTPSecret(ctx context.Context, arg UpsertTOTPSecretParams) (TotpSecret, error)
+	// internal/database/query/totp_secrets.sql
+	UpsertWalletLimits(ctx context.Context, arg UpsertWalletLimitsParams) (WalletLimit, error)
+	VerifyDevice(ctx context.Context, id uuid.UUID) (Device, error)
+	WalletHasLedgerEntries(ctx context.Context, walletID pgtype.UUID) (bool, error)
 }
 
 var _ Querier = (*Queries)(nil)
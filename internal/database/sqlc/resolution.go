@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrSyncLogNotInConflict     = errors.New("sync log is not in conflict")
+	ErrInvalidResolution        = errors.New("invalid conflict resolution")
+	ErrInvalidSplitAmount       = errors.New("split amount must be positive and less than the transaction amount")
+	ErrResolutionReason         = errors.New("resolution reason is required")
+	ErrInsufficientBalance      = errors.New("insufficient wallet balance")
+	ErrTransactionNotResolvable = errors.New("transaction cannot be resolved in its current state")
+)
+
+// ResolveConflictTxParams describes how an operator clears a conflicting sync log.
+type ResolveConflictTxParams struct {
+	SyncLogID  uuid.UUID
+	Resolution SyncResolution
+	ResolvedBy uuid.UUID
+	Reason     string
+	// Amount is the part of the transaction to settle; only used by split.
+	Amount pgtype.Numeric
+}
+
+// ResolveConflictTxResult is the result of resolving a conflict.
+type ResolveConflictTxResult struct {
+	SyncLog     SyncLog     `json:"sync_log"`
+	Transaction Transaction `json:"transaction"`
+	FromWallet  Wallet      `json:"from_wallet"`
+	ToWallet    Wallet      `json:"to_wallet"`
+}
+
+// ResolveConflictTx applies an operator's decision on a conflicting sync log.
+//
+//   - force_accept settles the full amount, skipping the ordering checks.
+//     A transaction that is already settled is kept as it is.
+//   - reject marks the transaction rolled_back, reversing the balances if it
+//     had already been settled.
+//   - split settles only arg.Amount of a confirmed transaction.
+//
+// Balances, transaction status and the sync log resolution are written in
+// one database transaction.
+func (store *Store) ResolveConflictTx(ctx context.Context, arg ResolveConflictTxParams) (ResolveConflictTxResult, error) {
+	var result ResolveConflictTxResult
+
+	if !arg.Resolution.Valid() {
+		return result, ErrInvalidResolution
+	}
+	reason := strings.TrimSpace(arg.Reason)
+	if reason == "" {
+		return result, ErrResolutionReason
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		log, err := q.GetSyncLogForUpdate(ctx, arg.SyncLogID)
+		if err != nil {
+			return err
+		}
+		if log.Status != SyncStatusConflict {
+			return ErrSyncLogNotInConflict
+		}
+
+		transaction, err := q.GetTransactionForUpdate(ctx, log.TransactionID)
+		if err != nil {
+			return err
+		}
+		result.FromWallet, result.ToWallet, err = lockTransferWallets(ctx, q, transaction.FromWalletID, transaction.ToWalletID)
+		if err != nil {
+			return err
+		}
+
+		status := SyncStatusSettled
+		amount := transaction.Amount
+		var event DomainEvent
+
+		switch arg.Resolution {
+		case SyncResolutionForceAccept:
+			switch transaction.Status {
+			case TransactionStatusSettled:
+			case TransactionStatusConfirmed:
+				if err := moveTransactionFunds(ctx, q, &result, transaction, amount); err != nil {
+					return err
+				}
+				transaction, err = q.SettledTransaction(ctx, transaction.ID)
+				if err != nil {
+					return err
+				}
+				event = TransferCompleted{Transaction: transaction, Amount: amount}
+			default:
+				return ErrTransactionNotResolvable
+			}
+
+		case SyncResolutionSplit:
+			if transaction.Status != TransactionStatusConfirmed {
+				return ErrTransactionNotResolvable
+			}
+			amount = arg.Amount
+			if !amount.Valid || numericRat(amount).Sign() <= 0 || numericCmp(amount, transaction.Amount) >= 0 {
+				return ErrInvalidSplitAmount
+			}
+			if err := moveTransactionFunds(ctx, q, &result, transaction, amount); err != nil {
+				return err
+			}
+			transaction, err = q.SettledTransaction(ctx, transaction.ID)
+			if err != nil {
+				return err
+			}
+			event = TransferCompleted{Transaction: transaction, Amount: amount}
+
+		case SyncResolutionReject:
+			status = SyncStatusFailed
+			switch transaction.Status {
+			case TransactionStatusConfirmed:
+			case TransactionStatusSettled:
+				// Only what the receiver still holds from it goes back:
+				// a split moved less than the amount, and refunds have
+				// already paid some of it back.
+				amount, err = q.GetRefundableAmount(ctx, GetRefundableAmountParams{
+					TransactionID: transaction.ID,
+					WalletID:      transaction.ToWalletID,
+				})
+				if err != nil {
+					return err
+				}
+				if numericRat(amount).Sign() > 0 {
+					if err := reverseTransactionFunds(ctx, q, &result, transaction, amount); err != nil {
+						return err
+					}
+				}
+			default:
+				return ErrTransactionNotResolvable
+			}
+			transaction, err = q.RollbackTransaction(ctx, transaction.ID)
+			if err != nil {
+				return err
+			}
+			event = TransactionFailed{Transaction: transaction}
+		}
+		result.Transaction = transaction
+		if event != nil {
+			if err := publishEvent(ctx, q, event); err != nil {
+				return err
+			}
+		}
+
+		result.SyncLog, err = q.ResolveSyncLog(ctx, ResolveSyncLogParams{
+			ID:             log.ID,
+			Status:         status,
+			Resolution:     NullSyncResolution{SyncResolution: arg.Resolution, Valid: true},
+			ResolvedBy:     pgtype.UUID{Bytes: arg.ResolvedBy, Valid: arg.ResolvedBy != uuid.Nil},
+			ResolutionNote: &reason,
+			ResolvedAmount: amount,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// moveTransactionFunds debits the sender and credits the receiver by amount.
+func moveTransactionFunds(ctx context.Context, q *Queries, result *ResolveConflictTxResult, transaction Transaction, amount pgtype.Numeric) error {
+	posted, err := postLedger(ctx, q, LedgerPosting{
+		TransactionID: pgtype.UUID{Bytes: transaction.ID, Valid: true},
+		EntryType:     LedgerEntryTypeTransfer,
+		Debit:         WalletAccount(transaction.FromWalletID),
+		Credit:        WalletAccount(transaction.ToWalletID),
+		Amount:        amount,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrInsufficientBalance
+	}
+	if err != nil {
+		return err
+	}
+	result.FromWallet, result.ToWallet = posted.DebitWallet, posted.CreditWallet
+
+	if err := upsertTransferPeers(ctx, q, result.FromWallet, result.ToWallet, transaction.ConnectionType); err != nil {
+		return err
+	}
+	return incrementPeerCounts(ctx, q, result.FromWallet.ID, result.ToWallet.ID)
+}
+
+// reverseTransactionFunds gives a settled amount back to the sender.
+func reverseTransactionFunds(ctx context.Context, q *Queries, result *ResolveConflictTxResult, transaction Transaction, amount pgtype.Numeric) error {
+	posted, err := postLedger(ctx, q, LedgerPosting{
+		TransactionID: pgtype.UUID{Bytes: transaction.ID, Valid: true},
+		EntryType:     LedgerEntryTypeReversal,
+		Debit:         WalletAccount(transaction.ToWalletID),
+		Credit:        WalletAccount(transaction.FromWalletID),
+		Amount:        amount,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrInsufficientBalance
+	}
+	if err != nil {
+		return err
+	}
+	result.ToWallet, result.FromWallet = posted.DebitWallet, posted.CreditWallet
+	return nil
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolveConflictTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet := createTestWallet(t, ctx, store.Queries)
+	toWallet := createTestWallet(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1 OR id = $2", fromWallet.ID, toWallet.ID)
+	}()
+
+	settleConflict := func(amount string) SettleTxResult {
+		t.Helper()
+		transaction := createTestTransaction(t, ctx, store.Queries, fromWallet.ID, toWallet.ID, amount, TransactionStatusConfirmed)
+		result, err := store.SettleTx(ctx, transaction.ID)
+		if err != nil {
+			t.Fatalf("settle tx: %v", err)
+		}
+		if result.Conflict == nil || result.Conflict.Reason != ConflictReasonOverdraft {
+			t.Fatalf("expected overdraft conflict")
+		}
+		return result
+	}
+
+	overdraft := settleConflict("150.00")
+
+	if _, err := store.ResolveConflictTx(ctx, ResolveConflictTxParams{
+		SyncLogID:  overdraft.SyncLog.ID,
+		Resolution: SyncResolutionSplit,
+		Reason:     "split above balance",
+		Amount:     numericFromString(t, "150.00"),
+	}); !errors.Is(err, ErrInvalidSplitAmount) {
+		t.Fatalf("expected invalid split amount, got %v", err)
+	}
+
+	split, err := store.ResolveConflictTx(ctx, ResolveConflictTxParams{
+		SyncLogID:  overdraft.SyncLog.ID,
+		Resolution: SyncResolutionSplit,
+		Reason:     "sender agreed to pay what they had",
+		Amount:     numericFromString(t, "80.00"),
+	})
+	if err != nil {
+		t.Fatalf("split conflict: %v", err)
+	}
+	if split.Transaction.Status != TransactionStatusSettled || split.SyncLog.Status != SyncStatusSettled {
+		t.Fatalf("expected settled split, got %s / %s", split.Transaction.Status, split.SyncLog.Status)
+	}
+	if split.SyncLog.ResolutionNote == nil || !split.SyncLog.ResolvedAt.Valid {
+		t.Fatalf("expected resolution to be recorded")
+	}
+	assertFloatApprox(t, numericToFloat64(t, split.SyncLog.ResolvedAmount), 80.00)
+	assertFloatApprox(t, numericToFloat64(t, split.FromWallet.Balance), 20.00)
+	assertFloatApprox(t, numericToFloat64(t, split.ToWallet.Balance), 180.00)
+
+	if _, err := store.ResolveConflictTx(ctx, ResolveConflictTxParams{
+		SyncLogID:  overdraft.SyncLog.ID,
+		Resolution: SyncResolutionReject,
+		Reason:     "already resolved",
+	}); !errors.Is(err, ErrSyncLogNotInConflict) {
+		t.Fatalf("expected not in conflict, got %v", err)
+	}
+
+	second := settleConflict("50.00")
+
+	if _, err := store.ResolveConflictTx(ctx, ResolveConflictTxParams{
+		SyncLogID:  second.SyncLog.ID,
+		Resolution: SyncResolutionForceAccept,
+		Reason:     "accept anyway",
+	}); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("expected insufficient balance, got %v", err)
+	}
+
+	rejected, err := store.ResolveConflictTx(ctx, ResolveConflictTxParams{
+		SyncLogID:  second.SyncLog.ID,
+		Resolution: SyncResolutionReject,
+		Reason:     "double spend",
+	})
+	if err != nil {
+		t.Fatalf("reject conflict: %v", err)
+	}
+	if rejected.Transaction.Status != TransactionStatusRolledBack || rejected.SyncLog.Status != SyncStatusFailed {
+		t.Fatalf("expected rolled back transaction, got %s / %s", rejected.Transaction.Status, rejected.SyncLog.Status)
+	}
+	assertFloatApprox(t, numericToFloat64(t, rejected.FromWallet.Balance), 20.00)
+}
+
+func TestResolveConflictTxRejectAfterRefund(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet, fromKey := createTestWalletWithKey(t, ctx, store.Queries)
+	toWallet, toKey := createTestWalletWithKey(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM transaction_reversals WHERE original_transaction_id IN (SELECT id FROM transactions WHERE from_wallet_id = $1)",
+			fromWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id IN ($1, $2)", fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id IN ($1, $2)", fromWallet.ID, toWallet.ID)
+	}()
+
+	arg := TransferTxParams{
+		FromWalletID: fromWallet.ID,
+		ToWalletID:   toWallet.ID,
+		Amount:       numericFromString(t, "40.00"),
+	}
+	signTestTransfer(t, fromKey, &arg)
+	transferred, err := store.TransferTx(ctx, arg)
+	if err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	original := transferred.Transaction
+
+	refund := RefundTxParams{TransactionID: original.ID, Amount: numericFromString(t, "15.00")}
+	refundTransfer := RefundTransfer(original, refund)
+	signTestTransfer(t, toKey, &refundTransfer)
+	refund.Signature, refund.Nonce, refund.TransactionAt = refundTransfer.Signature, refundTransfer.Nonce, refundTransfer.TransactionAt
+	if _, err := store.RefundTx(ctx, refund); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+
+	conflict, err := store.CreateSyncLog(ctx, CreateSyncLogParams{
+		TransactionID: original.ID,
+		WalletID:      fromWallet.ID,
+		Status:        SyncStatusConflict,
+	})
+	if err != nil {
+		t.Fatalf("create sync log: %v", err)
+	}
+
+	// Only the 25 the receiver still holds goes back.
+	rejected, err := store.ResolveConflictTx(ctx, ResolveConflictTxParams{
+		SyncLogID:  conflict.ID,
+		Resolution: SyncResolutionReject,
+		Reason:     "disputed after a partial refund",
+	})
+	if err != nil {
+		t.Fatalf("reject conflict: %v", err)
+	}
+	assertFloatApprox(t, numericToFloat64(t, rejected.SyncLog.ResolvedAmount), 25.00)
+	assertFloatApprox(t, numericToFloat64(t, rejected.FromWallet.Balance), 100.00)
+	assertFloatApprox(t, numericToFloat64(t, rejected.ToWallet.Balance), 100.00)
+}
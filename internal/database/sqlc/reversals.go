@@ -0,0 +1,223 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrReversalTransferType     = errors.New("refunds and reversals must be made against a transaction")
+	ErrTransactionNotRefundable = errors.New("transaction cannot be refunded or reversed in its current state")
+	ErrRefundExceedsAmount      = errors.New("refund exceeds the amount left to refund")
+	ErrReversalReason           = errors.New("reversal reason is required")
+)
+
+// RefundTxParams describes a refund the receiver of a transaction sends back
+// to its sender. The refund is a transfer from the receiver's wallet, so it
+// is signed like one.
+type RefundTxParams struct {
+	TransactionID uuid.UUID
+	Amount        pgtype.Numeric
+	Signature     string
+	Nonce         int64
+	Description   *string
+	TransactionAt pgtype.Timestamptz
+	Reason        *string
+	CreatedBy     uuid.UUID
+}
+
+// ReversalTxResult is the result of a refund or reversal. Transaction and
+// the wallets are nil when a reversal had nothing left to pay back.
+type ReversalTxResult struct {
+	Original    Transaction  `json:"original"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	FromWallet  *Wallet      `json:"from_wallet,omitempty"`
+	ToWallet    *Wallet      `json:"to_wallet,omitempty"`
+}
+
+// RefundTransfer returns the transfer a refund of the original transaction
+// makes, for signing custodial refunds.
+func RefundTransfer(original Transaction, arg RefundTxParams) TransferTxParams {
+	return TransferTxParams{
+		FromWalletID:   original.ToWalletID,
+		ToWalletID:     original.FromWalletID,
+		Amount:         arg.Amount,
+		Currency:       original.Currency,
+		Type:           TransactionTypeRefund,
+		Status:         TransactionStatusSettled,
+		Signature:      arg.Signature,
+		Nonce:          arg.Nonce,
+		ConnectionType: NullConnectionType{ConnectionType: ConnectionTypeOnline, Valid: true},
+		Description:    arg.Description,
+		Metadata:       []byte(`{}`),
+		TransactionAt:  arg.TransactionAt,
+	}
+}
+
+// RefundTx pays back all or part of a p2p transaction from its receiver.
+// Refunds of one transaction may not add up to more than the receiver got
+// from it; ErrRefundExceedsAmount is returned otherwise. The original
+// transaction keeps its status.
+func (store *Store) RefundTx(ctx context.Context, arg RefundTxParams) (ReversalTxResult, error) {
+	var result ReversalTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		original, err := q.GetTransactionForUpdate(ctx, arg.TransactionID)
+		if err != nil {
+			return err
+		}
+		if original.Type != TransactionTypeP2p || !isReversible(original.Status) {
+			return ErrTransactionNotRefundable
+		}
+		result.Original = original
+
+		refundable, err := q.GetRefundableAmount(ctx, GetRefundableAmountParams{
+			TransactionID: original.ID,
+			WalletID:      original.ToWalletID,
+		})
+		if err != nil {
+			return err
+		}
+		if numericRat(refundable).Sign() <= 0 {
+			return ErrTransactionNotRefundable
+		}
+		if !arg.Amount.Valid || numericRat(arg.Amount).Sign() <= 0 {
+			return ErrInvalidLedgerAmount
+		}
+		if numericCmp(arg.Amount, refundable) > 0 {
+			return ErrRefundExceedsAmount
+		}
+
+		var refunded TransferTxResult
+		if err := transfer(ctx, q, RefundTransfer(original, arg), &refunded); err != nil {
+			return err
+		}
+		result.Transaction = &refunded.Transaction
+		result.FromWallet, result.ToWallet = &refunded.FromWallet, &refunded.ToWallet
+
+		_, err = q.CreateTransactionReversal(ctx, CreateTransactionReversalParams{
+			TransactionID:         refunded.Transaction.ID,
+			OriginalTransactionID: original.ID,
+			Reason:                arg.Reason,
+			CreatedBy:             pgtype.UUID{Bytes: arg.CreatedBy, Valid: arg.CreatedBy != uuid.Nil},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ReverseTxParams describes an operator's reversal of a transaction.
+type ReverseTxParams struct {
+	TransactionID uuid.UUID
+	ReversedBy    uuid.UUID
+	Reason        string
+}
+
+// ReverseTx undoes a transaction: it is marked rolled_back and whatever its
+// receiver still holds from it, after earlier refunds, goes back to the
+// sender in a settled reversal transaction. Reversals are made by the
+// server, so they are unsigned, carry nonce 0 and skip the spending limits.
+// A receiver that has already spent the money gets ErrInsufficientBalance.
+func (store *Store) ReverseTx(ctx context.Context, arg ReverseTxParams) (ReversalTxResult, error) {
+	var result ReversalTxResult
+
+	reason := strings.TrimSpace(arg.Reason)
+	if reason == "" {
+		return result, ErrReversalReason
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		original, err := q.GetTransactionForUpdate(ctx, arg.TransactionID)
+		if err != nil {
+			return err
+		}
+		if original.Type == TransactionTypeReversal || !isReversible(original.Status) {
+			return ErrTransactionNotRefundable
+		}
+		fromWallet, toWallet, err := lockTransferWallets(ctx, q, original.FromWalletID, original.ToWalletID)
+		if err != nil {
+			return err
+		}
+
+		refundable, err := q.GetRefundableAmount(ctx, GetRefundableAmountParams{
+			TransactionID: original.ID,
+			WalletID:      original.ToWalletID,
+		})
+		if err != nil {
+			return err
+		}
+		result.Original, err = q.RollbackTransaction(ctx, original.ID)
+		if err != nil {
+			return err
+		}
+		if err := publishEvent(ctx, q, TransactionFailed{Transaction: result.Original}); err != nil {
+			return err
+		}
+		if numericRat(refundable).Sign() <= 0 {
+			return nil
+		}
+
+		reversal, err := q.CreateTransaction(ctx, CreateTransactionParams{
+			FromWalletID:   original.ToWalletID,
+			ToWalletID:     original.FromWalletID,
+			Amount:         refundable,
+			Currency:       original.Currency,
+			Type:           TransactionTypeReversal,
+			Status:         TransactionStatusSettled,
+			Signature:      "",
+			Nonce:          0,
+			ConnectionType: NullConnectionType{ConnectionType: ConnectionTypeOnline, Valid: true},
+			Description:    &reason,
+			Metadata:       []byte(`{}`),
+			TransactionAt:  pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		posted, err := postLedger(ctx, q, LedgerPosting{
+			TransactionID: pgtype.UUID{Bytes: reversal.ID, Valid: true},
+			EntryType:     LedgerEntryTypeReversal,
+			Debit:         WalletAccount(toWallet.ID),
+			Credit:        WalletAccount(fromWallet.ID),
+			Amount:        refundable,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInsufficientBalance
+		}
+		if err != nil {
+			return err
+		}
+		result.Transaction = &reversal
+		result.FromWallet, result.ToWallet = &posted.DebitWallet, &posted.CreditWallet
+		if err := publishEvent(ctx, q, TransferCompleted{Transaction: reversal, Amount: refundable}); err != nil {
+			return err
+		}
+
+		_, err = q.CreateTransactionReversal(ctx, CreateTransactionReversalParams{
+			TransactionID:         reversal.ID,
+			OriginalTransactionID: original.ID,
+			Reason:                &reason,
+			CreatedBy:             pgtype.UUID{Bytes: arg.ReversedBy, Valid: arg.ReversedBy != uuid.Nil},
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// isReversible reports whether a transaction in status may have moved money
+// that can still be paid back.
+func isReversible(status TransactionStatus) bool {
+	switch status {
+	case TransactionStatusPending, TransactionStatusConfirmed, TransactionStatusSettled:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRefundAndReverseTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet, fromKey := createTestWalletWithKey(t, ctx, store.Queries)
+	toWallet, toKey := createTestWalletWithKey(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM transaction_reversals WHERE original_transaction_id IN (SELECT id FROM transactions WHERE from_wallet_id = $1)",
+			fromWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id IN ($1, $2)", fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id IN ($1, $2)", fromWallet.ID, toWallet.ID)
+	}()
+
+	arg := TransferTxParams{
+		FromWalletID: fromWallet.ID,
+		ToWalletID:   toWallet.ID,
+		Amount:       numericFromString(t, "40.00"),
+	}
+	signTestTransfer(t, fromKey, &arg)
+	transferred, err := store.TransferTx(ctx, arg)
+	if err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	original := transferred.Transaction
+
+	refund := RefundTxParams{TransactionID: original.ID, Amount: numericFromString(t, "15.00")}
+	refundTransfer := RefundTransfer(original, refund)
+	signTestTransfer(t, toKey, &refundTransfer)
+	refund.Signature, refund.Nonce, refund.TransactionAt = refundTransfer.Signature, refundTransfer.Nonce, refundTransfer.TransactionAt
+
+	refunded, err := store.RefundTx(ctx, refund)
+	if err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if refunded.Transaction.Type != TransactionTypeRefund {
+		t.Fatalf("expected refund transaction, got %s", refunded.Transaction.Type)
+	}
+	assertFloatApprox(t, numericToFloat64(t, refunded.FromWallet.Balance), 125)
+	assertFloatApprox(t, numericToFloat64(t, refunded.ToWallet.Balance), 75)
+
+	tooMuch := RefundTxParams{TransactionID: original.ID, Amount: numericFromString(t, "30.00")}
+	tooMuchTransfer := RefundTransfer(original, tooMuch)
+	signTestTransfer(t, toKey, &tooMuchTransfer)
+	tooMuch.Signature, tooMuch.Nonce, tooMuch.TransactionAt = tooMuchTransfer.Signature, tooMuchTransfer.Nonce, tooMuchTransfer.TransactionAt
+	if _, err := store.RefundTx(ctx, tooMuch); !errors.Is(err, ErrRefundExceedsAmount) {
+		t.Fatalf("expected ErrRefundExceedsAmount, got %v", err)
+	}
+
+	if _, err := store.ReverseTx(ctx, ReverseTxParams{TransactionID: original.ID}); !errors.Is(err, ErrReversalReason) {
+		t.Fatalf("expected ErrReversalReason, got %v", err)
+	}
+	reversed, err := store.ReverseTx(ctx, ReverseTxParams{TransactionID: original.ID, Reason: "disputed"})
+	if err != nil {
+		t.Fatalf("reverse: %v", err)
+	}
+	if reversed.Original.Status != TransactionStatusRolledBack {
+		t.Fatalf("expected original to be rolled back, got %s", reversed.Original.Status)
+	}
+	assertFloatApprox(t, numericToFloat64(t, reversed.Transaction.Amount), 25)
+	assertFloatApprox(t, numericToFloat64(t, reversed.FromWallet.Balance), 100)
+	assertFloatApprox(t, numericToFloat64(t, reversed.ToWallet.Balance), 100)
+
+	if _, err := store.ReverseTx(ctx, ReverseTxParams{TransactionID: original.ID, Reason: "again"}); !errors.Is(err, ErrTransactionNotRefundable) {
+		t.Fatalf("expected ErrTransactionNotRefundable, got %v", err)
+	}
+	reversals, err := store.ListTransactionReversals(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("list reversals: %v", err)
+	}
+	if len(reversals) != 2 {
+		t.Fatalf("expected 2 reversals, got %d", len(reversals))
+	}
+}
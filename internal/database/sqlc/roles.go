@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrAdminExists = errors.New("an admin user already exists")
+
+// BootstrapAdmin promotes the user with the given phone number to admin, but
+// only while no admin exists yet. It is meant to be run once at startup to
+// create the first operator account.
+func (store *Store) BootstrapAdmin(ctx context.Context, phoneNumber string) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		admins, err := q.CountUsersByRole(ctx, UserRoleAdmin)
+		if err != nil {
+			return err
+		}
+		if admins > 0 {
+			return ErrAdminExists
+		}
+
+		user, err = q.GetUserByPhone(ctx, phoneNumber)
+		if err != nil {
+			return err
+		}
+		user, err = q.UpdateUserRole(ctx, UpdateUserRoleParams{
+			ID:   user.ID,
+			Role: UserRoleAdmin,
+		})
+		return err
+	})
+
+	return user, err
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCreateAgentTxPromotesOwner(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	user, err := store.CreateUser(ctx, CreateUserParams{
+		PhoneNumber:  nextPhoneNumber(),
+		PasswordHash: "password-hash",
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if user.Role != UserRoleUser {
+		t.Fatalf("expected new users to have the user role, got %s", user.Role)
+	}
+	wallet := createTestWallet(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, wallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1", wallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", user.ID)
+	}()
+
+	if _, err := testPool.Exec(ctx, "UPDATE wallets SET user_id = $1 WHERE id = $2", user.ID, wallet.ID); err != nil {
+		t.Fatalf("assign wallet owner: %v", err)
+	}
+
+	if _, err := store.CreateAgentTx(ctx, CreateAgentParams{
+		WalletID:   wallet.ID,
+		FloatLimit: numericFromString(t, "1000.00"),
+	}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+
+	owner, err := store.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if owner.Role != UserRoleAgent {
+		t.Fatalf("expected owner to be promoted to agent, got %s", owner.Role)
+	}
+
+	// Staff roles are not downgraded when a staff member runs an agent wallet.
+	if _, err := store.UpdateUserRole(ctx, UpdateUserRoleParams{ID: user.ID, Role: UserRoleSupport}); err != nil {
+		t.Fatalf("update user role: %v", err)
+	}
+	if _, err := testPool.Exec(ctx, "DELETE FROM agents WHERE wallet_id = $1", wallet.ID); err != nil {
+		t.Fatalf("delete agent: %v", err)
+	}
+	if _, err := store.CreateAgentTx(ctx, CreateAgentParams{
+		WalletID:   wallet.ID,
+		FloatLimit: numericFromString(t, "1000.00"),
+	}); err != nil {
+		t.Fatalf("recreate agent: %v", err)
+	}
+	owner, err = store.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if owner.Role != UserRoleSupport {
+		t.Fatalf("expected support role to be kept, got %s", owner.Role)
+	}
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used; the session has been revoked")
+)
+
+// CreateSessionTxParams contains the input parameters of a new login session.
+type CreateSessionTxParams struct {
+	UserID           uuid.UUID
+	UserAgent        *string
+	ClientIp         *netip.Addr
+	ExpiresAt        pgtype.Timestamptz
+	RefreshTokenHash string
+	DeviceID         pgtype.UUID
+}
+
+// CreateSessionTx starts a session and stores the hash of its first refresh token.
+func (store *Store) CreateSessionTx(ctx context.Context, arg CreateSessionTxParams) (Session, error) {
+	var session Session
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		session, err = q.CreateSession(ctx, CreateSessionParams{
+			UserID:    arg.UserID,
+			UserAgent: arg.UserAgent,
+			ClientIp:  arg.ClientIp,
+			ExpiresAt: arg.ExpiresAt,
+			DeviceID:  arg.DeviceID,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+			SessionID: session.ID,
+			TokenHash: arg.RefreshTokenHash,
+		})
+		return err
+	})
+
+	return session, err
+}
+
+// RotateRefreshTokenTxParams contains the input parameters of a token refresh.
+type RotateRefreshTokenTxParams struct {
+	TokenHash    string
+	NewTokenHash string
+}
+
+// RotateRefreshTokenTx exchanges a refresh token for a new one within the
+// same session.
+//
+// Refresh tokens are single use. Presenting one that was already exchanged
+// means it has leaked, so the whole session is revoked and
+// ErrRefreshTokenReused is returned.
+func (store *Store) RotateRefreshTokenTx(ctx context.Context, arg RotateRefreshTokenTxParams) (Session, error) {
+	var session Session
+	reused := false
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		token, err := q.GetRefreshTokenForUpdate(ctx, arg.TokenHash)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+
+		session, err = q.GetSessionForUpdate(ctx, token.SessionID)
+		if err != nil {
+			return err
+		}
+		if session.RevokedAt.Valid || !session.ExpiresAt.Time.After(time.Now()) {
+			return ErrRefreshTokenInvalid
+		}
+
+		if token.UsedAt.Valid {
+			// Commit the revocation rather than rolling it back with an error.
+			reused = true
+			_, err = q.RevokeSession(ctx, session.ID)
+			return err
+		}
+
+		if err := q.MarkRefreshTokenUsed(ctx, token.ID); err != nil {
+			return err
+		}
+		if _, err := q.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+			SessionID: session.ID,
+			TokenHash: arg.NewTokenHash,
+		}); err != nil {
+			return err
+		}
+		return q.MarkSessionRefreshed(ctx, session.ID)
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	if reused {
+		return Session{}, ErrRefreshTokenReused
+	}
+
+	return session, nil
+}
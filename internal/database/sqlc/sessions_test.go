@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestRotateRefreshTokenTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	user, err := store.CreateUser(ctx, CreateUserParams{
+		PhoneNumber:  nextPhoneNumber(),
+		PasswordHash: "password-hash",
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	defer func() {
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", user.ID)
+	}()
+
+	session, err := store.CreateSessionTx(ctx, CreateSessionTxParams{
+		UserID:           user.ID,
+		ExpiresAt:        pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+		RefreshTokenHash: "hash-1-" + user.ID.String(),
+	})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	rotated, err := store.RotateRefreshTokenTx(ctx, RotateRefreshTokenTxParams{
+		TokenHash:    "hash-1-" + user.ID.String(),
+		NewTokenHash: "hash-2-" + user.ID.String(),
+	})
+	if err != nil {
+		t.Fatalf("rotate refresh token: %v", err)
+	}
+	if rotated.ID != session.ID {
+		t.Fatalf("expected rotation to keep session %s, got %s", session.ID, rotated.ID)
+	}
+
+	if _, err := store.RotateRefreshTokenTx(ctx, RotateRefreshTokenTxParams{
+		TokenHash:    "unknown-" + user.ID.String(),
+		NewTokenHash: "hash-3-" + user.ID.String(),
+	}); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("expected invalid refresh token, got %v", err)
+	}
+
+	// Replaying the first token revokes the session, so the current token stops working too.
+	if _, err := store.RotateRefreshTokenTx(ctx, RotateRefreshTokenTxParams{
+		TokenHash:    "hash-1-" + user.ID.String(),
+		NewTokenHash: "hash-3-" + user.ID.String(),
+	}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("expected reused refresh token, got %v", err)
+	}
+	active, err := store.IsSessionActive(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("is session active: %v", err)
+	}
+	if active {
+		t.Fatal("expected session to be revoked after token reuse")
+	}
+	if _, err := store.RotateRefreshTokenTx(ctx, RotateRefreshTokenTxParams{
+		TokenHash:    "hash-2-" + user.ID.String(),
+		NewTokenHash: "hash-3-" + user.ID.String(),
+	}); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("expected revoked session to reject refresh, got %v", err)
+	}
+}
@@ -0,0 +1,373 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ConflictReason explains why a transaction could not be settled automatically.
+type ConflictReason string
+
+const (
+	ConflictReasonOverdraft      ConflictReason = "overdraft"
+	ConflictReasonDuplicateNonce ConflictReason = "duplicate_nonce"
+	ConflictReasonOutOfOrder     ConflictReason = "out_of_order"
+)
+
+const (
+	// competingWindow bounds how far back settlement looks for payments that
+	// drew on the same balance as a conflicting transaction.
+	competingWindow = 24 * time.Hour
+	competingLimit  = 20
+)
+
+var (
+	ErrTransactionNotSettleable = errors.New("transaction is not in a settleable state")
+	ErrSyncLogNotFailed         = errors.New("sync log is not failed")
+)
+
+// CompetingTransaction summarises a transaction involved in a conflict.
+type CompetingTransaction struct {
+	ID            uuid.UUID         `json:"id"`
+	ToWalletID    uuid.UUID         `json:"to_wallet_id"`
+	Amount        pgtype.Numeric    `json:"amount"`
+	Nonce         int64             `json:"nonce"`
+	Status        TransactionStatus `json:"status,omitempty"`
+	Signature     string            `json:"signature,omitempty"`
+	TransactionAt time.Time         `json:"transaction_at"`
+}
+
+// ConflictData is stored in sync_logs.conflict_data when settlement detects a conflict.
+type ConflictData struct {
+	Reason        ConflictReason         `json:"reason"`
+	TransactionID uuid.UUID              `json:"transaction_id"`
+	WalletID      uuid.UUID              `json:"wallet_id"`
+	Amount        pgtype.Numeric         `json:"amount"`
+	Nonce         int64                  `json:"nonce"`
+	TransactionAt time.Time              `json:"transaction_at"`
+	Balance       *pgtype.Numeric        `json:"balance,omitempty"`
+	Submitted     *CompetingTransaction  `json:"submitted,omitempty"`
+	Competing     []CompetingTransaction `json:"competing_transactions"`
+	DetectedAt    time.Time              `json:"detected_at"`
+}
+
+// SettleTxResult is the result of settling a confirmed transaction.
+type SettleTxResult struct {
+	Transaction Transaction   `json:"transaction"`
+	SyncLog     SyncLog       `json:"sync_log"`
+	FromWallet  *Wallet       `json:"from_wallet,omitempty"`
+	ToWallet    *Wallet       `json:"to_wallet,omitempty"`
+	Conflict    *ConflictData `json:"conflict,omitempty"`
+}
+
+// SettleTx settles a confirmed transaction, moving money only when no conflict is found.
+//
+// Both wallet rows are locked before any check runs, so concurrent
+// settlements from the same sender are serialised and the balance they see
+// is final. A conflict leaves the transaction confirmed, records the
+// competing transactions in the sync log and does not touch any balance.
+func (store *Store) SettleTx(ctx context.Context, transactionID uuid.UUID) (SettleTxResult, error) {
+	return store.settleTx(ctx, transactionID, openSyncLog)
+}
+
+// RetrySettlementTx settles the transaction of a failed sync log again and
+// records the outcome on that log. When the transaction was settled some
+// other way in the meantime, the log is simply closed. Logs an operator
+// resolved, such as rejected conflicts, are final and refused with
+// ErrSyncLogNotFailed.
+func (store *Store) RetrySettlementTx(ctx context.Context, syncLogID uuid.UUID) (SettleTxResult, error) {
+	log, err := store.GetSyncLogByID(ctx, syncLogID)
+	if err != nil {
+		return SettleTxResult{}, err
+	}
+
+	result, err := store.settleTx(ctx, log.TransactionID, func(ctx context.Context, q *Queries, _ Transaction) (SyncLog, error) {
+		log, err := q.GetSyncLogForUpdate(ctx, syncLogID)
+		if err != nil {
+			return SyncLog{}, err
+		}
+		if log.Status != SyncStatusFailed || log.Resolution.Valid {
+			return SyncLog{}, ErrSyncLogNotFailed
+		}
+		return log, nil
+	})
+	if !errors.Is(err, ErrTransactionNotSettleable) {
+		return result, err
+	}
+
+	transaction, lookupErr := store.GetTransactionByID(ctx, log.TransactionID)
+	if lookupErr != nil {
+		return result, lookupErr
+	}
+	if transaction.Status != TransactionStatusSettled {
+		return result, err
+	}
+	result.Transaction = transaction
+	result.SyncLog, err = store.MarkSettleSuccessful(ctx, log.ID)
+	return result, err
+}
+
+func (store *Store) settleTx(ctx context.Context, transactionID uuid.UUID, open func(context.Context, *Queries, Transaction) (SyncLog, error)) (SettleTxResult, error) {
+	var result SettleTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		transaction, err := q.GetTransactionForUpdate(ctx, transactionID)
+		if err != nil {
+			return err
+		}
+		if transaction.Status != TransactionStatusConfirmed {
+			return ErrTransactionNotSettleable
+		}
+		// A transaction whose money already moved, such as a transfer
+		// recorded before transfers were settled on creation, must not be
+		// posted twice.
+		alreadyPosted, err := q.TransactionHasLedgerEntries(ctx, pgtype.UUID{Bytes: transaction.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		if alreadyPosted {
+			return ErrTransactionNotSettleable
+		}
+		result.Transaction = transaction
+
+		fromWallet, _, err := lockTransferWallets(ctx, q, transaction.FromWalletID, transaction.ToWalletID)
+		if err != nil {
+			return err
+		}
+		// Transactions created through POST /transactions were never held
+		// to the limits, so every settlement checks them with the sender
+		// locked.
+		if err := checkSettlementLimits(ctx, q, fromWallet, transaction); err != nil {
+			return err
+		}
+
+		result.SyncLog, err = open(ctx, q, transaction)
+		if err != nil {
+			return err
+		}
+
+		conflict, err := detectOutOfOrder(ctx, q, transaction)
+		if err != nil {
+			return err
+		}
+		if conflict != nil {
+			return recordConflict(ctx, q, &result, conflict)
+		}
+
+		posted, err := postLedger(ctx, q, LedgerPosting{
+			TransactionID: pgtype.UUID{Bytes: transaction.ID, Valid: true},
+			EntryType:     LedgerEntryTypeTransfer,
+			Debit:         WalletAccount(transaction.FromWalletID),
+			Credit:        WalletAccount(transaction.ToWalletID),
+			Amount:        transaction.Amount,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			conflict, err := overdraftConflict(ctx, q, transaction, fromWallet.Balance)
+			if err != nil {
+				return err
+			}
+			return recordConflict(ctx, q, &result, conflict)
+		}
+		if err != nil {
+			return err
+		}
+		debited, credited := posted.DebitWallet, posted.CreditWallet
+		result.FromWallet = &debited
+		result.ToWallet = &credited
+
+		if err := upsertTransferPeers(ctx, q, debited, credited, transaction.ConnectionType); err != nil {
+			return err
+		}
+		if err := incrementPeerCounts(ctx, q, debited.ID, credited.ID); err != nil {
+			return err
+		}
+
+		result.Transaction, err = q.SettledTransaction(ctx, transaction.ID)
+		if err != nil {
+			return err
+		}
+		if err := publishEvent(ctx, q, TransferCompleted{Transaction: result.Transaction, Amount: result.Transaction.Amount}); err != nil {
+			return err
+		}
+		result.SyncLog, err = q.MarkSettleSuccessful(ctx, result.SyncLog.ID)
+		return err
+	})
+
+	return result, err
+}
+
+// SettleWalletTx settles a sender's confirmed transactions in transaction_at order,
+// so that earlier payments win when the balance cannot cover all of them.
+func (store *Store) SettleWalletTx(ctx context.Context, walletID uuid.UUID, limit int32) ([]SettleTxResult, error) {
+	transactions, err := store.ListSettleableTransactions(ctx, ListSettleableTransactionsParams{
+		FromWalletID: walletID,
+		Limit:        limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SettleTxResult, 0, len(transactions))
+	for _, transaction := range transactions {
+		result, err := store.SettleTx(ctx, transaction.ID)
+		if errors.Is(err, ErrTransactionNotSettleable) || IsLimitError(err) {
+			continue
+		}
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// RecordDuplicateNonce attaches a duplicate_nonce conflict to the transaction that
+// already owns the nonce, describing the competing payment that reused it.
+func (store *Store) RecordDuplicateNonce(ctx context.Context, walletID uuid.UUID, existing Transaction, submitted TransferTxParams) (SyncLog, error) {
+	var log SyncLog
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		log, err = q.CreateSyncLog(ctx, CreateSyncLogParams{
+			TransactionID: existing.ID,
+			WalletID:      walletID,
+			Status:        SyncStatusPending,
+		})
+		if err != nil {
+			return err
+		}
+
+		conflict := newConflictData(ConflictReasonDuplicateNonce, existing)
+		conflict.Submitted = &CompetingTransaction{
+			ToWalletID:    submitted.ToWalletID,
+			Amount:        submitted.Amount,
+			Nonce:         submitted.Nonce,
+			Signature:     submitted.Signature,
+			TransactionAt: submitted.TransactionAt.Time,
+		}
+		conflict.Competing = append(conflict.Competing, competingTransaction(existing))
+
+		data, err := json.Marshal(conflict)
+		if err != nil {
+			return err
+		}
+		log, err = q.MarkSettleConflict(ctx, MarkSettleConflictParams{
+			ID:           log.ID,
+			ConflictData: data,
+		})
+		return err
+	})
+
+	return log, err
+}
+
+func openSyncLog(ctx context.Context, q *Queries, transaction Transaction) (SyncLog, error) {
+	logs, err := q.GetSyncLogsByTransaction(ctx, transaction.ID)
+	if err != nil {
+		return SyncLog{}, err
+	}
+	for _, log := range logs {
+		switch log.Status {
+		case SyncStatusPending, SyncStatusConfirmed, SyncStatusSettling, SyncStatusFailed:
+			return log, nil
+		}
+	}
+
+	return q.CreateSyncLog(ctx, CreateSyncLogParams{
+		TransactionID: transaction.ID,
+		WalletID:      transaction.FromWalletID,
+		Status:        SyncStatusPending,
+	})
+}
+
+func detectOutOfOrder(ctx context.Context, q *Queries, transaction Transaction) (*ConflictData, error) {
+	competing, err := q.ListOutOfOrderTransactions(ctx, ListOutOfOrderTransactionsParams{
+		FromWalletID:  transaction.FromWalletID,
+		ID:            transaction.ID,
+		Nonce:         transaction.Nonce,
+		TransactionAt: transaction.TransactionAt,
+		Limit:         competingLimit,
+	})
+	if err != nil || len(competing) == 0 {
+		return nil, err
+	}
+
+	conflict := newConflictData(ConflictReasonOutOfOrder, transaction)
+	for _, other := range competing {
+		conflict.Competing = append(conflict.Competing, competingTransaction(other))
+	}
+	return &conflict, nil
+}
+
+func overdraftConflict(ctx context.Context, q *Queries, transaction Transaction, balance pgtype.Numeric) (*ConflictData, error) {
+	competing, err := q.ListCompetingTransactions(ctx, ListCompetingTransactionsParams{
+		FromWalletID: transaction.FromWalletID,
+		ID:           transaction.ID,
+		TransactionAt: pgtype.Timestamptz{
+			Time:  transaction.TransactionAt.Time.Add(-competingWindow),
+			Valid: true,
+		},
+		Limit: competingLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	conflict := newConflictData(ConflictReasonOverdraft, transaction)
+	conflict.Balance = &balance
+	for _, other := range competing {
+		conflict.Competing = append(conflict.Competing, competingTransaction(other))
+	}
+	return &conflict, nil
+}
+
+func recordConflict(ctx context.Context, q *Queries, result *SettleTxResult, conflict *ConflictData) error {
+	data, err := json.Marshal(conflict)
+	if err != nil {
+		return err
+	}
+	result.SyncLog, err = q.MarkSettleConflict(ctx, MarkSettleConflictParams{
+		ID:           result.SyncLog.ID,
+		ConflictData: data,
+	})
+	if err != nil {
+		return err
+	}
+	result.Conflict = conflict
+	return publishEvent(ctx, q, SyncConflictRaised{
+		SyncLogID:   result.SyncLog.ID,
+		Transaction: result.Transaction,
+		Conflict:    *conflict,
+	})
+}
+
+func newConflictData(reason ConflictReason, transaction Transaction) ConflictData {
+	return ConflictData{
+		Reason:        reason,
+		TransactionID: transaction.ID,
+		WalletID:      transaction.FromWalletID,
+		Amount:        transaction.Amount,
+		Nonce:         transaction.Nonce,
+		TransactionAt: transaction.TransactionAt.Time,
+		Competing:     []CompetingTransaction{},
+		DetectedAt:    time.Now().UTC(),
+	}
+}
+
+func competingTransaction(transaction Transaction) CompetingTransaction {
+	return CompetingTransaction{
+		ID:            transaction.ID,
+		ToWalletID:    transaction.ToWalletID,
+		Amount:        transaction.Amount,
+		Nonce:         transaction.Nonce,
+		Status:        transaction.Status,
+		Signature:     transaction.Signature,
+		TransactionAt: transaction.TransactionAt.Time,
+	}
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSettleTxConcurrent(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet := createTestWallet(t, ctx, store.Queries)
+	toWallet := createTestWallet(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1 OR id = $2", fromWallet.ID, toWallet.ID)
+	}()
+
+	// Two offline payments that each fit the balance but not together.
+	first := createTestTransaction(t, ctx, store.Queries, fromWallet.ID, toWallet.ID, "60.00", TransactionStatusConfirmed)
+	second := createTestTransaction(t, ctx, store.Queries, fromWallet.ID, toWallet.ID, "60.00", TransactionStatusConfirmed)
+
+	type outcome struct {
+		result SettleTxResult
+		err    error
+	}
+	outcomes := make(chan outcome)
+	for _, transaction := range []Transaction{first, second} {
+		go func(id uuid.UUID) {
+			result, err := store.SettleTx(ctx, id)
+			outcomes <- outcome{result: result, err: err}
+		}(transaction.ID)
+	}
+
+	settled, conflicts := 0, 0
+	for range 2 {
+		out := <-outcomes
+		if out.err != nil {
+			t.Fatalf("settle tx: %v", out.err)
+		}
+		result := out.result
+		if result.Conflict == nil {
+			settled++
+			if result.Transaction.Status != TransactionStatusSettled {
+				t.Fatalf("expected settled transaction, got %s", result.Transaction.Status)
+			}
+			continue
+		}
+		conflicts++
+		if result.SyncLog.Status != SyncStatusConflict {
+			t.Fatalf("expected conflict sync log, got %s", result.SyncLog.Status)
+		}
+		if result.Transaction.Status != TransactionStatusConfirmed {
+			t.Fatalf("expected conflicting transaction to stay confirmed, got %s", result.Transaction.Status)
+		}
+	}
+	if settled != 1 || conflicts != 1 {
+		t.Fatalf("expected one settled and one conflict, got %d and %d", settled, conflicts)
+	}
+
+	balance, err := store.GetWalletBalance(ctx, fromWallet.ID)
+	if err != nil {
+		t.Fatalf("get wallet balance: %v", err)
+	}
+	assertFloatApprox(t, numericToFloat64(t, balance), 40.00)
+}
+
+func TestRetrySettlementTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet := createTestWallet(t, ctx, store.Queries)
+	toWallet := createTestWallet(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1 OR id = $2", fromWallet.ID, toWallet.ID)
+	}()
+
+	transaction := createTestTransaction(t, ctx, store.Queries, fromWallet.ID, toWallet.ID, "25.00", TransactionStatusConfirmed)
+	failed := createTestSyncLog(t, ctx, store.Queries, transaction.ID, fromWallet.ID, SyncStatusFailed)
+
+	result, err := store.RetrySettlementTx(ctx, failed.ID)
+	if err != nil {
+		t.Fatalf("retry settlement: %v", err)
+	}
+	if result.SyncLog.ID != failed.ID || result.SyncLog.Status != SyncStatusSettled {
+		t.Fatalf("expected the failed log settled, got %+v", result.SyncLog)
+	}
+	if result.Transaction.Status != TransactionStatusSettled {
+		t.Fatalf("expected settled transaction, got %s", result.Transaction.Status)
+	}
+
+	if _, err := store.RetrySettlementTx(ctx, failed.ID); !errors.Is(err, ErrSyncLogNotFailed) {
+		t.Fatalf("expected ErrSyncLogNotFailed, got %v", err)
+	}
+
+	// A stale failed log of a settled transaction is closed.
+	stale := createTestSyncLog(t, ctx, store.Queries, transaction.ID, fromWallet.ID, SyncStatusFailed)
+	result, err = store.RetrySettlementTx(ctx, stale.ID)
+	if err != nil {
+		t.Fatalf("retry stale log: %v", err)
+	}
+	if result.SyncLog.Status != SyncStatusSettled {
+		t.Fatalf("expected the stale log settled, got %s", result.SyncLog.Status)
+	}
+}
+
+func TestSettleTxRefusesPostedTransfer(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet, fromKey := createTestWalletWithKey(t, ctx, store.Queries)
+	toWallet := createTestWallet(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1 OR id = $2", fromWallet.ID, toWallet.ID)
+	}()
+
+	arg := TransferTxParams{
+		FromWalletID: fromWallet.ID,
+		ToWalletID:   toWallet.ID,
+		Amount:       numericFromString(t, "25.00"),
+	}
+	signTestTransfer(t, fromKey, &arg)
+	transferred, err := store.TransferTx(ctx, arg)
+	if err != nil {
+		t.Fatalf("transfer tx: %v", err)
+	}
+	if transferred.Transaction.Status != TransactionStatusSettled {
+		t.Fatalf("expected the transfer settled, got %s", transferred.Transaction.Status)
+	}
+	if _, err := store.SettleTx(ctx, transferred.Transaction.ID); !errors.Is(err, ErrTransactionNotSettleable) {
+		t.Fatalf("expected a settled transfer refused, got %v", err)
+	}
+
+	// A transfer left confirmed by older code has still been posted.
+	if _, err := testPool.Exec(ctx, "UPDATE transactions SET status = 'confirmed' WHERE id = $1", transferred.Transaction.ID); err != nil {
+		t.Fatalf("confirm transfer: %v", err)
+	}
+	if _, err := store.SettleTx(ctx, transferred.Transaction.ID); !errors.Is(err, ErrTransactionNotSettleable) {
+		t.Fatalf("expected a posted transfer refused, got %v", err)
+	}
+
+	balance, err := store.GetWalletBalance(ctx, fromWallet.ID)
+	if err != nil {
+		t.Fatalf("get wallet balance: %v", err)
+	}
+	assertFloatApprox(t, numericToFloat64(t, balance), 75.00)
+}
@@ -1,12 +1,13 @@
 package database
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"time"
 
+	"github.com/Sahas001/pay-on/internal/signature"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -69,8 +70,22 @@ type TransferTxResult struct {
 func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
+	if err := setTransferDefaults(&arg); err != nil {
+		return result, err
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		return transfer(ctx, q, arg, &result)
+	})
+
+	return result, err
+}
+
+// setTransferDefaults fills in the defaults of a wallet-to-wallet transfer
+// and rejects transfers that cannot be made through TransferTx.
+func setTransferDefaults(arg *TransferTxParams) error {
 	if arg.FromWalletID == arg.ToWalletID {
-		return result, fmt.Errorf("from and to wallet must be different")
+		return ErrSameWallet
 	}
 
 	if arg.Currency == "" {
@@ -79,88 +94,127 @@ func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (Trans
 	if arg.Type == "" {
 		arg.Type = TransactionTypeP2p
 	}
-	if arg.Status == "" {
-		arg.Status = TransactionStatusPending
+	// The money moves as the transfer is recorded, so it is settled from
+	// the start and never goes through settlement.
+	arg.Status = TransactionStatusSettled
+	if !arg.ConnectionType.Valid {
+		arg.ConnectionType = NullConnectionType{ConnectionType: ConnectionTypeOnline, Valid: true}
 	}
 	if len(arg.Metadata) == 0 {
 		arg.Metadata = []byte(`{}`)
 	}
-	if arg.Nonce == 0 {
-		arg.Nonce = time.Now().UnixNano()
+
+	if arg.Type == TransactionTypeDeposit || arg.Type == TransactionTypeWithdraw {
+		return ErrCashTransferType
 	}
-	if !arg.TransactionAt.Valid {
-		arg.TransactionAt = pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
+	if arg.Type == TransactionTypeRefund || arg.Type == TransactionTypeReversal {
+		return ErrReversalTransferType
 	}
+	return nil
+}
 
-	err := store.execTx(ctx, func(q *Queries) error {
-		var err error
-
-		result.Transaction, err = q.CreateTransaction(ctx, CreateTransactionParams(arg))
-		if err != nil {
-			return err
-		}
+// transfer checks the sender's limits, records the transaction and posts it
+// to the ledger. Defaults are expected to be filled in by the caller. An
+// overdraft is reported as ErrInsufficientBalance and a missing wallet as
+// ErrWalletNotFound.
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams, result *TransferTxResult) error {
+	sender, _, err := lockTransferWallets(ctx, q, arg.FromWalletID, arg.ToWalletID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrWalletNotFound
+	}
+	if err != nil {
+		return err
+	}
+	offline := isOfflineConnection(arg.ConnectionType)
+	if err := verifyTransactionSignature(ctx, q, sender, CreateTransactionParams(arg), offline); err != nil {
+		return err
+	}
+	if err := checkWalletLimits(ctx, q, sender, arg, offline); err != nil {
+		return err
+	}
 
-		result.FromWallet, result.ToWallet, err = transferBalances(
-			ctx,
-			q,
-			arg.FromWalletID,
-			arg.ToWalletID,
-			arg.Amount,
-		)
-		if err != nil {
-			return err
-		}
+	result.Transaction, err = q.CreateTransaction(ctx, CreateTransactionParams(arg))
+	if err != nil {
+		return err
+	}
+	if err := linkPaymentRequest(ctx, q, result.Transaction); err != nil {
+		return err
+	}
 
-		if err := upsertTransferPeers(ctx, q, result.FromWallet, result.ToWallet, arg.ConnectionType); err != nil {
-			return err
-		}
+	posted, err := postLedger(ctx, q, LedgerPosting{
+		TransactionID: pgtype.UUID{Bytes: result.Transaction.ID, Valid: true},
+		EntryType:     ledgerEntryType(arg.Type),
+		Debit:         WalletAccount(arg.FromWalletID),
+		Credit:        WalletAccount(arg.ToWalletID),
+		Amount:        arg.Amount,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrInsufficientBalance
+	}
+	if err != nil {
+		return err
+	}
+	result.FromWallet, result.ToWallet = posted.DebitWallet, posted.CreditWallet
 
-		if err := incrementPeerCounts(ctx, q, result.FromWallet.ID, result.ToWallet.ID); err != nil {
-			return err
-		}
+	if err := upsertTransferPeers(ctx, q, result.FromWallet, result.ToWallet, arg.ConnectionType); err != nil {
+		return err
+	}
+	if err := incrementPeerCounts(ctx, q, result.FromWallet.ID, result.ToWallet.ID); err != nil {
+		return err
+	}
 
-		return nil
-	})
+	return publishEvent(ctx, q, TransferCompleted{Transaction: result.Transaction, Amount: arg.Amount})
+}
 
-	return result, err
+// VerifyTransactionSignature checks the transaction signature against the sender wallet's public key.
+func (store *Store) VerifyTransactionSignature(ctx context.Context, arg CreateTransactionParams) error {
+	sender, err := store.GetWalletByID(ctx, arg.FromWalletID)
+	if err != nil {
+		return err
+	}
+	return verifyTransactionSignature(ctx, store.Queries, sender, arg, isOfflineConnection(arg.ConnectionType))
 }
 
-func transferBalances(
-	ctx context.Context,
-	q *Queries,
-	fromWalletID uuid.UUID,
-	toWalletID uuid.UUID,
-	amount pgtype.Numeric,
-) (fromWallet Wallet, toWallet Wallet, err error) {
-	if bytes.Compare(fromWalletID[:], toWalletID[:]) < 0 {
-		fromWallet, err = q.DecrementWalletBalance(ctx, DecrementWalletBalanceParams{
-			ID:      fromWalletID,
-			Balance: amount,
+// verifyTransactionSignature checks the signature of a transaction. Offline
+// transactions are verified with the key the sender had at transaction_at,
+// so payments made before a key rotation can still be synced, as long as
+// they are no older than maxOfflineAge and the key has not been revoked.
+// Online transactions always need the current key.
+func verifyTransactionSignature(ctx context.Context, q *Queries, sender Wallet, arg CreateTransactionParams, offline bool) error {
+	payload, err := transactionPayload(arg)
+	if err != nil {
+		return err
+	}
+
+	publicKey := sender.PublicKey
+	if offline {
+		if arg.TransactionAt.Time.Before(time.Now().Add(-maxOfflineAge)) {
+			return ErrOfflineTooOld
+		}
+		key, err := q.GetWalletKeyAt(ctx, GetWalletKeyAtParams{
+			WalletID: sender.ID,
+			At:       arg.TransactionAt,
 		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNoWalletKeyAt
+		}
 		if err != nil {
-			return fromWallet, toWallet, err
+			return err
 		}
-
-		toWallet, err = q.IncrementWalletBalance(ctx, IncrementWalletBalanceParams{
-			ID:      toWalletID,
-			Balance: amount,
-		})
-		return fromWallet, toWallet, err
-	}
-
-	toWallet, err = q.IncrementWalletBalance(ctx, IncrementWalletBalanceParams{
-		ID:      toWalletID,
-		Balance: amount,
-	})
-	if err != nil {
-		return fromWallet, toWallet, err
+		publicKey = key.PublicKey
 	}
+	return signature.Verify(publicKey, payload, arg.Signature)
+}
 
-	fromWallet, err = q.DecrementWalletBalance(ctx, DecrementWalletBalanceParams{
-		ID:      fromWalletID,
-		Balance: amount,
-	})
-	return fromWallet, toWallet, err
+func transactionPayload(arg CreateTransactionParams) ([]byte, error) {
+	return signature.Payload{
+		FromWalletID:  arg.FromWalletID,
+		ToWalletID:    arg.ToWalletID,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+		Nonce:         arg.Nonce,
+		TransactionAt: arg.TransactionAt.Time,
+	}.Bytes()
 }
 
 func upsertTransferPeers(ctx context.Context, q *Queries, fromWallet Wallet, toWallet Wallet, connType NullConnectionType) error {
@@ -2,20 +2,25 @@ package database
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/Sahas001/pay-on/internal/signature"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func TestTransferTx(t *testing.T) {
 	ctx := context.Background()
 	store := NewStore(testPool)
 
-	fromWallet := createTestWallet(t, ctx, store.Queries)
+	fromWallet, fromKey := createTestWalletWithKey(t, ctx, store.Queries)
 	toWallet := createTestWallet(t, ctx, store.Queries)
 	var transactionID uuid.UUID
 
 	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
 		if transactionID != uuid.Nil {
 			_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE id = $1", transactionID)
 		}
@@ -29,12 +34,20 @@ func TestTransferTx(t *testing.T) {
 	}()
 
 	amount := numericFromString(t, "25.50")
-	result, err := store.TransferTx(ctx, TransferTxParams{
+	arg := TransferTxParams{
 		FromWalletID: fromWallet.ID,
 		ToWalletID:   toWallet.ID,
 		Amount:       amount,
-		Signature:    "sig-test",
-	})
+	}
+	signTestTransfer(t, fromKey, &arg)
+
+	forged := arg
+	forged.Nonce++
+	if _, err := store.TransferTx(ctx, forged); !errors.Is(err, signature.ErrInvalidSignature) {
+		t.Fatalf("expected invalid signature error, got %v", err)
+	}
+
+	result, err := store.TransferTx(ctx, arg)
 	if err != nil {
 		t.Fatalf("transfer tx: %v", err)
 	}
@@ -46,3 +59,75 @@ func TestTransferTx(t *testing.T) {
 	assertFloatApprox(t, fromBalance, 74.50)
 	assertFloatApprox(t, toBalance, 125.50)
 }
+
+func TestSyncTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	fromWallet, fromKey := createTestWalletWithKey(t, ctx, store.Queries)
+	toWallet := createTestWallet(t, ctx, store.Queries)
+	device, deviceKey := createTestDevice(t, ctx, store.Queries, fromWallet)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id = $1 OR id = $2", fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", device.UserID)
+	}()
+
+	now := time.Now().UTC()
+	items := make([]TransferTxParams, 3)
+	for i := range items {
+		items[i] = TransferTxParams{
+			FromWalletID:  fromWallet.ID,
+			ToWalletID:    toWallet.ID,
+			Amount:        numericFromString(t, "60.00"),
+			Nonce:         now.UnixNano() + int64(i),
+			TransactionAt: pgtype.Timestamptz{Time: now.Add(time.Duration(i) * time.Second), Valid: true},
+		}
+		signTestTransfer(t, fromKey, &items[i])
+	}
+	// The third item replays the first nonce.
+	items[2].Nonce = items[0].Nonce
+	signTestTransfer(t, fromKey, &items[2])
+
+	// Upload out of order; the store applies them by transaction_at.
+	uploaded := []TransferTxParams{items[1], items[0], items[2]}
+	devices := make([]DeviceAttestation, len(uploaded))
+	for i, item := range uploaded {
+		devices[i] = attestTestTransfer(t, device, deviceKey, item)
+	}
+	result, err := store.SyncTx(ctx, SyncTxParams{
+		WalletID:     toWallet.ID,
+		Transactions: uploaded,
+		Devices:      devices,
+	})
+	if err != nil {
+		t.Fatalf("sync tx: %v", err)
+	}
+
+	want := []SyncItemStatus{SyncItemConflict, SyncItemSettled, SyncItemConflict}
+	for i, item := range result.Results {
+		if item.Index != i {
+			t.Fatalf("expected index %d, got %d", i, item.Index)
+		}
+		if item.Status != want[i] {
+			t.Fatalf("item %d: expected status %s, got %s (%s)", i, want[i], item.Status, item.Error)
+		}
+	}
+	if result.Results[0].SyncLog == nil || result.Results[0].SyncLog.Status != SyncStatusConflict {
+		t.Fatalf("expected conflict sync log for overdraft")
+	}
+
+	balance, err := store.GetWalletBalance(ctx, fromWallet.ID)
+	if err != nil {
+		t.Fatalf("get wallet balance: %v", err)
+	}
+	assertFloatApprox(t, numericToFloat64(t, balance), 40.00)
+}
@@ -0,0 +1,337 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/Sahas001/pay-on/internal/signature"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SyncItemStatus is the outcome of syncing a single offline transaction.
+type SyncItemStatus string
+
+const (
+	SyncItemSettled  SyncItemStatus = "settled"
+	SyncItemFailed   SyncItemStatus = "failed"
+	SyncItemConflict SyncItemStatus = "conflict"
+)
+
+var (
+	ErrSyncWalletMismatch = errors.New("transaction does not involve the syncing wallet")
+	ErrSameWallet         = errors.New("from and to wallet must be different")
+	ErrDuplicateNonce     = errors.New("nonce already used by sender wallet")
+	ErrWalletInactive     = errors.New("wallet is inactive")
+	ErrWalletNotFound     = errors.New("wallet not found")
+	ErrOfflineAboveLimit  = errors.New("offline payments above the transfer confirmation threshold cannot be synced")
+)
+
+// SyncTxParams contains a batch of signed offline transactions uploaded by a device.
+// Devices[i] attests Transactions[i]; payments without a valid attestation fail.
+// When MaxAmount is set, payments above it fail: they could not have been
+// confirmed with a second factor while offline.
+type SyncTxParams struct {
+	WalletID     uuid.UUID
+	Transactions []TransferTxParams
+	Devices      []DeviceAttestation
+	MaxAmount    pgtype.Numeric
+}
+
+// SyncItemResult describes what happened to one uploaded transaction.
+type SyncItemResult struct {
+	Index       int            `json:"index"`
+	Nonce       int64          `json:"nonce"`
+	Status      SyncItemStatus `json:"status"`
+	Transaction *Transaction   `json:"transaction,omitempty"`
+	SyncLog     *SyncLog       `json:"sync_log,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// SyncTxResult holds the per-item results in the order they were uploaded.
+type SyncTxResult struct {
+	Results []SyncItemResult `json:"results"`
+}
+
+// SyncTx applies a batch of offline transactions in transaction_at order.
+//
+// Every item is settled in its own database transaction so that one bad
+// payment does not block the rest of the batch. Validation problems are
+// reported per item. A settlement that fails is recorded on the item's sync
+// log for the retry worker; only unexpected database errors outside
+// settlement abort the batch.
+func (store *Store) SyncTx(ctx context.Context, arg SyncTxParams) (SyncTxResult, error) {
+	result := SyncTxResult{Results: make([]SyncItemResult, len(arg.Transactions))}
+
+	order := make([]int, len(arg.Transactions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return arg.Transactions[order[a]].TransactionAt.Time.Before(arg.Transactions[order[b]].TransactionAt.Time)
+	})
+
+	for _, i := range order {
+		var device DeviceAttestation
+		if i < len(arg.Devices) {
+			device = arg.Devices[i]
+		}
+		item, err := store.syncTransaction(ctx, arg.WalletID, arg.Transactions[i], device, arg.MaxAmount)
+		if err != nil {
+			return result, err
+		}
+		item.Index = i
+		result.Results[i] = item
+	}
+
+	if err := store.UpdateWalletLastSync(ctx, arg.WalletID); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func (store *Store) syncTransaction(ctx context.Context, walletID uuid.UUID, arg TransferTxParams, device DeviceAttestation, maxAmount pgtype.Numeric) (SyncItemResult, error) {
+	item := SyncItemResult{Nonce: arg.Nonce}
+
+	if arg.FromWalletID != walletID && arg.ToWalletID != walletID {
+		return failedSyncItem(item, ErrSyncWalletMismatch), nil
+	}
+	if arg.FromWalletID == arg.ToWalletID {
+		return failedSyncItem(item, ErrSameWallet), nil
+	}
+	if maxAmount.Valid && arg.Amount.Valid && numericCmp(arg.Amount, maxAmount) > 0 {
+		return failedSyncItem(item, ErrOfflineAboveLimit), nil
+	}
+
+	if arg.Currency == "" {
+		arg.Currency = "NPR"
+	}
+	if arg.Type == "" {
+		arg.Type = TransactionTypeP2p
+	}
+	if arg.Type == TransactionTypeDeposit || arg.Type == TransactionTypeWithdraw {
+		return failedSyncItem(item, ErrCashTransferType), nil
+	}
+	if arg.Type == TransactionTypeRefund || arg.Type == TransactionTypeReversal {
+		return failedSyncItem(item, ErrReversalTransferType), nil
+	}
+	if len(arg.Metadata) == 0 {
+		arg.Metadata = []byte(`{}`)
+	}
+	arg.Status = TransactionStatusPending
+
+	transaction, err := store.ingestTransaction(ctx, walletID, arg, device)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrDuplicateNonce), IsUniqueViolation(err):
+		return store.duplicateNonceItem(ctx, walletID, item, arg)
+	case errors.Is(err, pgx.ErrNoRows):
+		return failedSyncItem(item, ErrWalletNotFound), nil
+	case isSyncValidationError(err), IsLimitError(err):
+		return failedSyncItem(item, err), nil
+	default:
+		return item, err
+	}
+
+	settled, err := store.SettleTx(ctx, transaction.ID)
+	if err != nil {
+		return store.settleFailedItem(ctx, item, transaction.ID, err)
+	}
+	return settledSyncItem(item, settled), nil
+}
+
+// ingestTransaction verifies an uploaded transaction and stores it as confirmed,
+// ready for settlement. No balance is touched here.
+func (store *Store) ingestTransaction(ctx context.Context, walletID uuid.UUID, arg TransferTxParams, device DeviceAttestation) (Transaction, error) {
+	var transaction Transaction
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// The sender is locked so that limit checks for concurrent uploads
+		// from the same wallet see each other's payments.
+		sender, err := q.GetWalletForUpdate(ctx, arg.FromWalletID)
+		if err != nil {
+			return err
+		}
+		if sender.IsActive != nil && !*sender.IsActive {
+			return ErrWalletInactive
+		}
+		if err := verifyTransactionSignature(ctx, q, sender, CreateTransactionParams(arg), true); err != nil {
+			return err
+		}
+		if err := verifyDeviceAttestation(ctx, q, sender, CreateTransactionParams(arg), device); err != nil {
+			return err
+		}
+
+		exists, err := q.CheckNonceExists(ctx, CheckNonceExistsParams{
+			FromWalletID: arg.FromWalletID,
+			Nonce:        arg.Nonce,
+		})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrDuplicateNonce
+		}
+		// Everything uploaded through sync was paid offline.
+		if err := checkWalletLimits(ctx, q, sender, arg, true); err != nil {
+			return err
+		}
+
+		// Inserted as pending and confirmed afterwards so the peer count
+		// trigger does not fire; settlement updates peers itself.
+		transaction, err = q.CreateTransaction(ctx, CreateTransactionParams(arg))
+		if err != nil {
+			return err
+		}
+		transaction, err = q.ConfirmTransaction(ctx, transaction.ID)
+		if err != nil {
+			return err
+		}
+		if err := linkPaymentRequest(ctx, q, transaction); err != nil {
+			return err
+		}
+		if err := publishEvent(ctx, q, TransactionConfirmed{Transaction: transaction}); err != nil {
+			return err
+		}
+
+		_, err = q.CreateSyncLog(ctx, CreateSyncLogParams{
+			TransactionID: transaction.ID,
+			WalletID:      walletID,
+			Status:        SyncStatusPending,
+		})
+		return err
+	})
+
+	return transaction, err
+}
+
+// duplicateNonceItem treats a re-upload of an already known transaction as a
+// no-op and records any other reuse of the nonce as a conflict.
+func (store *Store) duplicateNonceItem(ctx context.Context, walletID uuid.UUID, item SyncItemResult, arg TransferTxParams) (SyncItemResult, error) {
+	existing, err := store.GetTransactionByWalletNonce(ctx, GetTransactionByWalletNonceParams{
+		FromWalletID: arg.FromWalletID,
+		Nonce:        arg.Nonce,
+	})
+	if err != nil {
+		return item, err
+	}
+
+	if existing.Signature == arg.Signature {
+		item.Transaction = &existing
+		switch existing.Status {
+		case TransactionStatusSettled:
+			item.Status = SyncItemSettled
+			return item, nil
+		case TransactionStatusConfirmed:
+			settled, err := store.SettleTx(ctx, existing.ID)
+			if err != nil {
+				return store.settleFailedItem(ctx, item, existing.ID, err)
+			}
+			return settledSyncItem(item, settled), nil
+		}
+		return failedSyncItem(item, ErrTransactionNotSettleable), nil
+	}
+
+	log, err := store.RecordDuplicateNonce(ctx, walletID, existing, arg)
+	if err != nil {
+		return item, err
+	}
+	item.Status = SyncItemConflict
+	item.Error = ErrDuplicateNonce.Error()
+	item.Transaction = &existing
+	item.SyncLog = &log
+	return item, nil
+}
+
+// lockTransferWallets locks both wallet rows in a stable order to avoid deadlocks.
+func lockTransferWallets(ctx context.Context, q *Queries, fromWalletID, toWalletID uuid.UUID) (fromWallet Wallet, toWallet Wallet, err error) {
+	first, second := fromWalletID, toWalletID
+	swapped := false
+	if bytes.Compare(first[:], second[:]) > 0 {
+		first, second = second, first
+		swapped = true
+	}
+
+	a, err := q.GetWalletForUpdate(ctx, first)
+	if err != nil {
+		return fromWallet, toWallet, err
+	}
+	b, err := q.GetWalletForUpdate(ctx, second)
+	if err != nil {
+		return fromWallet, toWallet, err
+	}
+
+	if swapped {
+		return b, a, nil
+	}
+	return a, b, nil
+}
+
+func settledSyncItem(item SyncItemResult, settled SettleTxResult) SyncItemResult {
+	item.Status = SyncItemSettled
+	if settled.Conflict != nil {
+		item.Status = SyncItemConflict
+		item.Error = string(settled.Conflict.Reason)
+	}
+	item.Transaction = &settled.Transaction
+	item.SyncLog = &settled.SyncLog
+	return item
+}
+
+// settleFailedItem records a failed settlement and an attempt on the
+// transaction's open sync log, so the retry worker settles it again, and
+// reports the item as failed. Only an error recording it aborts the batch.
+func (store *Store) settleFailedItem(ctx context.Context, item SyncItemResult, transactionID uuid.UUID, cause error) (SyncItemResult, error) {
+	message := cause.Error()
+	log, err := store.FailOpenSyncLog(ctx, FailOpenSyncLogParams{
+		TransactionID: transactionID,
+		ErrorMessage:  &message,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return item, err
+	}
+	item = failedSyncItem(item, cause)
+	if err == nil {
+		item.SyncLog = &log
+	}
+	return item, nil
+}
+
+func failedSyncItem(item SyncItemResult, err error) SyncItemResult {
+	item.Status = SyncItemFailed
+	item.Error = err.Error()
+	return item
+}
+
+func isSyncValidationError(err error) bool {
+	for _, target := range []error{
+		ErrWalletInactive,
+		ErrOfflineTooOld,
+		ErrNoWalletKeyAt,
+		ErrUnregisteredDevice,
+		ErrDeviceSignature,
+		signature.ErrInvalidSignature,
+		signature.ErrInvalidPublicKey,
+		signature.ErrUnsupportedKey,
+		signature.ErrInvalidAmount,
+		signature.ErrMissingSignedData,
+		ErrPaymentRequestNotFound,
+		ErrPaymentRequestNotPayable,
+		ErrPaymentRequestMismatch,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUniqueViolation reports whether err is a Postgres unique constraint violation.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -32,7 +32,7 @@ INSERT INTO sync_logs (
     status
 ) VALUES (
     $1, $2, $3
-) RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at
+) RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount
 `
 
 type CreateSyncLogParams struct {
@@ -57,6 +57,68 @@ func (q *Queries) CreateSyncLog(ctx context.Context, arg CreateSyncLogParams) (S
 		&i.ResolvedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
+	)
+	return i, err
+}
+
+const deadLetterExhaustedSyncs = `-- name: DeadLetterExhaustedSyncs :execrows
+UPDATE sync_logs
+SET
+    status = 'dead_letter',
+    updated_at = NOW()
+WHERE status = 'failed'
+  AND resolution IS NULL
+  AND attempt_count >= $1
+`
+
+func (q *Queries) DeadLetterExhaustedSyncs(ctx context.Context, attemptCount *int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deadLetterExhaustedSyncs, attemptCount)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deadLetterSyncLog = `-- name: DeadLetterSyncLog :one
+UPDATE sync_logs
+SET
+    status = 'dead_letter',
+    last_attempt_at = NOW(),
+    attempt_count = attempt_count + 1,
+    error_message = $2,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'failed'
+RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount
+`
+
+type DeadLetterSyncLogParams struct {
+	ID           uuid.UUID `json:"id"`
+	ErrorMessage *string   `json:"error_message"`
+}
+
+func (q *Queries) DeadLetterSyncLog(ctx context.Context, arg DeadLetterSyncLogParams) (SyncLog, error) {
+	row := q.db.QueryRow(ctx, deadLetterSyncLog, arg.ID, arg.ErrorMessage)
+	var i SyncLog
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionID,
+		&i.WalletID,
+		&i.Status,
+		&i.AttemptCount,
+		&i.LastAttemptAt,
+		&i.ErrorMessage,
+		&i.ConflictData,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
 	)
 	return i, err
 }
@@ -72,8 +134,55 @@ func (q *Queries) DeleteOldSyncLogs(ctx context.Context, dollar_1 *string) error
 	return err
 }
 
+const failOpenSyncLog = `-- name: FailOpenSyncLog :one
+UPDATE sync_logs
+SET
+    status = 'failed',
+    last_attempt_at = NOW(),
+    attempt_count = attempt_count + 1,
+    error_message = $2,
+    updated_at = NOW()
+WHERE id = (
+    SELECT id FROM sync_logs
+    WHERE transaction_id = $1
+      AND status IN ('pending', 'confirmed', 'settling', 'failed')
+    ORDER BY created_at DESC
+    LIMIT 1
+    FOR UPDATE
+)
+RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount
+`
+
+type FailOpenSyncLogParams struct {
+	TransactionID uuid.UUID `json:"transaction_id"`
+	ErrorMessage  *string   `json:"error_message"`
+}
+
+func (q *Queries) FailOpenSyncLog(ctx context.Context, arg FailOpenSyncLogParams) (SyncLog, error) {
+	row := q.db.QueryRow(ctx, failOpenSyncLog, arg.TransactionID, arg.ErrorMessage)
+	var i SyncLog
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionID,
+		&i.WalletID,
+		&i.Status,
+		&i.AttemptCount,
+		&i.LastAttemptAt,
+		&i.ErrorMessage,
+		&i.ConflictData,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
+	)
+	return i, err
+}
+
 const getSyncLogByID = `-- name: GetSyncLogByID :one
-SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at FROM sync_logs
+SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount FROM sync_logs
 WHERE id = $1
 `
 
@@ -92,12 +201,45 @@ func (q *Queries) GetSyncLogByID(ctx context.Context, id uuid.UUID) (SyncLog, er
 		&i.ResolvedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
+	)
+	return i, err
+}
+
+const getSyncLogForUpdate = `-- name: GetSyncLogForUpdate :one
+SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount FROM sync_logs
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetSyncLogForUpdate(ctx context.Context, id uuid.UUID) (SyncLog, error) {
+	row := q.db.QueryRow(ctx, getSyncLogForUpdate, id)
+	var i SyncLog
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionID,
+		&i.WalletID,
+		&i.Status,
+		&i.AttemptCount,
+		&i.LastAttemptAt,
+		&i.ErrorMessage,
+		&i.ConflictData,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
 	)
 	return i, err
 }
 
 const getSyncLogsByTransaction = `-- name: GetSyncLogsByTransaction :many
-SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at FROM sync_logs
+SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount FROM sync_logs
 WHERE transaction_id = $1
 ORDER BY created_at DESC
 `
@@ -123,6 +265,10 @@ func (q *Queries) GetSyncLogsByTransaction(ctx context.Context, transactionID uu
 			&i.ResolvedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Resolution,
+			&i.ResolvedBy,
+			&i.ResolutionNote,
+			&i.ResolvedAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -135,7 +281,7 @@ func (q *Queries) GetSyncLogsByTransaction(ctx context.Context, transactionID uu
 }
 
 const getSyncLogsByWallet = `-- name: GetSyncLogsByWallet :many
-SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at FROM sync_logs
+SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount FROM sync_logs
 WHERE wallet_id = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3
@@ -168,6 +314,10 @@ func (q *Queries) GetSyncLogsByWallet(ctx context.Context, arg GetSyncLogsByWall
 			&i.ResolvedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Resolution,
+			&i.ResolvedBy,
+			&i.ResolutionNote,
+			&i.ResolvedAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -185,17 +335,19 @@ SELECT
     COUNT(*) FILTER (WHERE status = 'settled') as synced_count,
     COUNT(*) FILTER (WHERE status = 'failed') as failed_count,
     COUNT(*) FILTER (WHERE status = 'conflict') as conflict_count,
+    COUNT(*) FILTER (WHERE status = 'dead_letter') as dead_letter_count,
     AVG(attempt_count) as avg_attempts
 FROM sync_logs
 WHERE wallet_id = $1
 `
 
 type GetSyncStatsRow struct {
-	PendingCount  int64   `json:"pending_count"`
-	SyncedCount   int64   `json:"synced_count"`
-	FailedCount   int64   `json:"failed_count"`
-	ConflictCount int64   `json:"conflict_count"`
-	AvgAttempts   float64 `json:"avg_attempts"`
+	PendingCount    int64   `json:"pending_count"`
+	SyncedCount     int64   `json:"synced_count"`
+	FailedCount     int64   `json:"failed_count"`
+	ConflictCount   int64   `json:"conflict_count"`
+	DeadLetterCount int64   `json:"dead_letter_count"`
+	AvgAttempts     float64 `json:"avg_attempts"`
 }
 
 func (q *Queries) GetSyncStats(ctx context.Context, walletID uuid.UUID) (GetSyncStatsRow, error) {
@@ -206,16 +358,24 @@ func (q *Queries) GetSyncStats(ctx context.Context, walletID uuid.UUID) (GetSync
 		&i.SyncedCount,
 		&i.FailedCount,
 		&i.ConflictCount,
+		&i.DeadLetterCount,
 		&i.AvgAttempts,
 	)
 	return i, err
 }
 
 const getSyncsNeedingRetry = `-- name: GetSyncsNeedingRetry :many
-SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at FROM sync_logs
+SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount FROM sync_logs
 WHERE status = 'failed'
+  AND resolution IS NULL
   AND attempt_count < $1
-  AND (last_attempt_at IS NULL OR last_attempt_at < NOW() - INTERVAL '5 minutes')
+  AND (
+    attempt_count = 0
+    OR last_attempt_at IS NULL
+    OR last_attempt_at
+        + LEAST(INTERVAL '1 minute' * POWER(2, attempt_count - 1), INTERVAL '6 hours')
+        * (1 - get_byte(uuid_send(id), 15) / 510.0) <= NOW()
+  )
 ORDER BY attempt_count ASC, created_at ASC
 LIMIT $2
 `
@@ -246,6 +406,10 @@ func (q *Queries) GetSyncsNeedingRetry(ctx context.Context, arg GetSyncsNeedingR
 			&i.ResolvedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Resolution,
+			&i.ResolvedBy,
+			&i.ResolutionNote,
+			&i.ResolvedAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -257,8 +421,28 @@ func (q *Queries) GetSyncsNeedingRetry(ctx context.Context, arg GetSyncsNeedingR
 	return items, nil
 }
 
+const isSyncLogOwner = `-- name: IsSyncLogOwner :one
+SELECT EXISTS(
+    SELECT 1 FROM sync_logs s
+    JOIN wallets w ON w.id = s.wallet_id
+    WHERE s.id = $1 AND w.user_id = $2
+)
+`
+
+type IsSyncLogOwnerParams struct {
+	ID     uuid.UUID   `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) IsSyncLogOwner(ctx context.Context, arg IsSyncLogOwnerParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isSyncLogOwner, arg.ID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const listAllPendingSyncs = `-- name: ListAllPendingSyncs :many
-SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at FROM sync_logs
+SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount FROM sync_logs
 WHERE status = 'pending'
 ORDER BY created_at ASC
 LIMIT $1 OFFSET $2
@@ -290,6 +474,10 @@ func (q *Queries) ListAllPendingSyncs(ctx context.Context, arg ListAllPendingSyn
 			&i.ResolvedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Resolution,
+			&i.ResolvedBy,
+			&i.ResolutionNote,
+			&i.ResolvedAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -302,7 +490,7 @@ func (q *Queries) ListAllPendingSyncs(ctx context.Context, arg ListAllPendingSyn
 }
 
 const listConflictedSyncs = `-- name: ListConflictedSyncs :many
-SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at FROM sync_logs
+SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount FROM sync_logs
 WHERE status = 'conflict'
   AND wallet_id = $1
 ORDER BY created_at DESC
@@ -335,6 +523,58 @@ func (q *Queries) ListConflictedSyncs(ctx context.Context, arg ListConflictedSyn
 			&i.ResolvedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Resolution,
+			&i.ResolvedBy,
+			&i.ResolutionNote,
+			&i.ResolvedAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeadLetterSyncs = `-- name: ListDeadLetterSyncs :many
+SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount FROM sync_logs
+WHERE status = 'dead_letter'
+ORDER BY updated_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListDeadLetterSyncsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListDeadLetterSyncs(ctx context.Context, arg ListDeadLetterSyncsParams) ([]SyncLog, error) {
+	rows, err := q.db.Query(ctx, listDeadLetterSyncs, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SyncLog{}
+	for rows.Next() {
+		var i SyncLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.WalletID,
+			&i.Status,
+			&i.AttemptCount,
+			&i.LastAttemptAt,
+			&i.ErrorMessage,
+			&i.ConflictData,
+			&i.ResolvedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Resolution,
+			&i.ResolvedBy,
+			&i.ResolutionNote,
+			&i.ResolvedAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -347,7 +587,7 @@ func (q *Queries) ListConflictedSyncs(ctx context.Context, arg ListConflictedSyn
 }
 
 const listFailedSyncs = `-- name: ListFailedSyncs :many
-SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at FROM sync_logs
+SELECT id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount FROM sync_logs
 WHERE status = 'failed'
   AND wallet_id = $1
 ORDER BY last_attempt_at DESC
@@ -380,6 +620,10 @@ func (q *Queries) ListFailedSyncs(ctx context.Context, arg ListFailedSyncsParams
 			&i.ResolvedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Resolution,
+			&i.ResolvedBy,
+			&i.ResolutionNote,
+			&i.ResolvedAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -393,7 +637,7 @@ func (q *Queries) ListFailedSyncs(ctx context.Context, arg ListFailedSyncsParams
 
 const listPendingSyncs = `-- name: ListPendingSyncs :many
 SELECT 
-    sl.id, sl.transaction_id, sl.wallet_id, sl.status, sl.attempt_count, sl.last_attempt_at, sl.error_message, sl.conflict_data, sl.resolved_at, sl.created_at, sl.updated_at,
+    sl.id, sl.transaction_id, sl.wallet_id, sl.status, sl.attempt_count, sl.last_attempt_at, sl.error_message, sl.conflict_data, sl.resolved_at, sl.created_at, sl.updated_at, sl.resolution, sl.resolved_by, sl.resolution_note, sl.resolved_amount,
     t.amount,
     t.type as transaction_type,
     t.created_at as transaction_created_at
@@ -422,6 +666,10 @@ type ListPendingSyncsRow struct {
 	ResolvedAt           pgtype.Timestamptz `json:"resolved_at"`
 	CreatedAt            pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt            pgtype.Timestamptz `json:"updated_at"`
+	Resolution           NullSyncResolution `json:"resolution"`
+	ResolvedBy           pgtype.UUID        `json:"resolved_by"`
+	ResolutionNote       *string            `json:"resolution_note"`
+	ResolvedAmount       pgtype.Numeric     `json:"resolved_amount"`
 	Amount               pgtype.Numeric     `json:"amount"`
 	TransactionType      TransactionType    `json:"transaction_type"`
 	TransactionCreatedAt pgtype.Timestamptz `json:"transaction_created_at"`
@@ -448,6 +696,10 @@ func (q *Queries) ListPendingSyncs(ctx context.Context, arg ListPendingSyncsPara
 			&i.ResolvedAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Resolution,
+			&i.ResolvedBy,
+			&i.ResolutionNote,
+			&i.ResolvedAmount,
 			&i.Amount,
 			&i.TransactionType,
 			&i.TransactionCreatedAt,
@@ -471,7 +723,7 @@ SET
     conflict_data = $2,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at
+RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount
 `
 
 type MarkSettleConflictParams struct {
@@ -494,6 +746,10 @@ func (q *Queries) MarkSettleConflict(ctx context.Context, arg MarkSettleConflict
 		&i.ResolvedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
 	)
 	return i, err
 }
@@ -507,7 +763,7 @@ SET
     error_message = $2,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at
+RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount
 `
 
 type MarkSettleFailedParams struct {
@@ -530,6 +786,10 @@ func (q *Queries) MarkSettleFailed(ctx context.Context, arg MarkSettleFailedPara
 		&i.ResolvedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
 	)
 	return i, err
 }
@@ -541,7 +801,7 @@ SET
     last_attempt_at = NOW(),
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at
+RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount
 `
 
 func (q *Queries) MarkSettleSuccessful(ctx context.Context, id uuid.UUID) (SyncLog, error) {
@@ -559,6 +819,43 @@ func (q *Queries) MarkSettleSuccessful(ctx context.Context, id uuid.UUID) (SyncL
 		&i.ResolvedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
+	)
+	return i, err
+}
+
+const requeueSyncLog = `-- name: RequeueSyncLog :one
+UPDATE sync_logs
+SET
+    status = 'failed',
+    attempt_count = 0,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'dead_letter'
+RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount
+`
+
+func (q *Queries) RequeueSyncLog(ctx context.Context, id uuid.UUID) (SyncLog, error) {
+	row := q.db.QueryRow(ctx, requeueSyncLog, id)
+	var i SyncLog
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionID,
+		&i.WalletID,
+		&i.Status,
+		&i.AttemptCount,
+		&i.LastAttemptAt,
+		&i.ErrorMessage,
+		&i.ConflictData,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
 	)
 	return i, err
 }
@@ -570,7 +867,7 @@ SET
     resolved_at = NOW(),
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at
+RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount
 `
 
 func (q *Queries) ResolveSyncConflict(ctx context.Context, id uuid.UUID) (SyncLog, error) {
@@ -588,6 +885,95 @@ func (q *Queries) ResolveSyncConflict(ctx context.Context, id uuid.UUID) (SyncLo
 		&i.ResolvedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
+	)
+	return i, err
+}
+
+const resolveSyncLog = `-- name: ResolveSyncLog :one
+UPDATE sync_logs
+SET
+    status = $2,
+    resolution = $3,
+    resolved_by = $4,
+    resolution_note = $5,
+    resolved_amount = $6,
+    resolved_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND status = 'conflict'
+RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount
+`
+
+type ResolveSyncLogParams struct {
+	ID             uuid.UUID          `json:"id"`
+	Status         SyncStatus         `json:"status"`
+	Resolution     NullSyncResolution `json:"resolution"`
+	ResolvedBy     pgtype.UUID        `json:"resolved_by"`
+	ResolutionNote *string            `json:"resolution_note"`
+	ResolvedAmount pgtype.Numeric     `json:"resolved_amount"`
+}
+
+func (q *Queries) ResolveSyncLog(ctx context.Context, arg ResolveSyncLogParams) (SyncLog, error) {
+	row := q.db.QueryRow(ctx, resolveSyncLog, arg.ID, arg.Status, arg.Resolution, arg.ResolvedBy, arg.ResolutionNote, arg.ResolvedAmount)
+	var i SyncLog
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionID,
+		&i.WalletID,
+		&i.Status,
+		&i.AttemptCount,
+		&i.LastAttemptAt,
+		&i.ErrorMessage,
+		&i.ConflictData,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
+	)
+	return i, err
+}
+
+const scheduleSyncRetry = `-- name: ScheduleSyncRetry :one
+UPDATE sync_logs
+SET
+    last_attempt_at = NOW(),
+    attempt_count = attempt_count + 1,
+    error_message = $2,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'failed'
+RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount
+`
+
+type ScheduleSyncRetryParams struct {
+	ID           uuid.UUID `json:"id"`
+	ErrorMessage *string   `json:"error_message"`
+}
+
+func (q *Queries) ScheduleSyncRetry(ctx context.Context, arg ScheduleSyncRetryParams) (SyncLog, error) {
+	row := q.db.QueryRow(ctx, scheduleSyncRetry, arg.ID, arg.ErrorMessage)
+	var i SyncLog
+	err := row.Scan(
+		&i.ID,
+		&i.TransactionID,
+		&i.WalletID,
+		&i.Status,
+		&i.AttemptCount,
+		&i.LastAttemptAt,
+		&i.ErrorMessage,
+		&i.ConflictData,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
 	)
 	return i, err
 }
@@ -600,7 +986,7 @@ SET
     attempt_count = attempt_count + 1,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at
+RETURNING id, transaction_id, wallet_id, status, attempt_count, last_attempt_at, error_message, conflict_data, resolved_at, created_at, updated_at, resolution, resolved_by, resolution_note, resolved_amount
 `
 
 type UpdateSyncLogStatusParams struct {
@@ -623,6 +1009,10 @@ func (q *Queries) UpdateSyncLogStatus(ctx context.Context, arg UpdateSyncLogStat
 		&i.ResolvedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolutionNote,
+		&i.ResolvedAmount,
 	)
 	return i, err
 }
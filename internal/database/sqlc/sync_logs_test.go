@@ -2,7 +2,10 @@ package database
 
 import (
 	"context"
+	"errors"
 	"testing"
+
+	"github.com/jackc/pgx/v5"
 )
 
 func TestSyncLogQueries(t *testing.T) {
@@ -33,3 +36,117 @@ func TestSyncLogQueries(t *testing.T) {
 		}
 	})
 }
+
+func TestSyncLogDeadLetters(t *testing.T) {
+	withTx(t, func(ctx context.Context, q *Queries) {
+		wallet := createTestWallet(t, ctx, q)
+		peerWallet := createTestWallet(t, ctx, q)
+
+		transaction := createTestTransaction(t, ctx, q, wallet.ID, peerWallet.ID, "15.00", TransactionStatusConfirmed)
+		log := createTestSyncLog(t, ctx, q, transaction.ID, wallet.ID, SyncStatusFailed)
+
+		message := "connection reset"
+		retry, err := q.ScheduleSyncRetry(ctx, ScheduleSyncRetryParams{ID: log.ID, ErrorMessage: &message})
+		if err != nil {
+			t.Fatalf("schedule sync retry: %v", err)
+		}
+		if retry.AttemptCount == nil || *retry.AttemptCount != 1 || !retry.LastAttemptAt.Valid {
+			t.Fatalf("expected one recorded attempt, got %+v", retry)
+		}
+
+		maxAttempts := int32(5)
+		isDue := func() bool {
+			due, err := q.GetSyncsNeedingRetry(ctx, GetSyncsNeedingRetryParams{AttemptCount: &maxAttempts, Limit: 100})
+			if err != nil {
+				t.Fatalf("get syncs needing retry: %v", err)
+			}
+			for _, other := range due {
+				if other.ID == log.ID {
+					return true
+				}
+			}
+			return false
+		}
+		if isDue() {
+			t.Fatal("expected a log waiting out its backoff to be skipped")
+		}
+		// The first backoff is at most a minute.
+		if _, err := q.db.Exec(ctx, "UPDATE sync_logs SET last_attempt_at = last_attempt_at - INTERVAL '1 minute' WHERE id = $1", log.ID); err != nil {
+			t.Fatalf("age last attempt: %v", err)
+		}
+		if !isDue() {
+			t.Fatal("expected the log due once its backoff has passed")
+		}
+
+		dead, err := q.DeadLetterSyncLog(ctx, DeadLetterSyncLogParams{ID: log.ID, ErrorMessage: &message})
+		if err != nil {
+			t.Fatalf("dead letter sync log: %v", err)
+		}
+		if dead.Status != SyncStatusDeadLetter {
+			t.Fatalf("expected a dead letter, got %+v", dead)
+		}
+
+		requeued, err := q.RequeueSyncLog(ctx, log.ID)
+		if err != nil {
+			t.Fatalf("requeue sync log: %v", err)
+		}
+		if requeued.Status != SyncStatusFailed || *requeued.AttemptCount != 0 {
+			t.Fatalf("expected a failed log with its attempts reset, got %+v", requeued)
+		}
+		if !isDue() {
+			t.Fatal("expected a requeued log due straight away")
+		}
+		if _, err := q.RequeueSyncLog(ctx, log.ID); !errors.Is(err, pgx.ErrNoRows) {
+			t.Fatalf("expected only dead letters to be requeued, got %v", err)
+		}
+
+		// A conflict an operator rejected is failed for good: it is neither
+		// retried nor moved to the dead letters.
+		if _, err := q.db.Exec(ctx, "UPDATE sync_logs SET resolution = 'reject', resolved_at = NOW() WHERE id = $1", log.ID); err != nil {
+			t.Fatalf("reject sync log: %v", err)
+		}
+		if isDue() {
+			t.Fatal("expected a rejected log to be skipped")
+		}
+		noAttempts := int32(0)
+		if _, err := q.DeadLetterExhaustedSyncs(ctx, &noAttempts); err != nil {
+			t.Fatalf("dead letter exhausted syncs: %v", err)
+		}
+		rejected, err := q.GetSyncLogByID(ctx, log.ID)
+		if err != nil {
+			t.Fatalf("get sync log: %v", err)
+		}
+		if rejected.Status != SyncStatusFailed {
+			t.Fatalf("expected the rejected log to stay failed, got %+v", rejected)
+		}
+	})
+}
+
+func TestFailOpenSyncLog(t *testing.T) {
+	withTx(t, func(ctx context.Context, q *Queries) {
+		wallet := createTestWallet(t, ctx, q)
+		peerWallet := createTestWallet(t, ctx, q)
+
+		transaction := createTestTransaction(t, ctx, q, wallet.ID, peerWallet.ID, "15.00", TransactionStatusConfirmed)
+		settled := createTestSyncLog(t, ctx, q, transaction.ID, wallet.ID, SyncStatusSettled)
+		pending := createTestSyncLog(t, ctx, q, transaction.ID, wallet.ID, SyncStatusPending)
+
+		message := "daily limit exceeded"
+		failed, err := q.FailOpenSyncLog(ctx, FailOpenSyncLogParams{TransactionID: transaction.ID, ErrorMessage: &message})
+		if err != nil {
+			t.Fatalf("fail open sync log: %v", err)
+		}
+		if failed.ID != pending.ID || failed.Status != SyncStatusFailed ||
+			failed.AttemptCount == nil || *failed.AttemptCount != 1 || !failed.LastAttemptAt.Valid {
+			t.Fatalf("expected the pending log failed with one attempt, got %+v", failed)
+		}
+
+		closed, err := q.GetSyncLogByID(ctx, settled.ID)
+		if err != nil {
+			t.Fatalf("get sync log: %v", err)
+		}
+		if closed.Status != SyncStatusSettled {
+			t.Fatalf("expected the settled log untouched, got %+v", closed)
+		}
+	})
+}
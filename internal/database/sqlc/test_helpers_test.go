@@ -2,6 +2,9 @@ package database
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"math"
 	"net"
@@ -10,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Sahas001/pay-on/internal/signature"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -32,6 +36,35 @@ func withTx(t *testing.T, fn func(ctx context.Context, q *Queries)) {
 	fn(ctx, q)
 }
 
+// deleteTestLedger removes the ledger postings of the given wallets and of
+// their transactions, so that test cleanup can delete the wallets. Ledger
+// entries are immutable, so the guard is switched off inside this cleanup
+// transaction only.
+func deleteTestLedger(ctx context.Context, walletIDs ...uuid.UUID) {
+	tx, err := testPool.Begin(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err := tx.Exec(ctx, "ALTER TABLE ledger_entries DISABLE TRIGGER trigger_ledger_immutable"); err != nil {
+		return
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM ledger_entries WHERE posting_id IN (
+		SELECT posting_id FROM ledger_entries
+		WHERE wallet_id = ANY($1)
+		   OR transaction_id IN (SELECT id FROM transactions WHERE from_wallet_id = ANY($1) OR to_wallet_id = ANY($1))
+	)`, walletIDs); err != nil {
+		return
+	}
+	if _, err := tx.Exec(ctx, "ALTER TABLE ledger_entries ENABLE TRIGGER trigger_ledger_immutable"); err != nil {
+		return
+	}
+	_ = tx.Commit(ctx)
+}
+
 func numericFromString(t *testing.T, value string) pgtype.Numeric {
 	t.Helper()
 
@@ -78,13 +111,24 @@ func pgUUIDFromUUID(id uuid.UUID) pgtype.UUID {
 func createTestWallet(t *testing.T, ctx context.Context, q *Queries) Wallet {
 	t.Helper()
 
+	wallet, _ := createTestWalletWithKey(t, ctx, q)
+	return wallet
+}
+
+func createTestWalletWithKey(t *testing.T, ctx context.Context, q *Queries) (Wallet, ed25519.PrivateKey) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate wallet key: %v", err)
+	}
+
 	deviceID := fmt.Sprintf("device-%s", uuid.NewString())
 	name := fmt.Sprintf("User %d", atomic.AddInt64(&phoneSeq, 1))
-	balance := numericFromString(t, "100.00")
+	balance := numericFromString(t, "0")
 
 	wallet, err := q.CreateWallet(ctx, CreateWalletParams{
-		PublicKey:   "pub-" + uuid.NewString(),
-		PrivateKey:  "priv-" + uuid.NewString(),
+		PublicKey:   base64.StdEncoding.EncodeToString(pub),
 		Balance:     balance,
 		PhoneNumber: nextPhoneNumber(),
 		Name:        name,
@@ -94,7 +138,88 @@ func createTestWallet(t *testing.T, ctx context.Context, q *Queries) Wallet {
 	if err != nil {
 		t.Fatalf("create wallet: %v", err)
 	}
-	return wallet
+
+	posted, err := postLedger(ctx, q, LedgerPosting{
+		EntryType: LedgerEntryTypeOpening,
+		Debit:     SystemAccount(LedgerAccountOpening),
+		Credit:    WalletAccount(wallet.ID),
+		Amount:    numericFromString(t, "100.00"),
+	})
+	if err != nil {
+		t.Fatalf("post opening balance: %v", err)
+	}
+	return posted.CreditWallet, priv
+}
+
+func signTestTransfer(t *testing.T, key ed25519.PrivateKey, arg *TransferTxParams) {
+	t.Helper()
+
+	if arg.Nonce == 0 {
+		arg.Nonce = time.Now().UnixNano()
+	}
+	if !arg.TransactionAt.Valid {
+		arg.TransactionAt = pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
+	}
+
+	payload, err := signature.Payload{
+		FromWalletID:  arg.FromWalletID,
+		ToWalletID:    arg.ToWalletID,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+		Nonce:         arg.Nonce,
+		TransactionAt: arg.TransactionAt.Time,
+	}.Bytes()
+	if err != nil {
+		t.Fatalf("build signing payload: %v", err)
+	}
+	arg.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+}
+
+// createTestDevice gives the wallet an owner with a verified device. Callers
+// delete the owner (device.UserID) after the wallet.
+func createTestDevice(t *testing.T, ctx context.Context, q *Queries, wallet Wallet) (Device, ed25519.PrivateKey) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate device key: %v", err)
+	}
+	user, err := q.CreateUser(ctx, CreateUserParams{
+		PhoneNumber:  nextPhoneNumber(),
+		PasswordHash: "password-hash",
+	})
+	if err != nil {
+		t.Fatalf("create device owner: %v", err)
+	}
+	if _, err := q.db.Exec(ctx, "UPDATE wallets SET user_id = $1 WHERE id = $2", user.ID, wallet.ID); err != nil {
+		t.Fatalf("assign wallet owner: %v", err)
+	}
+
+	device, err := q.CreateDevice(ctx, CreateDeviceParams{
+		UserID:     user.ID,
+		DeviceID:   "device-" + uuid.NewString(),
+		PublicKey:  base64.StdEncoding.EncodeToString(pub),
+		VerifiedAt: pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("create device: %v", err)
+	}
+	return device, priv
+}
+
+// attestTestTransfer signs an already signed transfer with a device key, as
+// the sending device does for offline payments.
+func attestTestTransfer(t *testing.T, device Device, key ed25519.PrivateKey, arg TransferTxParams) DeviceAttestation {
+	t.Helper()
+
+	payload, err := transactionPayload(CreateTransactionParams(arg))
+	if err != nil {
+		t.Fatalf("build signing payload: %v", err)
+	}
+	return DeviceAttestation{
+		DeviceID:  device.DeviceID,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+	}
 }
 
 func createTestTransaction(t *testing.T, ctx context.Context, q *Queries, fromID, toID uuid.UUID, amount string, status TransactionStatus) Transaction {
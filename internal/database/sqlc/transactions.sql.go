@@ -37,7 +37,7 @@ SET
     status = 'confirmed',
     confirmed_at = NOW(),
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND status = 'pending'
 RETURNING id, from_wallet_id, to_wallet_id, amount, currency, type, status, signature, nonce, connection_type, description, metadata, transaction_at, confirmed_at, synced_at, created_at, updated_at
 `
 
@@ -383,6 +383,72 @@ func (q *Queries) GetTransactionByID(ctx context.Context, id uuid.UUID) (Transac
 	return i, err
 }
 
+const getTransactionByWalletNonce = `-- name: GetTransactionByWalletNonce :one
+SELECT id, from_wallet_id, to_wallet_id, amount, currency, type, status, signature, nonce, connection_type, description, metadata, transaction_at, confirmed_at, synced_at, created_at, updated_at FROM transactions
+WHERE from_wallet_id = $1 AND nonce = $2
+`
+
+type GetTransactionByWalletNonceParams struct {
+	FromWalletID uuid.UUID `json:"from_wallet_id"`
+	Nonce        int64     `json:"nonce"`
+}
+
+func (q *Queries) GetTransactionByWalletNonce(ctx context.Context, arg GetTransactionByWalletNonceParams) (Transaction, error) {
+	row := q.db.QueryRow(ctx, getTransactionByWalletNonce, arg.FromWalletID, arg.Nonce)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.FromWalletID,
+		&i.ToWalletID,
+		&i.Amount,
+		&i.Currency,
+		&i.Type,
+		&i.Status,
+		&i.Signature,
+		&i.Nonce,
+		&i.ConnectionType,
+		&i.Description,
+		&i.Metadata,
+		&i.TransactionAt,
+		&i.ConfirmedAt,
+		&i.SyncedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getTransactionForUpdate = `-- name: GetTransactionForUpdate :one
+SELECT id, from_wallet_id, to_wallet_id, amount, currency, type, status, signature, nonce, connection_type, description, metadata, transaction_at, confirmed_at, synced_at, created_at, updated_at FROM transactions
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetTransactionForUpdate(ctx context.Context, id uuid.UUID) (Transaction, error) {
+	row := q.db.QueryRow(ctx, getTransactionForUpdate, id)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.FromWalletID,
+		&i.ToWalletID,
+		&i.Amount,
+		&i.Currency,
+		&i.Type,
+		&i.Status,
+		&i.Signature,
+		&i.Nonce,
+		&i.ConnectionType,
+		&i.Description,
+		&i.Metadata,
+		&i.TransactionAt,
+		&i.ConfirmedAt,
+		&i.SyncedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getTransactionStats = `-- name: GetTransactionStats :one
 SELECT 
     COALESCE(SUM(CASE WHEN from_wallet_id = $1 THEN amount ELSE 0 END), 0) as total_sent,
@@ -639,6 +705,141 @@ func (q *Queries) GetTransactionsByMetadata(ctx context.Context, arg GetTransact
 	return items, nil
 }
 
+const isTransactionParticipant = `-- name: IsTransactionParticipant :one
+SELECT EXISTS(
+    SELECT 1 FROM transactions t
+    JOIN wallets w ON w.id IN (t.from_wallet_id, t.to_wallet_id)
+    WHERE t.id = $1 AND w.user_id = $2
+)
+`
+
+type IsTransactionParticipantParams struct {
+	ID     uuid.UUID   `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) IsTransactionParticipant(ctx context.Context, arg IsTransactionParticipantParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isTransactionParticipant, arg.ID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listCompetingTransactions = `-- name: ListCompetingTransactions :many
+SELECT id, from_wallet_id, to_wallet_id, amount, currency, type, status, signature, nonce, connection_type, description, metadata, transaction_at, confirmed_at, synced_at, created_at, updated_at FROM transactions
+WHERE from_wallet_id = $1
+  AND id <> $2
+  AND status IN ('confirmed', 'settling', 'settled')
+  AND transaction_at >= $3
+ORDER BY transaction_at ASC, nonce ASC
+LIMIT $4
+`
+
+type ListCompetingTransactionsParams struct {
+	FromWalletID  uuid.UUID          `json:"from_wallet_id"`
+	ID            uuid.UUID          `json:"id"`
+	TransactionAt pgtype.Timestamptz `json:"transaction_at"`
+	Limit         int32              `json:"limit"`
+}
+
+func (q *Queries) ListCompetingTransactions(ctx context.Context, arg ListCompetingTransactionsParams) ([]Transaction, error) {
+	rows, err := q.db.Query(ctx, listCompetingTransactions, arg.FromWalletID, arg.ID, arg.TransactionAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromWalletID,
+			&i.ToWalletID,
+			&i.Amount,
+			&i.Currency,
+			&i.Type,
+			&i.Status,
+			&i.Signature,
+			&i.Nonce,
+			&i.ConnectionType,
+			&i.Description,
+			&i.Metadata,
+			&i.TransactionAt,
+			&i.ConfirmedAt,
+			&i.SyncedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOutOfOrderTransactions = `-- name: ListOutOfOrderTransactions :many
+SELECT id, from_wallet_id, to_wallet_id, amount, currency, type, status, signature, nonce, connection_type, description, metadata, transaction_at, confirmed_at, synced_at, created_at, updated_at FROM transactions
+WHERE from_wallet_id = $1
+  AND id <> $2
+  AND status = 'settled'
+  AND nonce <> 0
+  AND (
+    (nonce < $3 AND transaction_at > $4)
+    OR (nonce > $3 AND transaction_at < $4)
+  )
+ORDER BY transaction_at ASC
+LIMIT $5
+`
+
+type ListOutOfOrderTransactionsParams struct {
+	FromWalletID  uuid.UUID          `json:"from_wallet_id"`
+	ID            uuid.UUID          `json:"id"`
+	Nonce         int64              `json:"nonce"`
+	TransactionAt pgtype.Timestamptz `json:"transaction_at"`
+	Limit         int32              `json:"limit"`
+}
+
+func (q *Queries) ListOutOfOrderTransactions(ctx context.Context, arg ListOutOfOrderTransactionsParams) ([]Transaction, error) {
+	rows, err := q.db.Query(ctx, listOutOfOrderTransactions, arg.FromWalletID, arg.ID, arg.Nonce, arg.TransactionAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromWalletID,
+			&i.ToWalletID,
+			&i.Amount,
+			&i.Currency,
+			&i.Type,
+			&i.Status,
+			&i.Signature,
+			&i.Nonce,
+			&i.ConnectionType,
+			&i.Description,
+			&i.Metadata,
+			&i.TransactionAt,
+			&i.ConfirmedAt,
+			&i.SyncedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listPendingTransactions = `-- name: ListPendingTransactions :many
 SELECT id, from_wallet_id, to_wallet_id, amount, currency, type, status, signature, nonce, connection_type, description, metadata, transaction_at, confirmed_at, synced_at, created_at, updated_at FROM transactions
 WHERE status IN ('pending', 'confirmed')
@@ -792,6 +993,62 @@ func (q *Queries) ListSentTransactions(ctx context.Context, arg ListSentTransact
 	return items, nil
 }
 
+const listSettleableTransactions = `-- name: ListSettleableTransactions :many
+SELECT id, from_wallet_id, to_wallet_id, amount, currency, type, status, signature, nonce, connection_type, description, metadata, transaction_at, confirmed_at, synced_at, created_at, updated_at FROM transactions
+WHERE from_wallet_id = $1
+  AND status = 'confirmed'
+  AND NOT EXISTS (
+    SELECT 1 FROM sync_logs
+    WHERE sync_logs.transaction_id = transactions.id
+      AND sync_logs.status = 'conflict'
+  )
+ORDER BY transaction_at ASC, nonce ASC
+LIMIT $2
+`
+
+type ListSettleableTransactionsParams struct {
+	FromWalletID uuid.UUID `json:"from_wallet_id"`
+	Limit        int32     `json:"limit"`
+}
+
+func (q *Queries) ListSettleableTransactions(ctx context.Context, arg ListSettleableTransactionsParams) ([]Transaction, error) {
+	rows, err := q.db.Query(ctx, listSettleableTransactions, arg.FromWalletID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromWalletID,
+			&i.ToWalletID,
+			&i.Amount,
+			&i.Currency,
+			&i.Type,
+			&i.Status,
+			&i.Signature,
+			&i.Nonce,
+			&i.ConnectionType,
+			&i.Description,
+			&i.Metadata,
+			&i.TransactionAt,
+			&i.ConfirmedAt,
+			&i.SyncedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransactionsByStatus = `-- name: ListTransactionsByStatus :many
 SELECT id, from_wallet_id, to_wallet_id, amount, currency, type, status, signature, nonce, connection_type, description, metadata, transaction_at, confirmed_at, synced_at, created_at, updated_at FROM transactions
 WHERE status = $1
@@ -1007,6 +1264,40 @@ func (q *Queries) MarkTransactionSettled(ctx context.Context, id uuid.UUID) (Tra
 	return i, err
 }
 
+const rollbackTransaction = `-- name: RollbackTransaction :one
+UPDATE transactions
+SET
+    status = 'rolled_back',
+    updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'confirmed', 'settled')
+RETURNING id, from_wallet_id, to_wallet_id, amount, currency, type, status, signature, nonce, connection_type, description, metadata, transaction_at, confirmed_at, synced_at, created_at, updated_at
+`
+
+func (q *Queries) RollbackTransaction(ctx context.Context, id uuid.UUID) (Transaction, error) {
+	row := q.db.QueryRow(ctx, rollbackTransaction, id)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.FromWalletID,
+		&i.ToWalletID,
+		&i.Amount,
+		&i.Currency,
+		&i.Type,
+		&i.Status,
+		&i.Signature,
+		&i.Nonce,
+		&i.ConnectionType,
+		&i.Description,
+		&i.Metadata,
+		&i.TransactionAt,
+		&i.ConfirmedAt,
+		&i.SyncedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const settingTransaction = `-- name: SettingTransaction :one
 UPDATE transactions
 SET
@@ -0,0 +1,391 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Sahas001/pay-on/internal/envelope"
+	"github.com/Sahas001/pay-on/internal/totp"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// TransferChallengeDuration is how long a high-value transfer waits for
+	// its code.
+	TransferChallengeDuration = 10 * time.Minute
+	// MaxTransferChallengeAttempts is the number of wrong codes after which a
+	// challenge can no longer be confirmed.
+	MaxTransferChallengeAttempts = 5
+
+	transferChallengeWindow       = time.Hour
+	maxTransferChallengesInWindow = 10
+
+	totpSecretBatchSize = 100
+)
+
+var (
+	ErrTOTPAlreadyEnabled      = errors.New("an authenticator app is already enrolled")
+	ErrTOTPNotEnrolled         = errors.New("no authenticator app is enrolled")
+	ErrTOTPUnavailable         = errors.New("authenticator apps are unavailable without a master key")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTransferChallengeClosed = errors.New("transfer challenge is expired, confirmed or out of attempts")
+)
+
+// EnrollTOTP stores a new authenticator secret for the user, envelope
+// encrypted and bound to the user ID. It replaces a secret that was never
+// confirmed, but not an enrolled one.
+func (store *Store) EnrollTOTP(ctx context.Context, userID uuid.UUID, secret string, sealer *envelope.Sealer) (TotpSecret, error) {
+	if sealer == nil {
+		return TotpSecret{}, ErrTOTPUnavailable
+	}
+	sealed, err := sealer.Seal([]byte(secret), userID[:])
+	if err != nil {
+		return TotpSecret{}, err
+	}
+	enrolled, err := store.UpsertTOTPSecret(ctx, UpsertTOTPSecretParams{
+		UserID:           userID,
+		MasterKeyID:      &sealed.KeyID,
+		EncryptedDataKey: sealed.DataKey,
+		EncryptedSecret:  sealed.Ciphertext,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return TotpSecret{}, ErrTOTPAlreadyEnabled
+	}
+	return enrolled, err
+}
+
+// ConfirmTOTPTx completes an enrollment with a code from the authenticator
+// app.
+func (store *Store) ConfirmTOTPTx(ctx context.Context, userID uuid.UUID, code string, sealer *envelope.Sealer) (TotpSecret, error) {
+	var secret TotpSecret
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		secret, err = q.GetTOTPSecretForUpdate(ctx, userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTOTPNotEnrolled
+		}
+		if err != nil {
+			return err
+		}
+		if secret.ConfirmedAt.Valid {
+			return ErrTOTPAlreadyEnabled
+		}
+		key, err := openTOTPSecret(secret, sealer)
+		if err != nil {
+			return err
+		}
+
+		step, ok, err := totp.Validate(key, code, time.Now(), secret.LastUsedStep)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		secret, err = q.ConfirmTOTPSecret(ctx, ConfirmTOTPSecretParams{
+			UserID:       userID,
+			LastUsedStep: step,
+		})
+		return err
+	})
+
+	return secret, err
+}
+
+// DisableTOTPTxParams contains the input parameters of removing an
+// authenticator app.
+type DisableTOTPTxParams struct {
+	UserID uuid.UUID
+	Code   string
+	Audit  CredentialAudit
+}
+
+// DisableTOTPTx removes the user's authenticator after checking a current
+// code from it. A wrong code counts against the user's second factor like a
+// wrong password: it is committed and ErrInvalidTwoFactorCode returned, or
+// a *CredentialLockError once it locks the second factor.
+func (store *Store) DisableTOTPTx(ctx context.Context, arg DisableTOTPTxParams, sealer *envelope.Sealer) error {
+	var failed error
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		attempts, err := lockCredential(ctx, q, CredentialKindTwoFactor, arg.UserID)
+		if err != nil {
+			return err
+		}
+		ok, err := checkTOTP(ctx, q, arg.UserID, arg.Code, sealer)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			lockErr, err := countCredentialFailure(ctx, q, attempts, arg.Audit)
+			if err != nil {
+				return err
+			}
+			failed = ErrInvalidTwoFactorCode
+			if lockErr != nil {
+				failed = lockErr
+			}
+			return nil
+		}
+		if err := resetCredential(ctx, q, attempts); err != nil {
+			return err
+		}
+		return q.DeleteTOTPSecret(ctx, arg.UserID)
+	})
+	if err != nil {
+		return err
+	}
+
+	return failed
+}
+
+// checkTOTP checks a code against the user's enrolled authenticator and
+// records its time step, so the code cannot be used again.
+func checkTOTP(ctx context.Context, q *Queries, userID uuid.UUID, code string, sealer *envelope.Sealer) (bool, error) {
+	secret, err := q.GetTOTPSecretForUpdate(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, ErrTOTPNotEnrolled
+	}
+	if err != nil {
+		return false, err
+	}
+	if !secret.ConfirmedAt.Valid {
+		return false, ErrTOTPNotEnrolled
+	}
+	key, err := openTOTPSecret(secret, sealer)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok, err := totp.Validate(key, code, time.Now(), secret.LastUsedStep)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, q.UpdateTOTPLastUsedStep(ctx, UpdateTOTPLastUsedStepParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	})
+}
+
+// openTOTPSecret decrypts an authenticator secret. A secret stored in
+// plaintext by an older version is used as it is until it is sealed.
+func openTOTPSecret(secret TotpSecret, sealer *envelope.Sealer) (string, error) {
+	if secret.Secret != nil {
+		return *secret.Secret, nil
+	}
+	if sealer == nil || secret.MasterKeyID == nil {
+		return "", ErrTOTPUnavailable
+	}
+	key, err := sealer.Open(envelope.Sealed{
+		KeyID:      *secret.MasterKeyID,
+		DataKey:    secret.EncryptedDataKey,
+		Ciphertext: secret.EncryptedSecret,
+	}, secret.UserID[:])
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+// SealTOTPSecrets encrypts the authenticator secrets stored in plaintext by
+// older versions and clears the plaintext. It returns how many it sealed and
+// is safe to run on every start.
+func (store *Store) SealTOTPSecrets(ctx context.Context, sealer *envelope.Sealer) (sealed int, err error) {
+	for {
+		batch := 0
+		err := store.execTx(ctx, func(q *Queries) error {
+			secrets, err := q.ListPlaintextTOTPSecrets(ctx, totpSecretBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, secret := range secrets {
+				sealedSecret, err := sealer.Seal([]byte(*secret.Secret), secret.UserID[:])
+				if err != nil {
+					return err
+				}
+				if err := q.SealTOTPSecret(ctx, SealTOTPSecretParams{
+					UserID:           secret.UserID,
+					MasterKeyID:      &sealedSecret.KeyID,
+					EncryptedDataKey: sealedSecret.DataKey,
+					EncryptedSecret:  sealedSecret.Ciphertext,
+				}); err != nil {
+					return err
+				}
+				sealed++
+			}
+			batch = len(secrets)
+			return nil
+		})
+		if err != nil || batch < totpSecretBatchSize {
+			return sealed, err
+		}
+	}
+}
+
+// CreateTransferChallengeTxParams contains the input parameters of a
+// high-value transfer that waits for a second factor.
+type CreateTransferChallengeTxParams struct {
+	UserID   uuid.UUID
+	Method   TwoFactorMethod
+	Transfer TransferTxParams
+}
+
+// CreateTransferChallengeTx holds a signed transfer until it is confirmed
+// with ConfirmTransferTx. The signature is checked now, so a bad transfer is
+// refused before the user is asked for a code.
+//
+// Challenges are refused with a *CredentialLockError while the user's second
+// factor is locked, and once the user has opened ten in the last hour.
+func (store *Store) CreateTransferChallengeTx(ctx context.Context, arg CreateTransferChallengeTxParams) (TransferChallenge, error) {
+	var challenge TransferChallenge
+
+	if err := setTransferDefaults(&arg.Transfer); err != nil {
+		return challenge, err
+	}
+	transfer, err := json.Marshal(arg.Transfer)
+	if err != nil {
+		return challenge, err
+	}
+
+	err = store.execTx(ctx, func(q *Queries) error {
+		// The attempt counter also serializes the user's challenges, so the
+		// count below cannot be raced.
+		if _, err := lockCredential(ctx, q, CredentialKindTwoFactor, arg.UserID); err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		stats, err := q.GetTransferChallengeStats(ctx, GetTransferChallengeStatsParams{
+			UserID:    arg.UserID,
+			CreatedAt: pgtype.Timestamptz{Time: now.Add(-transferChallengeWindow), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if stats.Created >= maxTransferChallengesInWindow {
+			return &CredentialLockError{Until: stats.FirstCreatedAt.Time.Add(transferChallengeWindow)}
+		}
+
+		sender, err := q.GetWalletByID(ctx, arg.Transfer.FromWalletID)
+		if err != nil {
+			return err
+		}
+		offline := isOfflineConnection(arg.Transfer.ConnectionType)
+		if err := verifyTransactionSignature(ctx, q, sender, CreateTransactionParams(arg.Transfer), offline); err != nil {
+			return err
+		}
+
+		challenge, err = q.CreateTransferChallenge(ctx, CreateTransferChallengeParams{
+			UserID:       arg.UserID,
+			FromWalletID: arg.Transfer.FromWalletID,
+			ToWalletID:   arg.Transfer.ToWalletID,
+			Amount:       arg.Transfer.Amount,
+			Method:       arg.Method,
+			Transfer:     transfer,
+			ExpiresAt:    pgtype.Timestamptz{Time: now.Add(TransferChallengeDuration), Valid: true},
+		})
+		return err
+	})
+
+	return challenge, err
+}
+
+// ConfirmTransferTxParams contains the input parameters of a transfer
+// confirmation.
+type ConfirmTransferTxParams struct {
+	ChallengeID uuid.UUID
+	UserID      uuid.UUID
+	Code        string
+	Audit       CredentialAudit
+}
+
+// ConfirmTransferTx checks the code for a transfer challenge and carries out
+// the held transfer. A wrong code is committed as an attempt on the
+// challenge and on the user's second factor, and ErrInvalidTwoFactorCode
+// returned, or a *CredentialLockError once it locks the second factor.
+// Challenges of other users are reported as pgx.ErrNoRows.
+func (store *Store) ConfirmTransferTx(ctx context.Context, arg ConfirmTransferTxParams, sealer *envelope.Sealer) (TransferTxResult, error) {
+	var result TransferTxResult
+	var failed error
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		challenge, err := q.GetTransferChallengeForUpdate(ctx, arg.ChallengeID)
+		if err != nil {
+			return err
+		}
+		if challenge.UserID != arg.UserID {
+			return pgx.ErrNoRows
+		}
+		if challenge.ConfirmedAt.Valid || !challenge.ExpiresAt.Time.After(time.Now()) ||
+			challenge.Attempts >= MaxTransferChallengeAttempts {
+			return ErrTransferChallengeClosed
+		}
+		attempts, err := lockCredential(ctx, q, CredentialKindTwoFactor, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		switch challenge.Method {
+		case TwoFactorMethodTotp:
+			ok, err = checkTOTP(ctx, q, arg.UserID, arg.Code, sealer)
+		default:
+			var user User
+			user, err = q.GetUserByID(ctx, arg.UserID)
+			if err != nil {
+				return err
+			}
+			_, ok, err = checkOTP(ctx, q, OTPCheck{
+				PhoneNumber: user.PhoneNumber,
+				Purpose:     OtpPurposeTransferConfirmation,
+				SubjectID:   pgtype.UUID{Bytes: challenge.ID, Valid: true},
+				Code:        arg.Code,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if _, err := q.IncrementTransferChallengeAttempts(ctx, challenge.ID); err != nil {
+				return err
+			}
+			lockErr, err := countCredentialFailure(ctx, q, attempts, arg.Audit)
+			if err != nil {
+				return err
+			}
+			failed = ErrInvalidTwoFactorCode
+			if lockErr != nil {
+				failed = lockErr
+			}
+			return nil
+		}
+		if err := resetCredential(ctx, q, attempts); err != nil {
+			return err
+		}
+
+		var transferArg TransferTxParams
+		if err := json.Unmarshal(challenge.Transfer, &transferArg); err != nil {
+			return err
+		}
+		if err := transfer(ctx, q, transferArg, &result); err != nil {
+			return err
+		}
+		_, err = q.ConfirmTransferChallenge(ctx, ConfirmTransferChallengeParams{
+			ID:            challenge.ID,
+			TransactionID: pgtype.UUID{Bytes: result.Transaction.ID, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+	if failed != nil {
+		return TransferTxResult{}, failed
+	}
+
+	return result, nil
+}
@@ -0,0 +1,243 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sahas001/pay-on/internal/totp"
+	"github.com/google/uuid"
+)
+
+func TestConfirmTransferTxWithTOTP(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	user, err := store.CreateUser(ctx, CreateUserParams{
+		PhoneNumber:  nextPhoneNumber(),
+		PasswordHash: "password-hash",
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	fromWallet, fromKey := createTestWalletWithKey(t, ctx, store.Queries)
+	toWallet := createTestWallet(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transfer_challenges WHERE user_id = $1", user.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM credential_attempts WHERE subject_id = $1", user.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id IN ($1, $2)", fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", user.ID)
+	}()
+
+	sealer := newTestSealer(t)
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("generate secret: %v", err)
+	}
+	enrolled, err := store.EnrollTOTP(ctx, user.ID, secret, sealer)
+	if err != nil {
+		t.Fatalf("enroll TOTP: %v", err)
+	}
+	if enrolled.Secret != nil || enrolled.MasterKeyID == nil || len(enrolled.EncryptedSecret) == 0 {
+		t.Fatalf("expected the secret to be sealed, got %+v", enrolled)
+	}
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	if err != nil {
+		t.Fatalf("TOTP code: %v", err)
+	}
+	if _, err := store.ConfirmTOTPTx(ctx, user.ID, code, nil); !errors.Is(err, ErrTOTPUnavailable) {
+		t.Fatalf("expected ErrTOTPUnavailable without a sealer, got %v", err)
+	}
+	if _, err := store.ConfirmTOTPTx(ctx, user.ID, code, sealer); err != nil {
+		t.Fatalf("confirm TOTP: %v", err)
+	}
+	if _, err := store.EnrollTOTP(ctx, user.ID, secret, sealer); !errors.Is(err, ErrTOTPAlreadyEnabled) {
+		t.Fatalf("expected ErrTOTPAlreadyEnabled, got %v", err)
+	}
+
+	arg := TransferTxParams{
+		FromWalletID: fromWallet.ID,
+		ToWalletID:   toWallet.ID,
+		Amount:       numericFromString(t, "40.00"),
+	}
+	signTestTransfer(t, fromKey, &arg)
+	challenge, err := store.CreateTransferChallengeTx(ctx, CreateTransferChallengeTxParams{
+		UserID:   user.ID,
+		Method:   TwoFactorMethodTotp,
+		Transfer: arg,
+	})
+	if err != nil {
+		t.Fatalf("create transfer challenge: %v", err)
+	}
+
+	confirm := ConfirmTransferTxParams{ChallengeID: challenge.ID, UserID: user.ID, Code: code}
+	// The code that confirmed the enrollment cannot be replayed.
+	if _, err := store.ConfirmTransferTx(ctx, confirm, sealer); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("expected ErrInvalidTwoFactorCode, got %v", err)
+	}
+	wallet, err := store.GetWalletByID(ctx, fromWallet.ID)
+	if err != nil {
+		t.Fatalf("get wallet: %v", err)
+	}
+	assertFloatApprox(t, numericToFloat64(t, wallet.Balance), 100)
+
+	// The wrong code also counts against the user's second factor.
+	confirm.Code, err = totp.Code(secret, step+1)
+	if err != nil {
+		t.Fatalf("TOTP code: %v", err)
+	}
+	var lockErr *CredentialLockError
+	if _, err := store.ConfirmTransferTx(ctx, confirm, sealer); !errors.As(err, &lockErr) || lockErr.Locked {
+		t.Fatalf("expected a backoff error, got %v", err)
+	}
+	if _, err := testPool.Exec(ctx, "UPDATE credential_attempts SET locked_until = NULL WHERE subject_id = $1", user.ID); err != nil {
+		t.Fatalf("clear backoff: %v", err)
+	}
+
+	result, err := store.ConfirmTransferTx(ctx, confirm, sealer)
+	if err != nil {
+		t.Fatalf("confirm transfer: %v", err)
+	}
+	assertFloatApprox(t, numericToFloat64(t, result.FromWallet.Balance), 60)
+
+	if _, err := store.ConfirmTransferTx(ctx, confirm, sealer); !errors.Is(err, ErrTransferChallengeClosed) {
+		t.Fatalf("expected ErrTransferChallengeClosed, got %v", err)
+	}
+	confirmed, err := store.GetTransferChallenge(ctx, challenge.ID)
+	if err != nil {
+		t.Fatalf("get transfer challenge: %v", err)
+	}
+	if confirmed.Attempts != 1 || confirmed.TransactionID.Bytes != result.Transaction.ID {
+		t.Fatalf("unexpected challenge %+v", confirmed)
+	}
+}
+
+func TestDisableTOTPTxLocksSecondFactor(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	user, err := store.CreateUser(ctx, CreateUserParams{
+		PhoneNumber:  nextPhoneNumber(),
+		PasswordHash: "password-hash",
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	defer func() {
+		_, _ = testPool.Exec(ctx, "DELETE FROM audit_logs WHERE record_id = $1", user.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM credential_attempts WHERE subject_id = $1", user.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", user.ID)
+	}()
+
+	sealer := newTestSealer(t)
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("generate secret: %v", err)
+	}
+	if _, err := store.EnrollTOTP(ctx, user.ID, secret, sealer); err != nil {
+		t.Fatalf("enroll TOTP: %v", err)
+	}
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	if err != nil {
+		t.Fatalf("TOTP code: %v", err)
+	}
+	if _, err := store.ConfirmTOTPTx(ctx, user.ID, code, sealer); err != nil {
+		t.Fatalf("confirm TOTP: %v", err)
+	}
+
+	// The enrollment code cannot be replayed, so every attempt with it fails.
+	arg := DisableTOTPTxParams{UserID: user.ID, Code: code}
+	for i := 1; i < MaxCredentialAttempts; i++ {
+		if err := store.DisableTOTPTx(ctx, arg, sealer); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("attempt %d: expected ErrInvalidTwoFactorCode, got %v", i, err)
+		}
+		if _, err := testPool.Exec(ctx, "UPDATE credential_attempts SET locked_until = NULL WHERE subject_id = $1", user.ID); err != nil {
+			t.Fatalf("clear backoff: %v", err)
+		}
+	}
+	var lockErr *CredentialLockError
+	if err := store.DisableTOTPTx(ctx, arg, sealer); !errors.As(err, &lockErr) || !lockErr.Locked {
+		t.Fatalf("expected the second factor to be locked, got %v", err)
+	}
+
+	// A current code is refused while locked, and so are new challenges.
+	arg.Code, err = totp.Code(secret, step+1)
+	if err != nil {
+		t.Fatalf("TOTP code: %v", err)
+	}
+	if err := store.DisableTOTPTx(ctx, arg, sealer); !errors.As(err, &lockErr) || !lockErr.Locked {
+		t.Fatalf("expected the lockout to hold, got %v", err)
+	}
+	if _, err := store.CreateTransferChallengeTx(ctx, CreateTransferChallengeTxParams{
+		UserID:   user.ID,
+		Method:   TwoFactorMethodTotp,
+		Transfer: TransferTxParams{FromWalletID: uuid.New(), ToWalletID: uuid.New()},
+	}); !errors.As(err, &lockErr) {
+		t.Fatalf("expected the challenge to be refused, got %v", err)
+	}
+	if _, err := store.GetTOTPSecret(ctx, user.ID); err != nil {
+		t.Fatalf("expected the authenticator to stay enrolled, got %v", err)
+	}
+}
+
+func TestSealTOTPSecrets(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+	sealer := newTestSealer(t)
+
+	user, err := store.CreateUser(ctx, CreateUserParams{
+		PhoneNumber:  nextPhoneNumber(),
+		PasswordHash: "password-hash",
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	defer func() {
+		_, _ = testPool.Exec(ctx, "DELETE FROM credential_attempts WHERE subject_id = $1", user.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", user.ID)
+	}()
+
+	// Older versions stored the secret in plaintext.
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("generate secret: %v", err)
+	}
+	if _, err := testPool.Exec(ctx, "INSERT INTO totp_secrets (user_id, secret, confirmed_at) VALUES ($1, $2, NOW())", user.ID, secret); err != nil {
+		t.Fatalf("insert plaintext secret: %v", err)
+	}
+
+	sealed, err := store.SealTOTPSecrets(ctx, sealer)
+	if err != nil {
+		t.Fatalf("seal TOTP secrets: %v", err)
+	}
+	if sealed < 1 {
+		t.Fatalf("expected at least 1 sealed secret, got %d", sealed)
+	}
+	stored, err := store.GetTOTPSecret(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("get TOTP secret: %v", err)
+	}
+	if stored.Secret != nil {
+		t.Fatalf("expected the plaintext secret to be cleared")
+	}
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatalf("TOTP code: %v", err)
+	}
+	if err := store.DisableTOTPTx(ctx, DisableTOTPTxParams{UserID: user.ID, Code: code}, sealer); err != nil {
+		t.Fatalf("expected the sealed secret to check codes, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sahas001/pay-on/internal/signature"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	walletKeyRotationVersion = "pay-on:key-rotation:v1"
+
+	// maxOfflineAge is how far back an offline transaction may be dated
+	// when it is synced.
+	maxOfflineAge = 30 * 24 * time.Hour
+)
+
+var (
+	ErrWalletKeyReused      = errors.New("public key is already used by a wallet")
+	ErrCustodialKeyRotation = errors.New("custodial wallet keys are managed by the server")
+	ErrOfflineTooOld        = errors.New("offline transaction is too old to sync")
+	ErrNoWalletKeyAt        = errors.New("wallet had no valid key at transaction_at")
+	ErrRevokeCurrentKey     = errors.New("the current wallet key cannot be revoked; rotate it or deactivate the wallet")
+)
+
+// WalletKeyRotationPayload returns the bytes the current wallet key signs to
+// hand over to a new key: the version tag, the wallet ID, the current public
+// key and the new public key, separated by newlines.
+func WalletKeyRotationPayload(walletID uuid.UUID, currentPublicKey, newPublicKey string) []byte {
+	return fmt.Appendf(nil, "%s\n%s\n%s\n%s", walletKeyRotationVersion, walletID, currentPublicKey, newPublicKey)
+}
+
+// RotateWalletKeyTxParams contains the input parameters of a key rotation.
+// Signature is made by the current key over WalletKeyRotationPayload.
+type RotateWalletKeyTxParams struct {
+	WalletID     uuid.UUID
+	NewPublicKey string
+	Signature    string
+}
+
+// RotateWalletKeyTxResult is the wallet with its new key.
+type RotateWalletKeyTxResult struct {
+	Wallet Wallet    `json:"wallet"`
+	Key    WalletKey `json:"key"`
+}
+
+// RotateWalletKeyTx replaces the public key of a wallet after the current
+// key has signed the handover. The old key stays in the key history so that
+// offline transactions it signed before the rotation can still be synced.
+func (store *Store) RotateWalletKeyTx(ctx context.Context, arg RotateWalletKeyTxParams) (RotateWalletKeyTxResult, error) {
+	var result RotateWalletKeyTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		wallet, err := q.GetWalletForUpdate(ctx, arg.WalletID)
+		if err != nil {
+			return err
+		}
+		if wallet.IsActive != nil && !*wallet.IsActive {
+			return ErrWalletInactive
+		}
+		if _, err := q.GetWalletCustodialKey(ctx, wallet.ID); err == nil {
+			return ErrCustodialKeyRotation
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		if _, err := signature.ParsePublicKey(arg.NewPublicKey); err != nil {
+			return err
+		}
+		payload := WalletKeyRotationPayload(wallet.ID, wallet.PublicKey, arg.NewPublicKey)
+		if err := signature.Verify(wallet.PublicKey, payload, arg.Signature); err != nil {
+			return err
+		}
+
+		used, err := q.IsWalletKeyUsed(ctx, arg.NewPublicKey)
+		if err != nil {
+			return err
+		}
+		if used {
+			return ErrWalletKeyReused
+		}
+
+		now := pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
+		if _, err := q.RetireWalletKey(ctx, RetireWalletKeyParams{
+			WalletID:   wallet.ID,
+			ValidUntil: now,
+		}); err != nil {
+			return err
+		}
+		result.Key, err = q.CreateWalletKey(ctx, CreateWalletKeyParams{
+			WalletID:          wallet.ID,
+			PublicKey:         arg.NewPublicKey,
+			ValidFrom:         now,
+			RotationSignature: &arg.Signature,
+		})
+		if err != nil {
+			return err
+		}
+		result.Wallet, err = q.UpdateWalletPublicKey(ctx, UpdateWalletPublicKeyParams{
+			ID:        wallet.ID,
+			PublicKey: arg.NewPublicKey,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Peers cache the key to check payments offline.
+		_, err = q.UpdatePeerPublicKeys(ctx, UpdatePeerPublicKeysParams{
+			PeerWalletID: wallet.ID,
+			PublicKey:    arg.NewPublicKey,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// RevokeWalletKeyTx marks a retired wallet key as compromised. Offline
+// transactions dated in its validity window are refused from then on. The
+// current key is not revoked here: the wallet is rotated to a new key, or
+// deactivated if its owner has lost control of it.
+func (store *Store) RevokeWalletKeyTx(ctx context.Context, walletID, keyID uuid.UUID, reason string) (WalletKey, error) {
+	var key WalletKey
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		key, err = q.GetWalletKeyForUpdate(ctx, GetWalletKeyForUpdateParams{
+			ID:       keyID,
+			WalletID: walletID,
+		})
+		if err != nil {
+			return err
+		}
+		if !key.ValidUntil.Valid {
+			return ErrRevokeCurrentKey
+		}
+		if key.RevokedAt.Valid {
+			return nil
+		}
+		key, err = q.RevokeWalletKey(ctx, RevokeWalletKeyParams{
+			ID:            keyID,
+			RevokedReason: &reason,
+		})
+		return err
+	})
+
+	return key, err
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sahas001/pay-on/internal/signature"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestRotateWalletKeyTx(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	wallet, oldKey := createTestWalletWithKey(t, ctx, store.Queries)
+	receiver := createTestWallet(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, wallet.ID, receiver.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id IN ($1, $2)", wallet.ID, receiver.ID)
+	}()
+
+	newPub, newKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate wallet key: %v", err)
+	}
+	newPublicKey := base64.StdEncoding.EncodeToString(newPub)
+	payload := WalletKeyRotationPayload(wallet.ID, wallet.PublicKey, newPublicKey)
+
+	// Only the current key may hand over.
+	_, err = store.RotateWalletKeyTx(ctx, RotateWalletKeyTxParams{
+		WalletID:     wallet.ID,
+		NewPublicKey: newPublicKey,
+		Signature:    base64.StdEncoding.EncodeToString(ed25519.Sign(newKey, payload)),
+	})
+	if !errors.Is(err, signature.ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+
+	beforeRotation := TransferTxParams{
+		FromWalletID:   wallet.ID,
+		ToWalletID:     receiver.ID,
+		Amount:         numericFromString(t, "5.00"),
+		Currency:       "NPR",
+		ConnectionType: NullConnectionType{ConnectionType: ConnectionTypeBluetooth, Valid: true},
+		TransactionAt:  pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true},
+	}
+	signTestTransfer(t, oldKey, &beforeRotation)
+
+	result, err := store.RotateWalletKeyTx(ctx, RotateWalletKeyTxParams{
+		WalletID:     wallet.ID,
+		NewPublicKey: newPublicKey,
+		Signature:    base64.StdEncoding.EncodeToString(ed25519.Sign(oldKey, payload)),
+	})
+	if err != nil {
+		t.Fatalf("rotate wallet key: %v", err)
+	}
+	if result.Wallet.PublicKey != newPublicKey || result.Key.PublicKey != newPublicKey {
+		t.Fatalf("expected the wallet to use the new key")
+	}
+
+	keys, err := store.ListWalletKeys(ctx, wallet.ID)
+	if err != nil {
+		t.Fatalf("list wallet keys: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys in the history, got %d", len(keys))
+	}
+	if keys[0].ValidUntil.Valid || !keys[1].ValidUntil.Valid || keys[1].PublicKey != wallet.PublicKey {
+		t.Fatalf("expected the old key to be retired, got %+v", keys)
+	}
+
+	// An offline payment signed before the rotation is still accepted...
+	if err := store.VerifyTransactionSignature(ctx, CreateTransactionParams(beforeRotation)); err != nil {
+		t.Fatalf("expected the old key to verify an earlier offline payment, got %v", err)
+	}
+	// ...but the old key cannot sign anything dated after it, or online.
+	afterRotation := beforeRotation
+	afterRotation.TransactionAt = pgtype.Timestamptz{Time: time.Now().UTC().Add(time.Second), Valid: true}
+	signTestTransfer(t, oldKey, &afterRotation)
+	if err := store.VerifyTransactionSignature(ctx, CreateTransactionParams(afterRotation)); !errors.Is(err, signature.ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature after the rotation, got %v", err)
+	}
+	online := beforeRotation
+	online.ConnectionType = NullConnectionType{ConnectionType: ConnectionTypeOnline, Valid: true}
+	if err := store.VerifyTransactionSignature(ctx, CreateTransactionParams(online)); !errors.Is(err, signature.ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature online, got %v", err)
+	}
+
+	// Offline payments cannot be dated before the wallet had a key, nor
+	// further back than the sync window.
+	beforeKey := beforeRotation
+	beforeKey.TransactionAt = pgtype.Timestamptz{Time: keys[1].ValidFrom.Time.Add(-time.Second), Valid: true}
+	signTestTransfer(t, oldKey, &beforeKey)
+	if err := store.VerifyTransactionSignature(ctx, CreateTransactionParams(beforeKey)); !errors.Is(err, ErrNoWalletKeyAt) {
+		t.Fatalf("expected ErrNoWalletKeyAt before the first key, got %v", err)
+	}
+	tooOld := beforeRotation
+	tooOld.TransactionAt = pgtype.Timestamptz{Time: time.Now().UTC().Add(-maxOfflineAge - time.Hour), Valid: true}
+	signTestTransfer(t, oldKey, &tooOld)
+	if err := store.VerifyTransactionSignature(ctx, CreateTransactionParams(tooOld)); !errors.Is(err, ErrOfflineTooOld) {
+		t.Fatalf("expected ErrOfflineTooOld, got %v", err)
+	}
+
+	// The current key cannot be revoked; a retired one stops verifying.
+	if _, err := store.RevokeWalletKeyTx(ctx, wallet.ID, keys[0].ID, "lost"); !errors.Is(err, ErrRevokeCurrentKey) {
+		t.Fatalf("expected ErrRevokeCurrentKey, got %v", err)
+	}
+	revoked, err := store.RevokeWalletKeyTx(ctx, wallet.ID, keys[1].ID, "device stolen")
+	if err != nil {
+		t.Fatalf("revoke wallet key: %v", err)
+	}
+	if !revoked.RevokedAt.Valid || revoked.RevokedReason == nil || *revoked.RevokedReason != "device stolen" {
+		t.Fatalf("expected the key revoked, got %+v", revoked)
+	}
+	if err := store.VerifyTransactionSignature(ctx, CreateTransactionParams(beforeRotation)); !errors.Is(err, ErrNoWalletKeyAt) {
+		t.Fatalf("expected ErrNoWalletKeyAt with the key revoked, got %v", err)
+	}
+
+	// A key cannot be handed back to.
+	back := WalletKeyRotationPayload(wallet.ID, newPublicKey, wallet.PublicKey)
+	_, err = store.RotateWalletKeyTx(ctx, RotateWalletKeyTxParams{
+		WalletID:     wallet.ID,
+		NewPublicKey: wallet.PublicKey,
+		Signature:    base64.StdEncoding.EncodeToString(ed25519.Sign(newKey, back)),
+	})
+	if !errors.Is(err, ErrWalletKeyReused) {
+		t.Fatalf("expected ErrWalletKeyReused, got %v", err)
+	}
+}
@@ -43,21 +43,19 @@ const createWallet = `-- name: CreateWallet :one
 INSERT INTO wallets (
 user_id,
 public_key,
-private_key,
 balance,
 phone_number,
 name,
 pin_hash,
 device_id
 ) VALUES (
-	$1, $2, $3, $4, $5, $6, $7, $8
-	) RETURNING id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id
+	$1, $2, $3, $4, $5, $6, $7
+	) RETURNING id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id
 `
 
 type CreateWalletParams struct {
 	UserID      pgtype.UUID    `json:"user_id"`
 	PublicKey   string         `json:"public_key"`
-	PrivateKey  string         `json:"private_key"`
 	Balance     pgtype.Numeric `json:"balance"`
 	PhoneNumber string         `json:"phone_number"`
 	Name        string         `json:"name"`
@@ -70,7 +68,6 @@ func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) (Wal
 	row := q.db.QueryRow(ctx, createWallet,
 		arg.UserID,
 		arg.PublicKey,
-		arg.PrivateKey,
 		arg.Balance,
 		arg.PhoneNumber,
 		arg.Name,
@@ -81,7 +78,6 @@ func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) (Wal
 	err := row.Scan(
 		&i.ID,
 		&i.PublicKey,
-		&i.PrivateKey,
 		&i.Balance,
 		&i.PhoneNumber,
 		&i.Name,
@@ -116,7 +112,7 @@ SET
 balance = balance - $2,
 updated_at = NOW()
 WHERE id = $1 AND deleted_at IS NULL AND balance >= $2
-RETURNING id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id
+RETURNING id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id
 `
 
 type DecrementWalletBalanceParams struct {
@@ -130,7 +126,6 @@ func (q *Queries) DecrementWalletBalance(ctx context.Context, arg DecrementWalle
 	err := row.Scan(
 		&i.ID,
 		&i.PublicKey,
-		&i.PrivateKey,
 		&i.Balance,
 		&i.PhoneNumber,
 		&i.Name,
@@ -159,7 +154,7 @@ func (q *Queries) GetWalletBalance(ctx context.Context, id uuid.UUID) (pgtype.Nu
 }
 
 const getWalletByDeviceID = `-- name: GetWalletByDeviceID :one
-SELECT id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE device_id = $1 AND deleted_at IS NULL
+SELECT id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE device_id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetWalletByDeviceID(ctx context.Context, deviceID *string) (Wallet, error) {
@@ -168,7 +163,6 @@ func (q *Queries) GetWalletByDeviceID(ctx context.Context, deviceID *string) (Wa
 	err := row.Scan(
 		&i.ID,
 		&i.PublicKey,
-		&i.PrivateKey,
 		&i.Balance,
 		&i.PhoneNumber,
 		&i.Name,
@@ -185,7 +179,7 @@ func (q *Queries) GetWalletByDeviceID(ctx context.Context, deviceID *string) (Wa
 }
 
 const getWalletByID = `-- name: GetWalletByID :one
-SELECT id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE id = $1 AND deleted_at IS NULL
+SELECT id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetWalletByID(ctx context.Context, id uuid.UUID) (Wallet, error) {
@@ -194,7 +188,6 @@ func (q *Queries) GetWalletByID(ctx context.Context, id uuid.UUID) (Wallet, erro
 	err := row.Scan(
 		&i.ID,
 		&i.PublicKey,
-		&i.PrivateKey,
 		&i.Balance,
 		&i.PhoneNumber,
 		&i.Name,
@@ -211,7 +204,7 @@ func (q *Queries) GetWalletByID(ctx context.Context, id uuid.UUID) (Wallet, erro
 }
 
 const getWalletByPhoneNumber = `-- name: GetWalletByPhoneNumber :one
-SELECT id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE phone_number = $1 AND deleted_at IS NULL
+SELECT id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE phone_number = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetWalletByPhoneNumber(ctx context.Context, phoneNumber string) (Wallet, error) {
@@ -220,7 +213,6 @@ func (q *Queries) GetWalletByPhoneNumber(ctx context.Context, phoneNumber string
 	err := row.Scan(
 		&i.ID,
 		&i.PublicKey,
-		&i.PrivateKey,
 		&i.Balance,
 		&i.PhoneNumber,
 		&i.Name,
@@ -237,7 +229,7 @@ func (q *Queries) GetWalletByPhoneNumber(ctx context.Context, phoneNumber string
 }
 
 const getWalletByPublicKey = `-- name: GetWalletByPublicKey :one
-SELECT id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE public_key = $1 AND deleted_at IS NULL
+SELECT id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE public_key = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetWalletByPublicKey(ctx context.Context, publicKey string) (Wallet, error) {
@@ -246,7 +238,33 @@ func (q *Queries) GetWalletByPublicKey(ctx context.Context, publicKey string) (W
 	err := row.Scan(
 		&i.ID,
 		&i.PublicKey,
-		&i.PrivateKey,
+		&i.Balance,
+		&i.PhoneNumber,
+		&i.Name,
+		&i.PinHash,
+		&i.IsActive,
+		&i.DeviceID,
+		&i.LastSyncedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const getWalletForUpdate = `-- name: GetWalletForUpdate :one
+SELECT id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) GetWalletForUpdate(ctx context.Context, id uuid.UUID) (Wallet, error) {
+	row := q.db.QueryRow(ctx, getWalletForUpdate, id)
+	var i Wallet
+	err := row.Scan(
+		&i.ID,
+		&i.PublicKey,
 		&i.Balance,
 		&i.PhoneNumber,
 		&i.Name,
@@ -292,7 +310,7 @@ func (q *Queries) GetWalletWithBalance(ctx context.Context, id uuid.UUID) (GetWa
 }
 
 const getWalletsNeedingSync = `-- name: GetWalletsNeedingSync :many
-SELECT id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets
+SELECT id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets
 WHERE (last_synced_at IS NULL OR last_synced_at < NOW() - INTERVAL '1 day')
 	AND deleted_at IS NULL
 ORDER BY last_synced_at ASC NULLS FIRST
@@ -311,7 +329,6 @@ func (q *Queries) GetWalletsNeedingSync(ctx context.Context, limit int32) ([]Wal
 		if err := rows.Scan(
 			&i.ID,
 			&i.PublicKey,
-			&i.PrivateKey,
 			&i.Balance,
 			&i.PhoneNumber,
 			&i.Name,
@@ -334,14 +351,17 @@ func (q *Queries) GetWalletsNeedingSync(ctx context.Context, limit int32) ([]Wal
 	return items, nil
 }
 
-const hardDeleteWallet = `-- name: HardDeleteWallet :exec
+const hardDeleteWallet = `-- name: HardDeleteWallet :execrows
 DELETE FROM wallets
 WHERE id = $1
 `
 
-func (q *Queries) HardDeleteWallet(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, hardDeleteWallet, id)
-	return err
+func (q *Queries) HardDeleteWallet(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, hardDeleteWallet, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const incrementWalletBalance = `-- name: IncrementWalletBalance :one
@@ -350,7 +370,7 @@ SET
 balance = balance + $2,
 updated_at = NOW()
 WHERE id = $1 AND deleted_at IS NULL
-RETURNING id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id
+RETURNING id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id
 `
 
 type IncrementWalletBalanceParams struct {
@@ -364,7 +384,6 @@ func (q *Queries) IncrementWalletBalance(ctx context.Context, arg IncrementWalle
 	err := row.Scan(
 		&i.ID,
 		&i.PublicKey,
-		&i.PrivateKey,
 		&i.Balance,
 		&i.PhoneNumber,
 		&i.Name,
@@ -380,8 +399,27 @@ func (q *Queries) IncrementWalletBalance(ctx context.Context, arg IncrementWalle
 	return i, err
 }
 
+const isWalletOwner = `-- name: IsWalletOwner :one
+SELECT EXISTS(
+    SELECT 1 FROM wallets
+    WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+)
+`
+
+type IsWalletOwnerParams struct {
+	ID     uuid.UUID   `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) IsWalletOwner(ctx context.Context, arg IsWalletOwnerParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isWalletOwner, arg.ID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const listActiveWallets = `-- name: ListActiveWallets :many
-SELECT id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE is_active = TRUE AND deleted_at IS NULL ORDER BY created_at DESC LIMIT $1 OFFSET $2
+SELECT id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE is_active = TRUE AND deleted_at IS NULL ORDER BY created_at DESC LIMIT $1 OFFSET $2
 `
 
 type ListActiveWalletsParams struct {
@@ -401,7 +439,6 @@ func (q *Queries) ListActiveWallets(ctx context.Context, arg ListActiveWalletsPa
 		if err := rows.Scan(
 			&i.ID,
 			&i.PublicKey,
-			&i.PrivateKey,
 			&i.Balance,
 			&i.PhoneNumber,
 			&i.Name,
@@ -425,7 +462,7 @@ func (q *Queries) ListActiveWallets(ctx context.Context, arg ListActiveWalletsPa
 }
 
 const listWallets = `-- name: ListWallets :many
-SELECT id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE deleted_at IS NULL ORDER BY created_at DESC LIMIT $1 OFFSET $2
+SELECT id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id FROM wallets WHERE deleted_at IS NULL ORDER BY created_at DESC LIMIT $1 OFFSET $2
 `
 
 type ListWalletsParams struct {
@@ -445,7 +482,6 @@ func (q *Queries) ListWallets(ctx context.Context, arg ListWalletsParams) ([]Wal
 		if err := rows.Scan(
 			&i.ID,
 			&i.PublicKey,
-			&i.PrivateKey,
 			&i.Balance,
 			&i.PhoneNumber,
 			&i.Name,
@@ -594,7 +630,7 @@ phone_number = COALESCE($3, phone_number),
 device_id = COALESCE($4, device_id),
 updated_at = NOW()
 WHERE id = $1 AND deleted_at IS NULL
-RETURNING id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id
+RETURNING id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id
 `
 
 type UpdateWalletParams struct {
@@ -615,7 +651,6 @@ func (q *Queries) UpdateWallet(ctx context.Context, arg UpdateWalletParams) (Wal
 	err := row.Scan(
 		&i.ID,
 		&i.PublicKey,
-		&i.PrivateKey,
 		&i.Balance,
 		&i.PhoneNumber,
 		&i.Name,
@@ -637,7 +672,7 @@ SET
 balance = $2,
 updated_at = NOW()
 WHERE id = $1 AND deleted_at IS NULL
-RETURNING id, public_key, private_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id
+RETURNING id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id
 `
 
 type UpdateWalletBalanceParams struct {
@@ -651,7 +686,6 @@ func (q *Queries) UpdateWalletBalance(ctx context.Context, arg UpdateWalletBalan
 	err := row.Scan(
 		&i.ID,
 		&i.PublicKey,
-		&i.PrivateKey,
 		&i.Balance,
 		&i.PhoneNumber,
 		&i.Name,
@@ -697,3 +731,38 @@ func (q *Queries) UpdateWalletPIN(ctx context.Context, arg UpdateWalletPINParams
 	_, err := q.db.Exec(ctx, updateWalletPIN, arg.ID, arg.PinHash)
 	return err
 }
+
+const updateWalletPublicKey = `-- name: UpdateWalletPublicKey :one
+UPDATE wallets
+SET
+public_key = $2,
+updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, public_key, balance, phone_number, name, pin_hash, is_active, device_id, last_synced_at, created_at, updated_at, deleted_at, user_id
+`
+
+type UpdateWalletPublicKeyParams struct {
+	ID        uuid.UUID `json:"id"`
+	PublicKey string    `json:"public_key"`
+}
+
+func (q *Queries) UpdateWalletPublicKey(ctx context.Context, arg UpdateWalletPublicKeyParams) (Wallet, error) {
+	row := q.db.QueryRow(ctx, updateWalletPublicKey, arg.ID, arg.PublicKey)
+	var i Wallet
+	err := row.Scan(
+		&i.ID,
+		&i.PublicKey,
+		&i.Balance,
+		&i.PhoneNumber,
+		&i.Name,
+		&i.PinHash,
+		&i.IsActive,
+		&i.DeviceID,
+		&i.LastSyncedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.UserID,
+	)
+	return i, err
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// WebhookEventType names an event sent to webhook subscriptions.
+type WebhookEventType string
+
+const (
+	// WebhookEventTransactionConfirmed is sent when an offline payment is
+	// accepted by sync and waits for settlement.
+	WebhookEventTransactionConfirmed WebhookEventType = "transaction.confirmed"
+	// WebhookEventTransactionSettled is sent when money reaches the wallet.
+	WebhookEventTransactionSettled WebhookEventType = "transaction.settled"
+	// WebhookEventTransactionFailed is sent when a transaction is failed or
+	// rolled back.
+	WebhookEventTransactionFailed WebhookEventType = "transaction.failed"
+	// WebhookEventTransactionConflict is sent when settlement finds a
+	// conflict that needs an operator.
+	WebhookEventTransactionConflict WebhookEventType = "transaction.conflict"
+)
+
+func (e WebhookEventType) Valid() bool {
+	switch e {
+	case WebhookEventTransactionConfirmed,
+		WebhookEventTransactionSettled,
+		WebhookEventTransactionFailed,
+		WebhookEventTransactionConflict:
+		return true
+	}
+	return false
+}
+
+// TransactionEventData is the data of a transaction webhook event.
+type TransactionEventData struct {
+	Transaction Transaction `json:"transaction"`
+}
+
+// EnqueueTransactionWebhook writes the event to the webhook outbox of every
+// active subscription for the receiving wallet. sourceEventID is the domain
+// event it is queued for; queueing the same one again does nothing, so a
+// relayed event can be retried.
+func (store *Store) EnqueueTransactionWebhook(ctx context.Context, sourceEventID uuid.UUID, event WebhookEventType, transaction Transaction) error {
+	payload, err := json.Marshal(TransactionEventData{Transaction: transaction})
+	if err != nil {
+		return err
+	}
+	_, err = store.EnqueueWebhookEvent(ctx, EnqueueWebhookEventParams{
+		EventType:     string(event),
+		Payload:       payload,
+		SourceEventID: sourceEventID,
+		WalletID:      transaction.ToWalletID,
+	})
+	return err
+}
+
+// FailTransactionTx marks a transaction failed and raises TransactionFailed.
+func (store *Store) FailTransactionTx(ctx context.Context, id uuid.UUID) (Transaction, error) {
+	var transaction Transaction
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if err := q.FailTransaction(ctx, id); err != nil {
+			return err
+		}
+		var err error
+		transaction, err = q.GetTransactionByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, q, TransactionFailed{Transaction: transaction})
+	})
+
+	return transaction, err
+}
+
+// RecordWebhookAttemptParams is the outcome of one delivery attempt.
+type RecordWebhookAttemptParams struct {
+	OutboxID   uuid.UUID
+	Attempt    int32
+	StatusCode *int32
+	Error      *string
+	Duration   time.Duration
+	Delivered  bool
+	// RetryAt is when to try again after a failed attempt; zero gives up.
+	RetryAt time.Time
+}
+
+// RecordWebhookAttemptTx logs a delivery attempt and moves the outbox event
+// on: delivered, due again at RetryAt, or failed for good.
+func (store *Store) RecordWebhookAttemptTx(ctx context.Context, arg RecordWebhookAttemptParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		if _, err := q.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams{
+			OutboxID:   arg.OutboxID,
+			Attempt:    arg.Attempt,
+			StatusCode: arg.StatusCode,
+			Error:      arg.Error,
+			DurationMs: int32(arg.Duration.Milliseconds()),
+		}); err != nil {
+			return err
+		}
+
+		switch {
+		case arg.Delivered:
+			return q.MarkWebhookDelivered(ctx, arg.OutboxID)
+		case arg.RetryAt.IsZero():
+			return q.MarkWebhookFailed(ctx, MarkWebhookFailedParams{
+				ID:        arg.OutboxID,
+				LastError: arg.Error,
+			})
+		default:
+			return q.ScheduleWebhookRetry(ctx, ScheduleWebhookRetryParams{
+				ID:            arg.OutboxID,
+				NextAttemptAt: pgtype.Timestamptz{Time: arg.RetryAt, Valid: true},
+				LastError:     arg.Error,
+			})
+		}
+	})
+}
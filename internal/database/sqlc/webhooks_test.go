@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestEnqueueTransactionWebhook(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testPool)
+
+	user, err := store.CreateUser(ctx, CreateUserParams{
+		PhoneNumber:  nextPhoneNumber(),
+		PasswordHash: "password-hash",
+	})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	fromWallet, fromKey := createTestWalletWithKey(t, ctx, store.Queries)
+	toWallet, _ := createTestWalletWithKey(t, ctx, store.Queries)
+
+	defer func() {
+		deleteTestLedger(ctx, fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM webhook_subscriptions WHERE user_id = $1", user.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM transactions WHERE from_wallet_id = $1", fromWallet.ID)
+		_, _ = testPool.Exec(
+			ctx,
+			"DELETE FROM peers WHERE wallet_id = $1 OR wallet_id = $2 OR peer_wallet_id = $1 OR peer_wallet_id = $2",
+			fromWallet.ID,
+			toWallet.ID,
+		)
+		_, _ = testPool.Exec(ctx, "DELETE FROM wallets WHERE id IN ($1, $2)", fromWallet.ID, toWallet.ID)
+		_, _ = testPool.Exec(ctx, "DELETE FROM users WHERE id = $1", user.ID)
+	}()
+
+	settled, err := store.CreateWebhookSubscription(ctx, CreateWebhookSubscriptionParams{
+		UserID:     user.ID,
+		WalletID:   pgtype.UUID{Bytes: toWallet.ID, Valid: true},
+		Url:        "https://example.com/settled",
+		Secret:     "secret",
+		EventTypes: []string{string(WebhookEventTransactionSettled)},
+	})
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	failedOnly, err := store.CreateWebhookSubscription(ctx, CreateWebhookSubscriptionParams{
+		UserID:     user.ID,
+		WalletID:   pgtype.UUID{Bytes: toWallet.ID, Valid: true},
+		Url:        "https://example.com/failed",
+		Secret:     "secret",
+		EventTypes: []string{string(WebhookEventTransactionFailed)},
+	})
+	if err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+
+	arg := TransferTxParams{
+		FromWalletID: fromWallet.ID,
+		ToWalletID:   toWallet.ID,
+		Amount:       numericFromString(t, "10.00"),
+	}
+	signTestTransfer(t, fromKey, &arg)
+	result, err := store.TransferTx(ctx, arg)
+	if err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	events, err := store.ListOutboxEventsByAggregate(ctx, result.Transaction.ID)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected the transfer's domain event, got %d (%v)", len(events), err)
+	}
+
+	// A relayed event that is retried is queued once.
+	for range 2 {
+		if err := store.EnqueueTransactionWebhook(ctx, events[0].ID, WebhookEventTransactionSettled, result.Transaction); err != nil {
+			t.Fatalf("enqueue webhook: %v", err)
+		}
+	}
+
+	var outboxID uuid.UUID
+	var eventType string
+	var payload []byte
+	err = testPool.QueryRow(
+		ctx,
+		"SELECT id, event_type, payload FROM webhook_outbox WHERE subscription_id = $1",
+		settled.ID,
+	).Scan(&outboxID, &eventType, &payload)
+	if err != nil {
+		t.Fatalf("load outbox event: %v", err)
+	}
+	if eventType != string(WebhookEventTransactionSettled) {
+		t.Fatalf("expected %s, got %s", WebhookEventTransactionSettled, eventType)
+	}
+	var data TransactionEventData
+	if err := json.Unmarshal(payload, &data); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if data.Transaction.ID != result.Transaction.ID {
+		t.Fatalf("expected payload for transaction %s, got %s", result.Transaction.ID, data.Transaction.ID)
+	}
+
+	var queued int
+	err = testPool.QueryRow(ctx, "SELECT count(*) FROM webhook_outbox WHERE subscription_id = $1", failedOnly.ID).Scan(&queued)
+	if err != nil {
+		t.Fatalf("count outbox events: %v", err)
+	}
+	if queued != 0 {
+		t.Fatalf("expected no event for a subscription to other types, got %d", queued)
+	}
+
+	reason := "unexpected status 500"
+	statusCode := int32(500)
+	err = store.RecordWebhookAttemptTx(ctx, RecordWebhookAttemptParams{
+		OutboxID:   outboxID,
+		Attempt:    1,
+		StatusCode: &statusCode,
+		Error:      &reason,
+		Duration:   120 * time.Millisecond,
+		RetryAt:    time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("record attempt: %v", err)
+	}
+	deliveries, err := store.ListWebhookDeliveriesBySubscription(ctx, ListWebhookDeliveriesBySubscriptionParams{
+		SubscriptionID: settled.ID,
+		Limit:          10,
+	})
+	if err != nil {
+		t.Fatalf("list deliveries: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].OutboxStatus != WebhookOutboxStatusPending || deliveries[0].DurationMs != 120 {
+		t.Fatalf("expected one failed attempt with the event still pending, got %+v", deliveries)
+	}
+
+	if err := store.RecordWebhookAttemptTx(ctx, RecordWebhookAttemptParams{OutboxID: outboxID, Attempt: 2, Delivered: true}); err != nil {
+		t.Fatalf("record attempt: %v", err)
+	}
+	deliveries, err = store.ListWebhookDeliveriesBySubscription(ctx, ListWebhookDeliveriesBySubscriptionParams{
+		SubscriptionID: settled.ID,
+		Limit:          10,
+	})
+	if err != nil {
+		t.Fatalf("list deliveries: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].OutboxStatus != WebhookOutboxStatusDelivered {
+		t.Fatalf("expected the event delivered after the second attempt, got %+v", deliveries)
+	}
+}
@@ -0,0 +1,128 @@
+// Package envelope encrypts secrets at rest with envelope encryption: each
+// secret gets its own random data key, and only that data key is encrypted
+// with the master key from the configuration.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const dataKeySize = 32
+
+var (
+	ErrInvalidMasterKey = errors.New("master key must be 32 bytes, base64 encoded")
+	ErrUnknownKey       = errors.New("secret was sealed with a different master key")
+	ErrMalformed        = errors.New("sealed secret is malformed")
+)
+
+// Sealed is an encrypted secret together with its encrypted data key.
+type Sealed struct {
+	// KeyID identifies the master key that encrypted DataKey.
+	KeyID      string
+	DataKey    []byte
+	Ciphertext []byte
+}
+
+// Sealer encrypts and decrypts secrets with one master key.
+type Sealer struct {
+	keyID  string
+	master cipher.AEAD
+}
+
+// NewSealer returns a Sealer for a base64 encoded 256-bit master key. The
+// key ID is derived from the key itself, so sealed secrets record which key
+// they need without any extra configuration.
+func NewSealer(encodedKey string) (*Sealer, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidMasterKey
+	}
+	master, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &Sealer{keyID: hex.EncodeToString(sum[:8]), master: master}, nil
+}
+
+// KeyID identifies the master key of the Sealer.
+func (s *Sealer) KeyID() string {
+	return s.keyID
+}
+
+// Seal encrypts plaintext under a fresh data key. The additional data is
+// authenticated but not stored; the same value must be passed to Open, which
+// binds the secret to e.g. the record it belongs to.
+func (s *Sealer) Seal(plaintext, additionalData []byte) (Sealed, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return Sealed{}, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	ciphertext, err := seal(aead, plaintext, additionalData)
+	if err != nil {
+		return Sealed{}, err
+	}
+	encryptedKey, err := seal(s.master, dataKey, []byte(s.keyID))
+	if err != nil {
+		return Sealed{}, err
+	}
+	return Sealed{KeyID: s.keyID, DataKey: encryptedKey, Ciphertext: ciphertext}, nil
+}
+
+// Open decrypts a secret sealed by Seal with the same master key and
+// additional data.
+func (s *Sealer) Open(sealed Sealed, additionalData []byte) ([]byte, error) {
+	if sealed.KeyID != s.keyID {
+		return nil, ErrUnknownKey
+	}
+	dataKey, err := open(s.master, sealed.DataKey, []byte(s.keyID))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, sealed.Ciphertext, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns the nonce followed by the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, data, additionalData []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return plaintext, nil
+}
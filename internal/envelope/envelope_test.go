@@ -0,0 +1,68 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func newTestSealer(t *testing.T) *Sealer {
+	t.Helper()
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("generate master key: %v", err)
+	}
+	sealer, err := NewSealer(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatalf("new sealer: %v", err)
+	}
+	return sealer
+}
+
+func TestSealOpen(t *testing.T) {
+	sealer := newTestSealer(t)
+	secret := []byte("wallet private key")
+
+	sealed, err := sealer.Seal(secret, []byte("wallet-1"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if sealed.KeyID != sealer.KeyID() {
+		t.Fatalf("expected key id %s, got %s", sealer.KeyID(), sealed.KeyID)
+	}
+	if bytes.Contains(sealed.Ciphertext, secret) {
+		t.Fatalf("ciphertext contains the plaintext")
+	}
+
+	opened, err := sealer.Open(sealed, []byte("wallet-1"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if !bytes.Equal(opened, secret) {
+		t.Fatalf("expected %q, got %q", secret, opened)
+	}
+
+	// The secret is bound to its additional data.
+	if _, err := sealer.Open(sealed, []byte("wallet-2")); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("expected ErrMalformed, got %v", err)
+	}
+}
+
+func TestOpenRejectsOtherMasterKey(t *testing.T) {
+	sealed, err := newTestSealer(t).Seal([]byte("secret"), nil)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if _, err := newTestSealer(t).Open(sealed, nil); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestNewSealerRejectsShortKey(t *testing.T) {
+	if _, err := NewSealer(base64.StdEncoding.EncodeToString([]byte("too short"))); !errors.Is(err, ErrInvalidMasterKey) {
+		t.Fatalf("expected ErrInvalidMasterKey, got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+// Package eventbus relays domain events from the outbox to in-process
+// subscribers. The store writes events in the same transaction as the change
+// they describe; a Bus claims committed events, calls every subscriber
+// registered for the event and marks it published once they all succeed.
+//
+// Delivery is at least once: when a subscriber fails, the event is retried
+// for all of its subscribers, so handlers must be idempotent.
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// MaxAttempts is the number of relays tried before an event is marked
+	// failed.
+	MaxAttempts = 10
+	// DefaultBatchSize is the number of events claimed at a time.
+	DefaultBatchSize = 50
+
+	// leaseDuration is how long a claimed event is hidden from other
+	// relays, so a relay that dies mid-batch only delays its events.
+	leaseDuration = 5 * time.Minute
+	baseBackoff   = 10 * time.Second
+	maxBackoff    = time.Hour
+)
+
+// Store is the part of the database store the bus uses.
+type Store interface {
+	ClaimOutboxEvents(ctx context.Context, arg database.ClaimOutboxEventsParams) ([]database.Outbox, error)
+	MarkOutboxEventPublished(ctx context.Context, id uuid.UUID) error
+	ScheduleOutboxEventRetry(ctx context.Context, arg database.ScheduleOutboxEventRetryParams) error
+	MarkOutboxEventFailed(ctx context.Context, arg database.MarkOutboxEventFailedParams) error
+}
+
+// Event is an outbox event as handed to subscribers. Attempt counts from 1.
+type Event struct {
+	ID          uuid.UUID
+	Type        string
+	AggregateID uuid.UUID
+	Payload     json.RawMessage
+	Attempt     int32
+	CreatedAt   time.Time
+}
+
+// Handler reacts to an event. Returning an error retries the event later.
+type Handler func(ctx context.Context, event Event) error
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// Bus is the subscriber registry and outbox relay.
+type Bus struct {
+	store     Store
+	batchSize int32
+	now       func() time.Time
+
+	mu          sync.RWMutex
+	subscribers map[string][]subscriber
+}
+
+// New returns a bus that relays events from store.
+func New(store Store) *Bus {
+	return &Bus{
+		store:       store,
+		batchSize:   DefaultBatchSize,
+		now:         time.Now,
+		subscribers: make(map[string][]subscriber),
+	}
+}
+
+// Subscribe registers handler for events of eventType. name identifies the
+// subscriber in logs and in the outbox's last_error.
+func (b *Bus) Subscribe(eventType, name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[eventType] = append(b.subscribers[eventType], subscriber{name: name, handler: handler})
+}
+
+// On registers a handler for the domain event T, decoding the payload for
+// it. For example:
+//
+//	eventbus.On(bus, "fraud", func(ctx context.Context, event eventbus.Event, transfer database.TransferCompleted) error {
+//		...
+//	})
+func On[T database.DomainEvent](b *Bus, name string, handle func(ctx context.Context, event Event, data T) error) {
+	var zero T
+	b.Subscribe(zero.EventName(), name, func(ctx context.Context, event Event) error {
+		var data T
+		if err := json.Unmarshal(event.Payload, &data); err != nil {
+			return fmt.Errorf("decode %s: %w", event.Type, err)
+		}
+		return handle(ctx, event, data)
+	})
+}
+
+// Backoff returns the wait after the given failed attempt: 10 seconds,
+// doubling each attempt, up to an hour.
+func Backoff(attempt int32) time.Duration {
+	delay := baseBackoff
+	for i := int32(1); i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// Run relays due events every interval until ctx is done. A full batch is
+// followed straight away by the next one.
+func (b *Bus) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := b.RelayOnce(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Cannot relay outbox events: %v", err)
+				}
+				break
+			}
+			if n < int(b.batchSize) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce claims one batch of due events and hands each to its
+// subscribers. It returns the number of events claimed.
+func (b *Bus) RelayOnce(ctx context.Context) (int, error) {
+	rows, err := b.store.ClaimOutboxEvents(ctx, database.ClaimOutboxEventsParams{
+		Limit:      b.batchSize,
+		LeaseUntil: pgtype.Timestamptz{Time: b.now().Add(leaseDuration), Valid: true},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, row := range rows {
+		if err := b.relay(ctx, row); err != nil {
+			return len(rows), fmt.Errorf("record outbox event %s: %w", row.ID, err)
+		}
+	}
+	return len(rows), nil
+}
+
+// relay calls the subscribers of one event and records the outcome.
+func (b *Bus) relay(ctx context.Context, row database.Outbox) error {
+	event := Event{
+		ID:          row.ID,
+		Type:        row.EventType,
+		AggregateID: row.AggregateID,
+		Payload:     row.Payload,
+		Attempt:     row.Attempts,
+		CreatedAt:   row.CreatedAt.Time,
+	}
+
+	b.mu.RLock()
+	subscribers := b.subscribers[event.Type]
+	b.mu.RUnlock()
+
+	for _, sub := range subscribers {
+		if err := b.call(ctx, sub, event); err != nil {
+			log.Printf("Subscriber %s failed on %s event %s (attempt %d): %v", sub.name, event.Type, event.ID, event.Attempt, err)
+			reason := fmt.Sprintf("%s: %v", sub.name, err)
+			if event.Attempt >= MaxAttempts {
+				return b.store.MarkOutboxEventFailed(ctx, database.MarkOutboxEventFailedParams{
+					ID:        event.ID,
+					LastError: &reason,
+				})
+			}
+			return b.store.ScheduleOutboxEventRetry(ctx, database.ScheduleOutboxEventRetryParams{
+				ID:            event.ID,
+				NextAttemptAt: pgtype.Timestamptz{Time: b.now().Add(Backoff(event.Attempt)), Valid: true},
+				LastError:     &reason,
+			})
+		}
+	}
+	return b.store.MarkOutboxEventPublished(ctx, event.ID)
+}
+
+// call runs a handler, turning a panic into an error so one subscriber
+// cannot stop the relay.
+func (b *Bus) call(ctx context.Context, sub subscriber, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sub.handler(ctx, event)
+}
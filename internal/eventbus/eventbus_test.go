@@ -0,0 +1,147 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/google/uuid"
+)
+
+// fakeStore hands out its events once and keeps what happened to them.
+type fakeStore struct {
+	events    []database.Outbox
+	published []uuid.UUID
+	retries   []database.ScheduleOutboxEventRetryParams
+	failed    []database.MarkOutboxEventFailedParams
+}
+
+func (s *fakeStore) ClaimOutboxEvents(_ context.Context, arg database.ClaimOutboxEventsParams) ([]database.Outbox, error) {
+	n := min(int(arg.Limit), len(s.events))
+	claimed := s.events[:n]
+	s.events = s.events[n:]
+	return claimed, nil
+}
+
+func (s *fakeStore) MarkOutboxEventPublished(_ context.Context, id uuid.UUID) error {
+	s.published = append(s.published, id)
+	return nil
+}
+
+func (s *fakeStore) ScheduleOutboxEventRetry(_ context.Context, arg database.ScheduleOutboxEventRetryParams) error {
+	s.retries = append(s.retries, arg)
+	return nil
+}
+
+func (s *fakeStore) MarkOutboxEventFailed(_ context.Context, arg database.MarkOutboxEventFailedParams) error {
+	s.failed = append(s.failed, arg)
+	return nil
+}
+
+func outboxEvent(t *testing.T, event database.DomainEvent, attempts int32) database.Outbox {
+	t.Helper()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal event: %v", err)
+	}
+	return database.Outbox{
+		ID:          uuid.New(),
+		EventType:   event.EventName(),
+		AggregateID: event.AggregateID(),
+		Payload:     payload,
+		Attempts:    attempts,
+	}
+}
+
+func TestRelayOnce(t *testing.T) {
+	walletID := uuid.New()
+	deactivated := outboxEvent(t, database.WalletDeactivated{WalletID: walletID, Deleted: true}, 1)
+	unhandled := outboxEvent(t, database.SyncConflictRaised{SyncLogID: uuid.New()}, 1)
+	store := &fakeStore{events: []database.Outbox{deactivated, unhandled}}
+
+	bus := New(store)
+	var got []database.WalletDeactivated
+	On(bus, "recorder", func(_ context.Context, event Event, data database.WalletDeactivated) error {
+		if event.ID != deactivated.ID || event.Attempt != 1 {
+			t.Errorf("unexpected event: %+v", event)
+		}
+		got = append(got, data)
+		return nil
+	})
+
+	n, err := bus.RelayOnce(context.Background())
+	if err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 events claimed, got %d", n)
+	}
+	if len(got) != 1 || got[0].WalletID != walletID || !got[0].Deleted {
+		t.Fatalf("expected the decoded event once, got %+v", got)
+	}
+	if len(store.published) != 2 {
+		t.Fatalf("expected both events published, including the one without subscribers, got %d", len(store.published))
+	}
+}
+
+func TestRelayRetriesFailedSubscribers(t *testing.T) {
+	retried := outboxEvent(t, database.TransferCompleted{}, 2)
+	exhausted := outboxEvent(t, database.TransferCompleted{}, MaxAttempts)
+	store := &fakeStore{events: []database.Outbox{retried, exhausted}}
+
+	bus := New(store)
+	bus.Subscribe(database.EventTransferCompleted, "broken", func(context.Context, Event) error {
+		return errors.New("unavailable")
+	})
+	bus.Subscribe(database.EventTransferCompleted, "panicky", func(context.Context, Event) error {
+		panic("unreachable after the first subscriber fails")
+	})
+
+	before := time.Now()
+	if _, err := bus.RelayOnce(context.Background()); err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	if len(store.published) != 0 {
+		t.Fatalf("expected nothing published, got %d", len(store.published))
+	}
+	if len(store.retries) != 1 || store.retries[0].ID != retried.ID {
+		t.Fatalf("expected the first event retried, got %+v", store.retries)
+	}
+	if store.retries[0].NextAttemptAt.Time.Before(before.Add(Backoff(2))) {
+		t.Fatalf("expected a retry after %v", Backoff(2))
+	}
+	if *store.retries[0].LastError != "broken: unavailable" {
+		t.Fatalf("unexpected last error %q", *store.retries[0].LastError)
+	}
+	if len(store.failed) != 1 || store.failed[0].ID != exhausted.ID {
+		t.Fatalf("expected the second event failed after %d attempts, got %+v", MaxAttempts, store.failed)
+	}
+}
+
+func TestRelayRecoversPanics(t *testing.T) {
+	store := &fakeStore{events: []database.Outbox{outboxEvent(t, database.TransferCompleted{}, 1)}}
+	bus := New(store)
+	bus.Subscribe(database.EventTransferCompleted, "panicky", func(context.Context, Event) error {
+		panic("boom")
+	})
+
+	if _, err := bus.RelayOnce(context.Background()); err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	if len(store.retries) != 1 || *store.retries[0].LastError != "panicky: panic: boom" {
+		t.Fatalf("expected the panic recorded as a retry, got %+v", store.retries)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	if Backoff(1) != 10*time.Second || Backoff(2) != 20*time.Second {
+		t.Fatalf("unexpected backoff: %v %v", Backoff(1), Backoff(2))
+	}
+	if Backoff(30) != time.Hour {
+		t.Fatalf("expected backoff capped at 1h, got %v", Backoff(30))
+	}
+}
@@ -0,0 +1,249 @@
+// Package jobs runs maintenance tasks on cron schedules. Each run takes a
+// Postgres advisory lock named after the job, so when several servers share
+// a database only one of them runs a job at a time, and every run is
+// recorded in job_runs. A scheduled run also claims its slot in job_runs,
+// so a server whose clock lags does not run the slot again after another
+// server has finished it.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	lockPrefix = "pay-on:job:"
+	// jobTimeout bounds a single run.
+	jobTimeout = 10 * time.Minute
+	// scheduleOff disables a job's schedule in JOB_SCHEDULES.
+	scheduleOff = "off"
+)
+
+var (
+	ErrUnknownJob      = errors.New("unknown job")
+	ErrJobRunning      = errors.New("job is already running")
+	ErrInvalidSchedule = errors.New("invalid job schedule")
+
+	// errSlotTaken reports a scheduled slot another server already ran.
+	errSlotTaken = errors.New("job schedule slot already ran")
+)
+
+// Job is a maintenance task. Run returns a short summary of what it did.
+type Job struct {
+	Name        string
+	Description string
+	// Schedule is a cron expression with five fields, or a descriptor such
+	// as @daily or @every 15m. An empty schedule only runs by hand.
+	Schedule string
+	Run      func(ctx context.Context) (string, error)
+}
+
+// Info describes a job and its schedule for operators.
+type Info struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Schedule    string           `json:"schedule"`
+	NextRunAt   *time.Time       `json:"next_run_at"`
+	LastRun     *database.JobRun `json:"last_run"`
+}
+
+// Runner schedules jobs and runs them on request.
+type Runner struct {
+	store   *database.Store
+	cron    *cron.Cron
+	jobs    map[string]Job
+	entries map[string]cron.EntryID
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// NewRunner returns a runner for jobs. Call Start to run them on schedule.
+func NewRunner(store *database.Store, jobs []Job) (*Runner, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Runner{
+		store:   store,
+		cron:    cron.New(),
+		jobs:    make(map[string]Job, len(jobs)),
+		entries: make(map[string]cron.EntryID),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	for _, job := range jobs {
+		r.jobs[job.Name] = job
+		if job.Schedule == "" {
+			continue
+		}
+		id, err := r.cron.AddFunc(job.Schedule, func() { r.runScheduled(job) })
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("%w for %s: %v", ErrInvalidSchedule, job.Name, err)
+		}
+		r.entries[job.Name] = id
+	}
+	return r, nil
+}
+
+// Start runs the jobs on their schedules in the background.
+func (r *Runner) Start() {
+	r.cron.Start()
+}
+
+// Stop stops scheduling, cancels running jobs and waits for them to return.
+func (r *Runner) Stop() {
+	r.cancel()
+	<-r.cron.Stop().Done()
+}
+
+// Jobs lists the jobs by name with their next scheduled and last runs.
+func (r *Runner) Jobs(ctx context.Context) ([]Info, error) {
+	runs, err := r.store.ListLatestJobRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lastRuns := make(map[string]database.JobRun, len(runs))
+	for _, run := range runs {
+		lastRuns[run.JobName] = run
+	}
+
+	infos := make([]Info, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		info := Info{Name: job.Name, Description: job.Description, Schedule: job.Schedule}
+		if id, ok := r.entries[job.Name]; ok {
+			if next := r.cron.Entry(id).Next; !next.IsZero() {
+				info.NextRunAt = &next
+			}
+		}
+		if run, ok := lastRuns[job.Name]; ok {
+			info.LastRun = &run
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Has reports whether a job is registered under name.
+func (r *Runner) Has(name string) bool {
+	_, ok := r.jobs[name]
+	return ok
+}
+
+// Trigger runs a job now on behalf of an admin and returns the finished run.
+// A job that failed still returns its run; the error is only for runs that
+// could not be made.
+func (r *Runner) Trigger(ctx context.Context, name string, triggeredBy uuid.UUID) (database.JobRun, error) {
+	job, ok := r.jobs[name]
+	if !ok {
+		return database.JobRun{}, ErrUnknownJob
+	}
+	return r.run(ctx, job, database.JobTriggerManual, triggeredBy, time.Time{})
+}
+
+func (r *Runner) runScheduled(job Job) {
+	// The entry's previous run time is the slot that fired, the same on
+	// every server for a cron expression.
+	slot := r.cron.Entry(r.entries[job.Name]).Prev
+	run, err := r.run(r.ctx, job, database.JobTriggerSchedule, uuid.Nil, slot)
+	switch {
+	case errors.Is(err, ErrJobRunning), errors.Is(err, errSlotTaken):
+		// Another server has it.
+	case err != nil:
+		log.Printf("Cannot run job %s: %v", job.Name, err)
+	case run.Status == database.JobRunStatusFailed:
+		log.Printf("Job %s failed: %s", job.Name, *run.Error)
+	}
+}
+
+// run runs job under its advisory lock. A scheduled run passes its slot,
+// and is skipped with errSlotTaken when a run already claimed it.
+func (r *Runner) run(ctx context.Context, job Job, trigger database.JobTrigger, triggeredBy uuid.UUID, slot time.Time) (database.JobRun, error) {
+	var run database.JobRun
+	locked, err := r.store.WithAdvisoryLock(ctx, lockPrefix+job.Name, func(ctx context.Context) error {
+		var err error
+		run, err = r.store.CreateJobRun(ctx, database.CreateJobRunParams{
+			JobName:     job.Name,
+			Trigger:     trigger,
+			TriggeredBy: pgtype.UUID{Bytes: triggeredBy, Valid: triggeredBy != uuid.Nil},
+			ScheduledAt: pgtype.Timestamptz{Time: slot, Valid: !slot.IsZero()},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errSlotTaken
+		}
+		if err != nil {
+			return err
+		}
+
+		jobCtx, cancel := context.WithTimeout(ctx, jobTimeout)
+		result, jobErr := job.Run(jobCtx)
+		cancel()
+
+		finish := database.FinishJobRunParams{ID: run.ID, Status: database.JobRunStatusSucceeded}
+		if result != "" {
+			finish.Result = &result
+		}
+		if jobErr != nil {
+			message := jobErr.Error()
+			finish.Status, finish.Error = database.JobRunStatusFailed, &message
+		}
+		// Record the outcome even if the job was cut short by ctx.
+		run, err = r.store.FinishJobRun(context.WithoutCancel(ctx), finish)
+		return err
+	})
+	if err != nil {
+		return run, err
+	}
+	if !locked {
+		return run, ErrJobRunning
+	}
+	return run, nil
+}
+
+// ParseSchedules reads JOB_SCHEDULES: semicolon separated name=schedule
+// pairs, such as "prune-stale-peers=@weekly;auto-trust-peers=off".
+func ParseSchedules(value string) (map[string]string, error) {
+	schedules := make(map[string]string)
+	for _, pair := range strings.Split(value, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, schedule, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not name=schedule", ErrInvalidSchedule, pair)
+		}
+		schedules[strings.TrimSpace(name)] = strings.TrimSpace(schedule)
+	}
+	return schedules, nil
+}
+
+// WithSchedules returns jobs with their schedules replaced by the ones in
+// schedules. "off" leaves a job to run by hand only.
+func WithSchedules(jobs []Job, schedules map[string]string) ([]Job, error) {
+	result := make([]Job, len(jobs))
+	copy(result, jobs)
+
+	for name, schedule := range schedules {
+		i := slices.IndexFunc(result, func(job Job) bool { return job.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownJob, name)
+		}
+		if strings.EqualFold(schedule, scheduleOff) {
+			schedule = ""
+		}
+		result[i].Schedule = schedule
+	}
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSchedules(t *testing.T) {
+	schedules, err := ParseSchedules(" purge-expired = @every 5m ; auto-trust-peers=off;;")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(schedules) != 2 || schedules["purge-expired"] != "@every 5m" || schedules["auto-trust-peers"] != "off" {
+		t.Fatalf("unexpected schedules: %v", schedules)
+	}
+
+	if _, err := ParseSchedules("purge-expired"); !errors.Is(err, ErrInvalidSchedule) {
+		t.Fatalf("expected ErrInvalidSchedule, got %v", err)
+	}
+}
+
+func TestWithSchedules(t *testing.T) {
+	defaults := Maintenance(nil)
+
+	jobs, err := WithSchedules(defaults, map[string]string{
+		"purge-expired":    "0,30 * * * *",
+		"auto-trust-peers": "OFF",
+	})
+	if err != nil {
+		t.Fatalf("with schedules: %v", err)
+	}
+	for _, job := range jobs {
+		switch job.Name {
+		case "purge-expired":
+			if job.Schedule != "0,30 * * * *" {
+				t.Fatalf("expected the override, got %q", job.Schedule)
+			}
+		case "auto-trust-peers":
+			if job.Schedule != "" {
+				t.Fatalf("expected the schedule turned off, got %q", job.Schedule)
+			}
+		}
+	}
+	if defaults[0].Schedule != "*/15 * * * *" {
+		t.Fatalf("expected the defaults untouched, got %q", defaults[0].Schedule)
+	}
+
+	if _, err := WithSchedules(defaults, map[string]string{"nope": "@daily"}); !errors.Is(err, ErrUnknownJob) {
+		t.Fatalf("expected ErrUnknownJob, got %v", err)
+	}
+}
+
+func TestNewRunnerRejectsBadSchedules(t *testing.T) {
+	if _, err := NewRunner(nil, Maintenance(nil)); err != nil {
+		t.Fatalf("default schedules: %v", err)
+	}
+
+	jobs, err := WithSchedules(Maintenance(nil), map[string]string{"purge-expired": "every so often"})
+	if err != nil {
+		t.Fatalf("with schedules: %v", err)
+	}
+	if _, err := NewRunner(nil, jobs); !errors.Is(err, ErrInvalidSchedule) {
+		t.Fatalf("expected ErrInvalidSchedule, got %v", err)
+	}
+}
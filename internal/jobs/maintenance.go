@@ -0,0 +1,143 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// idempotencyKeyLifetime is how long a stored response can be replayed.
+	idempotencyKeyLifetime = 24 * time.Hour
+	// autoTrustTransactionCount matches auto_trust_peers().
+	autoTrustTransactionCount = 5
+	syncLogRetentionDays      = 90
+	auditLogRetentionDays     = 365
+	webhookRetentionDays      = 30
+	outboxRetentionDays       = 7
+	jobRunRetentionDays       = 90
+	stalePeerBatchSize        = 1000
+)
+
+// Maintenance returns the built-in maintenance jobs with their default
+// schedules.
+func Maintenance(store *database.Store) []Job {
+	return []Job{
+		{
+			Name:        "purge-expired",
+			Description: "Delete expired sessions, one-time codes, device and transfer challenges, and idempotency keys older than a day",
+			Schedule:    "*/15 * * * *",
+			Run:         func(ctx context.Context) (string, error) { return purgeExpired(ctx, store) },
+		},
+		{
+			Name:        "auto-trust-peers",
+			Description: fmt.Sprintf("Trust peers seen in the last 30 days with at least %d transactions", autoTrustTransactionCount),
+			Schedule:    "0 * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				count := int32(autoTrustTransactionCount)
+				return "", store.AutoTrustFrequentPeers(ctx, &count)
+			},
+		},
+		{
+			Name:        "prune-stale-peers",
+			Description: "Soft delete peers not seen for 90 days; they come back on their next contact",
+			Schedule:    "30 3 * * *",
+			Run:         func(ctx context.Context) (string, error) { return pruneStalePeers(ctx, store) },
+		},
+		{
+			Name:        "cleanup-sync-logs",
+			Description: fmt.Sprintf("Delete settled sync logs older than %d days", syncLogRetentionDays),
+			Schedule:    "0 3 * * *",
+			Run: func(ctx context.Context) (string, error) {
+				days := strconv.Itoa(syncLogRetentionDays)
+				return "", store.DeleteOldSyncLogs(ctx, &days)
+			},
+		},
+		{
+			Name:        "cleanup-webhooks",
+			Description: fmt.Sprintf("Delete delivered and failed webhook events, with their delivery attempts, older than %d days", webhookRetentionDays),
+			Schedule:    "15 3 * * *",
+			Run: func(ctx context.Context) (string, error) {
+				before := time.Now().AddDate(0, 0, -webhookRetentionDays)
+				deleted, err := store.DeleteOldWebhookEvents(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+				return fmt.Sprintf("deleted %d webhook events", deleted), err
+			},
+		},
+		{
+			Name:        "cleanup-outbox",
+			Description: fmt.Sprintf("Delete domain events published more than %d days ago", outboxRetentionDays),
+			Schedule:    "45 3 * * *",
+			Run: func(ctx context.Context) (string, error) {
+				before := time.Now().AddDate(0, 0, -outboxRetentionDays)
+				deleted, err := store.DeleteOldOutboxEvents(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+				return fmt.Sprintf("deleted %d outbox events", deleted), err
+			},
+		},
+		{
+			Name:        "cleanup-job-runs",
+			Description: fmt.Sprintf("Delete finished job runs older than %d days", jobRunRetentionDays),
+			Schedule:    "30 4 * * 0",
+			Run: func(ctx context.Context) (string, error) {
+				before := time.Now().AddDate(0, 0, -jobRunRetentionDays)
+				deleted, err := store.DeleteOldJobRuns(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+				return fmt.Sprintf("deleted %d job runs", deleted), err
+			},
+		},
+		{
+			Name:        "cleanup-audit-logs",
+			Description: fmt.Sprintf("Delete audit logs older than %d days", auditLogRetentionDays),
+			Schedule:    "0 4 * * 0",
+			Run: func(ctx context.Context) (string, error) {
+				days := strconv.Itoa(auditLogRetentionDays)
+				return "", store.DeleteOldAuditLogs(ctx, &days)
+			},
+		},
+	}
+}
+
+func purgeExpired(ctx context.Context, store *database.Store) (string, error) {
+	now := time.Now()
+	expired := pgtype.Timestamptz{Time: now, Valid: true}
+	purges := []struct {
+		name  string
+		purge func(context.Context, pgtype.Timestamptz) (int64, error)
+		at    pgtype.Timestamptz
+	}{
+		{"sessions", store.DeleteExpiredSessions, expired},
+		{"OTP codes", store.DeleteExpiredOTPCodes, expired},
+		{"device challenges", store.DeleteExpiredDeviceChallenges, expired},
+		{"transfer challenges", store.DeleteExpiredTransferChallenges, expired},
+		{"idempotency keys", store.DeleteExpiredIdempotencyKeys, pgtype.Timestamptz{Time: now.Add(-idempotencyKeyLifetime), Valid: true}},
+	}
+
+	summary := ""
+	for _, p := range purges {
+		deleted, err := p.purge(ctx, p.at)
+		if err != nil {
+			return summary, fmt.Errorf("delete expired %s: %w", p.name, err)
+		}
+		if summary != "" {
+			summary += ", "
+		}
+		summary += fmt.Sprintf("%d %s", deleted, p.name)
+	}
+	return "deleted " + summary, nil
+}
+
+func pruneStalePeers(ctx context.Context, store *database.Store) (string, error) {
+	var total int64
+	for {
+		pruned, err := store.PruneStalePeers(ctx, stalePeerBatchSize)
+		total += pruned
+		if err != nil {
+			return fmt.Sprintf("pruned %d peers", total), err
+		}
+		if pruned < stalePeerBatchSize {
+			return fmt.Sprintf("pruned %d peers", total), nil
+		}
+	}
+}
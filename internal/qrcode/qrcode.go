@@ -0,0 +1,87 @@
+// Package qrcode renders text as a QR code image in PNG or SVG format.
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"rsc.io/qr"
+)
+
+const (
+	// DefaultScale is the number of image pixels per QR module.
+	DefaultScale = 8
+	// MaxScale keeps images at a sensible size.
+	MaxScale = 32
+
+	// quietZone is the blank border around the code, in modules.
+	quietZone = 4
+)
+
+var (
+	ErrInvalidScale  = fmt.Errorf("scale must be between 1 and %d", MaxScale)
+	ErrUnknownFormat = errors.New("unknown image format; use png or svg")
+)
+
+// Format is an image format for QR codes.
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+)
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Encode renders content as a QR code with medium error correction.
+func Encode(content string, format Format, scale int) ([]byte, error) {
+	if scale < 1 || scale > MaxScale {
+		return nil, ErrInvalidScale
+	}
+	code, err := qr.Encode(content, qr.M)
+	if err != nil {
+		return nil, err
+	}
+	code.Scale = scale
+
+	switch format {
+	case FormatPNG:
+		return code.PNG(), nil
+	case FormatSVG:
+		return svg(code), nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// svg draws the dark modules of each row as runs of one path, which keeps
+// the document small.
+func svg(code *qr.Code) []byte {
+	size := code.Size + 2*quietZone
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size*code.Scale, size*code.Scale, size, size)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, size, size)
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; {
+			if !code.Black(x, y) {
+				x++
+				continue
+			}
+			start := x
+			for x < code.Size && code.Black(x, y) {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start+quietZone, y+quietZone, x-start, x-start)
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
@@ -0,0 +1,43 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestEncodePNG(t *testing.T) {
+	data, err := Encode("pay-on test payload", FormatPNG, 4)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != bounds.Dy() || bounds.Dx()%4 != 0 {
+		t.Fatalf("unexpected image bounds %v", bounds)
+	}
+}
+
+func TestEncodeSVG(t *testing.T) {
+	data, err := Encode("pay-on test payload", FormatSVG, 4)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	doc := string(data)
+	if !strings.HasPrefix(doc, "<svg ") || !strings.HasSuffix(doc, "</svg>") || !strings.Contains(doc, `d="M`) {
+		t.Fatalf("unexpected svg %q", doc)
+	}
+}
+
+func TestEncodeRejectsBadInput(t *testing.T) {
+	if _, err := Encode("x", FormatPNG, 0); !errors.Is(err, ErrInvalidScale) {
+		t.Fatalf("expected ErrInvalidScale, got %v", err)
+	}
+	if _, err := Encode("x", Format("gif"), 4); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+// Package signature builds the canonical signing payload for transactions and
+// verifies client signatures against wallet public keys.
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	payloadVersion  = "pay-on:v1"
+	defaultCurrency = "NPR"
+)
+
+var (
+	ErrInvalidSignature  = errors.New("invalid transaction signature")
+	ErrInvalidPublicKey  = errors.New("invalid wallet public key")
+	ErrUnsupportedKey    = errors.New("unsupported public key type")
+	ErrInvalidAmount     = errors.New("invalid amount")
+	ErrMissingSignedData = errors.New("nonce and transaction_at are required for signing")
+)
+
+// Payload holds the transaction fields covered by a wallet signature.
+type Payload struct {
+	FromWalletID  uuid.UUID
+	ToWalletID    uuid.UUID
+	Amount        pgtype.Numeric
+	Currency      string
+	Nonce         int64
+	TransactionAt time.Time
+}
+
+// Bytes returns the canonical byte representation that clients must sign.
+//
+// The payload is a newline separated list of the version tag, the sender and
+// receiver wallet IDs, the amount with two decimal places, the upper-case
+// currency code (NPR when empty), the nonce and the transaction time in Unix
+// seconds.
+func (p Payload) Bytes() ([]byte, error) {
+	if p.Nonce == 0 || p.TransactionAt.IsZero() {
+		return nil, ErrMissingSignedData
+	}
+
+	amount, err := FormatAmount(p.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := strings.ToUpper(p.Currency)
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	fields := []string{
+		payloadVersion,
+		p.FromWalletID.String(),
+		p.ToWalletID.String(),
+		amount,
+		currency,
+		strconv.FormatInt(p.Nonce, 10),
+		strconv.FormatInt(p.TransactionAt.UTC().Unix(), 10),
+	}
+	return []byte(strings.Join(fields, "\n")), nil
+}
+
+// FormatAmount renders a numeric amount with exactly two decimal places.
+func FormatAmount(amount pgtype.Numeric) (string, error) {
+	if !amount.Valid || amount.NaN || amount.InfinityModifier != pgtype.Finite || amount.Int == nil {
+		return "", ErrInvalidAmount
+	}
+
+	value := new(big.Rat).SetInt(amount.Int)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(amount.Exp))), nil))
+	if amount.Exp < 0 {
+		value.Quo(value, scale)
+	} else {
+		value.Mul(value, scale)
+	}
+	return value.FloatString(2), nil
+}
+
+// Verify checks that sig is a valid signature of payload by publicKey.
+//
+// Public keys may be PEM encoded or base64 encoded PKIX DER. A base64 encoded
+// 32 byte value is treated as a raw Ed25519 key. Signatures are base64 encoded;
+// ECDSA P-256 signatures may be ASN.1 DER or raw r||s and cover the SHA-256
+// digest of the payload.
+func Verify(publicKey string, payload []byte, sig string) error {
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	sigBytes, err := decodeBase64(sig)
+	if err != nil || len(sigBytes) == 0 {
+		return ErrInvalidSignature
+	}
+
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, payload, sigBytes) {
+			return ErrInvalidSignature
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(payload)
+		if len(sigBytes) == 64 {
+			r := new(big.Int).SetBytes(sigBytes[:32])
+			s := new(big.Int).SetBytes(sigBytes[32:])
+			if ecdsa.Verify(k, digest[:], r, s) {
+				return nil
+			}
+		}
+		if !ecdsa.VerifyASN1(k, digest[:], sigBytes) {
+			return ErrInvalidSignature
+		}
+		return nil
+	default:
+		return ErrUnsupportedKey
+	}
+}
+
+// ParsePublicKey decodes a wallet public key into an Ed25519 or ECDSA P-256 key.
+func ParsePublicKey(publicKey string) (any, error) {
+	publicKey = strings.TrimSpace(publicKey)
+
+	var der []byte
+	if block, _ := pem.Decode([]byte(publicKey)); block != nil {
+		der = block.Bytes
+	} else {
+		decoded, err := decodeBase64(publicKey)
+		if err != nil {
+			return nil, ErrInvalidPublicKey
+		}
+		if len(decoded) == ed25519.PublicKeySize {
+			return ed25519.PublicKey(decoded), nil
+		}
+		der = decoded
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return k, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("%w: curve %s", ErrUnsupportedKey, k.Curve.Params().Name)
+		}
+		return k, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+func decodeBase64(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	for _, enc := range []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	} {
+		if decoded, err := enc.DecodeString(value); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, ErrInvalidSignature
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,125 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func testPayload(t *testing.T) Payload {
+	t.Helper()
+
+	var amount pgtype.Numeric
+	if err := amount.Scan("25.5"); err != nil {
+		t.Fatalf("scan amount: %v", err)
+	}
+	return Payload{
+		FromWalletID:  uuid.New(),
+		ToWalletID:    uuid.New(),
+		Amount:        amount,
+		Currency:      "npr",
+		Nonce:         42,
+		TransactionAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestPayloadBytes(t *testing.T) {
+	p := testPayload(t)
+
+	data, err := p.Bytes()
+	if err != nil {
+		t.Fatalf("payload bytes: %v", err)
+	}
+	want := "pay-on:v1\n" + p.FromWalletID.String() + "\n" + p.ToWalletID.String() + "\n25.50\nNPR\n42\n1735787045"
+	if string(data) != want {
+		t.Fatalf("expected payload %q, got %q", want, string(data))
+	}
+
+	p.Nonce = 0
+	if _, err := p.Bytes(); !errors.Is(err, ErrMissingSignedData) {
+		t.Fatalf("expected ErrMissingSignedData, got %v", err)
+	}
+}
+
+func TestVerifyEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	data, err := testPayload(t).Bytes()
+	if err != nil {
+		t.Fatalf("payload bytes: %v", err)
+	}
+
+	publicKey := base64.StdEncoding.EncodeToString(pub)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data))
+
+	if err := Verify(publicKey, data, sig); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 1
+	if err := Verify(publicKey, tampered, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestVerifyECDSAP256(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	data, err := testPayload(t).Bytes()
+	if err != nil {
+		t.Fatalf("payload bytes: %v", err)
+	}
+	digest := sha256.Sum256(data)
+	sigDER, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	if err := Verify(publicKey, data, base64.StdEncoding.EncodeToString(sigDER)); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := Verify(publicKey, []byte("other"), base64.StdEncoding.EncodeToString(sigDER)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestVerifyRejectsUnsupportedKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+
+	err = Verify(base64.StdEncoding.EncodeToString(der), []byte("data"), "c2ln")
+	if !errors.Is(err, ErrUnsupportedKey) {
+		t.Fatalf("expected ErrUnsupportedKey, got %v", err)
+	}
+	if err := Verify("not-a-key", []byte("data"), "c2ln"); !errors.Is(err, ErrInvalidPublicKey) {
+		t.Fatalf("expected ErrInvalidPublicKey, got %v", err)
+	}
+}
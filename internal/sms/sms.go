@@ -0,0 +1,76 @@
+// Package sms sends text messages to phone numbers. Deployments plug in their
+// SMS gateway by implementing Sender; LogSender and FileSender are meant for
+// local development and tests.
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownSender   = errors.New("unknown SMS sender; use log or file")
+	ErrMissingFilePath = errors.New("the file SMS sender needs SMS_FILE_PATH")
+)
+
+// Sender delivers a text message to a phone number.
+type Sender interface {
+	Send(ctx context.Context, phoneNumber, message string) error
+}
+
+// New returns the sender configured by name: "log" (the default) or "file",
+// which appends to path.
+func New(name, path string) (Sender, error) {
+	switch strings.ToLower(name) {
+	case "", "log":
+		return LogSender{}, nil
+	case "file":
+		if path == "" {
+			return nil, ErrMissingFilePath
+		}
+		return NewFileSender(path), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSender, name)
+	}
+}
+
+// LogSender writes messages to the standard logger instead of sending them.
+type LogSender struct{}
+
+func (LogSender) Send(_ context.Context, phoneNumber, message string) error {
+	log.Printf("SMS to %s: %s", phoneNumber, message)
+	return nil
+}
+
+// FileSender appends messages to a file, one tab separated line each: the
+// time, the phone number and the message.
+type FileSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileSender(path string) *FileSender {
+	return &FileSender{path: path}
+}
+
+func (s *FileSender) Send(_ context.Context, phoneNumber, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	line := fmt.Sprintf("%s\t%s\t%s\n", time.Now().UTC().Format(time.RFC3339), phoneNumber, message)
+	if _, err := file.WriteString(line); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
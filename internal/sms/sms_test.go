@@ -0,0 +1,51 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSender(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sms.log")
+	sender, err := New("file", path)
+	if err != nil {
+		t.Fatalf("new sender: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := sender.Send(ctx, "+9779812345678", "code 123456"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if err := sender.Send(ctx, "+9779812345679", "code 654321"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read sms file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(lines))
+	}
+	fields := strings.Split(lines[1], "\t")
+	if len(fields) != 3 || fields[1] != "+9779812345679" || fields[2] != "code 654321" {
+		t.Fatalf("unexpected line %q", lines[1])
+	}
+}
+
+func TestNewSender(t *testing.T) {
+	if sender, err := New("", ""); err != nil || sender == nil {
+		t.Fatalf("expected the log sender by default, got %v", err)
+	}
+	if _, err := New("file", ""); !errors.Is(err, ErrMissingFilePath) {
+		t.Fatalf("expected ErrMissingFilePath, got %v", err)
+	}
+	if _, err := New("carrier-pigeon", ""); !errors.Is(err, ErrUnknownSender) {
+		t.Fatalf("expected ErrUnknownSender, got %v", err)
+	}
+}
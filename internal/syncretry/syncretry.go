@@ -0,0 +1,161 @@
+// Package syncretry settles the transactions of failed sync logs again.
+// A failed log is due once a backoff has passed since its last attempt: one
+// minute doubling per attempt up to six hours, less up to half of it by a
+// fraction fixed per log so that logs which failed together are not retried
+// together. GetSyncsNeedingRetry works this out from attempt_count and
+// last_attempt_at, so the schedule survives restarts and is the same on
+// every server. A log that runs out of attempts becomes a dead letter and
+// waits for an operator to requeue it.
+package syncretry
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// MaxAttempts is the number of settlement attempts, the first one
+	// included, before a sync log becomes a dead letter.
+	MaxAttempts = 8
+	// DefaultBatchSize is the number of logs retried per pass.
+	DefaultBatchSize = 50
+
+	// lockKey keeps retries to one server at a time.
+	lockKey = "pay-on:sync-retry"
+)
+
+// Store is the part of the database store the worker uses.
+type Store interface {
+	WithAdvisoryLock(ctx context.Context, key string, fn func(context.Context) error) (bool, error)
+	DeadLetterExhaustedSyncs(ctx context.Context, attemptCount *int32) (int64, error)
+	GetSyncsNeedingRetry(ctx context.Context, arg database.GetSyncsNeedingRetryParams) ([]database.SyncLog, error)
+	RetrySettlementTx(ctx context.Context, syncLogID uuid.UUID) (database.SettleTxResult, error)
+	ScheduleSyncRetry(ctx context.Context, arg database.ScheduleSyncRetryParams) (database.SyncLog, error)
+	DeadLetterSyncLog(ctx context.Context, arg database.DeadLetterSyncLogParams) (database.SyncLog, error)
+}
+
+// Worker retries failed settlements.
+type Worker struct {
+	store     Store
+	batchSize int32
+}
+
+// NewWorker returns a worker that retries the failed sync logs in store.
+func NewWorker(store Store) *Worker {
+	return &Worker{store: store, batchSize: DefaultBatchSize}
+}
+
+// Run retries due sync logs every interval until ctx is done. A full batch
+// is followed straight away by the next one.
+func (w *Worker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := w.RetryOnce(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Cannot retry failed syncs: %v", err)
+				}
+				break
+			}
+			if n < int(w.batchSize) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RetryOnce retries one batch of due sync logs and returns how many it
+// tried. It does nothing while another server is retrying.
+func (w *Worker) RetryOnce(ctx context.Context) (int, error) {
+	var n int
+	_, err := w.store.WithAdvisoryLock(ctx, lockKey, func(ctx context.Context) error {
+		maxAttempts := int32(MaxAttempts)
+		exhausted, err := w.store.DeadLetterExhaustedSyncs(ctx, &maxAttempts)
+		if err != nil {
+			return err
+		}
+		if exhausted > 0 {
+			log.Printf("Moved %d exhausted sync logs to dead letters", exhausted)
+		}
+
+		logs, err := w.store.GetSyncsNeedingRetry(ctx, database.GetSyncsNeedingRetryParams{
+			AttemptCount: &maxAttempts,
+			Limit:        w.batchSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, syncLog := range logs {
+			if err := w.retry(ctx, syncLog); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (w *Worker) retry(ctx context.Context, syncLog database.SyncLog) error {
+	result, err := w.store.RetrySettlementTx(ctx, syncLog.ID)
+	switch {
+	case err == nil:
+		if result.Conflict != nil {
+			log.Printf("Retried sync log %s ran into a %s conflict", syncLog.ID, result.Conflict.Reason)
+		}
+		return nil
+	case errors.Is(err, database.ErrSyncLogNotFailed):
+		// Requeued, resolved or retried elsewhere since it was listed.
+		return nil
+	case ctx.Err() != nil:
+		return ctx.Err()
+	}
+
+	message := err.Error()
+	attempt := int32(1)
+	if syncLog.AttemptCount != nil {
+		attempt = *syncLog.AttemptCount + 1
+	}
+	// A transaction that can no longer be settled will not get better.
+	if attempt >= MaxAttempts || errors.Is(err, database.ErrTransactionNotSettleable) {
+		_, err = w.store.DeadLetterSyncLog(ctx, database.DeadLetterSyncLogParams{
+			ID:           syncLog.ID,
+			ErrorMessage: &message,
+		})
+		if err == nil {
+			log.Printf("Sync log %s is a dead letter after %d attempts: %s", syncLog.ID, attempt, message)
+		}
+		return ignoreNoRows(err)
+	}
+
+	// Recording the attempt starts its backoff.
+	_, err = w.store.ScheduleSyncRetry(ctx, database.ScheduleSyncRetryParams{
+		ID:           syncLog.ID,
+		ErrorMessage: &message,
+	})
+	return ignoreNoRows(err)
+}
+
+// ignoreNoRows drops the error of an update that found the log no longer
+// failed, which means someone else has dealt with it.
+func ignoreNoRows(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	return err
+}
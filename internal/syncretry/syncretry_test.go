@@ -0,0 +1,107 @@
+package syncretry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/google/uuid"
+)
+
+// fakeStore settles each log with a preset outcome and keeps what happened
+// to the ones that failed.
+type fakeStore struct {
+	locked    bool
+	logs      []database.SyncLog
+	outcomes  map[uuid.UUID]error
+	exhausted int64
+	retried   []uuid.UUID
+	scheduled []database.ScheduleSyncRetryParams
+	dead      []database.DeadLetterSyncLogParams
+}
+
+func (s *fakeStore) WithAdvisoryLock(ctx context.Context, _ string, fn func(context.Context) error) (bool, error) {
+	if s.locked {
+		return false, nil
+	}
+	return true, fn(ctx)
+}
+
+func (s *fakeStore) DeadLetterExhaustedSyncs(_ context.Context, attemptCount *int32) (int64, error) {
+	if attemptCount == nil || *attemptCount != MaxAttempts {
+		return 0, errors.New("unexpected attempt limit")
+	}
+	return s.exhausted, nil
+}
+
+func (s *fakeStore) GetSyncsNeedingRetry(_ context.Context, arg database.GetSyncsNeedingRetryParams) ([]database.SyncLog, error) {
+	n := min(int(arg.Limit), len(s.logs))
+	due := s.logs[:n]
+	s.logs = s.logs[n:]
+	return due, nil
+}
+
+func (s *fakeStore) RetrySettlementTx(_ context.Context, syncLogID uuid.UUID) (database.SettleTxResult, error) {
+	s.retried = append(s.retried, syncLogID)
+	return database.SettleTxResult{}, s.outcomes[syncLogID]
+}
+
+func (s *fakeStore) ScheduleSyncRetry(_ context.Context, arg database.ScheduleSyncRetryParams) (database.SyncLog, error) {
+	s.scheduled = append(s.scheduled, arg)
+	return database.SyncLog{ID: arg.ID}, nil
+}
+
+func (s *fakeStore) DeadLetterSyncLog(_ context.Context, arg database.DeadLetterSyncLogParams) (database.SyncLog, error) {
+	s.dead = append(s.dead, arg)
+	return database.SyncLog{ID: arg.ID}, nil
+}
+
+func failedLog(attempts int32) database.SyncLog {
+	return database.SyncLog{ID: uuid.New(), Status: database.SyncStatusFailed, AttemptCount: &attempts}
+}
+
+func TestRetryOnce(t *testing.T) {
+	settled := failedLog(1)
+	failing := failedLog(2)
+	lastChance := failedLog(MaxAttempts - 1)
+	gone := failedLog(1)
+	unsettleable := failedLog(1)
+	store := &fakeStore{
+		logs: []database.SyncLog{settled, failing, lastChance, gone, unsettleable},
+		outcomes: map[uuid.UUID]error{
+			failing.ID:      errors.New("connection reset"),
+			lastChance.ID:   errors.New("connection reset"),
+			gone.ID:         database.ErrSyncLogNotFailed,
+			unsettleable.ID: database.ErrTransactionNotSettleable,
+		},
+	}
+
+	n, err := NewWorker(store).RetryOnce(context.Background())
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if n != 5 || len(store.retried) != 5 {
+		t.Fatalf("expected 5 logs retried, got %d (%d calls)", n, len(store.retried))
+	}
+
+	if len(store.scheduled) != 1 || store.scheduled[0].ID != failing.ID {
+		t.Fatalf("expected only the failing log rescheduled, got %+v", store.scheduled)
+	}
+	if retry := store.scheduled[0]; *retry.ErrorMessage != "connection reset" {
+		t.Fatalf("expected the error recorded, got %q", *retry.ErrorMessage)
+	}
+
+	if len(store.dead) != 2 || store.dead[0].ID != lastChance.ID || store.dead[1].ID != unsettleable.ID {
+		t.Fatalf("expected the exhausted and unsettleable logs dead lettered, got %+v", store.dead)
+	}
+}
+
+func TestRetryOnceSkipsWhileLocked(t *testing.T) {
+	store := &fakeStore{locked: true, logs: []database.SyncLog{failedLog(1)}}
+
+	n, err := NewWorker(store).RetryOnce(context.Background())
+	if err != nil || n != 0 || len(store.retried) != 0 {
+		t.Fatalf("expected nothing retried while another server holds the lock, got n=%d err=%v", n, err)
+	}
+}
@@ -0,0 +1,206 @@
+// Package token signs and verifies pay-on access tokens and publishes the
+// verification keys as a JSON Web Key Set.
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey   = errors.New("the first JWT key must be a private key")
+	ErrUnknownKey     = errors.New("unknown token signing key")
+	ErrUnsupportedKey = errors.New("unsupported JWT key type; use Ed25519 or RSA")
+	ErrDuplicateKeyID = errors.New("duplicate JWT key id")
+)
+
+// Key is one signing or verification key. Keys are identified by the kid
+// header of the tokens they sign.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	Public crypto.PublicKey
+
+	// private is nil for keys that are kept only to verify tokens issued
+	// before a rotation.
+	private crypto.Signer
+}
+
+// KeySet signs tokens with one key and accepts tokens signed by any of its keys.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	ordered []*Key
+
+	// secret is set for the HS256 fallback used when no key files are configured.
+	secret []byte
+}
+
+// LoadKeySet reads PEM encoded keys from files. The first file must hold a
+// private key and is used for signing; the others may be private or public
+// keys and are only used to verify tokens. The kid of each key is its file
+// name without the extension, e.g. 2026-10.pem has kid "2026-10".
+//
+// To rotate keys without downtime, prepend the new key to the list and keep
+// the old one until the tokens it signed have expired.
+func LoadKeySet(paths []string) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*Key)}
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		key, err := ParseKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if i == 0 && key.private == nil {
+			return nil, ErrNoSigningKey
+		}
+		if err := set.add(key); err != nil {
+			return nil, err
+		}
+	}
+	if len(set.ordered) == 0 {
+		return nil, ErrNoSigningKey
+	}
+	set.signing = set.ordered[0]
+	return set, nil
+}
+
+// NewHMACKeySet returns a key set that signs and verifies HS256 tokens with
+// a shared secret. It publishes no keys and is meant for local development.
+func NewHMACKeySet(secret string) *KeySet {
+	return &KeySet{keys: make(map[string]*Key), secret: []byte(secret)}
+}
+
+// ParseKey parses a PEM encoded PKCS #8 private key or PKIX public key.
+func ParseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: id}
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.Method, key.Public, key.private = jwt.SigningMethodEdDSA, k.Public(), k
+	case *rsa.PrivateKey:
+		key.Method, key.Public, key.private = jwt.SigningMethodRS256, k.Public(), k
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	return key, nil
+}
+
+func (set *KeySet) add(key *Key) error {
+	if _, ok := set.keys[key.ID]; ok {
+		return fmt.Errorf("%w %q", ErrDuplicateKeyID, key.ID)
+	}
+	set.keys[key.ID] = key
+	set.ordered = append(set.ordered, key)
+	return nil
+}
+
+// Sign returns the signed token for the claims, with the signing key's kid
+// in the header.
+func (set *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if set.secret != nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(set.secret)
+	}
+	token := jwt.NewWithClaims(set.signing.Method, claims)
+	token.Header["kid"] = set.signing.ID
+	return token.SignedString(set.signing.private)
+}
+
+// Keyfunc selects the verification key for a token by its kid header. It is
+// meant to be passed to jwt.ParseWithClaims.
+func (set *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	if set.secret != nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return set.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := set.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	// The algorithm must match the key, so a token cannot pick a weaker one.
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Public, nil
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, signing key first.
+func (set *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range set.ordered {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch pub := key.Public.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
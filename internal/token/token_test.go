@@ -0,0 +1,146 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func writePrivateKey(t *testing.T, dir, name string, key any) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal private key: %v", err)
+	}
+	return writePEM(t, dir, name, "PRIVATE KEY", der)
+}
+
+func testClaims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   "user",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}
+}
+
+func parse(set *KeySet, signed string) error {
+	_, err := jwt.ParseWithClaims(signed, &jwt.RegisteredClaims{}, set.Keyfunc)
+	return err
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	oldPath := writePrivateKey(t, dir, "2026-09.pem", oldKey)
+	newPath := writePrivateKey(t, dir, "2026-10.pem", newKey)
+
+	oldSet, err := LoadKeySet([]string{oldPath})
+	if err != nil {
+		t.Fatalf("load old key set: %v", err)
+	}
+	oldToken, err := oldSet.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("sign with old key: %v", err)
+	}
+
+	// After rotation the old key only verifies; it can be a public key.
+	pubDER, err := x509.MarshalPKIXPublicKey(oldKey.Public())
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	oldPublicPath := writePEM(t, dir, "2026-09.pub", "PUBLIC KEY", pubDER)
+	rotated, err := LoadKeySet([]string{newPath, oldPublicPath})
+	if err != nil {
+		t.Fatalf("load rotated key set: %v", err)
+	}
+	newToken, err := rotated.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("sign with new key: %v", err)
+	}
+
+	if err := parse(rotated, oldToken); err != nil {
+		t.Fatalf("expected token signed before rotation to verify: %v", err)
+	}
+	if err := parse(rotated, newToken); err != nil {
+		t.Fatalf("expected token signed after rotation to verify: %v", err)
+	}
+	if err := parse(oldSet, newToken); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected unknown kid, got %v", err)
+	}
+
+	jwks := rotated.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 published keys, got %d", len(jwks.Keys))
+	}
+	if jwks.Keys[0].KeyID != "2026-10" || jwks.Keys[0].KeyType != "RSA" || jwks.Keys[0].Algorithm != "RS256" {
+		t.Fatalf("unexpected signing key %+v", jwks.Keys[0])
+	}
+	if jwks.Keys[1].KeyID != "2026-09" || jwks.Keys[1].KeyType != "OKP" || jwks.Keys[1].Curve != "Ed25519" {
+		t.Fatalf("unexpected verification key %+v", jwks.Keys[1])
+	}
+}
+
+func TestKeySetRejectsHMACTokens(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+	set, err := LoadKeySet([]string{writePrivateKey(t, t.TempDir(), "current.pem", key)})
+	if err != nil {
+		t.Fatalf("load key set: %v", err)
+	}
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	forged.Header["kid"] = "current"
+	signed, err := forged.SignedString([]byte("guessed-secret"))
+	if err != nil {
+		t.Fatalf("sign forged token: %v", err)
+	}
+	if err := parse(set, signed); err == nil {
+		t.Fatal("expected HS256 token to be rejected")
+	}
+}
+
+func TestLoadKeySetRequiresPrivateSigningKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ed25519 key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	path := writePEM(t, t.TempDir(), "public.pem", "PUBLIC KEY", der)
+
+	if _, err := LoadKeySet([]string{path}); !errors.Is(err, ErrNoSigningKey) {
+		t.Fatalf("expected missing signing key, got %v", err)
+	}
+}
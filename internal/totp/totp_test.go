@@ -0,0 +1,76 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key from the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to six digits.
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tc := range cases {
+		code, err := Code(rfcSecret, Step(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatalf("code: %v", err)
+		}
+		if code != tc.code {
+			t.Fatalf("time %d: expected %s, got %s", tc.unix, tc.code, code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("generate secret: %v", err)
+	}
+	now := time.Now()
+	previous, err := Code(secret, Step(now)-1)
+	if err != nil {
+		t.Fatalf("code: %v", err)
+	}
+
+	step, ok, err := Validate(secret, previous, now, 0)
+	if err != nil || !ok || step != Step(now)-1 {
+		t.Fatalf("expected the previous step to be accepted, got step=%d ok=%v err=%v", step, ok, err)
+	}
+	if _, ok, _ := Validate(secret, previous, now, step); ok {
+		t.Fatalf("expected a used code to be refused")
+	}
+
+	stale, err := Code(secret, Step(now)-Skew-1)
+	if err != nil {
+		t.Fatalf("code: %v", err)
+	}
+	if _, ok, _ := Validate(secret, stale, now, 0); ok {
+		t.Fatalf("expected a code outside the skew to be refused")
+	}
+
+	if _, _, err := Validate("not base32!", "000000", now, 0); err != ErrInvalidSecret {
+		t.Fatalf("expected ErrInvalidSecret, got %v", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Pay-On", "+9779812345678", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/Pay-On:+9779812345678?") {
+		t.Fatalf("unexpected URI %q", uri)
+	}
+	if !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") || !strings.Contains(uri, "issuer=Pay-On") {
+		t.Fatalf("unexpected URI %q", uri)
+	}
+}
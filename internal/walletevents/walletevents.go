@@ -0,0 +1,169 @@
+// Package walletevents fans out wallet changes published by Postgres to
+// subscribers in this process. Database triggers NOTIFY the wallet_events
+// channel when a balance, transaction or sync log changes; a Hub LISTENs on
+// its own connection, so every API instance sees every change.
+package walletevents
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Channel is the Postgres notification channel the triggers publish on.
+const Channel = "wallet_events"
+
+const (
+	// bufferSize is the number of events a subscriber may fall behind by
+	// before it is dropped.
+	bufferSize     = 32
+	reconnectDelay = 5 * time.Second
+)
+
+// Event types published by the database triggers.
+const (
+	TypeBalance     = "balance"
+	TypeTransaction = "transaction"
+	TypeSyncLog     = "sync_log"
+)
+
+// Event is one wallet change. Data is passed through as the trigger wrote it.
+type Event struct {
+	WalletID uuid.UUID       `json:"wallet_id"`
+	Type     string          `json:"type"`
+	Data     json.RawMessage `json:"data"`
+}
+
+type subscriber struct {
+	walletID uuid.UUID
+	events   chan Event
+}
+
+// Hub listens for wallet events and hands them to subscribers of each wallet.
+type Hub struct {
+	connString string
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*subscriber]struct{}
+}
+
+// NewHub returns a hub that listens on a dedicated connection to connString.
+// Call Run to start listening.
+func NewHub(connString string) *Hub {
+	return &Hub{
+		connString:  connString,
+		subscribers: make(map[uuid.UUID]map[*subscriber]struct{}),
+	}
+}
+
+// Subscribe returns the events of a wallet and a function that ends the
+// subscription. The channel is closed when the subscriber falls too far
+// behind or the hub loses its database connection; events may have been
+// missed, so callers should reload the wallet's state before subscribing
+// again.
+func (h *Hub) Subscribe(walletID uuid.UUID) (<-chan Event, func()) {
+	sub := &subscriber{walletID: walletID, events: make(chan Event, bufferSize)}
+
+	h.mu.Lock()
+	if h.subscribers[walletID] == nil {
+		h.subscribers[walletID] = make(map[*subscriber]struct{})
+	}
+	h.subscribers[walletID][sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub.events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(sub)
+	}
+}
+
+// remove drops a subscriber and closes its channel. It is a no-op for a
+// subscriber already removed. h.mu must be held.
+func (h *Hub) remove(sub *subscriber) {
+	subs := h.subscribers[sub.walletID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.walletID)
+	}
+	close(sub.events)
+}
+
+// Run listens for notifications until ctx is done, reconnecting after
+// connection errors.
+func (h *Hub) Run(ctx context.Context) {
+	for {
+		err := h.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Wallet event listener stopped: %v", err)
+		h.dropAll()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (h *Hub) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, h.connString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		h.publish(notification.Payload)
+	}
+}
+
+// publish delivers a notification payload to the subscribers of its wallet.
+// Subscribers whose buffer is full are dropped rather than blocking the
+// others.
+func (h *Hub) publish(payload string) {
+	var event Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		log.Printf("Cannot decode wallet event %q: %v", payload, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers[event.WalletID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// dropAll ends every subscription, since events sent while the hub was not
+// listening are lost.
+func (h *Hub) dropAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			h.remove(sub)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package walletevents
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func notification(walletID uuid.UUID, eventType string) string {
+	return fmt.Sprintf(`{"wallet_id": %q, "type": %q, "data": {"balance": 10.5}}`, walletID, eventType)
+}
+
+func TestPublish(t *testing.T) {
+	hub := NewHub("")
+	walletID, otherID := uuid.New(), uuid.New()
+
+	events, unsubscribe := hub.Subscribe(walletID)
+	defer unsubscribe()
+	other, unsubscribeOther := hub.Subscribe(otherID)
+	defer unsubscribeOther()
+
+	hub.publish(notification(walletID, TypeBalance))
+	hub.publish("not json")
+
+	select {
+	case event := <-events:
+		if event.WalletID != walletID || event.Type != TypeBalance || string(event.Data) != `{"balance": 10.5}` {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+	default:
+		t.Fatalf("expected an event for the subscribed wallet")
+	}
+	select {
+	case event := <-other:
+		t.Fatalf("expected no event for another wallet, got %+v", event)
+	default:
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	hub := NewHub("")
+	walletID := uuid.New()
+
+	events, unsubscribe := hub.Subscribe(walletID)
+	for range bufferSize + 1 {
+		hub.publish(notification(walletID, TypeTransaction))
+	}
+
+	for range bufferSize {
+		if _, ok := <-events; !ok {
+			t.Fatalf("expected the buffered events before the channel closes")
+		}
+	}
+	if _, ok := <-events; ok {
+		t.Fatalf("expected the channel closed after the buffer overflowed")
+	}
+	// Unsubscribing a dropped subscriber must not close the channel twice.
+	unsubscribe()
+}
+
+func TestDropAll(t *testing.T) {
+	hub := NewHub("")
+	events, unsubscribe := hub.Subscribe(uuid.New())
+	defer unsubscribe()
+
+	hub.dropAll()
+	if _, ok := <-events; ok {
+		t.Fatalf("expected the channel closed")
+	}
+	if len(hub.subscribers) != 0 {
+		t.Fatalf("expected no subscribers left, got %d", len(hub.subscribers))
+	}
+}
@@ -0,0 +1,383 @@
+// Package webhook delivers queued events to subscriber URLs. Events are
+// queued in the webhook outbox by an event bus subscriber as domain events
+// are relayed; a Dispatcher claims due events, POSTs them with an HMAC
+// signature and retries failures with exponential backoff.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/Sahas001/pay-on/internal/eventbus"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	SignatureHeader = "X-PayOn-Signature"
+	EventHeader     = "X-PayOn-Event"
+	DeliveryHeader  = "X-PayOn-Delivery"
+
+	// MaxAttempts is the number of deliveries tried before an event is
+	// marked failed.
+	MaxAttempts = 8
+	// DefaultBatchSize is the number of events claimed at a time.
+	DefaultBatchSize = 20
+
+	requestTimeout = 10 * time.Second
+	// leaseDuration is how long a claimed event is hidden from other
+	// dispatchers. It covers a batch of timed out requests, so a dispatcher
+	// that dies mid-batch only delays its events.
+	leaseDuration = DefaultBatchSize * requestTimeout
+	baseBackoff   = 30 * time.Second
+	maxBackoff    = 6 * time.Hour
+	secretPrefix  = "whsec_"
+	// inactiveReason is recorded for events of disabled subscriptions.
+	inactiveReason = "subscription is disabled"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrStaleSignature   = errors.New("webhook signature timestamp is outside the tolerance")
+	ErrInsecureURL      = errors.New("webhook URL must use https")
+	ErrPrivateAddress   = errors.New("webhook URL must resolve to a public address")
+)
+
+// sharedAddressSpace is the carrier-grade NAT range, which netip does not
+// count as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Store is the part of the database store the dispatcher uses.
+type Store interface {
+	ClaimWebhookOutbox(ctx context.Context, arg database.ClaimWebhookOutboxParams) ([]database.ClaimWebhookOutboxRow, error)
+	RecordWebhookAttemptTx(ctx context.Context, arg database.RecordWebhookAttemptParams) error
+}
+
+// Enqueuer is the part of the database store the event subscriber uses.
+type Enqueuer interface {
+	EnqueueTransactionWebhook(ctx context.Context, sourceEventID uuid.UUID, event database.WebhookEventType, transaction database.Transaction) error
+}
+
+// Subscribe queues webhook events for the domain events they report. The
+// webhook outbox row is keyed on the domain event, so a relay retry does not
+// queue it twice.
+func Subscribe(bus *eventbus.Bus, store Enqueuer) {
+	eventbus.On(bus, "webhooks", func(ctx context.Context, event eventbus.Event, data database.TransactionConfirmed) error {
+		return store.EnqueueTransactionWebhook(ctx, event.ID, database.WebhookEventTransactionConfirmed, data.Transaction)
+	})
+	eventbus.On(bus, "webhooks", func(ctx context.Context, event eventbus.Event, data database.TransferCompleted) error {
+		return store.EnqueueTransactionWebhook(ctx, event.ID, database.WebhookEventTransactionSettled, data.Transaction)
+	})
+	eventbus.On(bus, "webhooks", func(ctx context.Context, event eventbus.Event, data database.TransactionFailed) error {
+		return store.EnqueueTransactionWebhook(ctx, event.ID, database.WebhookEventTransactionFailed, data.Transaction)
+	})
+	eventbus.On(bus, "webhooks", func(ctx context.Context, event eventbus.Event, data database.SyncConflictRaised) error {
+		return store.EnqueueTransactionWebhook(ctx, event.ID, database.WebhookEventTransactionConflict, data.Transaction)
+	})
+}
+
+// Event is the JSON body POSTed to a subscriber. ID stays the same across
+// retries of one event, so receivers can drop duplicates.
+type Event struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewSecret returns a random signing secret for a subscription.
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Sign returns the signature header for body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of t.body>".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac(secret, t, body))
+}
+
+// Verify checks a signature header made by Sign. Signatures older or newer
+// than tolerance relative to now are rejected to limit replays.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var t string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			if sig, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if diff := now.Sub(time.Unix(unix, 0)); diff > tolerance || diff < -tolerance {
+		return ErrStaleSignature
+	}
+	expected := mac(secret, t, body)
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func mac(secret, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// ValidateURL checks that a subscriber URL uses https and that its host
+// resolves only to public addresses, so webhooks cannot be pointed at the
+// server's own network. The dispatcher checks the address again when it
+// connects, since DNS can change after a subscription is made.
+func ValidateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" {
+		return ErrInsecureURL
+	}
+	host := u.Hostname()
+	if host == "" || strings.EqualFold(host, "localhost") {
+		return ErrPrivateAddress
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return checkAddr(ip)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolve webhook host: %w", err)
+	}
+	for _, ip := range addrs {
+		if err := checkAddr(ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAddr refuses loopback, private, link-local, multicast and
+// unspecified addresses.
+func checkAddr(ip netip.Addr) error {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// dialControl stops the dispatcher's connections to non-public addresses
+// after DNS resolution, including those reached through redirects.
+func dialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	return checkAddr(addrPort.Addr())
+}
+
+// newClient returns the dispatcher's default HTTP client. It connects
+// directly, without a proxy, so that dialControl sees the real address.
+func newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: requestTimeout, Control: dialControl}
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: requestTimeout,
+			MaxIdleConnsPerHost: 2,
+		},
+	}
+}
+
+// Backoff returns the wait after the given failed attempt: 30 seconds,
+// doubling each attempt, up to six hours.
+func Backoff(attempt int32) time.Duration {
+	delay := baseBackoff
+	for i := int32(1); i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// Dispatcher delivers events from the webhook outbox. Several dispatchers
+// may share a database; each event is claimed by one of them at a time.
+type Dispatcher struct {
+	store     Store
+	client    *http.Client
+	batchSize int32
+	now       func() time.Time
+}
+
+// NewDispatcher returns a dispatcher for store. A nil client uses one with a
+// ten second timeout that only connects to public addresses.
+func NewDispatcher(store Store, client *http.Client) *Dispatcher {
+	if client == nil {
+		client = newClient()
+	}
+	return &Dispatcher{
+		store:     store,
+		client:    client,
+		batchSize: DefaultBatchSize,
+		now:       time.Now,
+	}
+}
+
+// Run dispatches due events every interval until ctx is done. A full batch
+// is followed straight away by the next one.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := d.DispatchOnce(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Cannot dispatch webhooks: %v", err)
+				}
+				break
+			}
+			if n < int(d.batchSize) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce claims one batch of due events and tries to deliver each. It
+// returns the number of events claimed.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	events, err := d.store.ClaimWebhookOutbox(ctx, database.ClaimWebhookOutboxParams{
+		Limit:      d.batchSize,
+		LeaseUntil: pgtype.Timestamptz{Time: d.now().Add(leaseDuration), Valid: true},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if err := d.store.RecordWebhookAttemptTx(ctx, d.deliver(ctx, event)); err != nil {
+			return len(events), fmt.Errorf("record webhook attempt %s: %w", event.ID, err)
+		}
+	}
+	return len(events), nil
+}
+
+// deliver sends one claimed event and returns the outcome to record. Events
+// of disabled subscriptions are failed without being sent.
+func (d *Dispatcher) deliver(ctx context.Context, event database.ClaimWebhookOutboxRow) database.RecordWebhookAttemptParams {
+	result := database.RecordWebhookAttemptParams{
+		OutboxID: event.ID,
+		Attempt:  event.Attempts,
+	}
+	if !event.SubscriptionActive {
+		reason := inactiveReason
+		result.Error = &reason
+		return result
+	}
+
+	started := d.now()
+	statusCode, err := d.post(ctx, event)
+	result.Duration = d.now().Sub(started)
+	if statusCode != 0 {
+		result.StatusCode = &statusCode
+	}
+	if err == nil {
+		result.Delivered = true
+		return result
+	}
+
+	reason := err.Error()
+	result.Error = &reason
+	if event.Attempts < MaxAttempts {
+		result.RetryAt = d.now().Add(Backoff(event.Attempts))
+	}
+	return result
+}
+
+// post sends the event and returns the response status. Any 2xx status is a
+// successful delivery.
+func (d *Dispatcher) post(ctx context.Context, event database.ClaimWebhookOutboxRow) (int32, error) {
+	body, err := json.Marshal(Event{
+		ID:        event.ID,
+		Type:      event.EventType,
+		CreatedAt: event.CreatedAt.Time,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+	// Subscriptions made before https was required are not sent in the
+	// clear.
+	if !strings.HasPrefix(event.Url, "https://") {
+		return 0, ErrInsecureURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, event.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PayOn-Webhooks/1")
+	req.Header.Set(EventHeader, event.EventType)
+	req.Header.Set(DeliveryHeader, event.ID.String())
+	req.Header.Set(SignatureHeader, Sign(event.Secret, d.now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return int32(resp.StatusCode), fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return int32(resp.StatusCode), nil
+}
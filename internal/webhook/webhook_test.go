@@ -0,0 +1,275 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/Sahas001/pay-on/internal/eventbus"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeStore hands out its events once and keeps the recorded attempts.
+type fakeStore struct {
+	events   []database.ClaimWebhookOutboxRow
+	attempts []database.RecordWebhookAttemptParams
+}
+
+func (s *fakeStore) ClaimWebhookOutbox(_ context.Context, arg database.ClaimWebhookOutboxParams) ([]database.ClaimWebhookOutboxRow, error) {
+	n := min(int(arg.Limit), len(s.events))
+	claimed := s.events[:n]
+	s.events = s.events[n:]
+	return claimed, nil
+}
+
+func (s *fakeStore) RecordWebhookAttemptTx(_ context.Context, arg database.RecordWebhookAttemptParams) error {
+	s.attempts = append(s.attempts, arg)
+	return nil
+}
+
+func outboxEvent(url, secret string, attempts int32) database.ClaimWebhookOutboxRow {
+	return database.ClaimWebhookOutboxRow{
+		ID:                 uuid.New(),
+		EventType:          string(database.WebhookEventTransactionSettled),
+		Payload:            []byte(`{"transaction":{"id":"abc"}}`),
+		Attempts:           attempts,
+		CreatedAt:          pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Url:                url,
+		Secret:             secret,
+		SubscriptionActive: true,
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"hello":"world"}`)
+	header := Sign("secret", now, body)
+
+	if err := Verify("secret", header, body, time.Minute, now); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := Verify("other", header, body, time.Minute, now); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature for the wrong secret, got %v", err)
+	}
+	if err := Verify("secret", header, []byte(`{}`), time.Minute, now); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature for a changed body, got %v", err)
+	}
+	if err := Verify("secret", header, body, time.Minute, now.Add(2*time.Minute)); err != ErrStaleSignature {
+		t.Fatalf("expected ErrStaleSignature, got %v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	if Backoff(1) != 30*time.Second || Backoff(2) != time.Minute || Backoff(3) != 2*time.Minute {
+		t.Fatalf("unexpected backoff: %v %v %v", Backoff(1), Backoff(2), Backoff(3))
+	}
+	if Backoff(40) != 6*time.Hour {
+		t.Fatalf("expected backoff capped at 6h, got %v", Backoff(40))
+	}
+}
+
+func TestDispatchOnce(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatalf("new secret: %v", err)
+	}
+
+	var received Event
+	ok := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(secret, r.Header.Get(SignatureHeader), body, time.Minute, time.Now()); err != nil {
+			t.Errorf("verify: %v", err)
+		}
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		if r.Header.Get(EventHeader) != received.Type || r.Header.Get(DeliveryHeader) != received.ID.String() {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ok.Close()
+	broken := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	delivered := outboxEvent(ok.URL, secret, 1)
+	retried := outboxEvent(broken.URL, secret, 2)
+	exhausted := outboxEvent(broken.URL, secret, MaxAttempts)
+	disabled := outboxEvent(ok.URL, secret, 1)
+	disabled.SubscriptionActive = false
+
+	store := &fakeStore{events: []database.ClaimWebhookOutboxRow{delivered, retried, exhausted, disabled}}
+	// The test servers listen on loopback, which the default client refuses.
+	dispatcher := NewDispatcher(store, ok.Client())
+	before := time.Now()
+	n, err := dispatcher.DispatchOnce(context.Background())
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if n != 4 || len(store.attempts) != 4 {
+		t.Fatalf("expected 4 events dispatched and recorded, got %d and %d", n, len(store.attempts))
+	}
+
+	if received.ID != delivered.ID || received.Type != delivered.EventType || string(received.Data) != string(delivered.Payload) {
+		t.Fatalf("unexpected event received: %+v", received)
+	}
+	if got := store.attempts[0]; !got.Delivered || got.StatusCode == nil || *got.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected the first event delivered, got %+v", got)
+	}
+
+	got := store.attempts[1]
+	if got.Delivered || got.StatusCode == nil || *got.StatusCode != http.StatusInternalServerError || got.Error == nil {
+		t.Fatalf("expected the second event to fail with a 500, got %+v", got)
+	}
+	if got.RetryAt.Before(before.Add(Backoff(2))) {
+		t.Fatalf("expected a retry after %v, got %v", Backoff(2), got.RetryAt.Sub(before))
+	}
+
+	if got := store.attempts[2]; got.Delivered || !got.RetryAt.IsZero() {
+		t.Fatalf("expected the third event to give up after %d attempts, got %+v", MaxAttempts, got)
+	}
+	if got := store.attempts[3]; got.Delivered || !got.RetryAt.IsZero() || got.StatusCode != nil {
+		t.Fatalf("expected the disabled subscription's event failed unsent, got %+v", got)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	ctx := context.Background()
+	for _, raw := range []string{
+		"https://127.0.0.1/hook",
+		"https://localhost:8443/hook",
+		"https://10.1.2.3/hook",
+		"https://192.168.0.10/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]/hook",
+		"https://[fe80::1]/hook",
+		"https://[::ffff:127.0.0.1]/hook",
+		"https://100.64.0.1/hook",
+		"https://0.0.0.0/hook",
+	} {
+		if err := ValidateURL(ctx, raw); !errors.Is(err, ErrPrivateAddress) {
+			t.Fatalf("%s: expected ErrPrivateAddress, got %v", raw, err)
+		}
+	}
+	if err := ValidateURL(ctx, "http://93.184.216.34/hook"); !errors.Is(err, ErrInsecureURL) {
+		t.Fatalf("expected ErrInsecureURL, got %v", err)
+	}
+	if err := ValidateURL(ctx, "https://93.184.216.34/hook"); err != nil {
+		t.Fatalf("expected a public address to pass, got %v", err)
+	}
+}
+
+func TestDefaultClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected no request to reach a loopback address")
+	}))
+	defer server.Close()
+
+	store := &fakeStore{events: []database.ClaimWebhookOutboxRow{outboxEvent(server.URL, "secret", 1)}}
+	if _, err := NewDispatcher(store, nil).DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if got := store.attempts[0]; got.Delivered || got.Error == nil || !strings.Contains(*got.Error, ErrPrivateAddress.Error()) {
+		t.Fatalf("expected the delivery refused, got %+v", got)
+	}
+}
+
+// fakeBus hands out domain events once and records those published.
+type fakeBus struct {
+	events    []database.Outbox
+	published int
+}
+
+func (b *fakeBus) ClaimOutboxEvents(_ context.Context, _ database.ClaimOutboxEventsParams) ([]database.Outbox, error) {
+	claimed := b.events
+	b.events = nil
+	return claimed, nil
+}
+
+func (b *fakeBus) MarkOutboxEventPublished(context.Context, uuid.UUID) error {
+	b.published++
+	return nil
+}
+
+func (b *fakeBus) ScheduleOutboxEventRetry(context.Context, database.ScheduleOutboxEventRetryParams) error {
+	return errors.New("unexpected retry")
+}
+
+func (b *fakeBus) MarkOutboxEventFailed(context.Context, database.MarkOutboxEventFailedParams) error {
+	return errors.New("unexpected failure")
+}
+
+type queued struct {
+	source uuid.UUID
+	event  database.WebhookEventType
+	tx     uuid.UUID
+}
+
+type fakeEnqueuer []queued
+
+func (e *fakeEnqueuer) EnqueueTransactionWebhook(_ context.Context, sourceEventID uuid.UUID, event database.WebhookEventType, transaction database.Transaction) error {
+	*e = append(*e, queued{sourceEventID, event, transaction.ID})
+	return nil
+}
+
+func TestSubscribe(t *testing.T) {
+	transaction := database.Transaction{ID: uuid.New(), ToWalletID: uuid.New()}
+	domainEvents := []database.DomainEvent{
+		database.TransactionConfirmed{Transaction: transaction},
+		database.TransferCompleted{Transaction: transaction},
+		database.TransactionFailed{Transaction: transaction},
+		database.SyncConflictRaised{SyncLogID: uuid.New(), Transaction: transaction},
+		database.WalletDeactivated{WalletID: transaction.ToWalletID},
+	}
+	bus := &fakeBus{}
+	var ids []uuid.UUID
+	for _, event := range domainEvents {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("marshal event: %v", err)
+		}
+		ids = append(ids, uuid.New())
+		bus.events = append(bus.events, database.Outbox{
+			ID:          ids[len(ids)-1],
+			EventType:   event.EventName(),
+			AggregateID: event.AggregateID(),
+			Payload:     payload,
+			Attempts:    1,
+		})
+	}
+
+	var enqueued fakeEnqueuer
+	relay := eventbus.New(bus)
+	Subscribe(relay, &enqueued)
+	if _, err := relay.RelayOnce(context.Background()); err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	if bus.published != len(domainEvents) {
+		t.Fatalf("expected every event published, got %d", bus.published)
+	}
+
+	want := []database.WebhookEventType{
+		database.WebhookEventTransactionConfirmed,
+		database.WebhookEventTransactionSettled,
+		database.WebhookEventTransactionFailed,
+		database.WebhookEventTransactionConflict,
+	}
+	if len(enqueued) != len(want) {
+		t.Fatalf("expected %d webhook events, got %+v", len(want), enqueued)
+	}
+	for i, got := range enqueued {
+		if got.event != want[i] || got.source != ids[i] || got.tx != transaction.ID {
+			t.Fatalf("event %d: expected %s for its domain event, got %+v", i, want[i], got)
+		}
+	}
+}
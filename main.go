@@ -2,11 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 
 	"github.com/Sahas001/pay-on/api"
 	"github.com/Sahas001/pay-on/config"
 	database "github.com/Sahas001/pay-on/internal/database/sqlc"
+	"github.com/Sahas001/pay-on/internal/envelope"
+	"github.com/Sahas001/pay-on/internal/eventbus"
+	"github.com/Sahas001/pay-on/internal/jobs"
+	"github.com/Sahas001/pay-on/internal/syncretry"
+	"github.com/Sahas001/pay-on/internal/walletevents"
+	"github.com/Sahas001/pay-on/internal/webhook"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -23,9 +31,144 @@ func main() {
 	}
 
 	store := database.NewStore(conn)
-	server := api.NewServer(cfg, store)
+	if cfg.BootstrapAdminPhone != "" {
+		bootstrapAdmin(ctx, store, cfg.BootstrapAdminPhone)
+	}
+	sealLegacyWalletKeys(ctx, store, cfg.WalletMasterKey)
+	sealTOTPSecrets(ctx, store, cfg.WalletMasterKey)
+
+	// Webhook events are queued by a bus subscriber, so the dispatcher would
+	// have nothing to send without the relay.
+	if cfg.WebhookDispatchInterval > 0 && cfg.EventRelayInterval <= 0 {
+		log.Fatal("WEBHOOK_DISPATCH_INTERVAL needs EVENT_RELAY_INTERVAL above 0, since the event relay queues webhook events")
+	}
+	if cfg.WebhookDispatchInterval > 0 {
+		go webhook.NewDispatcher(store, nil).Run(ctx, cfg.WebhookDispatchInterval)
+	}
+
+	if cfg.EventRelayInterval > 0 {
+		bus := eventbus.New(store)
+		registerEventSubscribers(bus, store)
+		go bus.Run(ctx, cfg.EventRelayInterval)
+	}
+
+	if cfg.SyncRetryInterval > 0 {
+		go syncretry.NewWorker(store).Run(ctx, cfg.SyncRetryInterval)
+	}
+
+	runner := newJobRunner(store, cfg.JobSchedules)
+	if cfg.JobsEnabled {
+		runner.Start()
+	}
+
+	walletEvents := walletevents.NewHub(cfg.DBSource)
+	go walletEvents.Run(ctx)
+
+	server, err := api.NewServer(cfg, store, walletEvents, runner)
+	if err != nil {
+		log.Fatal("Cannot create server:", err)
+	}
 	err = server.Start(cfg.ServerAddress)
 	if err != nil {
 		log.Fatal("Cannot start server:", err)
 	}
 }
+
+// registerEventSubscribers plugs in-process reactions to domain events into
+// the bus.
+func registerEventSubscribers(bus *eventbus.Bus, store *database.Store) {
+	webhook.Subscribe(bus, store)
+	eventbus.On(bus, "conflict-log", func(_ context.Context, _ eventbus.Event, event database.SyncConflictRaised) error {
+		log.Printf("Sync conflict on transaction %s (%s) needs an operator", event.Conflict.TransactionID, event.Conflict.Reason)
+		return nil
+	})
+}
+
+// newJobRunner sets up the maintenance jobs with any schedules overridden in
+// JOB_SCHEDULES.
+func newJobRunner(store *database.Store, overrides string) *jobs.Runner {
+	schedules, err := jobs.ParseSchedules(overrides)
+	if err != nil {
+		log.Fatal("Cannot parse job schedules:", err)
+	}
+	maintenance, err := jobs.WithSchedules(jobs.Maintenance(store), schedules)
+	if err != nil {
+		log.Fatal("Cannot apply job schedules:", err)
+	}
+	runner, err := jobs.NewRunner(store, maintenance)
+	if err != nil {
+		log.Fatal("Cannot create job runner:", err)
+	}
+	return runner
+}
+
+func bootstrapAdmin(ctx context.Context, store *database.Store, phoneNumber string) {
+	user, err := store.BootstrapAdmin(ctx, phoneNumber)
+	switch {
+	case err == nil:
+		log.Printf("Promoted user %s to admin", user.ID)
+	case errors.Is(err, database.ErrAdminExists):
+		// Already bootstrapped; nothing to do.
+	case errors.Is(err, pgx.ErrNoRows):
+		log.Printf("Cannot bootstrap admin: no user registered with phone number %s", phoneNumber)
+	default:
+		log.Fatal("Cannot bootstrap admin:", err)
+	}
+}
+
+// sealLegacyWalletKeys encrypts wallet private keys that were stored in
+// plaintext before custodial keys were envelope encrypted.
+func sealLegacyWalletKeys(ctx context.Context, store *database.Store, masterKey string) {
+	if masterKey == "" {
+		count, err := store.CountLegacyWalletCustodialKeys(ctx)
+		if err != nil {
+			log.Fatal("Cannot count legacy wallet keys:", err)
+		}
+		if count > 0 {
+			log.Fatalf("%d wallet private keys are stored in plaintext; set WALLET_MASTER_KEY to encrypt them", count)
+		}
+		return
+	}
+
+	sealer, err := envelope.NewSealer(masterKey)
+	if err != nil {
+		log.Fatal("Cannot load wallet master key:", err)
+	}
+	sealed, rejected, err := store.SealLegacyWalletKeys(ctx, sealer)
+	if err != nil {
+		log.Fatal("Cannot seal legacy wallet keys:", err)
+	}
+	if sealed > 0 {
+		log.Printf("Encrypted %d legacy wallet private keys", sealed)
+	}
+	if rejected > 0 {
+		log.Printf("Discarded %d legacy wallet private keys that could not be decoded; see wallet_custodial_keys.legacy_key_error", rejected)
+	}
+}
+
+// sealTOTPSecrets encrypts authenticator secrets that were stored in
+// plaintext before they were envelope encrypted.
+func sealTOTPSecrets(ctx context.Context, store *database.Store, masterKey string) {
+	if masterKey == "" {
+		count, err := store.CountPlaintextTOTPSecrets(ctx)
+		if err != nil {
+			log.Fatal("Cannot count plaintext authenticator secrets:", err)
+		}
+		if count > 0 {
+			log.Fatalf("%d authenticator secrets are stored in plaintext; set WALLET_MASTER_KEY to encrypt them", count)
+		}
+		return
+	}
+
+	sealer, err := envelope.NewSealer(masterKey)
+	if err != nil {
+		log.Fatal("Cannot load wallet master key:", err)
+	}
+	sealed, err := store.SealTOTPSecrets(ctx, sealer)
+	if err != nil {
+		log.Fatal("Cannot seal authenticator secrets:", err)
+	}
+	if sealed > 0 {
+		log.Printf("Encrypted %d authenticator secrets", sealed)
+	}
+}